@@ -18,7 +18,7 @@ func Test33AcrossSyncer(t *testing.T) {
 			},
 		},
 	})
-	syncInfo := ttx.GetUsersyncInfo("", "")
+	syncInfo := ttx.GetUsersyncInfo("", "", "", "", "")
 	assert.Equal(t, "https://ssc-cms.33across.com/ps/?ri=123&ru=localhost%2Fsetuid%3Fbidder%3Dttx%26uid%3D33XUSERID33X", syncInfo.URL)
 	assert.Equal(t, "redirect", syncInfo.Type)
 	assert.False(t, syncInfo.SupportCORS)
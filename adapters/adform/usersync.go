@@ -9,8 +9,31 @@ import (
 	"github.com/prebid/prebid-server/usersync"
 )
 
+// defaultGDPRVendorID is Adform's ID in the IAB Global Vendor List.
+const defaultGDPRVendorID uint16 = 50
+
+// defaultFamilyName is the cookie family which Adform's syncs are stored under, unless the host overrides it.
+const defaultFamilyName = "adform"
+
 func NewAdformSyncer(cfg *config.Configuration) usersync.Usersyncer {
-	usersyncURL := cfg.Adapters[string(openrtb_ext.BidderAdform)].UserSyncURL
-	redirectURI := url.QueryEscape(cfg.ExternalURL) + "%2Fsetuid%3Fbidder%3Dadform%26gdpr%3D{{gdpr}}%26gdpr_consent%3D{{gdpr_consent}}%26uid%3D%24UID"
-	return adapters.NewSyncer("adform", 50, adapters.ResolveMacros(usersyncURL+redirectURI), adapters.SyncTypeRedirect)
+	adapterCfg := cfg.Adapters[string(openrtb_ext.BidderAdform)]
+	vendorID := adapterCfg.GDPRVendorID
+	if vendorID == 0 {
+		vendorID = defaultGDPRVendorID
+	}
+	familyName := adapterCfg.UserSyncFamily
+	if familyName == "" {
+		familyName = defaultFamilyName
+	}
+	// The /setuid endpoint stores the uid under the "bidder" param, so it must match the syncer's family.
+	redirectURI := url.QueryEscape(cfg.ExternalURL) + "%2Fsetuid%3Fbidder%3D" + url.QueryEscape(familyName) + "%26gdpr%3D{{gdpr}}%26gdpr_consent%3D{{gdpr_consent}}%26us_privacy%3D{{us_privacy}}%26gpp%3D{{gpp}}%26gpp_sid%3D{{gpp_sid}}%26uid%3D"
+
+	// Adform's iframe appends the uid to the callback itself. Redirects need the $UID macro so Adform knows where to put it.
+	syncType := adapters.SyncTypeRedirect
+	if adapters.SyncType(adapterCfg.UserSyncType) == adapters.SyncTypeIframe {
+		syncType = adapters.SyncTypeIframe
+	} else {
+		redirectURI += "%24UID"
+	}
+	return adapters.NewSyncer(familyName, vendorID, adapters.ResolveMacros(adapterCfg.UserSyncURL+redirectURI), syncType)
 }
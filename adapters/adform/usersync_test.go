@@ -15,9 +15,76 @@ func TestAdformSyncer(t *testing.T) {
 			UserSyncURL: "//cm.adform.net?return_url=",
 		},
 	}})
-	u := syncer.GetUsersyncInfo("1", "BONciguONcjGKADACHENAOLS1rAHDAFAAEAASABQAMwAeACEAFw")
-	assert.Equal(t, "//cm.adform.net?return_url=localhost%2Fsetuid%3Fbidder%3Dadform%26gdpr%3D1%26gdpr_consent%3DBONciguONcjGKADACHENAOLS1rAHDAFAAEAASABQAMwAeACEAFw%26uid%3D%24UID", u.URL)
+	u := syncer.GetUsersyncInfo("1", "BONciguONcjGKADACHENAOLS1rAHDAFAAEAASABQAMwAeACEAFw", "", "", "")
+	assert.Equal(t, "//cm.adform.net?return_url=localhost%2Fsetuid%3Fbidder%3Dadform%26gdpr%3D1%26gdpr_consent%3DBONciguONcjGKADACHENAOLS1rAHDAFAAEAASABQAMwAeACEAFw%26us_privacy%3D%26gpp%3D%26gpp_sid%3D%26uid%3D%24UID", u.URL)
 	assert.Equal(t, "redirect", u.Type)
 	assert.Equal(t, uint16(50), syncer.GDPRVendorID())
 	assert.Equal(t, false, u.SupportCORS)
 }
+
+func TestAdformSyncerUSPrivacy(t *testing.T) {
+	syncer := NewAdformSyncer(&config.Configuration{ExternalURL: "localhost", Adapters: map[string]config.Adapter{
+		string(openrtb_ext.BidderAdform): {
+			UserSyncURL: "//cm.adform.net?return_url=",
+		},
+	}})
+	u := syncer.GetUsersyncInfo("0", "", "1YN-", "", "")
+	assert.Equal(t, "//cm.adform.net?return_url=localhost%2Fsetuid%3Fbidder%3Dadform%26gdpr%3D0%26gdpr_consent%3D%26us_privacy%3D1YN-%26gpp%3D%26gpp_sid%3D%26uid%3D%24UID", u.URL)
+}
+
+func TestAdformIframeSyncer(t *testing.T) {
+	syncer := NewAdformSyncer(&config.Configuration{ExternalURL: "localhost", Adapters: map[string]config.Adapter{
+		string(openrtb_ext.BidderAdform): {
+			UserSyncURL:  "//cm.adform.net/cookie?redirect_url=",
+			UserSyncType: "iframe",
+		},
+	}})
+	u := syncer.GetUsersyncInfo("1", "BONciguONcjGKADACHENAOLS1rAHDAFAAEAASABQAMwAeACEAFw", "", "", "")
+	assert.Equal(t, "//cm.adform.net/cookie?redirect_url=localhost%2Fsetuid%3Fbidder%3Dadform%26gdpr%3D1%26gdpr_consent%3DBONciguONcjGKADACHENAOLS1rAHDAFAAEAASABQAMwAeACEAFw%26us_privacy%3D%26gpp%3D%26gpp_sid%3D%26uid%3D", u.URL)
+	assert.Equal(t, "iframe", u.Type)
+	assert.Equal(t, uint16(50), syncer.GDPRVendorID())
+}
+
+func TestAdformSyncerVendorOverride(t *testing.T) {
+	syncer := NewAdformSyncer(&config.Configuration{ExternalURL: "localhost", Adapters: map[string]config.Adapter{
+		string(openrtb_ext.BidderAdform): {
+			UserSyncURL:  "//cm.adform.net/cookie?redirect_url=",
+			GDPRVendorID: 123,
+		},
+	}})
+	assert.Equal(t, uint16(123), syncer.GDPRVendorID())
+}
+
+func TestAdformSyncerFamilyOverride(t *testing.T) {
+	syncer := NewAdformSyncer(&config.Configuration{ExternalURL: "localhost", Adapters: map[string]config.Adapter{
+		string(openrtb_ext.BidderAdform): {
+			UserSyncURL:    "//cm.adform.net?return_url=",
+			UserSyncFamily: "adform-test",
+		},
+	}})
+	u := syncer.GetUsersyncInfo("0", "", "", "", "")
+	assert.Equal(t, "adform-test", syncer.FamilyName())
+	assert.Equal(t, "//cm.adform.net?return_url=localhost%2Fsetuid%3Fbidder%3Dadform-test%26gdpr%3D0%26gdpr_consent%3D%26us_privacy%3D%26gpp%3D%26gpp_sid%3D%26uid%3D%24UID", u.URL)
+}
+
+func TestAdformSyncerDefaultFamily(t *testing.T) {
+	syncer := NewAdformSyncer(&config.Configuration{ExternalURL: "localhost", Adapters: map[string]config.Adapter{
+		string(openrtb_ext.BidderAdform): {
+			UserSyncURL: "//cm.adform.net?return_url=",
+		},
+	}})
+	assert.Equal(t, "adform", syncer.FamilyName())
+}
+
+func TestAdformSyncerGPP(t *testing.T) {
+	syncer := NewAdformSyncer(&config.Configuration{ExternalURL: "localhost", Adapters: map[string]config.Adapter{
+		string(openrtb_ext.BidderAdform): {
+			UserSyncURL: "//cm.adform.net?return_url=",
+		},
+	}})
+	u := syncer.GetUsersyncInfo("", "", "", "DBABMA~CPXxRfAPXxRfAAfKABENB-CgAAAAAAAAAAYgAAAAAAAA", "2")
+	assert.Equal(t, "//cm.adform.net?return_url=localhost%2Fsetuid%3Fbidder%3Dadform%26gdpr%3D%26gdpr_consent%3D%26us_privacy%3D%26gpp%3DDBABMA~CPXxRfAPXxRfAAfKABENB-CgAAAAAAAAAAYgAAAAAAAA%26gpp_sid%3D2%26uid%3D%24UID", u.URL)
+
+	u = syncer.GetUsersyncInfo("1", "BONciguONcjGKADACHENAOLS1rAHDAFAAEAASABQAMwAeACEAFw", "1YN-", "DBABMA~CPXxRfAPXxRfAAfKABENB-CgAAAAAAAAAAYgAAAAAAAA", "2,6")
+	assert.Equal(t, "//cm.adform.net?return_url=localhost%2Fsetuid%3Fbidder%3Dadform%26gdpr%3D1%26gdpr_consent%3DBONciguONcjGKADACHENAOLS1rAHDAFAAEAASABQAMwAeACEAFw%26us_privacy%3D1YN-%26gpp%3DDBABMA~CPXxRfAPXxRfAAfKABENB-CgAAAAAAAAAAYgAAAAAAAA%26gpp_sid%3D2,6%26uid%3D%24UID", u.URL)
+}
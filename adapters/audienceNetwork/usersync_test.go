@@ -16,7 +16,7 @@ func TestFacebookSyncer(t *testing.T) {
 			UserSyncURL: "https://www.facebook.com/audiencenetwork/idsync/?partner=partnerId&callback=localhost%2Fsetuid%3Fbidder%3DaudienceNetwork%26gdpr%3D{{gdpr}}%26gdpr_consent%3D{{gdpr_consent}}%26uid%3D%24UID",
 		},
 	}})
-	u := syncer.GetUsersyncInfo("", "")
+	u := syncer.GetUsersyncInfo("", "", "", "", "")
 	assert.Equal(t, "https://www.facebook.com/audiencenetwork/idsync/?partner=partnerId&callback=localhost%2Fsetuid%3Fbidder%3DaudienceNetwork%26gdpr%3D%26gdpr_consent%3D%26uid%3D%24UID", u.URL)
 	assert.Equal(t, "redirect", u.Type)
 	assert.Equal(t, uint16(0), syncer.GDPRVendorID())
@@ -15,7 +15,7 @@ func TestBeachfrontSyncer(t *testing.T) {
 			UserSyncURL: "localhost",
 		},
 	}})
-	u := syncer.GetUsersyncInfo("0", "")
+	u := syncer.GetUsersyncInfo("0", "", "", "", "")
 	assert.Equal(t, "localhost", u.URL)
 	assert.Equal(t, "iframe", u.Type)
 	assert.Equal(t, uint16(0), syncer.GDPRVendorID())
@@ -28,7 +28,7 @@ func TestBeachfrontNullSyncer(t *testing.T) {
 			PlatformID: "142",
 		},
 	}})
-	u := syncer.GetUsersyncInfo("0", "")
+	u := syncer.GetUsersyncInfo("0", "", "", "", "")
 	assert.Equal(t, "", u.URL)
 	assert.Equal(t, "iframe", u.Type)
 	assert.Equal(t, uint16(0), syncer.GDPRVendorID())
@@ -50,9 +50,13 @@ func BadInput(msg string) *errortypes.BadInput {
 // Currency declaration is not mandatory but helps to detect an eventual currency mismatch issue.
 // From the bid response, the bidder accepts a list of valid currencies for the bid.
 // The currency is the same accross all bids.
+//
+// Seat is optional. Bidders which declare it should use their own bidder name, so that the exchange
+// can tell who the bids belong to. How a seat without one is treated depends on the host's config.
 type BidderResponse struct {
 	Currency string
 	Bids     []*TypedBid
+	Seat     string
 }
 
 // NewBidderResponseWithBidsCapacity create a new BidderResponse initialising the bids array capacity and the default currency value
@@ -84,6 +88,18 @@ func NewBidderResponse() *BidderResponse {
 type TypedBid struct {
 	Bid     *openrtb.Bid
 	BidType openrtb_ext.BidType
+	// BidVideo describes video creatives. Bids on imps with a video object must supply their duration.
+	BidVideo *openrtb_ext.ExtBidPrebidVideo
+	// BidMeta describes who is behind the Bid. Hosts may require some of it.
+	BidMeta *openrtb_ext.ExtBidPrebidMeta
+	// DealPriority ranks Bids which carry a DealID. Higher numbers are more important deals.
+	// It's compared against the publisher's deal tier config, if they have one for this Bidder.
+	DealPriority int
+	// MType is the bid.mtype, for Bidders whose servers speak OpenRTB 2.6. The openrtb.Bid doesn't have that field.
+	MType openrtb_ext.MarkupType
+	// Dur is the bid.dur, in seconds, for Bidders whose servers speak OpenRTB 2.6. It's used for video and audio Bids
+	// whose BidVideo doesn't give a duration.
+	Dur int64
 }
 
 // RequestData and ResponseData exist so that prebid-server core code can implement its "debug" functionality
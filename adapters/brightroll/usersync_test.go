@@ -15,7 +15,7 @@ func TestBrightrollSyncer(t *testing.T) {
 			UserSyncURL: "http://east-bid.ybp.yahoo.com/sync/appnexuspbs?gdpr={{gdpr}}&euconsent={{gdpr_consent}}&url=",
 		},
 	}})
-	u := syncer.GetUsersyncInfo("", "")
+	u := syncer.GetUsersyncInfo("", "", "", "", "")
 	assert.Equal(t, "http://east-bid.ybp.yahoo.com/sync/appnexuspbs?gdpr=&euconsent=&url=localhost%2Fsetuid%3Fbidder%3Dbrightroll%26gdpr%3D%26gdpr_consent%3D%26uid%3D%24%7BUID%7D", u.URL)
 	assert.Equal(t, "redirect", u.Type)
 	assert.Equal(t, uint16(25), syncer.GDPRVendorID())
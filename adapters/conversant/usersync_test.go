@@ -15,7 +15,7 @@ func TestConversantSyncer(t *testing.T) {
 			UserSyncURL: "usersync?rurl=",
 		},
 	}})
-	u := syncer.GetUsersyncInfo("0", "")
+	u := syncer.GetUsersyncInfo("0", "", "", "", "")
 	assert.Equal(t, "usersync?rurl=localhost%2Fsetuid%3Fbidder%3Dconversant%26gdpr%3D0%26gdpr_consent%3D%26uid%3D", u.URL)
 	assert.Equal(t, "redirect", u.Type)
 	assert.Equal(t, uint16(24), syncer.GDPRVendorID())
@@ -15,7 +15,7 @@ func TestEPlanningSyncer(t *testing.T) {
 			UserSyncURL: "http://sync.e-planning.net/um?uid",
 		},
 	}})
-	u := syncer.GetUsersyncInfo("", "")
+	u := syncer.GetUsersyncInfo("", "", "", "", "")
 	assert.Equal(t, "http://sync.e-planning.net/um?uidlocalhost%2Fsetuid%3Fbidder%3Deplanning%26gdpr%3D%26gdpr_consent%3D%26uid%3D%24UID", u.URL)
 	assert.Equal(t, "redirect", u.Type)
 	assert.Equal(t, uint16(0), syncer.GDPRVendorID())
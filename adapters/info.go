@@ -167,8 +167,22 @@ type BidderInfo struct {
 	Maintainer   *MaintainerInfo   `yaml:"maintainer" json:"maintainer"`
 	Capabilities *CapabilitiesInfo `yaml:"capabilities" json:"capabilities"`
 	AliasOf      string            `json:"aliasOf,omitempty"`
+	// OpenRTB describes the Bidder's OpenRTB support. If nil, every field in its responses is interpreted.
+	OpenRTB *OpenRTBInfo `yaml:"openrtb" json:"openrtb,omitempty"`
 }
 
+// OpenRTBInfo describes which version of OpenRTB a Bidder's server speaks.
+type OpenRTBInfo struct {
+	// Version is the OpenRTB version of the Bidder's responses. It must be one of the OpenRTBVersion values.
+	// Bids which use fields from a newer version are rejected, since the Bidder can't have meant them.
+	Version string `yaml:"version" json:"version"`
+}
+
+const (
+	OpenRTBVersion25 = "2.5"
+	OpenRTBVersion26 = "2.6"
+)
+
 type MaintainerInfo struct {
 	Email string `yaml:"email" json:"email"`
 }
@@ -142,4 +142,8 @@ func TestParsing(t *testing.T) {
 	assert.Equal(t, true, infos.SupportsWebMediaType(mockBidderName, openrtb_ext.BidTypeVideo))
 	assert.Equal(t, false, infos.SupportsWebMediaType(mockBidderName, openrtb_ext.BidTypeAudio))
 	assert.Equal(t, true, infos.SupportsWebMediaType(mockBidderName, openrtb_ext.BidTypeNative))
+
+	if assert.NotNil(t, infos[string(mockBidderName)].OpenRTB) {
+		assert.Equal(t, adapters.OpenRTBVersion26, infos[string(mockBidderName)].OpenRTB.Version)
+	}
 }
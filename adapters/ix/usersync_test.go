@@ -15,7 +15,7 @@ func TestIxSyncer(t *testing.T) {
 			UserSyncURL: "//ssum-sec.casalemedia.com/usermatchredir?s=184932&cb=localhost%2Fsetuid%3Fbidder%3Dix%26gdpr%3D{{gdpr}}%26gdpr_consent%3D{{gdpr_consent}}%26uid%3D",
 		},
 	}})
-	u := syncer.GetUsersyncInfo("", "")
+	u := syncer.GetUsersyncInfo("", "", "", "", "")
 	assert.Equal(t, "//ssum-sec.casalemedia.com/usermatchredir?s=184932&cb=localhost%2Fsetuid%3Fbidder%3Dix%26gdpr%3D%26gdpr_consent%3D%26uid%3D", u.URL)
 	assert.Equal(t, "redirect", u.Type)
 	assert.Equal(t, uint16(10), syncer.GDPRVendorID())
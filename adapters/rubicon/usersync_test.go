@@ -15,7 +15,7 @@ func TestRubiconSyncer(t *testing.T) {
 			UserSyncURL: "https://pixel.rubiconproject.com/exchange/sync.php?p=prebid&gdpr={{gdpr}}&gdpr_consent={{gdpr_consent}}",
 		},
 	}})
-	u := syncer.GetUsersyncInfo("0", "")
+	u := syncer.GetUsersyncInfo("0", "", "", "", "")
 	assert.Equal(t, "https://pixel.rubiconproject.com/exchange/sync.php?p=prebid&gdpr=0&gdpr_consent=", u.URL)
 	assert.Equal(t, "redirect", u.Type)
 	assert.Equal(t, uint16(52), syncer.GDPRVendorID())
@@ -15,7 +15,7 @@ func TestSovrnSyncer(t *testing.T) {
 			UserSyncURL: "//ap.lijit.com/pixel?",
 		},
 	}})
-	u := syncer.GetUsersyncInfo("0", "")
+	u := syncer.GetUsersyncInfo("0", "", "", "", "")
 	assert.Equal(t, "//ap.lijit.com/pixel?redir=external.com%2Fsetuid%3Fbidder%3Dsovrn%26gdpr%3D0%26gdpr_consent%3D%26uid%3D%24UID", u.URL)
 	assert.Equal(t, "redirect", u.Type)
 	assert.Equal(t, uint16(13), syncer.GDPRVendorID())
@@ -7,6 +7,10 @@ import (
 	"github.com/prebid/prebid-server/usersync"
 )
 
+// GDPRAwareSyncerIDs returns the IAB vendor IDs of the syncers which declare one.
+// These are passed to gdpr.NewPermissions, so that the /cookie_sync and /setuid endpoints only
+// fire a bidder's sync when its vendor has consent for purpose 1 (Information storage and access).
+// Syncers without a vendor ID are left out, and the GDPR config decides whether they may sync.
 func GDPRAwareSyncerIDs(syncers map[openrtb_ext.BidderName]usersync.Usersyncer) map[openrtb_ext.BidderName]uint16 {
 	gdprAwareSyncers := make(map[openrtb_ext.BidderName]uint16, len(syncers))
 	for bidderName, syncer := range syncers {
@@ -20,11 +24,11 @@ func GDPRAwareSyncerIDs(syncers map[openrtb_ext.BidderName]usersync.Usersyncer)
 type Syncer struct {
 	familyName          string
 	gdprVendorID        uint16
-	syncEndpointBuilder func(gdpr string, consent string) string
+	syncEndpointBuilder func(gdpr string, consent string, usPrivacy string, gpp string, gppSID string) string
 	syncType            SyncType
 }
 
-func NewSyncer(familyName string, vendorID uint16, endpointBulder func(gdpr string, consent string) string, syncType SyncType) *Syncer {
+func NewSyncer(familyName string, vendorID uint16, endpointBulder func(gdpr string, consent string, usPrivacy string, gpp string, gppSID string) string, syncType SyncType) *Syncer {
 	return &Syncer{
 		familyName:          familyName,
 		gdprVendorID:        vendorID,
@@ -40,9 +44,9 @@ const (
 	SyncTypeIframe   SyncType = "iframe"
 )
 
-func (s *Syncer) GetUsersyncInfo(gdpr string, consent string) *usersync.UsersyncInfo {
+func (s *Syncer) GetUsersyncInfo(gdpr string, consent string, usPrivacy string, gpp string, gppSID string) *usersync.UsersyncInfo {
 	return &usersync.UsersyncInfo{
-		URL:         s.syncEndpointBuilder(gdpr, consent),
+		URL:         s.syncEndpointBuilder(gdpr, consent, usPrivacy, gpp, gppSID),
 		Type:        string(s.syncType),
 		SupportCORS: false,
 	}
@@ -60,6 +64,9 @@ func (s *Syncer) GDPRVendorID() uint16 {
 //
 //   {{gdpr}} -- with the "gdpr" string (should be either "0", "1", or "")
 //   {{gdpr_consent}} -- with the Raw base64 URL-encoded GDPR Vendor Consent string.
+//   {{us_privacy}} -- with the US Privacy (CCPA) string, or "" if there isn't one.
+//   {{gpp}} -- with the IAB Global Privacy Platform string, or "" if there isn't one.
+//   {{gpp_sid}} -- with the comma-separated GPP section IDs which apply, or "" if there aren't any.
 //
 // For example, the template:
 //   //some-domain.com/getuid?gdpr={{gdpr}}&gdpr_consent={{gdpr_consent}}&callback=prebid-server-domain.com%2Fsetuid%3Fbidder%3Dadnxs%26gdpr={{gdpr}}%26gdpr_consent={{gdpr_consent}}%26uid%3D%24UID
@@ -68,9 +75,9 @@ func (s *Syncer) GDPRVendorID() uint16 {
 //   //some-domain.com/getuid?gdpr=&gdpr_consent=BONciguONcjGKADACHENAOLS1rAHDAFAAEAASABQAMwAeACEAFw&callback=prebid-server-domain.com%2Fsetuid%3Fbidder%3Dadnxs%26gdpr=%26gdpr_consent=BONciguONcjGKADACHENAOLS1rAHDAFAAEAASABQAMwAeACEAFw%26uid%3D%24UID
 //
 // if the "gdpr" arg was empty, and the consent arg was "BONciguONcjGKADACHENAOLS1rAHDAFAAEAASABQAMwAeACEAFw"
-func ResolveMacros(template string) func(gdpr string, consent string) string {
-	return func(gdpr string, consent string) string {
-		replacer := strings.NewReplacer("{{gdpr}}", gdpr, "{{gdpr_consent}}", consent)
+func ResolveMacros(template string) func(gdpr string, consent string, usPrivacy string, gpp string, gppSID string) string {
+	return func(gdpr string, consent string, usPrivacy string, gpp string, gppSID string) string {
+		replacer := strings.NewReplacer("{{gdpr}}", gdpr, "{{gdpr_consent}}", consent, "{{us_privacy}}", usPrivacy, "{{gpp}}", gpp, "{{gpp_sid}}", gppSID)
 		return replacer.Replace(template)
 	}
 }
@@ -0,0 +1,42 @@
+package adapters
+
+import (
+	"testing"
+
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/usersync"
+)
+
+func TestGDPRAwareSyncerIDs(t *testing.T) {
+	endpoint := func(gdpr string, consent string, usPrivacy string, gpp string, gppSID string) string { return "" }
+	ids := GDPRAwareSyncerIDs(map[openrtb_ext.BidderName]usersync.Usersyncer{
+		openrtb_ext.BidderAdform:   NewSyncer("adform", 50, endpoint, SyncTypeRedirect),
+		openrtb_ext.BidderAppnexus: NewSyncer("adnxs", 0, endpoint, SyncTypeRedirect),
+	})
+	if len(ids) != 1 {
+		t.Fatalf("Expected 1 vendor ID. Got %d", len(ids))
+	}
+	if ids[openrtb_ext.BidderAdform] != 50 {
+		t.Errorf("Expected adform to have vendor ID 50. Got %d", ids[openrtb_ext.BidderAdform])
+	}
+}
+
+func TestResolveMacros(t *testing.T) {
+	resolve := ResolveMacros("//some-domain.com/getuid?gdpr={{gdpr}}&gdpr_consent={{gdpr_consent}}&us_privacy={{us_privacy}}&gpp={{gpp}}&gpp_sid={{gpp_sid}}")
+	if url := resolve("1", "BONciguONcjGKADACHENAOLS1rAHDAFAAEAASABQAMwAeACEAFw", "1YN-", "DBABMA~CPXxRfAPXxRfAAfKABENB-CgAAAAAAAAAAYgAAAAAAAA", "2,6"); url != "//some-domain.com/getuid?gdpr=1&gdpr_consent=BONciguONcjGKADACHENAOLS1rAHDAFAAEAASABQAMwAeACEAFw&us_privacy=1YN-&gpp=DBABMA~CPXxRfAPXxRfAAfKABENB-CgAAAAAAAAAAYgAAAAAAAA&gpp_sid=2,6" {
+		t.Errorf("Bad URL with every macro populated: %s", url)
+	}
+	if url := resolve("", "", "", "", ""); url != "//some-domain.com/getuid?gdpr=&gdpr_consent=&us_privacy=&gpp=&gpp_sid=" {
+		t.Errorf("Bad URL with every macro empty: %s", url)
+	}
+}
+
+func TestResolveGPPMacros(t *testing.T) {
+	resolve := ResolveMacros("//some-domain.com/getuid?gdpr={{gdpr}}&us_privacy={{us_privacy}}&gpp={{gpp}}&gpp_sid={{gpp_sid}}")
+	if url := resolve("", "", "", "DBABMA~CPXxRfAPXxRfAAfKABENB-CgAAAAAAAAAAYgAAAAAAAA", "2"); url != "//some-domain.com/getuid?gdpr=&us_privacy=&gpp=DBABMA~CPXxRfAPXxRfAAfKABENB-CgAAAAAAAAAAYgAAAAAAAA&gpp_sid=2" {
+		t.Errorf("Bad URL with only GPP: %s", url)
+	}
+	if url := resolve("1", "", "1YN-", "", ""); url != "//some-domain.com/getuid?gdpr=1&us_privacy=1YN-&gpp=&gpp_sid=" {
+		t.Errorf("Bad URL with only the legacy strings: %s", url)
+	}
+}
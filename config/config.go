@@ -3,13 +3,16 @@ package config
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"reflect"
 	"strings"
 	"time"
 
 	"github.com/golang/glog"
+	"github.com/prebid/prebid-server/currencies"
 	"github.com/prebid/prebid-server/openrtb_ext"
 	"github.com/spf13/viper"
+	"golang.org/x/text/currency"
 )
 
 // Configuration
@@ -38,6 +41,19 @@ type Configuration struct {
 	AMPTimeoutAdjustment int64              `mapstructure:"amp_timeout_adjustment_ms"`
 	GDPR                 GDPR               `mapstructure:"gdpr"`
 	DefReqConfig         DefReqConfig       `mapstructure:"default_request"`
+	CurrencyConverter    CurrencyConverter  `mapstructure:"currency_converter"`
+	// MaxBidsPerImp caps the number of Bids which each Imp keeps, across all bidders. Use 0 for no cap.
+	MaxBidsPerImp int           `mapstructure:"max_bids_per_imp"`
+	BidValidation BidValidation `mapstructure:"bid_validation"`
+	PriceRounding PriceRounding `mapstructure:"price_rounding"`
+	// MaxBidsPerBidder caps the number of Bids which each bidder keeps, summed across all the Imps. Use 0 for no cap.
+	MaxBidsPerBidder int `mapstructure:"max_bids_per_bidder"`
+	// AllowedBidders restricts auctions to these bidders, even if a request names others. Aliases follow their core bidder.
+	// If empty, every bidder is allowed.
+	AllowedBidders []string `mapstructure:"allowed_bidders"`
+	Events         Events   `mapstructure:"events"`
+	// Accounts overrides some of the host's config for requests from these publisher accounts, keyed by account ID.
+	Accounts map[string]Account `mapstructure:"accounts"`
 }
 
 type HTTPClient struct {
@@ -70,10 +86,130 @@ func (cfg *Configuration) validate() configErrors {
 	if cfg.MaxRequestSize < 0 {
 		errs = append(errs, fmt.Errorf("cfg.max_request_size must be >= 0. Got %d", cfg.MaxRequestSize))
 	}
+	if cfg.MaxBidsPerImp < 0 {
+		errs = append(errs, fmt.Errorf("cfg.max_bids_per_imp must be >= 0. Got %d", cfg.MaxBidsPerImp))
+	}
+	if cfg.MaxBidsPerBidder < 0 {
+		errs = append(errs, fmt.Errorf("cfg.max_bids_per_bidder must be >= 0. Got %d", cfg.MaxBidsPerBidder))
+	}
 	errs = cfg.GDPR.validate(errs)
+	errs = cfg.CurrencyConverter.validate(errs)
+	errs = cfg.PriceRounding.validate(errs)
+	for bidder, adapter := range cfg.Adapters {
+		if adapter.LatencyBudget < 0 || adapter.LatencyBudget > 1 {
+			errs = append(errs, fmt.Errorf("cfg.adapters.%s.latency_budget must be between 0 and 1. Got %f", bidder, adapter.LatencyBudget))
+		}
+		for _, cur := range adapter.Currencies {
+			if isExtraCurrency(cfg.BidValidation.ExtraCurrencies, cur) {
+				continue
+			}
+			if _, err := currency.ParseISO(strings.ToUpper(cur)); err != nil {
+				errs = append(errs, fmt.Errorf("cfg.adapters.%s.currencies must contain ISO 4217 codes or bid_validation.extra_currencies. Got \"%s\"", bidder, cur))
+			}
+		}
+	}
+	for _, bidder := range cfg.AllowedBidders {
+		if _, ok := openrtb_ext.BidderMap[bidder]; !ok {
+			errs = append(errs, fmt.Errorf("cfg.allowed_bidders contains %s, which is not a known bidder", bidder))
+		}
+	}
+	if cfg.CacheURL.MaxTTLSeconds < 0 {
+		errs = append(errs, fmt.Errorf("cfg.cache.max_ttl_seconds must be >= 0. Got %d", cfg.CacheURL.MaxTTLSeconds))
+	}
+	if cfg.BidValidation.MaxAdmSize < 0 {
+		errs = append(errs, fmt.Errorf("cfg.bid_validation.max_adm_size must be >= 0. Got %d", cfg.BidValidation.MaxAdmSize))
+	}
+	if cfg.BidValidation.MaxInterstitialRatio < 0 {
+		errs = append(errs, fmt.Errorf("cfg.bid_validation.max_interstitial_ratio must be >= 0. Got %f", cfg.BidValidation.MaxInterstitialRatio))
+	}
+	if cfg.BidValidation.MaxBidPrice < 0 {
+		errs = append(errs, fmt.Errorf("cfg.bid_validation.max_bid_price must be >= 0. Got %f", cfg.BidValidation.MaxBidPrice))
+	}
+	if cfg.BidValidation.MaxCrIDLength < 0 {
+		errs = append(errs, fmt.Errorf("cfg.bid_validation.max_crid_length must be >= 0. Got %d", cfg.BidValidation.MaxCrIDLength))
+	}
+	for _, attr := range cfg.BidValidation.COPPAProhibitedAttributes {
+		if attr <= 0 {
+			errs = append(errs, fmt.Errorf("cfg.bid_validation.coppa_prohibited_attributes must be positive creative attribute IDs. Got %d", attr))
+		}
+	}
+	for mediaType, macros := range cfg.BidValidation.RequiredMacros {
+		if _, err := openrtb_ext.ParseBidType(mediaType); err != nil {
+			errs = append(errs, fmt.Errorf("cfg.bid_validation.required_macros must be keyed by banner, video, audio or native. Got \"%s\"", mediaType))
+		}
+		for _, macro := range macros {
+			if macro == "" {
+				errs = append(errs, fmt.Errorf("cfg.bid_validation.required_macros.%s must not contain empty macros", mediaType))
+			}
+		}
+	}
+	for _, attr := range cfg.BidValidation.DNTProhibitedAttributes {
+		if attr <= 0 {
+			errs = append(errs, fmt.Errorf("cfg.bid_validation.dnt_prohibited_attributes must be positive creative attribute IDs. Got %d", attr))
+		}
+	}
+	for _, path := range cfg.BidValidation.StripBidExt {
+		if path == "" || strings.HasPrefix(path, ".") || strings.HasSuffix(path, ".") || strings.Contains(path, "..") {
+			errs = append(errs, fmt.Errorf("cfg.bid_validation.strip_bid_ext must be keys separated by dots. Got \"%s\"", path))
+		}
+	}
+	if cfg.BidValidation.RejectionTelemetrySampling < 0 {
+		errs = append(errs, fmt.Errorf("cfg.bid_validation.rejection_telemetry_sampling must be >= 0. Got %d", cfg.BidValidation.RejectionTelemetrySampling))
+	}
+	if cfg.BidValidation.FloorTolerance < 0 || cfg.BidValidation.FloorTolerance >= 1 {
+		errs = append(errs, fmt.Errorf("cfg.bid_validation.floor_tolerance must be >= 0 and < 1. Got %f", cfg.BidValidation.FloorTolerance))
+	}
+	if _, err := currency.ParseISO(cfg.BidValidation.DefaultCurrency); cfg.BidValidation.DefaultCurrency != "" && err != nil {
+		errs = append(errs, fmt.Errorf("cfg.bid_validation.default_currency must be an ISO 4217 currency code. Got \"%s\"", cfg.BidValidation.DefaultCurrency))
+	}
+	for accountID, account := range cfg.Accounts {
+		if _, err := currency.ParseISO(account.DefaultCurrency); account.DefaultCurrency != "" && err != nil {
+			errs = append(errs, fmt.Errorf("cfg.accounts.%s.default_currency must be an ISO 4217 currency code. Got \"%s\"", accountID, account.DefaultCurrency))
+		}
+		switch account.ValidationMode {
+		case "", ValidationModeEnforce, ValidationModePermissive:
+		default:
+			errs = append(errs, fmt.Errorf("cfg.accounts.%s.validation_mode must be \"%s\" or \"%s\". Got \"%s\"", accountID, ValidationModeEnforce, ValidationModePermissive, account.ValidationMode))
+		}
+		for _, mediaType := range account.MediaTypes {
+			if _, err := openrtb_ext.ParseBidType(mediaType); err != nil {
+				errs = append(errs, fmt.Errorf("cfg.accounts.%s.media_types must contain banner, video, audio or native. Got \"%s\"", accountID, mediaType))
+			}
+		}
+	}
+	switch cfg.BidValidation.DuplicateBidIDs {
+	case "", DuplicateBidIDsKeepFirst, DuplicateBidIDsKeepHighestPrice:
+	default:
+		errs = append(errs, fmt.Errorf("cfg.bid_validation.duplicate_bid_ids must be \"%s\" or \"%s\". Got \"%s\"", DuplicateBidIDsKeepFirst, DuplicateBidIDsKeepHighestPrice, cfg.BidValidation.DuplicateBidIDs))
+	}
+	switch cfg.BidValidation.MissingSeat {
+	case "", MissingSeatAssign, MissingSeatDrop:
+	default:
+		errs = append(errs, fmt.Errorf("cfg.bid_validation.missing_seat must be \"%s\" or \"%s\". Got \"%s\"", MissingSeatAssign, MissingSeatDrop, cfg.BidValidation.MissingSeat))
+	}
+	switch cfg.BidValidation.Mode {
+	case "", ValidationModeEnforce, ValidationModePermissive:
+	default:
+		errs = append(errs, fmt.Errorf("cfg.bid_validation.mode must be \"%s\" or \"%s\". Got \"%s\"", ValidationModeEnforce, ValidationModePermissive, cfg.BidValidation.Mode))
+	}
+	switch cfg.BidValidation.CurrencyCheck {
+	case "", CurrencyCheckSeat, CurrencyCheckBid:
+	default:
+		errs = append(errs, fmt.Errorf("cfg.bid_validation.currency_check must be \"%s\" or \"%s\". Got \"%s\"", CurrencyCheckSeat, CurrencyCheckBid, cfg.BidValidation.CurrencyCheck))
+	}
 	return errs
 }
 
+// isExtraCurrency returns true if the code is one of the extraCurrencies, ignoring case.
+func isExtraCurrency(extraCurrencies []string, code string) bool {
+	for _, extra := range extraCurrencies {
+		if strings.EqualFold(extra, code) {
+			return true
+		}
+	}
+	return false
+}
+
 type AuctionTimeouts struct {
 	// The default timeout is used if the user's request didn't define one. Use 0 if there's no default.
 	Default uint64 `mapstructure:"default"`
@@ -129,6 +265,247 @@ func (t *GDPRTimeouts) ActiveTimeout() time.Duration {
 	return time.Duration(t.ActiveVendorlistFetch) * time.Millisecond
 }
 
+// BidValidation configures the checks which Prebid Server makes on every Bid.
+type BidValidation struct {
+	// SkipSecureMarkupCheck stops rejecting Bids with insecure http:// resources in their markup when the Imp is secure.
+	// Hosts which rewrite the markup into https:// before serving it can use this to keep those Bids.
+	SkipSecureMarkupCheck bool `mapstructure:"skip_secure_markup_check"`
+	// CheckSecureNURL also rejects Bids on secure Imps whose nurl is http://, since browsers block those win notices.
+	// It's off by default. The request's skipsecurecheck turns it off too.
+	CheckSecureNURL bool `mapstructure:"check_secure_nurl"`
+	// EndpointEnabled exposes the /validation/bids endpoint, which reports how Prebid Server would treat a SeatBid.
+	// It's meant for onboarding demand partners, so it should stay disabled in production.
+	EndpointEnabled bool `mapstructure:"endpoint_enabled"`
+	// ExtraCurrencies are accepted as Bid currencies in addition to the ISO 4217 codes.
+	// Requests must still list them in the request.cur for the Bids to be allowed.
+	ExtraCurrencies []string `mapstructure:"extra_currencies"`
+	// CheckVAST rejects video Bids whose adm isn't well-formed VAST XML. It's off by default, since parsing every Bid is slow.
+	CheckVAST bool `mapstructure:"check_vast"`
+	// CheckNativeAssets rejects native Bids whose adm is missing any of the assets which the Imp's native request marks as required.
+	CheckNativeAssets bool `mapstructure:"check_native_assets"`
+	// AllowZeroPriceBids keeps Bids with a zero price, which are otherwise rejected. Each one is reported as a warning.
+	// This is meant for test and house ads. Bids with negative prices are rejected regardless.
+	AllowZeroPriceBids bool `mapstructure:"allow_zero_price_bids"`
+	// MaxAdmSize is the largest adm, in bytes, which a Bid may have. Larger Bids are rejected to protect
+	// Prebid Cache and the response size. Use 0 for no limit.
+	MaxAdmSize int `mapstructure:"max_adm_size"`
+	// MaxInterstitialRatio is how many times the device's screen width or height a Bid on an interstitial Imp may be.
+	// Larger Bids are rejected, since they'd overflow the screen. For example, 1 means they must fit it. Use 0 for no limit.
+	MaxInterstitialRatio float64 `mapstructure:"max_interstitial_ratio"`
+	// MaxBidPrice is the highest CPM which a Bid may have, in the request's first currency or the default one if it has none.
+	// Bids in other currencies are converted first.
+	// Absurd prices usually come from buggy demand, and would corrupt reporting if they won. Use 0 for no limit.
+	MaxBidPrice float64 `mapstructure:"max_bid_price"`
+	// MaxCrIDLength is the longest crid, in bytes, which a Bid may have. Some ad servers truncate long creative IDs,
+	// which breaks reconciliation, so longer Bids are rejected. Use 0 for no limit.
+	MaxCrIDLength int `mapstructure:"max_crid_length"`
+	// RequiredBidMeta lists the keys which each Bid must define in its ext.prebid.meta, like "advertiserDomains".
+	// Bids which are missing any of them are rejected.
+	RequiredBidMeta []string `mapstructure:"required_bid_meta"`
+	// SummarizeRejections reports each Bidder's rejected Bids as one error per reason, rather than one per Bid.
+	// Debug responses still list every rejected Bid in the response.ext.debug.rejectedbids.
+	SummarizeRejections bool `mapstructure:"summarize_rejections"`
+	// ReportNoBidReasons adds response.ext.prebid.nobidreasons, which counts why the Bids on each Imp without a Bid were rejected.
+	// Unlike debug info, it only has the rejection reasons, so it's safe to enable in production.
+	ReportNoBidReasons bool `mapstructure:"report_no_bid_reasons"`
+	// BackfillAdvertiserDomains copies the bid.adomain into a Bid's meta.advertiserDomains, if the Bidder left it empty.
+	BackfillAdvertiserDomains bool `mapstructure:"backfill_advertiser_domains"`
+	// DefaultCurrency is assumed for requests which don't list any currencies, and for Bids which don't declare one.
+	// Accounts may override it.
+	DefaultCurrency string `mapstructure:"default_currency"`
+	// COPPAProhibitedAttributes are the OpenRTB creative attributes which Bids may not have when the request.regs.coppa is 1.
+	// Those Bids are rejected, as are ones whose meta says they used behavioral targeting.
+	COPPAProhibitedAttributes []int `mapstructure:"coppa_prohibited_attributes"`
+	// CheckDNT rejects Bids which track the user when the request.device.dnt is 1: ones whose meta says they used
+	// behavioral targeting, and ones with any of the DNTProhibitedAttributes. Requests without Do-Not-Track aren't affected.
+	CheckDNT bool `mapstructure:"check_dnt"`
+	// DNTProhibitedAttributes are the OpenRTB creative attributes which Bids may not have under Do-Not-Track, if CheckDNT is on.
+	DNTProhibitedAttributes []int `mapstructure:"dnt_prohibited_attributes"`
+	// BannerMIMEs lists the creative MIME types, like "image/png", which banner Bids may declare in their bid.ext "mime".
+	// Bids which declare any other type are rejected. If empty, all types are allowed. Accounts may override it.
+	BannerMIMEs []string `mapstructure:"banner_mimes"`
+	// RequiredMacros lists the macros, like "${AUCTION_PRICE}", which the adm of Bids must contain, keyed by media type.
+	// Hosts who substitute the macros on the server can use this to reject Bids whose price could never be filled in.
+	RequiredMacros map[string][]string `mapstructure:"required_macros"`
+	// StripBidExt lists the keys which are removed from each valid Bid's bid.ext before it's cached or returned, so that
+	// Bidders' internal data doesn't reach publishers. Nested keys are separated by dots, like "vendor.internal".
+	StripBidExt []string `mapstructure:"strip_bid_ext"`
+	// NormalizeBidExt gives each valid Bid without a bid.ext a minimal one, with an ext.prebid which holds the Bid's type.
+	// It's off by default, since some hosts depend on those exts staying empty.
+	NormalizeBidExt bool `mapstructure:"normalize_bid_ext"`
+	// BlockedCreativeIDs rejects Bids with these crids. Operators can block more at runtime through the admin server.
+	BlockedCreativeIDs []string `mapstructure:"blocked_creative_ids"`
+	// MissingSeat says what to do with SeatBids whose seat isn't the name of the Bidder which made them.
+	// "assign" attributes them to the Bidder, and "drop" rejects all of their Bids.
+	MissingSeat string `mapstructure:"missing_seat"`
+	// DuplicateBidIDs says which Bid to keep when a SeatBid has several with the same ID. The others are rejected.
+	// "first" keeps the first one, and "highest_price" keeps the one with the highest price.
+	DuplicateBidIDs string `mapstructure:"duplicate_bid_ids"`
+	// FloorTolerance is how far below its imp's floor a Bid may be, as a fraction of the floor, before it's rejected.
+	// This absorbs rounding errors from currency conversion. Bids within the tolerance are kept with a warning.
+	FloorTolerance float64 `mapstructure:"floor_tolerance"`
+	// RejectionTelemetrySampling records the bid rejection metrics and debug info for 1 in every RejectionTelemetrySampling auctions.
+	// Invalid Bids are removed from every auction regardless. Use 0 or 1 to record them for every auction.
+	RejectionTelemetrySampling int `mapstructure:"rejection_telemetry_sampling"`
+	// Mode says what happens to invalid Bids. "enforce" rejects them, and "permissive" keeps them with a warning
+	// for each problem, so that publishers can audit their demand before enforcing validation. Accounts may override it.
+	Mode string `mapstructure:"mode"`
+	// CurrencyCheck says how Bid currencies are checked against the request.cur. "seat" rejects the whole SeatBid if
+	// its currency isn't allowed, and "bid" checks each Bid on its own, in its bid.ext "cur" if it has one.
+	CurrencyCheck string `mapstructure:"currency_check"`
+}
+
+const (
+	// CurrencyCheckSeat rejects every Bid in a SeatBid whose currency isn't allowed.
+	CurrencyCheckSeat = "seat"
+	// CurrencyCheckBid checks the currency of each Bid on its own, so that Bids in allowed currencies survive.
+	CurrencyCheckBid = "bid"
+)
+
+const (
+	// MissingSeatAssign attributes a SeatBid without a resolvable seat to the Bidder which made it.
+	MissingSeatAssign = "assign"
+	// MissingSeatDrop rejects every Bid in a SeatBid without a resolvable seat.
+	MissingSeatDrop = "drop"
+)
+
+const (
+	// ValidationModeEnforce rejects invalid Bids.
+	ValidationModeEnforce = "enforce"
+	// ValidationModePermissive keeps invalid Bids, and warns about each of their problems instead.
+	ValidationModePermissive = "permissive"
+)
+
+const (
+	// DuplicateBidIDsKeepFirst keeps the first of the Bids which share an ID.
+	DuplicateBidIDsKeepFirst = "first"
+	// DuplicateBidIDsKeepHighestPrice keeps the highest priced of the Bids which share an ID. If their prices tie, the first one is kept.
+	DuplicateBidIDsKeepHighestPrice = "highest_price"
+)
+
+// Account holds the config for a single publisher account.
+type Account struct {
+	// DefaultCurrency overrides the bid_validation.default_currency for this account. For example, "EUR" for EU publishers.
+	DefaultCurrency string `mapstructure:"default_currency"`
+	// AllowSkipValidation lets this account's requests skip bid validation with request.ext.prebid.validation.skipall.
+	// It should only be given to publishers whose demand is fully trusted.
+	AllowSkipValidation bool `mapstructure:"allow_skip_validation"`
+	// BannerMIMEs overrides the bid_validation.banner_mimes for this account.
+	BannerMIMEs []string `mapstructure:"banner_mimes"`
+	// ValidationMode overrides the bid_validation.mode for this account.
+	ValidationMode string `mapstructure:"validation_mode"`
+	// MediaTypes lists the media types, like "banner", which this account may run. Bids of other types are dropped.
+	// If empty, every type is allowed.
+	MediaTypes []string `mapstructure:"media_types"`
+}
+
+// Events configures the win and imp event URLs which Prebid Server adds to valid Bids.
+// These point at the /event endpoint on the ExternalURL.
+type Events struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Accounts limits the event URLs to requests from these publisher accounts. If empty, every account gets them.
+	Accounts []string `mapstructure:"accounts"`
+}
+
+// PriceRounding normalizes Bid prices after currency conversion, so that the targeting keys match the ad server's granularity.
+type PriceRounding struct {
+	// Mode must be one of the PriceRounding values. Leave it empty to keep prices unchanged.
+	Mode string `mapstructure:"mode"`
+	// Precision is the number of decimal places to keep. Currencies with fewer minor units, like JPY, keep fewer.
+	Precision int `mapstructure:"precision"`
+}
+
+const (
+	// PriceRoundingHalfUp rounds prices to the nearest value, and rounds halves up.
+	PriceRoundingHalfUp = "half_up"
+	// PriceRoundingTruncate drops the digits past the precision.
+	PriceRoundingTruncate = "truncate"
+)
+
+func (cfg *PriceRounding) validate(errs configErrors) configErrors {
+	switch cfg.Mode {
+	case "", PriceRoundingHalfUp, PriceRoundingTruncate:
+	default:
+		errs = append(errs, fmt.Errorf("price_rounding.mode must be \"%s\" or \"%s\". Got \"%s\"", PriceRoundingHalfUp, PriceRoundingTruncate, cfg.Mode))
+	}
+	if cfg.Precision < 0 {
+		errs = append(errs, fmt.Errorf("price_rounding.precision must be >= 0. Got %d", cfg.Precision))
+	}
+	return errs
+}
+
+type CurrencyConverter struct {
+	FetchURL string `mapstructure:"fetch_url"`
+	// FetchIntervalSeconds is how often the conversion rates are refreshed. Use 0 to disable currency conversion.
+	FetchIntervalSeconds int `mapstructure:"fetch_interval_seconds"`
+	// TargetSelection decides which currency Bids are converted into when the request.cur has several entries.
+	// It must be one of the CurrencySelection values.
+	TargetSelection string `mapstructure:"target_selection"`
+	// StaleRatesSeconds is how old the fetched rates may get before the StaleRatesPolicy applies. Use 0 to never treat them as stale.
+	StaleRatesSeconds int `mapstructure:"stale_rates_seconds"`
+	// StaleRatesPolicy decides what happens to stale rates. It must be "reject", "warn" or "static".
+	StaleRatesPolicy string `mapstructure:"stale_rates_policy"`
+	// StaticRatesFile holds the rates used by the "static" StaleRatesPolicy, in the same format as the FetchURL.
+	StaticRatesFile string `mapstructure:"static_rates_file"`
+	// FallbackRatesFile holds rates for the conversions which the fetched rates can't make, in the same format as the FetchURL.
+	// This keeps Bids convertible while the FetchURL is unavailable. If empty, there are no fallback rates.
+	FallbackRatesFile string `mapstructure:"fallback_rates_file"`
+	// BidAdjustments multiply the prices of Bids in each currency, keyed by ISO 4217 code. For example, 0.9 down-weights
+	// a currency with volatile rates. They apply on top of the request's bidadjustmentfactors. Unlisted currencies are left alone.
+	BidAdjustments map[string]float64 `mapstructure:"bid_adjustments"`
+}
+
+const (
+	// CurrencySelectionFirst picks the first currency in the request.cur which all the Bids can be converted into.
+	CurrencySelectionFirst = "first"
+	// CurrencySelectionHighestValue picks the currency in the request.cur which makes the top Bid's price the largest number.
+	CurrencySelectionHighestValue = "highest_value"
+	// CurrencySelectionAccountDefault picks the account's default currency, if the request.cur has it and all the Bids
+	// can be converted into it. Otherwise, it falls back to CurrencySelectionFirst.
+	CurrencySelectionAccountDefault = "account_default"
+)
+
+func (cfg *CurrencyConverter) validate(errs configErrors) configErrors {
+	if cfg.FetchIntervalSeconds < 0 {
+		errs = append(errs, fmt.Errorf("currency_converter.fetch_interval_seconds must be >= 0. Got %d", cfg.FetchIntervalSeconds))
+	}
+	switch cfg.TargetSelection {
+	case "", CurrencySelectionFirst, CurrencySelectionHighestValue, CurrencySelectionAccountDefault:
+	default:
+		errs = append(errs, fmt.Errorf("currency_converter.target_selection must be \"%s\", \"%s\" or \"%s\". Got \"%s\"", CurrencySelectionFirst, CurrencySelectionHighestValue, CurrencySelectionAccountDefault, cfg.TargetSelection))
+	}
+	if cfg.StaleRatesSeconds < 0 {
+		errs = append(errs, fmt.Errorf("currency_converter.stale_rates_seconds must be >= 0. Got %d", cfg.StaleRatesSeconds))
+	}
+	switch currencies.StalenessPolicy(cfg.StaleRatesPolicy) {
+	case "", currencies.StalenessPolicyReject, currencies.StalenessPolicyWarn:
+	case currencies.StalenessPolicyStatic:
+		if cfg.StaticRatesFile == "" {
+			errs = append(errs, fmt.Errorf("currency_converter.static_rates_file is required when currency_converter.stale_rates_policy is \"static\""))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("currency_converter.stale_rates_policy must be \"reject\", \"warn\" or \"static\". Got \"%s\"", cfg.StaleRatesPolicy))
+	}
+	for cur, multiplier := range cfg.BidAdjustments {
+		if _, err := currency.ParseISO(strings.ToUpper(cur)); err != nil {
+			errs = append(errs, fmt.Errorf("currency_converter.bid_adjustments must be keyed by ISO 4217 currency codes. Got \"%s\"", cur))
+		}
+		if multiplier <= 0 || math.IsInf(multiplier, 0) || math.IsNaN(multiplier) {
+			errs = append(errs, fmt.Errorf("currency_converter.bid_adjustments.%s must be a positive number. Got %f", cur, multiplier))
+		}
+	}
+	return errs
+}
+
+func (cfg *CurrencyConverter) FetchInterval() time.Duration {
+	return time.Duration(cfg.FetchIntervalSeconds) * time.Second
+}
+
+// StaleRatesAge is how old the rates may get before they're stale. It's zero if they never are.
+func (cfg *CurrencyConverter) StaleRatesAge() time.Duration {
+	return time.Duration(cfg.StaleRatesSeconds) * time.Second
+}
+
 type Analytics struct {
 	File FileLogs `mapstructure:"file"`
 }
@@ -154,15 +531,24 @@ func (cfg *HostCookie) TTLDuration() time.Duration {
 }
 
 type Adapter struct {
-	Endpoint    string `mapstructure:"endpoint"` // Required
-	UserSyncURL string `mapstructure:"usersync_url"`
-	PlatformID  string `mapstructure:"platform_id"` // needed for Facebook
-	PartnerId   string `mapstructure:"partner_id"`  // needed for 33Across
-	XAPI        struct {
+	Endpoint       string `mapstructure:"endpoint"` // Required
+	UserSyncURL    string `mapstructure:"usersync_url"`
+	UserSyncType   string `mapstructure:"usersync_type"`   // "iframe" or "redirect". Only used by Adform for now
+	UserSyncFamily string `mapstructure:"usersync_family"` // Overrides the cookie family name. Only used by Adform for now
+	GDPRVendorID   uint16 `mapstructure:"gdpr_vendor_id"`  // Overrides the IAB vendor ID. Only used by Adform for now
+	PlatformID     string `mapstructure:"platform_id"`     // needed for Facebook
+	PartnerId      string `mapstructure:"partner_id"`      // needed for 33Across
+	XAPI           struct {
 		Username string `mapstructure:"username"`
 		Password string `mapstructure:"password"`
 		Tracker  string `mapstructure:"tracker"`
 	} `mapstructure:"xapi"` // needed for Rubicon
+	// LatencyBudget is the fraction of the auction timeout which this bidder may use, between 0 and 1.
+	// Bidders which take longer are cut off, and their Bids are discarded. Use 0 to let them use the whole timeout.
+	LatencyBudget float64 `mapstructure:"latency_budget"`
+	// Currencies are the only ones which this bidder may bid in, even if the request accepts others.
+	// For example, a US-only DSP might be limited to USD. If empty, the bidder may use any currency.
+	Currencies []string `mapstructure:"currencies"`
 }
 
 type Metrics struct {
@@ -208,6 +594,16 @@ type Cache struct {
 	ExpectedTimeMillis int `mapstructure:"expected_millis"`
 
 	DefaultTTLs DefaultTTLs `mapstructure:"default_ttl_seconds"`
+	// CapTTLAtDefault caches each Bid for no longer than the default TTL of its media type, even if the
+	// imp.exp or bid.exp asks for longer. Otherwise the default is only used when neither of them is set.
+	CapTTLAtDefault bool `mapstructure:"cap_ttl_at_default"`
+	// MaxTTLSeconds is the longest any Bid is cached for, even if its imp.exp, bid.exp or default TTL asks for longer.
+	// It protects the cache's storage from Bidders with long expiries. If 0, there is no cap.
+	MaxTTLSeconds int `mapstructure:"max_ttl_seconds"`
+	// SigningSecret keys the HMAC which is stored alongside each cached creative. If empty, creatives aren't signed.
+	SigningSecret string `mapstructure:"signing_secret"`
+	// DedupeCreatives stores identical creatives from different Bids once, so that those Bids share a cache key.
+	DedupeCreatives bool `mapstructure:"dedupe_creatives"`
 }
 
 // Default TTLs to use to cache bids for different types of imps.
@@ -289,6 +685,10 @@ func SetupViper(v *viper.Viper, filename string) {
 	v.SetDefault("cache.default_ttl_seconds.video", 0)
 	v.SetDefault("cache.default_ttl_seconds.native", 0)
 	v.SetDefault("cache.default_ttl_seconds.audio", 0)
+	v.SetDefault("cache.cap_ttl_at_default", false)
+	v.SetDefault("cache.max_ttl_seconds", 0)
+	v.SetDefault("cache.signing_secret", "")
+	v.SetDefault("cache.dedupe_creatives", false)
 	v.SetDefault("recaptcha_secret", "")
 	v.SetDefault("host_cookie.domain", "")
 	v.SetDefault("host_cookie.family", "")
@@ -384,6 +784,37 @@ func SetupViper(v *viper.Viper, filename string) {
 	v.SetDefault("adapters.rhythmone.usersync_url", "//sync.1rx.io/usersync2/rmphb?gdpr={{gdpr}}&gdpr_consent={{gdpr_consent}}&redir=")
 
 	v.SetDefault("max_request_size", 1024*256)
+	v.SetDefault("max_bids_per_imp", 0)
+	v.SetDefault("max_bids_per_bidder", 0)
+	v.SetDefault("events.enabled", false)
+	v.SetDefault("bid_validation.skip_secure_markup_check", false)
+	v.SetDefault("bid_validation.normalize_bid_ext", false)
+	v.SetDefault("bid_validation.currency_check", CurrencyCheckSeat)
+	v.SetDefault("bid_validation.check_secure_nurl", false)
+	v.SetDefault("bid_validation.endpoint_enabled", false)
+	v.SetDefault("bid_validation.check_vast", false)
+	v.SetDefault("bid_validation.check_native_assets", false)
+	v.SetDefault("bid_validation.allow_zero_price_bids", false)
+	v.SetDefault("bid_validation.max_adm_size", 0)
+	v.SetDefault("bid_validation.max_interstitial_ratio", 0)
+	v.SetDefault("bid_validation.max_crid_length", 0)
+	v.SetDefault("bid_validation.max_bid_price", 0)
+	v.SetDefault("bid_validation.backfill_advertiser_domains", false)
+	v.SetDefault("bid_validation.summarize_rejections", false)
+	v.SetDefault("bid_validation.report_no_bid_reasons", false)
+	v.SetDefault("bid_validation.coppa_prohibited_attributes", []int{})
+	v.SetDefault("bid_validation.check_dnt", false)
+	v.SetDefault("bid_validation.dnt_prohibited_attributes", []int{})
+	v.SetDefault("bid_validation.banner_mimes", []string{})
+	v.SetDefault("bid_validation.strip_bid_ext", []string{})
+	v.SetDefault("bid_validation.missing_seat", MissingSeatAssign)
+	v.SetDefault("bid_validation.default_currency", "USD")
+	v.SetDefault("bid_validation.duplicate_bid_ids", DuplicateBidIDsKeepFirst)
+	v.SetDefault("bid_validation.floor_tolerance", 0)
+	v.SetDefault("bid_validation.rejection_telemetry_sampling", 0)
+	v.SetDefault("bid_validation.mode", ValidationModeEnforce)
+	v.SetDefault("price_rounding.mode", "")
+	v.SetDefault("price_rounding.precision", 2)
 	v.SetDefault("analytics.file.filename", "")
 	v.SetDefault("amp_timeout_adjustment_ms", 0)
 	v.SetDefault("gdpr.host_vendor_id", 0)
@@ -393,6 +824,13 @@ func SetupViper(v *viper.Viper, filename string) {
 	v.SetDefault("default_request.type", "")
 	v.SetDefault("default_request.file.name", "")
 	v.SetDefault("default_request.alias_info", false)
+	v.SetDefault("currency_converter.fetch_url", "https://cdn.jsdelivr.net/gh/prebid/currency-file@1/latest.json")
+	v.SetDefault("currency_converter.fetch_interval_seconds", 0)
+	v.SetDefault("currency_converter.target_selection", CurrencySelectionFirst)
+	v.SetDefault("currency_converter.stale_rates_seconds", 0)
+	v.SetDefault("currency_converter.stale_rates_policy", string(currencies.StalenessPolicyWarn))
+	v.SetDefault("currency_converter.static_rates_file", "")
+	v.SetDefault("currency_converter.fallback_rates_file", "")
 
 	// Set environment variable support:
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
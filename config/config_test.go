@@ -2,6 +2,7 @@ package config
 
 import (
 	"bytes"
+	"math"
 	"strings"
 	"testing"
 	"time"
@@ -65,6 +66,13 @@ datacache:
   filename: /usr/db/db.db
   cache_size: 10000000
   ttl_seconds: 3600
+currency_converter:
+  fetch_url: https://currency.prebid.org/latest.json
+  fetch_interval_seconds: 1800
+  target_selection: highest_value
+  stale_rates_seconds: 7200
+  stale_rates_policy: reject
+  fallback_rates_file: /etc/prebid/rates.json
 adapters:
   appnexus:
     endpoint: http://ib.adnxs.com/some/endpoint
@@ -137,6 +145,12 @@ func TestFullConfig(t *testing.T) {
 	cmpInts(t, "gdpr.host_vendor_id", cfg.GDPR.HostVendorID, 15)
 	cmpBools(t, "gdpr.usersync_if_ambiguous", cfg.GDPR.UsersyncIfAmbiguous, true)
 	cmpStrings(t, "recaptcha_secret", cfg.RecaptchaSecret, "asdfasdfasdfasdf")
+	cmpStrings(t, "currency_converter.fetch_url", cfg.CurrencyConverter.FetchURL, "https://currency.prebid.org/latest.json")
+	cmpInts(t, "currency_converter.fetch_interval_seconds", cfg.CurrencyConverter.FetchIntervalSeconds, 1800)
+	cmpStrings(t, "currency_converter.target_selection", cfg.CurrencyConverter.TargetSelection, "highest_value")
+	cmpInts(t, "currency_converter.stale_rates_seconds", cfg.CurrencyConverter.StaleRatesSeconds, 7200)
+	cmpStrings(t, "currency_converter.stale_rates_policy", cfg.CurrencyConverter.StaleRatesPolicy, "reject")
+	cmpStrings(t, "currency_converter.fallback_rates_file", cfg.CurrencyConverter.FallbackRatesFile, "/etc/prebid/rates.json")
 	cmpStrings(t, "metrics.influxdb.host", cfg.Metrics.Influxdb.Host, "upstream:8232")
 	cmpStrings(t, "metrics.influxdb.database", cfg.Metrics.Influxdb.Database, "metricsdb")
 	cmpStrings(t, "metrics.influxdb.username", cfg.Metrics.Influxdb.Username, "admin")
@@ -188,6 +202,297 @@ func TestNegativeRequestSize(t *testing.T) {
 	}
 }
 
+func TestNegativeMaxBidsPerImp(t *testing.T) {
+	cfg := Configuration{
+		MaxBidsPerImp: -1,
+	}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.max_bids_per_imp should prevent negative values, but it doesn't")
+	}
+}
+
+func TestNegativeMaxBidsPerBidder(t *testing.T) {
+	cfg := Configuration{
+		MaxBidsPerBidder: -1,
+	}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.max_bids_per_bidder should prevent negative values, but it doesn't")
+	}
+}
+
+func TestNegativeMaxAdmSize(t *testing.T) {
+	cfg := Configuration{
+		BidValidation: BidValidation{
+			MaxAdmSize: -1,
+		},
+	}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.bid_validation.max_adm_size should prevent negative values, but it doesn't")
+	}
+}
+
+func TestNegativeMaxInterstitialRatio(t *testing.T) {
+	cfg := Configuration{
+		BidValidation: BidValidation{
+			MaxInterstitialRatio: -1,
+		},
+	}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.bid_validation.max_interstitial_ratio should prevent negative values, but it doesn't")
+	}
+}
+
+func TestNegativeMaxBidPrice(t *testing.T) {
+	cfg := Configuration{
+		BidValidation: BidValidation{
+			MaxBidPrice: -1,
+		},
+	}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.bid_validation.max_bid_price should prevent negative values, but it doesn't")
+	}
+}
+
+func TestNegativeMaxCrIDLength(t *testing.T) {
+	cfg := Configuration{
+		BidValidation: BidValidation{
+			MaxCrIDLength: -1,
+		},
+	}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.bid_validation.max_crid_length should prevent negative values, but it doesn't")
+	}
+}
+
+func TestNegativeRejectionTelemetrySampling(t *testing.T) {
+	cfg := Configuration{
+		BidValidation: BidValidation{
+			RejectionTelemetrySampling: -1,
+		},
+	}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.bid_validation.rejection_telemetry_sampling should prevent negative values, but it doesn't")
+	}
+}
+
+func TestInvalidCOPPAProhibitedAttributes(t *testing.T) {
+	cfg := Configuration{
+		BidValidation: BidValidation{
+			COPPAProhibitedAttributes: []int{1, 0},
+		},
+	}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.bid_validation.coppa_prohibited_attributes should prevent non-positive values, but it doesn't")
+	}
+}
+
+func TestInvalidMissingSeat(t *testing.T) {
+	cfg := Configuration{
+		BidValidation: BidValidation{
+			MissingSeat: "ignore",
+		},
+	}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.bid_validation.missing_seat should only allow assign or drop, but it doesn't")
+	}
+}
+
+func TestInvalidDuplicateBidIDs(t *testing.T) {
+	cfg := Configuration{
+		BidValidation: BidValidation{
+			DuplicateBidIDs: "last",
+		},
+	}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.bid_validation.duplicate_bid_ids should only allow first or highest_price, but it doesn't")
+	}
+}
+
+func TestInvalidValidationModes(t *testing.T) {
+	cfg := Configuration{
+		BidValidation: BidValidation{
+			Mode: "lenient",
+		},
+	}
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.bid_validation.mode should only allow enforce or permissive, but it doesn't")
+	}
+
+	cfg = Configuration{
+		Accounts: map[string]Account{
+			"some-account": {ValidationMode: "lenient"},
+		},
+	}
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.accounts.{id}.validation_mode should only allow enforce or permissive, but it doesn't")
+	}
+}
+
+func TestInvalidCurrencyCheck(t *testing.T) {
+	cfg := Configuration{
+		BidValidation: BidValidation{
+			CurrencyCheck: "imp",
+		},
+	}
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.bid_validation.currency_check should only allow seat or bid, but it doesn't")
+	}
+}
+
+func TestInvalidRequiredMacros(t *testing.T) {
+	cfg := Configuration{
+		BidValidation: BidValidation{
+			RequiredMacros: map[string][]string{"display": {"${AUCTION_PRICE}"}},
+		},
+	}
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.bid_validation.required_macros should only allow media types as keys, but it doesn't")
+	}
+
+	cfg.BidValidation.RequiredMacros = map[string][]string{"banner": {""}}
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.bid_validation.required_macros should not allow empty macros, but it does")
+	}
+}
+
+func TestInvalidAccountMediaTypes(t *testing.T) {
+	cfg := Configuration{
+		Accounts: map[string]Account{
+			"banner-only":  {MediaTypes: []string{"banner"}},
+			"some-account": {MediaTypes: []string{"banner", "display"}},
+		},
+	}
+	found := false
+	for _, err := range cfg.validate() {
+		if strings.Contains(err.Error(), "cfg.accounts.banner-only.media_types") {
+			t.Errorf("cfg.accounts.{id}.media_types should allow media types. Got %v", err)
+		}
+		found = found || strings.Contains(err.Error(), `cfg.accounts.some-account.media_types must contain banner, video, audio or native. Got "display"`)
+	}
+	if !found {
+		t.Error("cfg.accounts.{id}.media_types should only allow media types, but it doesn't")
+	}
+}
+
+func TestNegativeMaxCacheTTL(t *testing.T) {
+	cfg := Configuration{
+		CacheURL: Cache{
+			MaxTTLSeconds: -1,
+		},
+	}
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.cache.max_ttl_seconds should not be negative, but it is")
+	}
+}
+
+func TestInvalidDNTProhibitedAttributes(t *testing.T) {
+	cfg := Configuration{
+		BidValidation: BidValidation{
+			DNTProhibitedAttributes: []int{0},
+		},
+	}
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.bid_validation.dnt_prohibited_attributes should only allow positive attribute IDs, but it doesn't")
+	}
+}
+
+func TestInvalidStripBidExt(t *testing.T) {
+	for _, path := range []string{"", ".vendor", "vendor.", "vendor..internal"} {
+		cfg := Configuration{
+			BidValidation: BidValidation{
+				StripBidExt: []string{path},
+			},
+		}
+		if err := cfg.validate(); err == nil {
+			t.Errorf("cfg.bid_validation.strip_bid_ext should reject \"%s\", but it doesn't", path)
+		}
+	}
+}
+
+func TestInvalidDefaultCurrencies(t *testing.T) {
+	cfg := Configuration{
+		BidValidation: BidValidation{
+			DefaultCurrency: "EURO",
+		},
+	}
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.bid_validation.default_currency should only allow ISO 4217 codes, but it doesn't")
+	}
+
+	cfg = Configuration{
+		Accounts: map[string]Account{
+			"eu-publisher": {
+				DefaultCurrency: "not-a-currency",
+			},
+		},
+	}
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.accounts.{id}.default_currency should only allow ISO 4217 codes, but it doesn't")
+	}
+}
+
+func TestValidDefaultCurrencies(t *testing.T) {
+	cfg := Configuration{
+		StoredRequests: StoredRequests{
+			Files: true,
+			InMemoryCache: InMemoryCache{
+				Type: "none",
+			},
+		},
+		BidValidation: BidValidation{
+			DefaultCurrency: "USD",
+		},
+		Accounts: map[string]Account{
+			"eu-publisher": {
+				DefaultCurrency: "EUR",
+			},
+			"other-publisher": {},
+		},
+	}
+	if err := cfg.validate(); err != nil {
+		t.Errorf("Valid default currencies should be allowed. Got %v", err)
+	}
+}
+
+func TestUnknownAllowedBidder(t *testing.T) {
+	cfg := Configuration{
+		AllowedBidders: []string{"appnexus", "not-a-bidder"},
+	}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.allowed_bidders should prevent unknown bidders, but it doesn't")
+	}
+}
+
+func TestInvalidPriceRounding(t *testing.T) {
+	cfg := Configuration{
+		PriceRounding: PriceRounding{
+			Mode: "half_down",
+		},
+	}
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.price_rounding.mode should prevent unknown modes, but it doesn't")
+	}
+
+	cfg.PriceRounding = PriceRounding{
+		Mode:      PriceRoundingTruncate,
+		Precision: -1,
+	}
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.price_rounding.precision should prevent negative values, but it doesn't")
+	}
+}
+
 func TestNegativeVendorID(t *testing.T) {
 	cfg := Configuration{
 		GDPR: GDPR{
@@ -200,6 +505,114 @@ func TestNegativeVendorID(t *testing.T) {
 	}
 }
 
+func TestNegativeCurrencyConverterFetchInterval(t *testing.T) {
+	cfg := Configuration{
+		CurrencyConverter: CurrencyConverter{
+			FetchIntervalSeconds: -1,
+		},
+	}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.currency_converter.fetch_interval_seconds should prevent negative values, but it doesn't")
+	}
+}
+
+func TestInvalidCurrencyTargetSelection(t *testing.T) {
+	cfg := Configuration{
+		CurrencyConverter: CurrencyConverter{
+			TargetSelection: "cheapest",
+		},
+	}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.currency_converter.target_selection should prevent unknown values, but it doesn't")
+	}
+}
+
+func TestInvalidCurrencyBidAdjustments(t *testing.T) {
+	for _, adjustments := range []map[string]float64{{"euro": 0.9}, {"eur": 0}, {"USD": -1}, {"GBP": math.Inf(1)}} {
+		cfg := Configuration{
+			CurrencyConverter: CurrencyConverter{
+				BidAdjustments: adjustments,
+			},
+		}
+		if err := cfg.validate(); err == nil {
+			t.Errorf("cfg.currency_converter.bid_adjustments should reject %v, but it doesn't", adjustments)
+		}
+	}
+}
+
+func TestInvalidStaleRatesPolicy(t *testing.T) {
+	cfg := Configuration{
+		CurrencyConverter: CurrencyConverter{
+			StaleRatesPolicy: "ignore",
+		},
+	}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.currency_converter.stale_rates_policy should prevent unknown values, but it doesn't")
+	}
+}
+
+func TestStaticRatesPolicyNeedsFile(t *testing.T) {
+	cfg := Configuration{
+		CurrencyConverter: CurrencyConverter{
+			StaleRatesSeconds: 3600,
+			StaleRatesPolicy:  "static",
+		},
+	}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.currency_converter.static_rates_file should be required by the static policy, but it isn't")
+	}
+}
+
+func TestInvalidFloorTolerance(t *testing.T) {
+	cfg := Configuration{
+		BidValidation: BidValidation{
+			FloorTolerance: -0.01,
+		},
+	}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.bid_validation.floor_tolerance should prevent negative values, but it doesn't")
+	}
+}
+
+func TestInvalidLatencyBudget(t *testing.T) {
+	cfg := Configuration{
+		Adapters: map[string]Adapter{
+			"appnexus": {LatencyBudget: 1.5},
+		},
+	}
+
+	if err := cfg.validate(); err == nil {
+		t.Error("cfg.adapters.appnexus.latency_budget should prevent values above 1, but it doesn't")
+	}
+}
+
+func TestAdapterCurrencies(t *testing.T) {
+	cfg := Configuration{
+		StoredRequests: StoredRequests{
+			Files: true,
+			InMemoryCache: InMemoryCache{
+				Type: "none",
+			},
+		},
+		Adapters: map[string]Adapter{
+			"appnexus": {Currencies: []string{"USD", "dollars"}},
+		},
+	}
+	if err := cfg.validate(); len(err) != 1 || !strings.Contains(err[0].Error(), "cfg.adapters.appnexus.currencies") {
+		t.Errorf("cfg.adapters.appnexus.currencies should only allow ISO 4217 codes. Got %v", err)
+	}
+
+	cfg.BidValidation.ExtraCurrencies = []string{"DOLLARS"}
+	if err := cfg.validate(); err != nil {
+		t.Errorf("cfg.adapters.appnexus.currencies should allow the bid_validation.extra_currencies. Got %v", err)
+	}
+}
+
 func TestOverflowedVendorID(t *testing.T) {
 	cfg := Configuration{
 		GDPR: GDPR{
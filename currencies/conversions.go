@@ -0,0 +1,114 @@
+package currencies
+
+import (
+	"fmt"
+	"math"
+)
+
+// Conversions allows to get a conversion rate between two currencies.
+type Conversions interface {
+	// GetRate returns the rate to multiply an amount in currency `from` by in order to get it in currency `to`.
+	GetRate(from string, to string) (float64, error)
+}
+
+// InvalidRateError is returned for rates which are zero, negative, or not finite.
+// These come from bad rates data, and would otherwise turn every converted price into 0 or NaN.
+type InvalidRateError struct {
+	From string
+	To   string
+	Rate float64
+}
+
+func (err *InvalidRateError) Error() string {
+	return fmt.Sprintf("conversion rate invalid: %s->%s is %v", err.From, err.To, err.Rate)
+}
+
+// checkRate returns an InvalidRateError if the rate can't be used to convert prices.
+func checkRate(from string, to string, rate float64) error {
+	if rate <= 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return &InvalidRateError{From: from, To: to, Rate: rate}
+	}
+	return nil
+}
+
+// ConversionCache memoizes the rates returned by some Conversions.
+//
+// It is meant to live for a single auction, so that the same rate isn't looked up for every bid
+// without risking serving stale rates across auctions. It is not threadsafe.
+type ConversionCache struct {
+	conversions Conversions
+	rates       map[conversionKey]float64
+}
+
+type conversionKey struct {
+	from string
+	to   string
+}
+
+// NewConversionCache returns an empty ConversionCache which fetches rates from the given Conversions.
+func NewConversionCache(conversions Conversions) *ConversionCache {
+	return &ConversionCache{
+		conversions: conversions,
+		rates:       make(map[conversionKey]float64),
+	}
+}
+
+// GetRate returns the conversion rate between two currencies, looking it up only on the first call.
+// Converting a currency to itself always has a rate of 1, even if the underlying Conversions don't know about it.
+// Invalid rates from the underlying Conversions are returned as an InvalidRateError.
+func (c *ConversionCache) GetRate(from string, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+	key := conversionKey{from: from, to: to}
+	if rate, ok := c.rates[key]; ok {
+		return rate, nil
+	}
+	if c.conversions == nil {
+		return 0, fmt.Errorf("conversion %s->%s not available, because no rates are loaded", from, to)
+	}
+	rate, err := c.conversions.GetRate(from, to)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkRate(from, to, rate); err != nil {
+		return 0, err
+	}
+	c.rates[key] = rate
+	return rate, nil
+}
+
+// FallbackConversions uses its Fallback for any conversion which its Primary can't make.
+// This keeps currencies convertible with the rates from a static file while the live rates are unavailable.
+type FallbackConversions struct {
+	Primary  Conversions
+	Fallback Conversions
+}
+
+// NewFallbackConversions returns Conversions which try the primary first, then the fallback.
+// If the fallback is nil, the primary is returned as is.
+func NewFallbackConversions(primary Conversions, fallback Conversions) Conversions {
+	if fallback == nil {
+		return primary
+	}
+	return &FallbackConversions{
+		Primary:  primary,
+		Fallback: fallback,
+	}
+}
+
+// GetRate returns the primary's rate if it has one, and the fallback's rate otherwise.
+// If neither has a rate, the primary's error is returned.
+func (c *FallbackConversions) GetRate(from string, to string) (float64, error) {
+	if c.Primary != nil {
+		rate, err := c.Primary.GetRate(from, to)
+		if err == nil {
+			return rate, nil
+		}
+		if fallbackRate, fallbackErr := c.Fallback.GetRate(from, to); fallbackErr == nil {
+			return fallbackRate, nil
+		}
+		return 0, err
+	}
+	return c.Fallback.GetRate(from, to)
+}
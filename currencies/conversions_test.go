@@ -0,0 +1,260 @@
+package currencies_test
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/prebid/prebid-server/currencies"
+)
+
+func TestConversionCache_Memoizes(t *testing.T) {
+
+	// Setup:
+	conversions := &countingConversions{
+		rates: currencies.NewRates(time.Time{}, map[string]map[string]float64{
+			"USD": {
+				"EUR": 0.85,
+			},
+		}),
+	}
+	cache := currencies.NewConversionCache(conversions)
+
+	// Execute:
+	for i := 0; i < 3; i++ {
+		rate, err := cache.GetRate("USD", "EUR")
+
+		// Verify:
+		assert.Nil(t, err)
+		assert.Equal(t, 0.85, rate)
+	}
+	assert.Equal(t, 1, conversions.calls, "The underlying conversions should only be asked once.")
+}
+
+func TestConversionCache_SameCurrency(t *testing.T) {
+
+	// Setup:
+	conversions := &countingConversions{
+		rates: currencies.NewRates(time.Time{}, nil),
+	}
+	cache := currencies.NewConversionCache(conversions)
+
+	// Execute:
+	rate, err := cache.GetRate("USD", "USD")
+
+	// Verify:
+	assert.Nil(t, err)
+	assert.Equal(t, float64(1), rate)
+	assert.Equal(t, 0, conversions.calls)
+}
+
+func TestConversionCache_Errors(t *testing.T) {
+
+	// Setup:
+	conversions := &countingConversions{
+		rates: currencies.NewRates(time.Time{}, map[string]map[string]float64{}),
+	}
+	cache := currencies.NewConversionCache(conversions)
+
+	// Execute:
+	rate, err := cache.GetRate("USD", "EUR")
+
+	// Verify:
+	assert.NotNil(t, err)
+	assert.Equal(t, float64(0), rate)
+}
+
+func TestConversionCache_NilRates(t *testing.T) {
+
+	// Setup:
+	var rates *currencies.Rates
+	cache := currencies.NewConversionCache(rates)
+
+	// Execute:
+	_, err := cache.GetRate("USD", "EUR")
+
+	// Verify:
+	assert.NotNil(t, err)
+}
+
+func TestConversionCache_InvalidRates(t *testing.T) {
+	for _, invalidRate := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+
+		// Setup:
+		cache := currencies.NewConversionCache(fixedRateConversions(invalidRate))
+
+		// Execute:
+		rate, err := cache.GetRate("USD", "EUR")
+
+		// Verify:
+		assert.IsType(t, &currencies.InvalidRateError{}, err, "A rate of %v should be invalid", invalidRate)
+		assert.Equal(t, float64(0), rate)
+	}
+}
+
+func TestFallbackConversions_PrimaryRateInvalid(t *testing.T) {
+
+	// Setup:
+	liveRates := currencies.NewRates(time.Time{}, map[string]map[string]float64{"USD": {"EUR": 0}})
+	staticRates := currencies.NewRates(time.Time{}, map[string]map[string]float64{"USD": {"EUR": 0.9}})
+	conversions := currencies.NewFallbackConversions(liveRates, staticRates)
+
+	// Execute:
+	rate, err := conversions.GetRate("USD", "EUR")
+
+	// Verify:
+	assert.Nil(t, err)
+	assert.Equal(t, 0.9, rate)
+}
+
+func TestFallbackConversions_PrimaryFails(t *testing.T) {
+
+	// Setup:
+	file, err := ioutil.TempFile("", "rates")
+	if err != nil {
+		t.Fatalf("Failed to create a temp file: %v", err)
+	}
+	defer os.Remove(file.Name())
+	file.Write([]byte(`{"dataAsOf":"2018-09-12","conversions":{"USD":{"EUR":0.9}}}`))
+	file.Close()
+	staticRates, err := currencies.LoadRatesFile(file.Name())
+	if err != nil {
+		t.Fatalf("Failed to load the rates file: %v", err)
+	}
+	var liveRates *currencies.Rates
+	conversions := currencies.NewFallbackConversions(liveRates, staticRates)
+
+	// Execute:
+	rate, err := conversions.GetRate("USD", "EUR")
+
+	// Verify:
+	assert.Nil(t, err)
+	assert.Equal(t, 0.9, rate)
+}
+
+func TestFallbackConversions_PrimarySucceeds(t *testing.T) {
+
+	// Setup:
+	liveRates := &countingConversions{
+		rates: currencies.NewRates(time.Time{}, map[string]map[string]float64{"USD": {"EUR": 0.85}}),
+	}
+	staticRates := &countingConversions{
+		rates: currencies.NewRates(time.Time{}, map[string]map[string]float64{"USD": {"EUR": 0.9}}),
+	}
+	conversions := currencies.NewFallbackConversions(liveRates, staticRates)
+
+	// Execute:
+	rate, err := conversions.GetRate("USD", "EUR")
+
+	// Verify:
+	assert.Nil(t, err)
+	assert.Equal(t, 0.85, rate)
+	assert.Equal(t, 0, staticRates.calls, "The fallback shouldn't be used if the primary has the rate.")
+}
+
+func TestFallbackConversions_BothFail(t *testing.T) {
+
+	// Setup:
+	liveRates := currencies.NewRates(time.Time{}, map[string]map[string]float64{})
+	staticRates := currencies.NewRates(time.Time{}, map[string]map[string]float64{"USD": {"GBP": 0.77}})
+	conversions := currencies.NewFallbackConversions(liveRates, staticRates)
+
+	// Execute:
+	_, err := conversions.GetRate("USD", "EUR")
+
+	// Verify:
+	assert.EqualError(t, err, "conversion USD->EUR not present in rates dictionnary")
+}
+
+func TestFallbackConversions_NoFallback(t *testing.T) {
+
+	// Setup:
+	liveRates := currencies.NewRates(time.Time{}, nil)
+
+	// Execute:
+	conversions := currencies.NewFallbackConversions(liveRates, nil)
+
+	// Verify:
+	assert.Equal(t, liveRates, conversions)
+}
+
+// BenchmarkRates_GetRate and BenchmarkConversionCache_GetRate simulate converting every bid in a seat.
+func BenchmarkRates_GetRate(b *testing.B) {
+	rates := newBenchmarkConversions()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < 10; j++ {
+			rates.GetRate("USD", "EUR")
+		}
+	}
+}
+
+func BenchmarkConversionCache_GetRate(b *testing.B) {
+	rates := newBenchmarkConversions()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cache := currencies.NewConversionCache(rates)
+		for j := 0; j < 10; j++ {
+			cache.GetRate("USD", "EUR")
+		}
+	}
+}
+
+// newBenchmarkConversions returns conversions which must be derived through an intermediate currency,
+// which is the expensive case that the ConversionCache is meant to avoid repeating.
+func newBenchmarkConversions() currencies.Conversions {
+	return &crossRateConversions{
+		rates: currencies.NewRates(time.Time{}, map[string]map[string]float64{
+			"USD": {
+				"GBP": 0.77,
+			},
+			"GBP": {
+				"EUR": 1.11,
+			},
+		}),
+		via: "GBP",
+	}
+}
+
+// fixedRateConversions has the same rate between every pair of currencies.
+type fixedRateConversions float64
+
+func (c fixedRateConversions) GetRate(from string, to string) (float64, error) {
+	return float64(c), nil
+}
+
+type countingConversions struct {
+	rates *currencies.Rates
+	calls int
+}
+
+func (c *countingConversions) GetRate(from string, to string) (float64, error) {
+	c.calls++
+	return c.rates.GetRate(from, to)
+}
+
+type crossRateConversions struct {
+	rates *currencies.Rates
+	via   string
+}
+
+func (c *crossRateConversions) GetRate(from string, to string) (float64, error) {
+	if rate, err := c.rates.GetRate(from, to); err == nil {
+		return rate, nil
+	}
+	first, err := c.rates.GetRate(from, c.via)
+	if err != nil {
+		return 0, err
+	}
+	second, err := c.rates.GetRate(c.via, to)
+	if err != nil {
+		return 0, err
+	}
+	return first * second, nil
+}
@@ -44,10 +44,14 @@ func (r *Rates) UnmarshalJSON(b []byte) error {
 }
 
 // GetRate returns the conversion rate between two currencies
-// returns an error in case the conversion rate between the two given currencies is not in the currencies rates map
+// returns an error in case the conversion rate between the two given currencies is not in the currencies rates map,
+// or is an InvalidRateError
 func (r *Rates) GetRate(from string, to string) (float64, error) {
-	if r.Conversions != nil {
+	if r != nil && r.Conversions != nil {
 		if conversion, present := r.Conversions[from][to]; present == true {
+			if err := checkRate(from, to, conversion); err != nil {
+				return 0, err
+			}
 			return conversion, nil
 		}
 		return 0, fmt.Errorf("conversion %s->%s not present in rates dictionnary", from, to)
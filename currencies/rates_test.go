@@ -174,3 +174,24 @@ func TestGetRate_EmptyRates(t *testing.T) {
 	assert.NotNil(t, err, "err shouldn't be nil")
 	assert.Equal(t, float64(0), rate, "rate should be 0")
 }
+
+func TestGetRate_InvalidRates(t *testing.T) {
+
+	// Setup:
+	rates := currencies.NewRates(time.Time{}, map[string]map[string]float64{
+		"USD": {
+			"EUR": 0,
+			"GBP": -0.77,
+		},
+	})
+
+	// Verify:
+	for _, to := range []string{"EUR", "GBP"} {
+		rate, err := rates.GetRate("USD", to)
+
+		assert.IsType(t, &currencies.InvalidRateError{}, err, "USD->%s should be an invalid rate", to)
+		assert.Equal(t, float64(0), rate, "rate should be 0")
+	}
+	_, err := rates.GetRate("USD", "EUR")
+	assert.EqualError(t, err, "conversion rate invalid: USD->EUR is 0")
+}
@@ -0,0 +1,79 @@
+package currencies
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+// StalenessPolicy decides how currencies are converted once the rates haven't been updated for too long.
+type StalenessPolicy string
+
+const (
+	// StalenessPolicyReject stops converting currencies, so Bids which need a conversion are rejected.
+	StalenessPolicyReject StalenessPolicy = "reject"
+	// StalenessPolicyWarn keeps using the last rates which were fetched, but reports that they're stale.
+	StalenessPolicyWarn StalenessPolicy = "warn"
+	// StalenessPolicyStatic falls back to the rates from a static file.
+	StalenessPolicyStatic StalenessPolicy = "static"
+)
+
+// StalenessCheck applies a StalenessPolicy to rates which are older than its MaxAge.
+type StalenessCheck struct {
+	MaxAge time.Duration
+	Policy StalenessPolicy
+	// StaticRates are only used by the StalenessPolicyStatic.
+	StaticRates *Rates
+}
+
+// StalenessDecision describes what a StalenessCheck did with rates which were stale or missing.
+type StalenessDecision struct {
+	Policy StalenessPolicy
+	// Age is how long ago the rates were fetched. It's zero if no rates have been fetched.
+	Age     time.Duration
+	Message string
+}
+
+// Apply returns the Conversions which should be used, given the latest rates and the time they were fetched.
+// The decision is nil if the rates are fresh, or if the check is nil or has no MaxAge. The Conversions are threadsafe.
+func (c *StalenessCheck) Apply(rates *Rates, lastUpdated time.Time, now time.Time) (Conversions, *StalenessDecision) {
+	if c == nil || c.MaxAge <= 0 {
+		return rates, nil
+	}
+	decision := &StalenessDecision{Policy: c.Policy}
+	if rates == nil || lastUpdated.IsZero() {
+		decision.Message = "No currency rates have been fetched"
+	} else {
+		decision.Age = now.Sub(lastUpdated)
+		if decision.Age <= c.MaxAge {
+			return rates, nil
+		}
+		decision.Message = fmt.Sprintf("Currency rates are %d seconds old, which is more than the limit of %d", int64(decision.Age/time.Second), int64(c.MaxAge/time.Second))
+	}
+
+	switch c.Policy {
+	case StalenessPolicyReject:
+		decision.Message += ". Currencies will not be converted"
+		return (*Rates)(nil), decision
+	case StalenessPolicyStatic:
+		decision.Message += ". The static rates are used instead"
+		return c.StaticRates, decision
+	default:
+		decision.Message += ". They are used anyway"
+		return rates, decision
+	}
+}
+
+// LoadRatesFile reads Rates from a file with the same format as http://currency.prebid.org/latest.json
+func LoadRatesFile(path string) (*Rates, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rates := &Rates{}
+	if err := json.Unmarshal(data, rates); err != nil {
+		return nil, fmt.Errorf("%s is not a valid rates file: %v", path, err)
+	}
+	return rates, nil
+}
@@ -0,0 +1,119 @@
+package currencies_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/prebid/prebid-server/currencies"
+)
+
+func TestStalenessCheck_Fresh(t *testing.T) {
+
+	// Setup:
+	now := time.Now()
+	rates := currencies.NewRates(now, map[string]map[string]float64{"USD": {"EUR": 0.85}})
+	check := &currencies.StalenessCheck{MaxAge: time.Hour, Policy: currencies.StalenessPolicyReject}
+
+	// Execute:
+	conversions, decision := check.Apply(rates, now.Add(-time.Minute), now)
+
+	// Verify:
+	assert.Nil(t, decision)
+	rate, err := conversions.GetRate("USD", "EUR")
+	assert.Nil(t, err)
+	assert.Equal(t, 0.85, rate)
+}
+
+func TestStalenessCheck_Stale(t *testing.T) {
+
+	// Setup:
+	now := time.Now()
+	rates := currencies.NewRates(now, map[string]map[string]float64{"USD": {"EUR": 0.85}})
+	staticRates := currencies.NewRates(now, map[string]map[string]float64{"USD": {"EUR": 0.9}})
+
+	testCases := []struct {
+		policy       currencies.StalenessPolicy
+		expectedRate float64
+		expectError  bool
+	}{
+		{policy: currencies.StalenessPolicyReject, expectError: true},
+		{policy: currencies.StalenessPolicyWarn, expectedRate: 0.85},
+		{policy: currencies.StalenessPolicyStatic, expectedRate: 0.9},
+	}
+
+	for _, tc := range testCases {
+		check := &currencies.StalenessCheck{MaxAge: time.Hour, Policy: tc.policy, StaticRates: staticRates}
+
+		// Execute:
+		conversions, decision := check.Apply(rates, now.Add(-2*time.Hour), now)
+
+		// Verify:
+		if assert.NotNil(t, decision, string(tc.policy)) {
+			assert.Equal(t, tc.policy, decision.Policy)
+			assert.Equal(t, 2*time.Hour, decision.Age)
+		}
+		rate, err := conversions.GetRate("USD", "EUR")
+		if tc.expectError {
+			assert.NotNil(t, err, string(tc.policy))
+		} else {
+			assert.Nil(t, err, string(tc.policy))
+			assert.Equal(t, tc.expectedRate, rate, string(tc.policy))
+		}
+	}
+}
+
+func TestStalenessCheck_Missing(t *testing.T) {
+
+	// Setup:
+	staticRates := currencies.NewRates(time.Time{}, map[string]map[string]float64{"USD": {"EUR": 0.9}})
+	check := &currencies.StalenessCheck{MaxAge: time.Hour, Policy: currencies.StalenessPolicyStatic, StaticRates: staticRates}
+
+	// Execute:
+	conversions, decision := check.Apply(nil, time.Time{}, time.Now())
+
+	// Verify:
+	if assert.NotNil(t, decision) {
+		assert.Equal(t, time.Duration(0), decision.Age)
+	}
+	rate, err := conversions.GetRate("USD", "EUR")
+	assert.Nil(t, err)
+	assert.Equal(t, 0.9, rate)
+}
+
+func TestStalenessCheck_Disabled(t *testing.T) {
+
+	// Setup:
+	var check *currencies.StalenessCheck
+	rates := currencies.NewRates(time.Time{}, map[string]map[string]float64{"USD": {"EUR": 0.85}})
+
+	// Execute:
+	_, decision := check.Apply(rates, time.Now().Add(-24*time.Hour), time.Now())
+
+	// Verify:
+	assert.Nil(t, decision)
+}
+
+func TestLoadRatesFile(t *testing.T) {
+
+	// Setup:
+	file, err := ioutil.TempFile("", "rates")
+	if err != nil {
+		t.Fatalf("Failed to create a temp file: %v", err)
+	}
+	defer os.Remove(file.Name())
+	file.Write([]byte(`{"dataAsOf":"2018-09-12","conversions":{"USD":{"GBP":0.77208}}}`))
+	file.Close()
+
+	// Execute:
+	rates, err := currencies.LoadRatesFile(file.Name())
+
+	// Verify:
+	assert.Nil(t, err)
+	rate, err := rates.GetRate("USD", "GBP")
+	assert.Nil(t, err)
+	assert.Equal(t, 0.77208, rate)
+}
@@ -0,0 +1,67 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/golang/glog"
+	"github.com/prebid/prebid-server/exchange"
+)
+
+type blockedCreativesRequest struct {
+	CrID    string `json:"crid"`
+	Blocked bool   `json:"blocked"`
+}
+
+type blockedCreativesResponse struct {
+	Blocked []string `json:"blocked"`
+}
+
+// NewBlockedCreativesEndpoint implements /creatives/blocked on the admin server.
+//
+// GET lists the blocked creative IDs. POST blocks or unblocks one, and takes effect on the next auction.
+// Both respond with the creative IDs which are blocked afterwards.
+func NewBlockedCreativesEndpoint(blocked *exchange.BlockedCreatives) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			var req blockedCreativesRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, "Invalid request: %v\n", err)
+				return
+			}
+			if req.CrID == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, "Invalid request: crid is required\n")
+				return
+			}
+			blocked.Set(req.CrID, req.Blocked)
+			if req.Blocked {
+				glog.Infof("Creative %s has been blocked", req.CrID)
+			} else {
+				glog.Infof("Creative %s has been unblocked", req.CrID)
+			}
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		list := blocked.List()
+		if list == nil {
+			list = []string{}
+		}
+		jsonOutput, err := json.Marshal(blockedCreativesResponse{
+			Blocked: list,
+		})
+		if err != nil {
+			glog.Errorf("/creatives/blocked Critical error when trying to marshal blockedCreativesResponse: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(jsonOutput)
+	}
+}
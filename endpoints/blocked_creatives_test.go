@@ -0,0 +1,73 @@
+package endpoints
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prebid/prebid-server/exchange"
+)
+
+func TestListBlockedCreatives(t *testing.T) {
+	handler := NewBlockedCreativesEndpoint(exchange.NewBlockedCreatives("bad-creative"))
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/creatives/blocked", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d. Got %d", http.StatusOK, w.Code)
+	}
+	if expected := `{"blocked":["bad-creative"]}`; w.Body.String() != expected {
+		t.Errorf("Expected body %s. Got %s", expected, w.Body.String())
+	}
+}
+
+func TestToggleBlockedCreatives(t *testing.T) {
+	blocked := exchange.NewBlockedCreatives()
+	handler := NewBlockedCreativesEndpoint(blocked)
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("POST", "/creatives/blocked", strings.NewReader(`{"crid":"bad-creative","blocked":true}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d. Got %d", http.StatusOK, w.Code)
+	}
+	if expected := `{"blocked":["bad-creative"]}`; w.Body.String() != expected {
+		t.Errorf("Expected body %s. Got %s", expected, w.Body.String())
+	}
+	if !blocked.Contains("bad-creative") {
+		t.Errorf("bad-creative should have been blocked")
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("POST", "/creatives/blocked", strings.NewReader(`{"crid":"bad-creative","blocked":false}`)))
+	if expected := `{"blocked":[]}`; w.Body.String() != expected {
+		t.Errorf("Expected body %s. Got %s", expected, w.Body.String())
+	}
+	if blocked.Contains("bad-creative") {
+		t.Errorf("bad-creative should have been unblocked")
+	}
+}
+
+func TestBlockEmptyCreativeID(t *testing.T) {
+	blocked := exchange.NewBlockedCreatives()
+	handler := NewBlockedCreativesEndpoint(blocked)
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("POST", "/creatives/blocked", strings.NewReader(`{"blocked":true}`)))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d. Got %d", http.StatusBadRequest, w.Code)
+	}
+	if len(blocked.List()) != 0 {
+		t.Errorf("No creatives should have been blocked. Got %v", blocked.List())
+	}
+}
+
+func TestBlockedCreativesBadMethod(t *testing.T) {
+	handler := NewBlockedCreativesEndpoint(exchange.NewBlockedCreatives())
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("DELETE", "/creatives/blocked", nil))
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d. Got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
@@ -0,0 +1,70 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/golang/glog"
+	"github.com/prebid/prebid-server/exchange"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+type disabledBiddersRequest struct {
+	Bidder   string `json:"bidder"`
+	Disabled bool   `json:"disabled"`
+}
+
+// The names are strings because BidderName.MarshalJSON doesn't quote them.
+type disabledBiddersResponse struct {
+	Disabled []string `json:"disabled"`
+}
+
+// NewDisabledBiddersEndpoint implements /bidders/disabled on the admin server.
+//
+// GET lists the bidders which are switched off. POST switches one on or off, and takes effect on the next auction.
+// Both respond with the bidders which are disabled afterwards.
+func NewDisabledBiddersEndpoint(disabled *exchange.DisabledBidders) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			var req disabledBiddersRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, "Invalid request: %v\n", err)
+				return
+			}
+			if _, ok := openrtb_ext.BidderMap[req.Bidder]; !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, "Invalid request: unknown bidder \"%s\"\n", req.Bidder)
+				return
+			}
+			disabled.Set(openrtb_ext.BidderName(req.Bidder), req.Disabled)
+			if req.Disabled {
+				glog.Infof("Bidder %s has been disabled", req.Bidder)
+			} else {
+				glog.Infof("Bidder %s has been enabled", req.Bidder)
+			}
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		bidders := disabled.List()
+		list := make([]string, len(bidders))
+		for i, bidder := range bidders {
+			list[i] = string(bidder)
+		}
+		jsonOutput, err := json.Marshal(disabledBiddersResponse{
+			Disabled: list,
+		})
+		if err != nil {
+			glog.Errorf("/bidders/disabled Critical error when trying to marshal disabledBiddersResponse: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(jsonOutput)
+	}
+}
@@ -0,0 +1,74 @@
+package endpoints
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prebid/prebid-server/exchange"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+func TestListDisabledBidders(t *testing.T) {
+	handler := NewDisabledBiddersEndpoint(exchange.NewDisabledBidders(openrtb_ext.BidderRubicon))
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/bidders/disabled", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d. Got %d", http.StatusOK, w.Code)
+	}
+	if expected := `{"disabled":["rubicon"]}`; w.Body.String() != expected {
+		t.Errorf("Expected body %s. Got %s", expected, w.Body.String())
+	}
+}
+
+func TestToggleDisabledBidders(t *testing.T) {
+	disabled := exchange.NewDisabledBidders()
+	handler := NewDisabledBiddersEndpoint(disabled)
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("POST", "/bidders/disabled", strings.NewReader(`{"bidder":"appnexus","disabled":true}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d. Got %d", http.StatusOK, w.Code)
+	}
+	if expected := `{"disabled":["appnexus"]}`; w.Body.String() != expected {
+		t.Errorf("Expected body %s. Got %s", expected, w.Body.String())
+	}
+	if !disabled.Contains(openrtb_ext.BidderAppnexus) {
+		t.Errorf("appnexus should have been disabled")
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("POST", "/bidders/disabled", strings.NewReader(`{"bidder":"appnexus","disabled":false}`)))
+	if expected := `{"disabled":[]}`; w.Body.String() != expected {
+		t.Errorf("Expected body %s. Got %s", expected, w.Body.String())
+	}
+	if disabled.Contains(openrtb_ext.BidderAppnexus) {
+		t.Errorf("appnexus should have been enabled again")
+	}
+}
+
+func TestDisableUnknownBidder(t *testing.T) {
+	disabled := exchange.NewDisabledBidders()
+	handler := NewDisabledBiddersEndpoint(disabled)
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("POST", "/bidders/disabled", strings.NewReader(`{"bidder":"not-a-bidder","disabled":true}`)))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d. Got %d", http.StatusBadRequest, w.Code)
+	}
+	if len(disabled.List()) != 0 {
+		t.Errorf("No bidders should have been disabled. Got %v", disabled.List())
+	}
+}
+
+func TestDisabledBiddersBadMethod(t *testing.T) {
+	handler := NewDisabledBiddersEndpoint(exchange.NewDisabledBidders())
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("DELETE", "/bidders/disabled", nil))
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d. Got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
@@ -238,6 +238,10 @@ func validateInfo(info *adapters.BidderInfo) error {
 		return err
 	}
 
+	if info.OpenRTB != nil && info.OpenRTB.Version != adapters.OpenRTBVersion25 && info.OpenRTB.Version != adapters.OpenRTBVersion26 {
+		return fmt.Errorf("openrtb.version must be %s or %s. Got %s", adapters.OpenRTBVersion25, adapters.OpenRTBVersion26, info.OpenRTB.Version)
+	}
+
 	return nil
 }
 
@@ -256,6 +256,14 @@ func (deps *endpointDeps) validateRequest(req *openrtb.BidRequest) []error {
 		if err := validateBidAdjustmentFactors(bidExt.Prebid.BidAdjustmentFactors, aliases); err != nil {
 			return []error{err}
 		}
+
+		if err := validateMediaTypeBidAdjustmentFactors(bidExt.Prebid.MediaTypeBidAdjustmentFactors, aliases); err != nil {
+			return []error{err}
+		}
+
+		if err := validateDealTiers(bidExt.Prebid.DealTiers, aliases); err != nil {
+			return []error{err}
+		}
 	}
 
 	impIDs := make(map[string]int, len(req.Imp))
@@ -316,6 +324,44 @@ func validateBidAdjustmentFactors(adjustmentFactors map[string]float64, aliases
 	return nil
 }
 
+func validateMediaTypeBidAdjustmentFactors(adjustmentFactors map[string]map[openrtb_ext.BidType]float64, aliases map[string]string) error {
+	for bidderToAdjust, factorsByType := range adjustmentFactors {
+		if _, isBidder := openrtb_ext.BidderMap[bidderToAdjust]; !isBidder {
+			if _, isAlias := aliases[bidderToAdjust]; !isAlias {
+				return fmt.Errorf("request.ext.prebid.mediatypebidadjustmentfactors.%s is not a known bidder or alias", bidderToAdjust)
+			}
+		}
+		for mediaType, adjustmentFactor := range factorsByType {
+			switch mediaType {
+			case openrtb_ext.BidTypeBanner, openrtb_ext.BidTypeVideo, openrtb_ext.BidTypeAudio, openrtb_ext.BidTypeNative:
+			default:
+				return fmt.Errorf("request.ext.prebid.mediatypebidadjustmentfactors.%s.%s is not a known media type", bidderToAdjust, mediaType)
+			}
+			if adjustmentFactor <= 0 {
+				return fmt.Errorf("request.ext.prebid.mediatypebidadjustmentfactors.%s.%s must be a positive number. Got %f", bidderToAdjust, mediaType, adjustmentFactor)
+			}
+		}
+	}
+	return nil
+}
+
+func validateDealTiers(dealTiers map[string]openrtb_ext.DealTier, aliases map[string]string) error {
+	for bidder, dealTier := range dealTiers {
+		if _, isBidder := openrtb_ext.BidderMap[bidder]; !isBidder {
+			if _, isAlias := aliases[bidder]; !isAlias {
+				return fmt.Errorf("request.ext.prebid.dealtiers.%s is not a known bidder or alias", bidder)
+			}
+		}
+		if dealTier.Prefix == "" {
+			return fmt.Errorf("request.ext.prebid.dealtiers.%s.prefix must not be empty", bidder)
+		}
+		if dealTier.MinDealTier <= 0 {
+			return fmt.Errorf("request.ext.prebid.dealtiers.%s.mindealtier must be a positive number. Got %d", bidder, dealTier.MinDealTier)
+		}
+	}
+	return nil
+}
+
 func (deps *endpointDeps) validateImp(imp *openrtb.Imp, aliases map[string]string, index int) []error {
 	if imp.ID == "" {
 		return []error{fmt.Errorf("request.imp[%d] missing required field: \"id\"", index)}
@@ -0,0 +1,195 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/golang/glog"
+	"github.com/julienschmidt/httprouter"
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/adapters"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/exchange"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+)
+
+type validateBidsRequest struct {
+	// Bidder is the name of the bidder, or an alias from the request.ext.prebid.aliases, which made the SeatBid.
+	Bidder  string              `json:"bidder"`
+	Request *openrtb.BidRequest `json:"request"`
+	// Cur is the currency of the Bids. It defaults to USD, like bidresponse.cur.
+	Cur     string          `json:"cur"`
+	SeatBid openrtb.SeatBid `json:"seatbid"`
+}
+
+type validateBidsResponse struct {
+	Kept    []string     `json:"kept"`
+	Dropped []droppedBid `json:"dropped"`
+	// Warnings are about Bids which were kept, but which might not behave as the bidder expects.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type droppedBid struct {
+	ID      string                        `json:"id"`
+	Reason  pbsmetrics.BidRejectionReason `json:"reason"`
+	Message string                        `json:"message"`
+}
+
+// NewValidateBidsEndpoint implements /validation/bids
+//
+// It runs a SeatBid through the same checks which the Exchange makes on the Bids from a real auction,
+// and reports which Bids would be kept and why the others would be dropped. No auction is held.
+// Currency conversion rates aren't available here, so Bids compared to floors in other currencies are dropped.
+func NewValidateBidsEndpoint(infos adapters.BidderInfos, validators []exchange.BidValidator, cfg config.BidValidation) httprouter.Handle {
+	return httprouter.Handle(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		var req validateBidsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "Invalid request: %v\n", err)
+			return
+		}
+		if req.Request == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("Invalid request: request is required\n"))
+			return
+		}
+
+		var requestExt openrtb_ext.ExtRequest
+		if len(req.Request.Ext) > 0 {
+			if err := json.Unmarshal(req.Request.Ext, &requestExt); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprintf(w, "Invalid request: request.ext is malformed: %v\n", err)
+				return
+			}
+		}
+		coreBidder := exchange.ResolveBidder(req.Bidder, requestExt.Prebid.Aliases)
+		if _, ok := openrtb_ext.BidderMap[string(coreBidder)]; !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "Invalid request: unknown bidder \"%s\"\n", req.Bidder)
+			return
+		}
+
+		bids, err := toPBSOrtbBids(req.SeatBid.Bid)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "Invalid request: %v\n", err)
+			return
+		}
+		brw := &exchange.BidResponseWrapper{
+			AdapterBids: &exchange.PBSOrtbSeatBid{
+				Bids:     bids,
+				Currency: req.Cur,
+			},
+			Bidder: openrtb_ext.BidderName(req.Bidder),
+		}
+		errs, warnings := brw.ValidateBids(req.Request, requestExt.Prebid.Validation, cfg, nil, exchange.BidderValidators(coreBidder, infos[string(coreBidder)], identifyRejections(validators)))
+
+		report := buildValidateBidsResponse(bids, brw.AdapterBids.Bids, errs)
+		for _, warning := range warnings {
+			report.Warnings = append(report.Warnings, warning.Error())
+		}
+		response, err := json.Marshal(report)
+		if err != nil {
+			glog.Errorf("/validation/bids Critical error when trying to marshal the response: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(response)
+	})
+}
+
+// toPBSOrtbBids reads each Bid's type, video and meta from its ext.prebid, like the Exchange puts them in the response.
+func toPBSOrtbBids(ortbBids []openrtb.Bid) ([]*exchange.PBSOrtbBid, error) {
+	bids := make([]*exchange.PBSOrtbBid, len(ortbBids))
+	for i := 0; i < len(ortbBids); i++ {
+		bids[i] = &exchange.PBSOrtbBid{
+			Bid: &ortbBids[i],
+		}
+		if len(ortbBids[i].Ext) == 0 {
+			continue
+		}
+		var bidExt openrtb_ext.ExtBid
+		if err := json.Unmarshal(ortbBids[i].Ext, &bidExt); err != nil {
+			return nil, fmt.Errorf("seatbid.bid[%d].ext is malformed: %v", i, err)
+		}
+		if bidExt.Prebid != nil {
+			bids[i].BidType = bidExt.Prebid.Type
+			bids[i].BidVideo = bidExt.Prebid.Video
+			bids[i].BidMeta = bidExt.Prebid.Meta
+		}
+	}
+	return bids, nil
+}
+
+// identifyRejections wraps the host's validators so that each error they return is a BidRejectionError with the
+// rejected Bid's ID. Otherwise buildValidateBidsResponse couldn't tell which Bid the error was for.
+func identifyRejections(validators []exchange.BidValidator) []exchange.BidValidator {
+	identified := make([]exchange.BidValidator, len(validators))
+	for i := 0; i < len(validators); i++ {
+		validator := validators[i]
+		identified[i] = exchange.BidValidatorFunc(func(request *openrtb.BidRequest, bid *exchange.PBSOrtbBid) error {
+			err := validator.Validate(request, bid)
+			if err == nil {
+				return nil
+			}
+			if rejection, ok := err.(*exchange.BidRejectionError); ok {
+				if rejection.BidID != "" {
+					return rejection
+				}
+				return &exchange.BidRejectionError{BidID: bid.Bid.ID, Reason: rejection.Reason, Message: rejection.Message}
+			}
+			return &exchange.BidRejectionError{BidID: bid.Bid.ID, Reason: pbsmetrics.BidRejectionCustom, Message: err.Error()}
+		})
+	}
+	return identified
+}
+
+// buildValidateBidsResponse matches the validation errors to the Bids which were dropped, using the BidRejectionError.BidID.
+// Errors without a Bid ID reject the whole SeatBid, e.g. because its currency isn't allowed. They explain every dropped Bid
+// which doesn't have an error of its own.
+func buildValidateBidsResponse(allBids []*exchange.PBSOrtbBid, keptBids []*exchange.PBSOrtbBid, errs []error) validateBidsResponse {
+	bidErrs := make(map[string]error, len(errs))
+	var seatErr error
+	for _, err := range errs {
+		if rejection, ok := err.(*exchange.BidRejectionError); ok && rejection.BidID != "" {
+			if _, ok := bidErrs[rejection.BidID]; !ok {
+				bidErrs[rejection.BidID] = err
+			}
+		} else if seatErr == nil {
+			seatErr = err
+		}
+	}
+	kept := make(map[*exchange.PBSOrtbBid]struct{}, len(keptBids))
+	response := validateBidsResponse{
+		Kept:    make([]string, 0, len(keptBids)),
+		Dropped: make([]droppedBid, 0, len(allBids)-len(keptBids)),
+	}
+	for _, bid := range keptBids {
+		kept[bid] = struct{}{}
+		response.Kept = append(response.Kept, bid.Bid.ID)
+	}
+	for _, bid := range allBids {
+		if _, ok := kept[bid]; ok {
+			continue
+		}
+		err, ok := bidErrs[bid.Bid.ID]
+		if !ok {
+			err = seatErr
+		}
+		response.Dropped = append(response.Dropped, newDroppedBid(bid.Bid.ID, err))
+	}
+	return response
+}
+
+func newDroppedBid(bidID string, err error) droppedBid {
+	if err == nil {
+		return droppedBid{ID: bidID, Reason: pbsmetrics.BidRejectionCustom}
+	}
+	if rejection, ok := err.(*exchange.BidRejectionError); ok {
+		return droppedBid{ID: bidID, Reason: rejection.Reason, Message: rejection.Message}
+	}
+	return droppedBid{ID: bidID, Reason: pbsmetrics.BidRejectionCustom, Message: err.Error()}
+}
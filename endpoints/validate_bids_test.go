@@ -0,0 +1,180 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/adapters"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/exchange"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+)
+
+func TestValidateBidsReport(t *testing.T) {
+	body := `{
+		"bidder": "appnexus",
+		"request": {
+			"id": "some-request",
+			"imp": [{"id": "my-imp", "banner": {"format": [{"w": 300, "h": 250}]}, "video": {"mimes": ["video/mp4"]}, "bidfloor": 0.5}]
+		},
+		"seatbid": {
+			"bid": [
+				{"id": "good-bid", "impid": "my-imp", "price": 0.8, "crid": "creative", "adm": "markup", "w": 300, "h": 250},
+				{"id": "cheap-bid", "impid": "my-imp", "price": 0.2, "crid": "creative", "adm": "markup", "w": 300, "h": 250},
+				{"id": "video-bid", "impid": "my-imp", "price": 0.8, "crid": "creative", "adm": "markup", "ext": {"prebid": {"type": "video", "video": {"duration": 30}}}}
+			]
+		}
+	}`
+	response := runValidateBids(t, NewValidateBidsEndpoint(bannerOnlyInfos(), nil, config.BidValidation{}), body)
+
+	if len(response.Kept) != 1 || response.Kept[0] != "good-bid" {
+		t.Errorf("Expected only good-bid to be kept. Got %v", response.Kept)
+	}
+	if len(response.Dropped) != 2 {
+		t.Fatalf("Expected 2 dropped bids. Got %d", len(response.Dropped))
+	}
+	assertDroppedBid(t, response.Dropped[0], "cheap-bid", pbsmetrics.BidRejectionBelowFloor)
+	assertDroppedBid(t, response.Dropped[1], "video-bid", pbsmetrics.BidRejectionUnsupportedMediaType)
+}
+
+func TestValidateBidsCustomValidators(t *testing.T) {
+	validator := exchange.BidValidatorFunc(func(request *openrtb.BidRequest, bid *exchange.PBSOrtbBid) error {
+		return &exchange.BidRejectionError{Message: "always rejected"}
+	})
+	body := `{
+		"bidder": "appnexus",
+		"request": {"id": "some-request", "imp": [{"id": "my-imp"}]},
+		"seatbid": {"bid": [{"id": "one-bid", "impid": "my-imp", "price": 0.8, "crid": "creative", "adm": "markup"}]}
+	}`
+	response := runValidateBids(t, NewValidateBidsEndpoint(bannerOnlyInfos(), []exchange.BidValidator{validator}, config.BidValidation{}), body)
+
+	if len(response.Dropped) != 1 || response.Dropped[0].Message != "always rejected" {
+		t.Errorf("Expected the custom validator to drop the bid. Got %v", response.Dropped)
+	}
+}
+
+func TestValidateBidsMatchesErrorsByBidID(t *testing.T) {
+	allBids := []*exchange.PBSOrtbBid{
+		{Bid: &openrtb.Bid{ID: "first-bid"}},
+		{Bid: &openrtb.Bid{ID: "good-bid"}},
+		{Bid: &openrtb.Bid{ID: "second-bid"}},
+	}
+	errs := []error{
+		&exchange.BidRejectionError{BidID: "second-bid", Reason: pbsmetrics.BidRejectionBelowFloor},
+		&exchange.BidRejectionError{BidID: "first-bid", Reason: pbsmetrics.BidRejectionEmptyMarkup},
+	}
+	response := buildValidateBidsResponse(allBids, allBids[1:2], errs)
+
+	if len(response.Dropped) != 2 {
+		t.Fatalf("Expected 2 dropped bids. Got %d", len(response.Dropped))
+	}
+	assertDroppedBid(t, response.Dropped[0], "first-bid", pbsmetrics.BidRejectionEmptyMarkup)
+	assertDroppedBid(t, response.Dropped[1], "second-bid", pbsmetrics.BidRejectionBelowFloor)
+}
+
+func TestValidateBidsSeatRejection(t *testing.T) {
+	body := `{
+		"bidder": "appnexus",
+		"request": {"id": "some-request", "imp": [{"id": "my-imp"}], "cur": ["USD"]},
+		"seatbid": {"bid": [
+			{"id": "one-bid", "impid": "my-imp", "price": 0.8, "crid": "creative", "adm": "markup"},
+			{"id": "other-bid", "impid": "my-imp", "price": 0.8, "crid": "creative", "adm": "markup"}
+		]},
+		"cur": "EUR"
+	}`
+	response := runValidateBids(t, NewValidateBidsEndpoint(bannerOnlyInfos(), nil, config.BidValidation{}), body)
+
+	if len(response.Dropped) != 2 {
+		t.Fatalf("Expected the whole seat to be dropped. Got %v", response.Dropped)
+	}
+	assertDroppedBid(t, response.Dropped[0], "one-bid", pbsmetrics.BidRejectionCurrencyNotAllowed)
+	assertDroppedBid(t, response.Dropped[1], "other-bid", pbsmetrics.BidRejectionCurrencyNotAllowed)
+}
+
+func TestValidateBidsIdentifiesCustomErrors(t *testing.T) {
+	validator := exchange.BidValidatorFunc(func(request *openrtb.BidRequest, bid *exchange.PBSOrtbBid) error {
+		if bid.Bid.ID == "good-bid" {
+			return nil
+		}
+		return errors.New("rejected " + bid.Bid.ID)
+	})
+	body := `{
+		"bidder": "appnexus",
+		"request": {"id": "some-request", "imp": [{"id": "my-imp"}]},
+		"seatbid": {"bid": [
+			{"id": "one-bid", "impid": "my-imp", "price": 0.8, "crid": "creative", "adm": "markup"},
+			{"id": "good-bid", "impid": "my-imp", "price": 0.8, "crid": "creative", "adm": "markup"},
+			{"id": "other-bid", "impid": "my-imp", "price": 0.8, "crid": "creative", "adm": "markup"}
+		]}
+	}`
+	response := runValidateBids(t, NewValidateBidsEndpoint(bannerOnlyInfos(), []exchange.BidValidator{validator}, config.BidValidation{}), body)
+
+	if len(response.Dropped) != 2 {
+		t.Fatalf("Expected 2 dropped bids. Got %v", response.Dropped)
+	}
+	for _, dropped := range response.Dropped {
+		if dropped.Message != "rejected "+dropped.ID || dropped.Reason != pbsmetrics.BidRejectionCustom {
+			t.Errorf("Expected bid %s to be dropped by the custom validator. Got %v", dropped.ID, dropped)
+		}
+	}
+}
+
+func TestValidateBidsBadRequests(t *testing.T) {
+	endpoint := NewValidateBidsEndpoint(bannerOnlyInfos(), nil, config.BidValidation{})
+	badBodies := []string{
+		`{`,
+		`{"bidder": "appnexus"}`,
+		`{"bidder": "unknown", "request": {"id": "some-request"}}`,
+		`{"bidder": "appnexus", "request": {"id": "some-request"}, "seatbid": {"bid": [{"id": "one-bid", "ext": "bad"}]}}`,
+	}
+	for _, body := range badBodies {
+		w := httptest.NewRecorder()
+		endpoint(w, httptest.NewRequest("POST", "/validation/bids", strings.NewReader(body)), nil)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected a 400 for body %s. Got %d", body, w.Code)
+		}
+	}
+}
+
+func bannerOnlyInfos() adapters.BidderInfos {
+	return adapters.BidderInfos{
+		string(openrtb_ext.BidderAppnexus): {
+			Capabilities: &adapters.CapabilitiesInfo{
+				Site: &adapters.PlatformInfo{
+					MediaTypes: []openrtb_ext.BidType{openrtb_ext.BidTypeBanner},
+				},
+			},
+		},
+	}
+}
+
+func runValidateBids(t *testing.T, endpoint httprouter.Handle, body string) validateBidsResponse {
+	t.Helper()
+	w := httptest.NewRecorder()
+	endpoint(w, httptest.NewRequest("POST", "/validation/bids", strings.NewReader(body)), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected a 200. Got %d: %s", w.Code, w.Body.String())
+	}
+	var response validateBidsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal the response: %v", err)
+	}
+	return response
+}
+
+func assertDroppedBid(t *testing.T, dropped droppedBid, expectedID string, expectedReason pbsmetrics.BidRejectionReason) {
+	t.Helper()
+	if dropped.ID != expectedID {
+		t.Errorf("Expected dropped bid %s. Got %s", expectedID, dropped.ID)
+	}
+	if dropped.Reason != expectedReason {
+		t.Errorf("Expected bid %s to be dropped for %s. Got %s", expectedID, expectedReason, dropped.Reason)
+	}
+}
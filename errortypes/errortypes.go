@@ -1,5 +1,10 @@
 package errortypes
 
+import (
+	"fmt"
+	"time"
+)
+
 // These define the error codes for all the errors enumerated in this package
 // NoErrorCode is to reserve 0 for non error states.
 const (
@@ -9,6 +14,7 @@ const (
 	BadServerResponseCode
 	FailedToRequestBidsCode
 	BidderTemporarilyDisabledCode
+	WarningCode
 )
 
 // We should use this code for any Error interface that is not in this package
@@ -35,6 +41,21 @@ func (err *Timeout) Code() int {
 	return TimeoutCode
 }
 
+// BidderTimeout is a Timeout which names the Bidder that was too slow, and how long Prebid Server waited for it.
+// It shares the TimeoutCode, so it's counted with the other timeouts in the metrics.
+type BidderTimeout struct {
+	Bidder  string
+	Elapsed time.Duration
+}
+
+func (err *BidderTimeout) Error() string {
+	return fmt.Sprintf("%s timed out after %d ms", err.Bidder, err.Elapsed/time.Millisecond)
+}
+
+func (err *BidderTimeout) Code() int {
+	return TimeoutCode
+}
+
 // BadInput should be used when returning errors which are caused by bad input.
 // It should _not_ be used if the error is a server-side issue (e.g. failed to send the external request).
 //
@@ -104,6 +125,20 @@ func (err *BidderTemporarilyDisabled) Code() int {
 	return BidderTemporarilyDisabledCode
 }
 
+// Warning is used for informational notices, where Prebid Server did something the caller may want to know about,
+// but nothing actually went wrong. For example, valid Bids may be dropped to keep the response small.
+type Warning struct {
+	Message string
+}
+
+func (err *Warning) Error() string {
+	return err.Message
+}
+
+func (err *Warning) Code() int {
+	return WarningCode
+}
+
 // DecodeError provides the error code for an error, as defined above
 func DecodeError(err error) int {
 	if ce, ok := err.(Coder); ok {
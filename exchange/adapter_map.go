@@ -75,5 +75,8 @@ func newAdapterMap(client *http.Client, cfg *config.Configuration, infos adapter
 	for name, bidder := range ortbBidders {
 		allBidders[name] = AdaptBidder(adapters.EnforceBidderInfo(bidder, infos[string(name)]), client)
 	}
+	for name, bidder := range allBidders {
+		allBidders[name] = withLatencyBudget(bidder, cfg.Adapters[strings.ToLower(string(name))].LatencyBudget)
+	}
 	return allBidders
 }
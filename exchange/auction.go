@@ -11,21 +11,22 @@ import (
 	"github.com/prebid/prebid-server/prebid_cache_client"
 )
 
-func NewAuction(seatBids map[openrtb_ext.BidderName]*PBSOrtbSeatBid, numImps int) *Auction {
+// NewAuction picks the winning Bids on each imp, overall and for each Bidder. The highest price wins, unless preferDeals is true.
+// In that case, see outranks.
+func NewAuction(seatBids map[openrtb_ext.BidderName]*PBSOrtbSeatBid, numImps int, preferDeals bool) *Auction {
 	winningBids := make(map[string]*PBSOrtbBid, numImps)
 	winningBidsByBidder := make(map[string]map[openrtb_ext.BidderName]*PBSOrtbBid, numImps)
 
 	for bidderName, seatBid := range seatBids {
 		if seatBid != nil {
 			for _, bid := range seatBid.Bids {
-				cpm := bid.Bid.Price
 				wbid, ok := winningBids[bid.Bid.ImpID]
-				if !ok || cpm > wbid.Bid.Price {
+				if !ok || outranks(bid, wbid, preferDeals) {
 					winningBids[bid.Bid.ImpID] = bid
 				}
 				if bidMap, ok := winningBidsByBidder[bid.Bid.ImpID]; ok {
 					bestSoFar, ok := bidMap[bidderName]
-					if !ok || cpm > bestSoFar.Bid.Price {
+					if !ok || outranks(bid, bestSoFar, preferDeals) {
 						bidMap[bidderName] = bid
 					}
 				} else {
@@ -42,13 +43,23 @@ func NewAuction(seatBids map[openrtb_ext.BidderName]*PBSOrtbSeatBid, numImps int
 	}
 }
 
-func (a *Auction) SetRoundedPrices(priceGranularity openrtb_ext.PriceGranularity) {
+// SetRoundedPrices rounds the price of each top Bid into its price bucket. Bids with a ClearingPrice are rounded by that instead.
+// Bids whose media type has an entry in mediaTypeGranularity use it. The others use the priceGranularity.
+func (a *Auction) SetRoundedPrices(priceGranularity openrtb_ext.PriceGranularity, mediaTypeGranularity map[openrtb_ext.BidType]openrtb_ext.PriceGranularity) {
 	roundedPrices := make(map[*PBSOrtbBid]string, 5*len(a.winningBids))
 	for _, topBidsPerImp := range a.winningBidsByBidder {
 		for _, topBidPerBidder := range topBidsPerImp {
-			roundedPrice, err := GetCpmStringValue(topBidPerBidder.Bid.Price, priceGranularity)
+			granularity := priceGranularity
+			if typeGranularity, ok := mediaTypeGranularity[topBidPerBidder.BidType]; ok {
+				granularity = typeGranularity
+			}
+			price := topBidPerBidder.Bid.Price
+			if topBidPerBidder.ClearingPrice > 0 {
+				price = topBidPerBidder.ClearingPrice
+			}
+			roundedPrice, err := GetCpmStringValue(price, granularity)
 			if err != nil {
-				glog.Errorf(`Error rounding price according to granularity. This shouldn't happen unless /openrtb2 input validation is buggy. Granularity was "%v".`, priceGranularity)
+				glog.Errorf(`Error rounding price according to granularity. This shouldn't happen unless /openrtb2 input validation is buggy. Granularity was "%v".`, granularity)
 			}
 			roundedPrices[topBidPerBidder] = roundedPrice
 		}
@@ -56,7 +67,9 @@ func (a *Auction) SetRoundedPrices(priceGranularity openrtb_ext.PriceGranularity
 	a.roundedPrices = roundedPrices
 }
 
-func (a *Auction) doCache(ctx context.Context, cache prebid_cache_client.Client, bids bool, vast bool, bidRequest *openrtb.BidRequest, ttlBuffer int64, defaultTTLs *config.DefaultTTLs) []error {
+// doCache sends the top Bids to Prebid Cache. Each Bid is cached for its CacheTTL plus the ttlBuffer.
+// If dedupe is true, identical creatives are only sent once, and the Bids which made them share its cache key.
+func (a *Auction) doCache(ctx context.Context, cache prebid_cache_client.Client, bids bool, vast bool, ttlBuffer int64, dedupe bool) []error {
 	if !bids && !vast {
 		return nil
 	}
@@ -66,20 +79,16 @@ func (a *Auction) doCache(ctx context.Context, cache prebid_cache_client.Client,
 	bidIndices := make(map[int]*openrtb.Bid, expectNumBids)
 	vastIndices := make(map[int]*openrtb.Bid, expectNumVast)
 	toCache := make([]prebid_cache_client.Cacheable, 0, expectNumBids+expectNumVast)
-	expByImp := make(map[string]int64)
 
-	// Grab the imp TTLs
-	for _, imp := range bidRequest.Imp {
-		expByImp[imp.ID] = imp.Exp
-	}
-	for impID, topBidsPerImp := range a.winningBidsByBidder {
+	for _, topBidsPerImp := range a.winningBidsByBidder {
 		for _, topBidPerBidder := range topBidsPerImp {
 			if bids {
 				if jsonBytes, err := json.Marshal(topBidPerBidder.Bid); err == nil {
 					toCache = append(toCache, prebid_cache_client.Cacheable{
 						Type:       prebid_cache_client.TypeJSON,
 						Data:       jsonBytes,
-						TTLSeconds: cacheTTL(expByImp[impID], topBidPerBidder.Bid.Exp, defTTL(topBidPerBidder.BidType, defaultTTLs), ttlBuffer),
+						TTLSeconds: addBuffer(topBidPerBidder.CacheTTL, ttlBuffer),
+						Signature:  topBidPerBidder.CreativeSignature,
 					})
 					bidIndices[len(toCache)-1] = topBidPerBidder.Bid
 				}
@@ -90,7 +99,8 @@ func (a *Auction) doCache(ctx context.Context, cache prebid_cache_client.Client,
 					toCache = append(toCache, prebid_cache_client.Cacheable{
 						Type:       prebid_cache_client.TypeXML,
 						Data:       jsonBytes,
-						TTLSeconds: cacheTTL(expByImp[impID], topBidPerBidder.Bid.Exp, defTTL(topBidPerBidder.BidType, defaultTTLs), ttlBuffer),
+						TTLSeconds: addBuffer(topBidPerBidder.CacheTTL, ttlBuffer),
+						Signature:  topBidPerBidder.CreativeSignature,
 					})
 					vastIndices[len(toCache)-1] = topBidPerBidder.Bid
 				}
@@ -98,7 +108,20 @@ func (a *Auction) doCache(ctx context.Context, cache prebid_cache_client.Client,
 		}
 	}
 
-	ids, errs := cache.PutJson(ctx, toCache)
+	var ids []string
+	var errs []error
+	if dedupe {
+		unique, uniqueIndices := dedupeCacheables(toCache)
+		uniqueIDs, putErrs := cache.PutJson(ctx, unique)
+		ids, errs = make([]string, len(toCache)), putErrs
+		for index, uniqueIndex := range uniqueIndices {
+			if uniqueIndex < len(uniqueIDs) {
+				ids[index] = uniqueIDs[uniqueIndex]
+			}
+		}
+	} else {
+		ids, errs = cache.PutJson(ctx, toCache)
+	}
 
 	if bids {
 		a.cacheIds = make(map[*openrtb.Bid]string, len(bidIndices))
@@ -119,6 +142,35 @@ func (a *Auction) doCache(ctx context.Context, cache prebid_cache_client.Client,
 	return errs
 }
 
+// dedupeCacheables returns the distinct values in toCache, and the index in that list of each value in toCache.
+// Values are the same if they have the same type, data and signature. Shared values are cached for the longest of their TTLs.
+//
+// The cached Bid JSON includes each Bid's ID and price, so it's mostly the VAST of video Bids with the same adm which is shared.
+func dedupeCacheables(toCache []prebid_cache_client.Cacheable) ([]prebid_cache_client.Cacheable, []int) {
+	type cacheableKey struct {
+		cacheType prebid_cache_client.PayloadType
+		data      string
+		signature string
+	}
+	unique := make([]prebid_cache_client.Cacheable, 0, len(toCache))
+	uniqueIndices := make([]int, len(toCache))
+	seen := make(map[cacheableKey]int, len(toCache))
+	for i, value := range toCache {
+		key := cacheableKey{value.Type, string(value.Data), value.Signature}
+		if index, ok := seen[key]; ok {
+			if value.TTLSeconds > unique[index].TTLSeconds {
+				unique[index].TTLSeconds = value.TTLSeconds
+			}
+			uniqueIndices[i] = index
+			continue
+		}
+		seen[key] = len(unique)
+		uniqueIndices[i] = len(unique)
+		unique = append(unique, value)
+	}
+	return unique, uniqueIndices
+}
+
 // makeVAST returns some VAST XML for the given bid. If AdM is defined,
 // it takes precedence. Otherwise the Nurl will be wrapped in a redirect tag.
 func makeVAST(bid *openrtb.Bid) string {
@@ -146,23 +198,64 @@ func maybeMake(shouldMake bool, capacity int) []prebid_cache_client.Cacheable {
 	return nil
 }
 
-func cacheTTL(impTTL int64, bidTTL int64, defTTL int64, buffer int64) (ttl int64) {
-	if impTTL <= 0 && bidTTL <= 0 {
-		// Only use default if there is no imp nor bid TTL provided. We don't want the default
-		// to cut short a requested longer TTL.
-		return addBuffer(defTTL, buffer)
+// signCreatives sets the CreativeSignature of every Bid with an adm, so that it can be verified after it's fetched from the cache.
+// It does nothing if the secret is empty.
+func signCreatives(seatBid *PBSOrtbSeatBid, secret string) {
+	if secret == "" || seatBid == nil {
+		return
 	}
-	if impTTL <= 0 {
-		// Use <= to handle the case of someone sending a negative ttl. We treat it as zero
-		return addBuffer(bidTTL, buffer)
+	for _, bid := range seatBid.Bids {
+		if bid.Bid.AdM != "" {
+			bid.CreativeSignature = prebid_cache_client.SignCreative(secret, bid.Bid.AdM)
+		}
 	}
-	if bidTTL <= 0 {
-		return addBuffer(impTTL, buffer)
+}
+
+// applyCacheTTLs sets the CacheTTL of every Bid from its imp.exp, its bid.exp, and the default TTL for its media type.
+// If maxTTL is positive, no Bid is cached for longer than that, whatever the others say.
+func applyCacheTTLs(seatBids map[openrtb_ext.BidderName]*PBSOrtbSeatBid, bidRequest *openrtb.BidRequest, defaultTTLs *config.DefaultTTLs, capAtDefault bool, maxTTL int64) {
+	expByImp := make(map[string]int64, len(bidRequest.Imp))
+	for _, imp := range bidRequest.Imp {
+		expByImp[imp.ID] = imp.Exp
+	}
+	for _, seatBid := range seatBids {
+		if seatBid == nil {
+			continue
+		}
+		for _, bid := range seatBid.Bids {
+			bid.CacheTTL = capTTL(cacheTTL(expByImp[bid.Bid.ImpID], bid.Bid.Exp, defTTL(bid.BidType, defaultTTLs), capAtDefault), maxTTL)
+		}
+	}
+}
+
+// cacheTTL returns the smaller of the imp and bid TTLs. If capAtDefault is true, the default TTL caps them too.
+// Otherwise it's only used if there is no imp nor bid TTL, so that it doesn't cut short a requested longer TTL.
+func cacheTTL(impTTL int64, bidTTL int64, defTTL int64, capAtDefault bool) (ttl int64) {
+	ttl = minPositive(impTTL, bidTTL)
+	if ttl <= 0 || capAtDefault {
+		ttl = minPositive(ttl, defTTL)
+	}
+	return ttl
+}
+
+// capTTL returns the ttl, or maxTTL if that's smaller. A ttl of 0 means "no TTL", so it's left for the cache to decide.
+func capTTL(ttl int64, maxTTL int64) int64 {
+	if ttl > 0 && maxTTL > 0 && ttl > maxTTL {
+		return maxTTL
+	}
+	return ttl
+}
+
+// minPositive returns the smaller of a and b. Values <= 0 mean "no TTL", so they're ignored.
+// It returns 0 if neither is positive.
+func minPositive(a int64, b int64) int64 {
+	if a <= 0 && b <= 0 {
+		return 0
 	}
-	if impTTL < bidTTL {
-		return addBuffer(impTTL, buffer)
+	if b <= 0 || (a > 0 && a < b) {
+		return a
 	}
-	return addBuffer(bidTTL, buffer)
+	return b
 }
 
 func addBuffer(base int64, buffer int64) int64 {
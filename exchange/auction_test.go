@@ -76,6 +76,7 @@ func runCacheSpec(t *testing.T, fileDisplayName string, specData *cacheSpec) {
 	var bid *PBSOrtbBid
 	winningBidsByBidder := make(map[string]map[openrtb_ext.BidderName]*PBSOrtbBid)
 	roundedPrices := make(map[*PBSOrtbBid]string)
+	seatBids := make(map[openrtb_ext.BidderName]*PBSOrtbSeatBid)
 	for i, pbsBid := range specData.PbsBids {
 		if _, ok := winningBidsByBidder[pbsBid.Bid.ID]; !ok {
 			winningBidsByBidder[pbsBid.Bid.ID] = make(map[openrtb_ext.BidderName]*PBSOrtbBid)
@@ -85,6 +86,10 @@ func runCacheSpec(t *testing.T, fileDisplayName string, specData *cacheSpec) {
 			BidType: pbsBid.BidType,
 		}
 		winningBidsByBidder[pbsBid.Bid.ID][pbsBid.Bidder] = bid
+		if _, ok := seatBids[pbsBid.Bidder]; !ok {
+			seatBids[pbsBid.Bidder] = &PBSOrtbSeatBid{}
+		}
+		seatBids[pbsBid.Bidder].Bids = append(seatBids[pbsBid.Bidder].Bids, bid)
 		roundedPrices[bid] = strconv.FormatFloat(bid.Bid.Price, 'f', 2, 64)
 		// Marshal the bid for the expected cacheables
 		cjson, _ := json.Marshal(bid.Bid)
@@ -96,7 +101,8 @@ func runCacheSpec(t *testing.T, fileDisplayName string, specData *cacheSpec) {
 	testAuction := &Auction{
 		winningBidsByBidder: winningBidsByBidder,
 	}
-	_ = testAuction.doCache(ctx, cache, true, false, &specData.BidRequest, 60, &specData.DefaultTTLs)
+	applyCacheTTLs(seatBids, &specData.BidRequest, &specData.DefaultTTLs, specData.CapTTLAtDefault, specData.MaxTTLSeconds)
+	_ = testAuction.doCache(ctx, cache, true, false, 60, false)
 	found := 0
 
 	for _, cExpected := range specData.ExpectedCacheables {
@@ -121,6 +127,8 @@ type cacheSpec struct {
 	PbsBids            []pbsBid                        `json:"pbsBids"`
 	ExpectedCacheables []prebid_cache_client.Cacheable `json:"expectedCacheables"`
 	DefaultTTLs        config.DefaultTTLs              `json:"defaultTTLs"`
+	CapTTLAtDefault    bool                            `json:"capTTLAtDefault"`
+	MaxTTLSeconds      int64                           `json:"maxTTLSeconds"`
 }
 
 type pbsBid struct {
@@ -137,3 +145,211 @@ func (c *mockCache) PutJson(ctx context.Context, values []prebid_cache_client.Ca
 	c.items = values
 	return []string{"", "", "", "", ""}, nil
 }
+
+func TestCacheTTLUsesImpExp(t *testing.T) {
+	assert.Equal(t, int64(100), cacheTTL(100, 200, 300, true), "imp.exp should win when it's the smallest")
+}
+
+func TestCacheTTLUsesBidExp(t *testing.T) {
+	assert.Equal(t, int64(100), cacheTTL(200, 100, 300, true), "bid.exp should win when it's the smallest")
+}
+
+func TestCacheTTLUsesDefault(t *testing.T) {
+	assert.Equal(t, int64(100), cacheTTL(200, 300, 100, true), "The default should win when it's the smallest and caps the TTL")
+	assert.Equal(t, int64(200), cacheTTL(200, 300, 100, false), "The default shouldn't cut short a longer requested TTL unless it caps it")
+}
+
+func TestCacheTTLIgnoresMissingValues(t *testing.T) {
+	assert.Equal(t, int64(300), cacheTTL(0, 0, 300, false), "The default should be used if there is no imp nor bid exp")
+	assert.Equal(t, int64(200), cacheTTL(-1, 200, 0, true), "Negative and missing values should be ignored")
+	assert.Equal(t, int64(0), cacheTTL(0, 0, 0, true), "There should be no TTL if nothing defines one")
+}
+
+func TestApplyCacheTTLsCapsBidExp(t *testing.T) {
+	bidRequest := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{{ID: "my-imp", Banner: &openrtb.Banner{}}},
+	}
+	defaultTTLs := &config.DefaultTTLs{Banner: 300}
+	seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Bids: []*PBSOrtbBid{
+				{Bid: &openrtb.Bid{ID: "short-exp", ImpID: "my-imp", Exp: 100}, BidType: openrtb_ext.BidTypeBanner},
+				{Bid: &openrtb.Bid{ID: "long-exp", ImpID: "my-imp", Exp: 3600}, BidType: openrtb_ext.BidTypeBanner},
+				{Bid: &openrtb.Bid{ID: "no-exp", ImpID: "my-imp"}, BidType: openrtb_ext.BidTypeBanner},
+			},
+		},
+	}
+	applyCacheTTLs(seatBids, bidRequest, defaultTTLs, false, 600)
+
+	bids := seatBids[openrtb_ext.BidderAppnexus].Bids
+	assert.Equal(t, int64(100), bids[0].CacheTTL, "bid.exp should be used when it's below the max")
+	assert.Equal(t, int64(600), bids[1].CacheTTL, "bid.exp should be capped at the max")
+	assert.Equal(t, int64(300), bids[2].CacheTTL, "The default should be used when there's no bid.exp")
+
+	applyCacheTTLs(seatBids, bidRequest, defaultTTLs, false, 200)
+	assert.Equal(t, int64(200), bids[2].CacheTTL, "The default should be capped at the max too")
+
+	applyCacheTTLs(seatBids, bidRequest, defaultTTLs, false, 0)
+	assert.Equal(t, int64(3600), bids[1].CacheTTL, "bid.exp shouldn't be capped if there's no max")
+}
+
+func TestCapTTL(t *testing.T) {
+	assert.Equal(t, int64(100), capTTL(100, 200), "TTLs below the max should be kept")
+	assert.Equal(t, int64(200), capTTL(300, 200), "TTLs above the max should be capped")
+	assert.Equal(t, int64(300), capTTL(300, 0), "A max of 0 shouldn't cap anything")
+	assert.Equal(t, int64(0), capTTL(0, 200), "Bids without a TTL should be left to the cache")
+}
+
+func TestSetRoundedPricesByMediaType(t *testing.T) {
+	video := openrtb_ext.PriceGranularity{
+		Precision: 2,
+		Ranges: []openrtb_ext.GranularityRange{{
+			Min:       0,
+			Max:       50,
+			Increment: 5,
+		}},
+	}
+	testCases := []struct {
+		description string
+		bidType     openrtb_ext.BidType
+		price       float64
+		expected    string
+	}{
+		{"Video uses its own granularity", openrtb_ext.BidTypeVideo, 12.34, "10.00"},
+		{"Video at a bucket boundary", openrtb_ext.BidTypeVideo, 15, "15.00"},
+		{"Video above the default max", openrtb_ext.BidTypeVideo, 42.5, "40.00"},
+		{"Video above its own max", openrtb_ext.BidTypeVideo, 75, "50.00"},
+		{"Banner falls back to the default", openrtb_ext.BidTypeBanner, 12.34, "12.30"},
+		{"Banner at the default max", openrtb_ext.BidTypeBanner, 20, "20.00"},
+		{"Banner above the default max", openrtb_ext.BidTypeBanner, 42.5, "20.00"},
+		{"Native falls back to the default", openrtb_ext.BidTypeNative, 0.05, "0.00"},
+	}
+
+	for _, test := range testCases {
+		bid := &PBSOrtbBid{
+			Bid: &openrtb.Bid{
+				ID:    "bid",
+				ImpID: "imp",
+				Price: test.price,
+			},
+			BidType: test.bidType,
+		}
+		auc := NewAuction(map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+			openrtb_ext.BidderAppnexus: {
+				Bids: []*PBSOrtbBid{bid},
+			},
+		}, 1, false)
+		auc.SetRoundedPrices(openrtb_ext.PriceGranularityFromString("med"), map[openrtb_ext.BidType]openrtb_ext.PriceGranularity{
+			openrtb_ext.BidTypeVideo: video,
+		})
+		assert.Equal(t, test.expected, auc.roundedPrices[bid], test.description)
+	}
+}
+
+func TestSignedCreativesAreCached(t *testing.T) {
+	signed := &PBSOrtbBid{
+		Bid: &openrtb.Bid{
+			ID:    "signed",
+			ImpID: "imp",
+			Price: 1,
+			AdM:   "<div>ad</div>",
+		},
+		BidType: openrtb_ext.BidTypeBanner,
+	}
+	seatBid := &PBSOrtbSeatBid{
+		Bids: []*PBSOrtbBid{signed},
+	}
+	signCreatives(seatBid, "host-secret")
+	assert.True(t, prebid_cache_client.VerifyCreative("host-secret", "<div>ad</div>", signed.CreativeSignature))
+
+	auc := NewAuction(map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: seatBid,
+	}, 1, false)
+	auc.SetRoundedPrices(openrtb_ext.PriceGranularityFromString("med"), nil)
+	cache := &mockCache{}
+	auc.doCache(context.Background(), cache, true, false, 0, false)
+	if assert.Len(t, cache.items, 1) {
+		assert.Equal(t, signed.CreativeSignature, cache.items[0].Signature)
+		assert.False(t, prebid_cache_client.VerifyCreative("host-secret", "<div>tampered</div>", cache.items[0].Signature))
+	}
+}
+
+func TestSignCreativesWithoutSecret(t *testing.T) {
+	bid := &PBSOrtbBid{
+		Bid: &openrtb.Bid{
+			AdM: "<div>ad</div>",
+		},
+	}
+	signCreatives(&PBSOrtbSeatBid{Bids: []*PBSOrtbBid{bid}}, "")
+	assert.Empty(t, bid.CreativeSignature)
+}
+
+func TestIdenticalCreativesShareCacheKeys(t *testing.T) {
+	auc := newDedupeTestAuction("<VAST>shared</VAST>", "<VAST>shared</VAST>", "<VAST>distinct</VAST>")
+	cache := &keyedMockCache{}
+	auc.doCache(context.Background(), cache, false, true, 0, true)
+
+	assert.Len(t, cache.items, 2)
+	for _, item := range cache.items {
+		if string(item.Data) == `"<VAST>shared</VAST>"` {
+			assert.Equal(t, int64(300), item.TTLSeconds, "Shared creatives should be cached for the longest TTL")
+		}
+	}
+	appnexusID := auc.vastCacheIds[auc.winningBidsByBidder["imp"][openrtb_ext.BidderAppnexus].Bid]
+	rubiconID := auc.vastCacheIds[auc.winningBidsByBidder["imp"][openrtb_ext.BidderRubicon].Bid]
+	openxID := auc.vastCacheIds[auc.winningBidsByBidder["imp"][openrtb_ext.BidderOpenx].Bid]
+	assert.NotEmpty(t, appnexusID)
+	assert.Equal(t, appnexusID, rubiconID, "Identical creatives should share a cache key")
+	assert.NotEqual(t, appnexusID, openxID, "Distinct creatives should have their own cache keys")
+}
+
+func TestCreativesAreNotDedupedByDefault(t *testing.T) {
+	auc := newDedupeTestAuction("<VAST>shared</VAST>", "<VAST>shared</VAST>", "<VAST>distinct</VAST>")
+	cache := &keyedMockCache{}
+	auc.doCache(context.Background(), cache, false, true, 0, false)
+
+	assert.Len(t, cache.items, 3)
+	appnexusID := auc.vastCacheIds[auc.winningBidsByBidder["imp"][openrtb_ext.BidderAppnexus].Bid]
+	rubiconID := auc.vastCacheIds[auc.winningBidsByBidder["imp"][openrtb_ext.BidderRubicon].Bid]
+	assert.NotEqual(t, appnexusID, rubiconID)
+}
+
+func TestDedupeCacheables(t *testing.T) {
+	toCache := []prebid_cache_client.Cacheable{
+		{Type: prebid_cache_client.TypeXML, Data: json.RawMessage(`"<VAST></VAST>"`), TTLSeconds: 60},
+		{Type: prebid_cache_client.TypeJSON, Data: json.RawMessage(`"<VAST></VAST>"`), TTLSeconds: 60},
+		{Type: prebid_cache_client.TypeXML, Data: json.RawMessage(`"<VAST></VAST>"`), TTLSeconds: 120},
+		{Type: prebid_cache_client.TypeXML, Data: json.RawMessage(`"<VAST></VAST>"`), TTLSeconds: 60, Signature: "abc"},
+	}
+	unique, indices := dedupeCacheables(toCache)
+
+	assert.Len(t, unique, 3, "Values of different types or signatures shouldn't be shared")
+	assert.Equal(t, []int{0, 1, 0, 2}, indices)
+	assert.Equal(t, int64(120), unique[0].TTLSeconds)
+}
+
+// newDedupeTestAuction makes an auction whose video Bids from appnexus, rubicon and openx have the given adms.
+func newDedupeTestAuction(appnexusAdm string, rubiconAdm string, openxAdm string) *Auction {
+	seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {Bids: []*PBSOrtbBid{{Bid: &openrtb.Bid{ID: "apn", ImpID: "imp", Price: 1, AdM: appnexusAdm}, BidType: openrtb_ext.BidTypeVideo, CacheTTL: 60}}},
+		openrtb_ext.BidderRubicon:  {Bids: []*PBSOrtbBid{{Bid: &openrtb.Bid{ID: "rubi", ImpID: "imp", Price: 2, AdM: rubiconAdm}, BidType: openrtb_ext.BidTypeVideo, CacheTTL: 300}}},
+		openrtb_ext.BidderOpenx:    {Bids: []*PBSOrtbBid{{Bid: &openrtb.Bid{ID: "openx", ImpID: "imp", Price: 3, AdM: openxAdm}, BidType: openrtb_ext.BidTypeVideo, CacheTTL: 60}}},
+	}
+	auc := NewAuction(seatBids, 1, false)
+	auc.SetRoundedPrices(openrtb_ext.PriceGranularityFromString("med"), nil)
+	return auc
+}
+
+// keyedMockCache gives each value it's sent a distinct key.
+type keyedMockCache struct {
+	items []prebid_cache_client.Cacheable
+}
+
+func (c *keyedMockCache) PutJson(ctx context.Context, values []prebid_cache_client.Cacheable) ([]string, []error) {
+	c.items = values
+	ids := make([]string, len(values))
+	for i := range values {
+		ids[i] = fmt.Sprintf("cache-id-%d", i)
+	}
+	return ids, nil
+}
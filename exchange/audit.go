@@ -0,0 +1,74 @@
+package exchange
+
+import (
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+)
+
+// AuditEntry is a historical SeatBid to audit, along with the request which it answered.
+type AuditEntry struct {
+	Request *openrtb.BidRequest
+	// Validation is the request's ext.prebid.validation. It may be nil.
+	Validation *openrtb_ext.ExtRequestValidation
+	// Bidder is the core bidder which made the SeatBid.
+	Bidder  openrtb_ext.BidderName
+	SeatBid *PBSOrtbSeatBid
+}
+
+// AuditReport counts how many of the audited Bids would be rejected today, and why.
+type AuditReport struct {
+	SeatBids int
+	Bids     int
+	Rejected int
+	// RejectedByReason counts the rejected Bids by reason. Errors from the host's BidValidators count as BidRejectionCustom.
+	RejectedByReason map[pbsmetrics.BidRejectionReason]int
+	// RejectedByBidder counts the rejected Bids by the bidder which made them.
+	RejectedByBidder map[openrtb_ext.BidderName]int
+}
+
+// AuditBids runs historical SeatBids through the checks which the Exchange makes on the Bids from a live auction,
+// so that ad quality teams can find out which Bids would be rejected today. No auctions are held.
+//
+// It reads the entries until the channel is closed. The validators should return the BidValidators for each bidder,
+// e.g. from BidderValidators, and may be nil. The host's bid_validation.mode is ignored, so that rejections are counted
+// even if the host is permissive. Currency conversion rates aren't available, so Bids compared to floors in other
+// currencies are rejected, like in the /validation/bids endpoint.
+//
+// The entries' SeatBids aren't changed, but their Bids' meta may be backfilled like in a live auction.
+func AuditBids(entries <-chan AuditEntry, hostValidation config.BidValidation, validators func(openrtb_ext.BidderName) []BidValidator) AuditReport {
+	hostValidation.Mode = config.ValidationModeEnforce
+	report := AuditReport{
+		RejectedByReason: make(map[pbsmetrics.BidRejectionReason]int),
+		RejectedByBidder: make(map[openrtb_ext.BidderName]int),
+	}
+	for entry := range entries {
+		if entry.Request == nil || entry.SeatBid == nil {
+			continue
+		}
+		seatBid := *entry.SeatBid
+		brw := &BidResponseWrapper{
+			AdapterBids: &seatBid,
+			Bidder:      entry.Bidder,
+		}
+		var bidderValidators []BidValidator
+		if validators != nil {
+			bidderValidators = validators(entry.Bidder)
+		}
+		errs, _ := brw.ValidateBids(entry.Request, entry.Validation, hostValidation, nil, bidderValidators)
+
+		report.SeatBids++
+		report.Bids += len(entry.SeatBid.Bids)
+		for _, err := range errs {
+			reason, rejected := pbsmetrics.BidRejectionCustom, 1
+			if rejection, ok := err.(*BidRejectionError); ok {
+				reason, rejected = rejection.Reason, rejectedBidCount(rejection, len(entry.SeatBid.Bids))
+			}
+			report.Rejected += rejected
+			report.RejectedByReason[reason] += rejected
+			report.RejectedByBidder[entry.Bidder] += rejected
+		}
+	}
+	return report
+}
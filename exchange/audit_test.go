@@ -0,0 +1,109 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+)
+
+func TestAuditBids(t *testing.T) {
+	request := &openrtb.BidRequest{
+		ID:  "some-request",
+		Cur: []string{"USD"},
+		Imp: newTestImps("some-imp"),
+	}
+	appnexusSeat := &PBSOrtbSeatBid{
+		Bids: []*PBSOrtbBid{
+			newAuditBid("good-bid", "some-imp", "<div>an ad</div>"),
+			newAuditBid("no-markup", "some-imp", ""),
+			newAuditBid("unknown-imp", "other-imp", "<div>an ad</div>"),
+			newAuditBid("custom-bid", "some-imp", "<div>a custom ad</div>"),
+		},
+	}
+	entries := make(chan AuditEntry, 4)
+	entries <- AuditEntry{Request: request, Bidder: openrtb_ext.BidderAppnexus, SeatBid: appnexusSeat}
+	entries <- AuditEntry{
+		Request: request,
+		Bidder:  openrtb_ext.BidderRubicon,
+		SeatBid: &PBSOrtbSeatBid{
+			Bids: []*PBSOrtbBid{
+				newAuditBid("eur-bid", "some-imp", "<div>an ad</div>"),
+				newAuditBid("other-eur-bid", "some-imp", "<div>an ad</div>"),
+			},
+			Currency: "EUR",
+		},
+	}
+	entries <- AuditEntry{Request: request, Bidder: openrtb_ext.BidderRubicon, SeatBid: &PBSOrtbSeatBid{}}
+	entries <- AuditEntry{Bidder: openrtb_ext.BidderRubicon}
+	close(entries)
+
+	validators := func(bidder openrtb_ext.BidderName) []BidValidator {
+		if bidder != openrtb_ext.BidderAppnexus {
+			return nil
+		}
+		return []BidValidator{BidValidatorFunc(func(request *openrtb.BidRequest, bid *PBSOrtbBid) error {
+			if bid.Bid.ID == "custom-bid" {
+				return errors.New("custom rejection")
+			}
+			return nil
+		})}
+	}
+	// Audits should count the rejections even if the host only warns about them.
+	report := AuditBids(entries, config.BidValidation{Mode: config.ValidationModePermissive}, validators)
+
+	if report.SeatBids != 3 {
+		t.Errorf("Expected 3 seat bids to be audited. Got %d", report.SeatBids)
+	}
+	if report.Bids != 6 {
+		t.Errorf("Expected 6 bids to be audited. Got %d", report.Bids)
+	}
+	if report.Rejected != 5 {
+		t.Errorf("Expected 5 bids to be rejected. Got %d", report.Rejected)
+	}
+	expectedReasons := map[pbsmetrics.BidRejectionReason]int{
+		pbsmetrics.BidRejectionEmptyMarkup:        1,
+		pbsmetrics.BidRejectionUnknownImpID:       1,
+		pbsmetrics.BidRejectionCustom:             1,
+		pbsmetrics.BidRejectionCurrencyNotAllowed: 2,
+	}
+	if len(report.RejectedByReason) != len(expectedReasons) {
+		t.Errorf("Expected rejections for %d reasons. Got %v", len(expectedReasons), report.RejectedByReason)
+	}
+	for reason, expected := range expectedReasons {
+		if report.RejectedByReason[reason] != expected {
+			t.Errorf("Expected %d bids rejected for %s. Got %d", expected, reason, report.RejectedByReason[reason])
+		}
+	}
+	if report.RejectedByBidder[openrtb_ext.BidderAppnexus] != 3 || report.RejectedByBidder[openrtb_ext.BidderRubicon] != 2 {
+		t.Errorf("Expected 3 appnexus and 2 rubicon bids to be rejected. Got %v", report.RejectedByBidder)
+	}
+	if len(appnexusSeat.Bids) != 4 {
+		t.Errorf("The audited seat bid shouldn't be changed. Got %d bids", len(appnexusSeat.Bids))
+	}
+}
+
+func TestAuditBidsEmptyBatch(t *testing.T) {
+	entries := make(chan AuditEntry)
+	close(entries)
+	report := AuditBids(entries, config.BidValidation{}, nil)
+
+	if report.SeatBids != 0 || report.Bids != 0 || report.Rejected != 0 {
+		t.Errorf("Expected an empty report. Got %v", report)
+	}
+}
+
+func newAuditBid(id string, impID string, adm string) *PBSOrtbBid {
+	return &PBSOrtbBid{
+		Bid: &openrtb.Bid{
+			ID:    id,
+			ImpID: impID,
+			Price: 0.5,
+			CrID:  "some-creative",
+			AdM:   adm,
+		},
+	}
+}
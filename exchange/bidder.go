@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	"github.com/mxmCherry/openrtb"
 	"github.com/prebid/prebid-server/adapters"
@@ -37,7 +38,24 @@ type AdaptedBidder interface {
 	//
 	// Any errors will be user-facing in the API.
 	// Error messages should help publishers understand what might account for "bad" bids.
-	RequestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustment float64) (*PBSOrtbSeatBid, []error)
+	RequestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustments BidAdjustments) (*PBSOrtbSeatBid, []error)
+}
+
+// BidAdjustments are the factors which a Bidder's prices get multiplied by. They come from
+// request.ext.prebid.bidadjustmentfactors and request.ext.prebid.mediatypebidadjustmentfactors.
+type BidAdjustments struct {
+	// Factor applies to Bids whose media type doesn't have its own factor.
+	Factor float64
+	// MediaTypeFactors override the Factor for Bids of their media type.
+	MediaTypeFactors map[openrtb_ext.BidType]float64
+}
+
+// FactorFor returns the factor which a Bid of the given media type should be multiplied by.
+func (adjustments BidAdjustments) FactorFor(mediaType openrtb_ext.BidType) float64 {
+	if factor, ok := adjustments.MediaTypeFactors[mediaType]; ok {
+		return factor
+	}
+	return adjustments.Factor
 }
 
 // PBSOrtbBid is a Bid returned by an adaptedBidder.
@@ -45,10 +63,37 @@ type AdaptedBidder interface {
 // PBSOrtbBid.bid.Ext will become "response.seatbid[i].bid.ext.bidder" in the final OpenRTB response.
 // PBSOrtbBid.bidType will become "response.seatbid[i].bid.ext.prebid.type" in the final OpenRTB response.
 // PBSOrtbBid.bidTargets does not need to be filled out by the Bidder. It will be set later by the exchange.
+// PBSOrtbBid.bidVideo will become "response.seatbid[i].bid.ext.prebid.video" in the final OpenRTB response.
+// PBSOrtbBid.OriginalPrice and OriginalCurrency are only set by the exchange if it converts the Bid into another currency.
 type PBSOrtbBid struct {
-	Bid        *openrtb.Bid
-	BidType    openrtb_ext.BidType
-	BidTargets map[string]string
+	Bid              *openrtb.Bid
+	BidType          openrtb_ext.BidType
+	BidTargets       map[string]string
+	BidVideo         *openrtb_ext.ExtBidPrebidVideo
+	BidMeta          *openrtb_ext.ExtBidPrebidMeta
+	OriginalPrice    float64
+	OriginalCurrency string
+	DealPriority     int
+	// BidMType is the Bid's OpenRTB 2.6 bid.mtype, or 0 if the Bidder didn't give one.
+	BidMType openrtb_ext.MarkupType
+	// BidDur is the Bid's OpenRTB 2.6 bid.dur, in seconds, or 0 if the Bidder didn't give one.
+	BidDur int64
+	// DealTierSatisfied is true if the Bid's DealPriority meets its Bidder's deal tier config.
+	// If so, DealTier holds the bucket which should be sent to the ad server.
+	DealTierSatisfied bool
+	DealTier          string
+	// Events holds the Bid's event URLs, if the host and account have them enabled.
+	Events *openrtb_ext.ExtBidPrebidEvents
+	// CacheTTL is how many seconds Prebid Cache should keep the Bid, before any buffer is added. 0 means no limit.
+	CacheTTL int64
+	// CreativeSignature is the HMAC of the Bid's adm, if the host signs cached creatives.
+	CreativeSignature string
+	// ClearingPrice is what the Bid pays if it wins a second-price auction. It's 0 in first-price auctions,
+	// and for Bids which didn't have the top price on their Imp.
+	ClearingPrice float64
+	// FinalPrice is the Bid's price in the response currency, after the bid adjustments, currency conversion and rounding.
+	// It's 0 until the exchange has applied all of those.
+	FinalPrice float64
 }
 
 // PBSOrtbSeatBid is a SeatBid returned by an adaptedBidder.
@@ -60,9 +105,18 @@ type PBSOrtbSeatBid struct {
 	// Currency is the Currency in which the bids are made.
 	// Should be a valid curreny ISO code.
 	Currency string
+	// DefaultedCurrency is true if neither the request nor the bidder named the Currency, so it's USD by default.
+	DefaultedCurrency bool
 	// HTTPCalls is the list of debugging info. It should only be populated if the request.test == 1.
 	// This will become response.ext.debug.httpcalls.{bidder} on the final Response.
 	HTTPCalls []*openrtb_ext.ExtHttpCall
+	// Seat is the bidder name which the Bids are attributed to. It's empty if the Bidder didn't declare one.
+	Seat string
+	// OpenRTBVersion is the version of OpenRTB which the Bidder declares for its responses in its bidder-info file.
+	// It's empty if the Bidder didn't declare one.
+	OpenRTBVersion string
+	// AllowedCurrencies are the only currencies which the host lets this Bidder bid in. It's empty if the Bidder may use any.
+	AllowedCurrencies []string
 	// Ext contains the extension for this seatbid.
 	// if len(bids) > 0, this will become response.seatbid[i].Ext.{bidder} on the final OpenRTB response.
 	// if len(bids) == 0, this will be ignored because the OpenRTB spec doesn't allow a SeatBid with 0 Bids.
@@ -85,7 +139,8 @@ type BidderAdapter struct {
 	Client *http.Client
 }
 
-func (bidder *BidderAdapter) RequestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustment float64) (*PBSOrtbSeatBid, []error) {
+func (bidder *BidderAdapter) RequestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustments BidAdjustments) (*PBSOrtbSeatBid, []error) {
+	start := time.Now()
 	reqData, errs := bidder.Bidder.MakeRequests(request)
 
 	if len(reqData) == 0 {
@@ -110,14 +165,16 @@ func (bidder *BidderAdapter) RequestBid(ctx context.Context, request *openrtb.Bi
 	}
 
 	seatBid := &PBSOrtbSeatBid{
-		Bids:      make([]*PBSOrtbBid, 0, len(reqData)),
-		Currency:  "USD",
-		HTTPCalls: make([]*openrtb_ext.ExtHttpCall, 0, len(reqData)),
+		Bids:              make([]*PBSOrtbBid, 0, len(reqData)),
+		Currency:          "USD",
+		DefaultedCurrency: true,
+		HTTPCalls:         make([]*openrtb_ext.ExtHttpCall, 0, len(reqData)),
 	}
 
 	// If request's currency is only one, we use it as the default currency
 	if len(request.Cur) == 1 {
 		seatBid.Currency = request.Cur[0]
+		seatBid.DefaultedCurrency = false
 	}
 
 	firstHTTPCallCurrency := ""
@@ -138,6 +195,7 @@ func (bidder *BidderAdapter) RequestBid(ctx context.Context, request *openrtb.Bi
 
 			if bidResponse != nil {
 
+				declaredCurrency := bidResponse.Currency
 				if bidResponse.Currency == "" {
 					bidResponse.Currency = "USD"
 				}
@@ -153,15 +211,33 @@ func (bidder *BidderAdapter) RequestBid(ctx context.Context, request *openrtb.Bi
 				// TODO: #281 - Once currencies rate conversion is out, this shouldn't be an issue anymore, we will only
 				// need to convert the bid price based on the currency.
 				if firstHTTPCallCurrency == bidResponse.Currency {
+					if seatBid.Seat == "" {
+						seatBid.Seat = bidResponse.Seat
+					}
+					// The request's currency still wins, since many bidders declare USD whatever they bid in.
+					if declaredCurrency != "" && len(request.Cur) != 1 {
+						seatBid.Currency = declaredCurrency
+						seatBid.DefaultedCurrency = false
+					}
 					for i := 0; i < len(bidResponse.Bids); i++ {
-						if bidResponse.Bids[i].Bid != nil {
+						pbsBid := &PBSOrtbBid{
+							Bid:          bidResponse.Bids[i].Bid,
+							BidType:      bidResponse.Bids[i].BidType,
+							BidVideo:     bidResponse.Bids[i].BidVideo,
+							BidMeta:      bidResponse.Bids[i].BidMeta,
+							DealPriority: bidResponse.Bids[i].DealPriority,
+							BidMType:     bidResponse.Bids[i].MType,
+							BidDur:       bidResponse.Bids[i].Dur,
+						}
+						if pbsBid.Bid != nil {
+							// Untyped Bids are typed first, so that they get their media type's factor too.
+							if pbsBid.BidType == "" {
+								pbsBid.BidType = inferBidType(request, pbsBid)
+							}
 							// TODO #280: Convert the bid price
-							bidResponse.Bids[i].Bid.Price = bidResponse.Bids[i].Bid.Price * bidAdjustment
+							pbsBid.Bid.Price = pbsBid.Bid.Price * bidAdjustments.FactorFor(pbsBid.BidType)
 						}
-						seatBid.Bids = append(seatBid.Bids, &PBSOrtbBid{
-							Bid:     bidResponse.Bids[i].Bid,
-							BidType: bidResponse.Bids[i].BidType,
-						})
+						seatBid.Bids = append(seatBid.Bids, pbsBid)
 					}
 				} else {
 					errs = append(errs, fmt.Errorf(
@@ -171,6 +247,9 @@ func (bidder *BidderAdapter) RequestBid(ctx context.Context, request *openrtb.Bi
 					))
 				}
 			}
+		} else if _, isTimeout := httpInfo.err.(*errortypes.Timeout); isTimeout {
+			// The Bids from any calls which finished in time are still kept.
+			errs = append(errs, &errortypes.BidderTimeout{Bidder: string(name), Elapsed: time.Since(start)})
 		} else {
 			errs = append(errs, httpInfo.err)
 		}
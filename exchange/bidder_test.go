@@ -10,6 +10,7 @@ import (
 
 	"github.com/mxmCherry/openrtb"
 	"github.com/prebid/prebid-server/adapters"
+	"github.com/prebid/prebid-server/errortypes"
 	"github.com/prebid/prebid-server/openrtb_ext"
 )
 
@@ -56,7 +57,7 @@ func TestSingleBidder(t *testing.T) {
 		bidResponse: mockBidderResponse,
 	}
 	bidder := AdaptBidder(bidderImpl, server.Client())
-	seatBid, errs := bidder.RequestBid(context.Background(), &openrtb.BidRequest{}, "test", bidAdjustment)
+	seatBid, errs := bidder.RequestBid(context.Background(), &openrtb.BidRequest{}, "test", BidAdjustments{Factor: bidAdjustment})
 
 	// Make sure the goodSingleBidder was called with the expected arguments.
 	if bidderImpl.httpResponse == nil {
@@ -99,6 +100,115 @@ func TestSingleBidder(t *testing.T) {
 	}
 }
 
+func TestMediaTypeBidAdjustments(t *testing.T) {
+	server := httptest.NewServer(mockHandler(200, "getBody", "{\"bid\":false}"))
+	defer server.Close()
+
+	mockBidderResponse := &adapters.BidderResponse{
+		Bids: []*adapters.TypedBid{
+			{
+				Bid: &openrtb.Bid{
+					Price: 2.0,
+				},
+				BidType: openrtb_ext.BidTypeBanner,
+			},
+			{
+				Bid: &openrtb.Bid{
+					Price: 2.0,
+				},
+				BidType: openrtb_ext.BidTypeVideo,
+			},
+			{
+				Bid: &openrtb.Bid{
+					Price: 2.0,
+				},
+				BidType: openrtb_ext.BidTypeNative,
+			},
+		},
+	}
+	bidderImpl := &goodSingleBidder{
+		httpRequest: &adapters.RequestData{
+			Method:  "POST",
+			Uri:     server.URL,
+			Body:    []byte("{\"key\":\"val\"}"),
+			Headers: http.Header{},
+		},
+		bidResponse: mockBidderResponse,
+	}
+	bidder := AdaptBidder(bidderImpl, server.Client())
+	adjustments := BidAdjustments{
+		Factor: 0.5,
+		MediaTypeFactors: map[openrtb_ext.BidType]float64{
+			openrtb_ext.BidTypeBanner: 0.95,
+			openrtb_ext.BidTypeVideo:  0.9,
+		},
+	}
+	seatBid, errs := bidder.RequestBid(context.Background(), &openrtb.BidRequest{}, "test", adjustments)
+
+	if len(errs) != 0 {
+		t.Errorf("bidder.Bid returned %d errors. Expected 0", len(errs))
+	}
+	if len(seatBid.Bids) != 3 {
+		t.Fatalf("Expected 3 bids. Got %d", len(seatBid.Bids))
+	}
+	expectedPrices := []float64{1.9, 1.8, 1.0}
+	for i, expected := range expectedPrices {
+		if seatBid.Bids[i].Bid.Price != expected {
+			t.Errorf("Bid[%d] with type %s was not adjusted properly. Expected %f, got %f", i, seatBid.Bids[i].BidType, expected, seatBid.Bids[i].Bid.Price)
+		}
+	}
+}
+
+func TestMediaTypeBidAdjustmentsForUntypedBids(t *testing.T) {
+	server := httptest.NewServer(mockHandler(200, "getBody", "{\"bid\":false}"))
+	defer server.Close()
+
+	mockBidderResponse := &adapters.BidderResponse{
+		Bids: []*adapters.TypedBid{
+			{
+				Bid: &openrtb.Bid{
+					ImpID: "video",
+					Price: 2.0,
+					AdM:   "<VAST></VAST>",
+				},
+			},
+		},
+	}
+	bidderImpl := &goodSingleBidder{
+		httpRequest: &adapters.RequestData{
+			Method:  "POST",
+			Uri:     server.URL,
+			Body:    []byte("{\"key\":\"val\"}"),
+			Headers: http.Header{},
+		},
+		bidResponse: mockBidderResponse,
+	}
+	bidder := AdaptBidder(bidderImpl, server.Client())
+	adjustments := BidAdjustments{
+		Factor: 0.5,
+		MediaTypeFactors: map[openrtb_ext.BidType]float64{
+			openrtb_ext.BidTypeVideo: 0.9,
+		},
+	}
+	request := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{{ID: "video", Banner: &openrtb.Banner{}, Video: &openrtb.Video{}}},
+	}
+	seatBid, errs := bidder.RequestBid(context.Background(), request, "test", adjustments)
+
+	if len(errs) != 0 {
+		t.Errorf("bidder.Bid returned %d errors. Expected 0", len(errs))
+	}
+	if len(seatBid.Bids) != 1 {
+		t.Fatalf("Expected 1 bid. Got %d", len(seatBid.Bids))
+	}
+	if seatBid.Bids[0].BidType != openrtb_ext.BidTypeVideo {
+		t.Errorf("Expected the bid to be inferred as video. Got %q", seatBid.Bids[0].BidType)
+	}
+	if seatBid.Bids[0].Bid.Price != 1.8 {
+		t.Errorf("Expected the video factor to adjust the bid to 1.8. Got %f", seatBid.Bids[0].Bid.Price)
+	}
+}
+
 // TestMultiBidder makes sure all the requests get sent, and the responses processed.
 // Because this is done in parallel, it should be run under the race detector.
 func TestMultiBidder(t *testing.T) {
@@ -140,7 +250,7 @@ func TestMultiBidder(t *testing.T) {
 		bidResponse: mockBidderResponse,
 	}
 	bidder := AdaptBidder(bidderImpl, server.Client())
-	seatBid, errs := bidder.RequestBid(context.Background(), &openrtb.BidRequest{}, "test", 1.0)
+	seatBid, errs := bidder.RequestBid(context.Background(), &openrtb.BidRequest{}, "test", BidAdjustments{Factor: 1.0})
 
 	if seatBid == nil {
 		t.Fatalf("SeatBid should exist, because bids exist.")
@@ -192,6 +302,61 @@ func TestBidderTimeout(t *testing.T) {
 	}
 }
 
+// TestPartialBidderTimeout makes sure that timeouts name the Bidder, and that Bids from the calls which
+// finished in time are kept.
+func TestPartialBidderTimeout(t *testing.T) {
+	fastServer := httptest.NewServer(mockHandler(200, "getBody", "postBody"))
+	defer fastServer.Close()
+	slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer slowServer.Close()
+
+	bidderImpl := &mixedMultiBidder{
+		httpRequests: []*adapters.RequestData{{
+			Method:  "POST",
+			Uri:     fastServer.URL,
+			Headers: http.Header{},
+		}, {
+			Method:  "POST",
+			Uri:     slowServer.URL,
+			Headers: http.Header{},
+		}},
+		bidResponse: &adapters.BidderResponse{
+			Bids: []*adapters.TypedBid{{
+				Bid:     &openrtb.Bid{},
+				BidType: openrtb_ext.BidTypeBanner,
+			}},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	bidder := AdaptBidder(bidderImpl, http.DefaultClient)
+	seatBid, errs := bidder.RequestBid(ctx, &openrtb.BidRequest{}, "test", BidAdjustments{Factor: 1.0})
+
+	if seatBid == nil || len(seatBid.Bids) != 1 {
+		t.Fatalf("The bid from the fast call should be kept.")
+	}
+	var timeout *errortypes.BidderTimeout
+	for _, err := range errs {
+		if bidderTimeout, ok := err.(*errortypes.BidderTimeout); ok {
+			timeout = bidderTimeout
+		}
+	}
+	if timeout == nil {
+		t.Fatalf("Expected a BidderTimeout. Got %v", errs)
+	}
+	if timeout.Bidder != "test" {
+		t.Errorf("Expected the timeout to name the bidder \"test\". Got %s", timeout.Bidder)
+	}
+	if timeout.Elapsed < 50*time.Millisecond {
+		t.Errorf("Expected the timeout to happen after at least 50ms. Got %v", timeout.Elapsed)
+	}
+	if errortypes.DecodeError(timeout) != errortypes.TimeoutCode {
+		t.Errorf("BidderTimeouts should be counted with the other timeouts.")
+	}
+}
+
 // TestInvalidRequest makes sure that bidderAdapter.doRequest returns errors on bad requests.
 func TestInvalidRequest(t *testing.T) {
 	server := httptest.NewServer(mockHandler(200, "getBody", "postBody"))
@@ -341,7 +506,7 @@ func TestMultiCurrencies(t *testing.T) {
 			context.Background(),
 			&openrtb.BidRequest{},
 			"test",
-			1,
+			BidAdjustments{Factor: 1},
 		)
 
 		// Verify:
@@ -358,6 +523,80 @@ func TestMultiCurrencies(t *testing.T) {
 	}
 }
 
+// TestSeatCurrency makes sure that the SeatBid says whether its currency was named or defaulted to USD.
+func TestSeatCurrency(t *testing.T) {
+	respStatus := 200
+	getRespBody := "{\"wasPost\":false}"
+	postRespBody := "{\"wasPost\":true}"
+	server := httptest.NewServer(mockHandler(respStatus, getRespBody, postRespBody))
+	defer server.Close()
+
+	testCases := []struct {
+		description          string
+		requestCurrencies    []string
+		bidCurrency          string
+		expectedSeatCurrency string
+		expectedDefaulted    bool
+	}{
+		{
+			description:          "Neither the request nor the bidder names a currency",
+			expectedSeatCurrency: "USD",
+			expectedDefaulted:    true,
+		},
+		{
+			description:          "The bidder names a currency",
+			bidCurrency:          "EUR",
+			expectedSeatCurrency: "EUR",
+		},
+		{
+			description:          "The request names a currency",
+			requestCurrencies:    []string{"EUR"},
+			expectedSeatCurrency: "EUR",
+		},
+		{
+			description:          "The request's currency is used over the bidder's",
+			requestCurrencies:    []string{"EUR"},
+			bidCurrency:          "USD",
+			expectedSeatCurrency: "EUR",
+		},
+		{
+			description:          "The bidder's currency is used if the request names several",
+			requestCurrencies:    []string{"EUR", "GBP"},
+			bidCurrency:          "GBP",
+			expectedSeatCurrency: "GBP",
+		},
+	}
+
+	for _, tc := range testCases {
+		bidderImpl := &goodMultiHTTPCallsBidder{
+			bidResponses: []*adapters.BidderResponse{{
+				Bids: []*adapters.TypedBid{
+					{
+						Bid:     &openrtb.Bid{},
+						BidType: openrtb_ext.BidTypeBanner,
+					},
+				},
+				Currency: tc.bidCurrency,
+			}},
+			httpRequest: []*adapters.RequestData{{
+				Method:  "POST",
+				Uri:     server.URL,
+				Body:    []byte("{\"key\":\"val\"}"),
+				Headers: http.Header{},
+			}},
+		}
+		bidder := AdaptBidder(bidderImpl, server.Client())
+		seatBid, _ := bidder.RequestBid(context.Background(), &openrtb.BidRequest{Cur: tc.requestCurrencies}, "test", BidAdjustments{Factor: 1})
+
+		if seatBid.Currency != tc.expectedSeatCurrency {
+			t.Errorf("%s: expected the seat currency to be \"%s\". Got \"%s\"", tc.description, tc.expectedSeatCurrency, seatBid.Currency)
+		}
+		if seatBid.DefaultedCurrency != tc.expectedDefaulted {
+			t.Errorf("%s: expected DefaultedCurrency to be %t", tc.description, tc.expectedDefaulted)
+		}
+	}
+}
+
 // TestBadResponseLogging makes sure that openrtb_ext works properly on malformed HTTP requests.
 func TestBadRequestLogging(t *testing.T) {
 	info := &httpCallInfo{
@@ -450,7 +689,7 @@ func TestServerCallDebugging(t *testing.T) {
 
 	bids, _ := bidder.RequestBid(context.Background(), &openrtb.BidRequest{
 		Test: 1,
-	}, "test", 1.0)
+	}, "test", BidAdjustments{Factor: 1.0})
 
 	if len(bids.HTTPCalls) != 1 {
 		t.Errorf("We should log the server call if this is a test bid. Got %d", len(bids.HTTPCalls))
@@ -471,7 +710,7 @@ func TestServerCallDebugging(t *testing.T) {
 
 func TestErrorReporting(t *testing.T) {
 	bidder := AdaptBidder(&bidRejector{}, nil)
-	bids, errs := bidder.RequestBid(context.Background(), &openrtb.BidRequest{}, "test", 1.0)
+	bids, errs := bidder.RequestBid(context.Background(), &openrtb.BidRequest{}, "test", BidAdjustments{Factor: 1.0})
 	if bids != nil {
 		t.Errorf("There should be no seatbid if no http requests are returned.")
 	}
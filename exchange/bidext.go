@@ -0,0 +1,73 @@
+package exchange
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/buger/jsonparser"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// stripBidExts removes the paths from every Bid's ext, so that Bidders' internal data doesn't reach publishers.
+// Each path names a key within the bid.ext, with dots between nested keys, like "vendor.internal".
+// This should run after the Bids have been validated, since some checks read the ext.
+//
+// Bids which don't have any of the paths keep their ext as-is.
+func stripBidExts(seatBid *PBSOrtbSeatBid, paths []string) {
+	if seatBid == nil || len(paths) == 0 {
+		return
+	}
+	for _, bid := range seatBid.Bids {
+		bid.Bid.Ext = stripJSONPaths(bid.Bid.Ext, paths)
+	}
+}
+
+// stripJSONPaths returns the data without the paths. If any were removed, the result is a copy,
+// since the data may share memory with the Bidder's response, which is kept for debugging.
+func stripJSONPaths(data []byte, paths []string) []byte {
+	copied := false
+	for _, path := range paths {
+		keys := strings.Split(path, ".")
+		if _, _, _, err := jsonparser.Get(data, keys...); err != nil {
+			continue
+		}
+		if !copied {
+			data = append([]byte(nil), data...)
+			copied = true
+		}
+		data = jsonparser.Delete(data, keys...)
+	}
+	return data
+}
+
+// normalizeBidExts gives every Bid without an ext a minimal one with an ext.prebid, so that code which reads the
+// bid.ext doesn't need to handle it being missing. The ext.prebid.type is the Bid's type, so this should run after
+// the types have been inferred. Exts which are null or {} count as missing.
+//
+// Bids which already have an ext keep it as-is, even if it has no "prebid" key.
+func normalizeBidExts(seatBid *PBSOrtbSeatBid) {
+	if seatBid == nil {
+		return
+	}
+	for _, bid := range seatBid.Bids {
+		if !isEmptyJSON(bid.Bid.Ext) {
+			continue
+		}
+		if ext, err := json.Marshal(openrtb_ext.ExtBid{Prebid: &openrtb_ext.ExtBidPrebid{Type: bid.BidType}}); err == nil {
+			bid.Bid.Ext = ext
+		}
+	}
+}
+
+// isEmptyJSON returns true if the data is blank, null, or an empty object.
+func isEmptyJSON(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return true
+	}
+	if trimmed[0] != '{' || trimmed[len(trimmed)-1] != '}' {
+		return false
+	}
+	return len(bytes.TrimSpace(trimmed[1:len(trimmed)-1])) == 0
+}
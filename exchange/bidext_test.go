@@ -0,0 +1,144 @@
+package exchange
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+func TestStripBidExts(t *testing.T) {
+	testCases := []struct {
+		description string
+		ext         string
+		paths       []string
+		expected    string
+	}{
+		{
+			description: "Listed keys are removed",
+			ext:         `{"vendorInternal":{"margin":0.3},"mime":"image/png"}`,
+			paths:       []string{"vendorInternal"},
+			expected:    `{"mime":"image/png"}`,
+		},
+		{
+			description: "Nested keys are removed without their siblings",
+			ext:         `{"vendor":{"internal":"secret","public":"data"}}`,
+			paths:       []string{"vendor.internal"},
+			expected:    `{"vendor":{"public":"data"}}`,
+		},
+		{
+			description: "Several keys can be removed",
+			ext:         `{"a":1,"b":2,"c":3}`,
+			paths:       []string{"a", "c"},
+			expected:    `{"b":2}`,
+		},
+		{
+			description: "Exts without the keys are kept",
+			ext:         `{"mime":"image/png"}`,
+			paths:       []string{"vendorInternal", "vendor.internal"},
+			expected:    `{"mime":"image/png"}`,
+		},
+		{
+			description: "Everything is kept by default",
+			ext:         `{"vendorInternal":{"margin":0.3}}`,
+			expected:    `{"vendorInternal":{"margin":0.3}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		seatBid := &PBSOrtbSeatBid{
+			Bids: []*PBSOrtbBid{{
+				Bid: &openrtb.Bid{ID: "one-bid", Ext: json.RawMessage(tc.ext)},
+			}},
+		}
+		stripBidExts(seatBid, tc.paths)
+
+		assertJSONEqual(t, tc.description, tc.expected, seatBid.Bids[0].Bid.Ext)
+	}
+}
+
+func TestStripBidExtsDoesNotChangeTheResponse(t *testing.T) {
+	responseBody := []byte(`{"vendorInternal":"secret","mime":"image/png"}`)
+	seatBid := &PBSOrtbSeatBid{
+		Bids: []*PBSOrtbBid{{
+			Bid: &openrtb.Bid{ID: "one-bid", Ext: json.RawMessage(responseBody)},
+		}},
+	}
+	stripBidExts(seatBid, []string{"vendorInternal"})
+
+	if string(responseBody) != `{"vendorInternal":"secret","mime":"image/png"}` {
+		t.Errorf("The bidder's response shouldn't be changed. Got %s", responseBody)
+	}
+}
+
+func TestStripBidExtsNilSeatBid(t *testing.T) {
+	stripBidExts(nil, []string{"vendorInternal"})
+}
+
+func TestNormalizeBidExts(t *testing.T) {
+	testCases := []struct {
+		description string
+		ext         string
+		bidType     openrtb_ext.BidType
+		expected    string
+	}{
+		{
+			description: "Missing exts get an ext.prebid with the Bid's type",
+			bidType:     openrtb_ext.BidTypeVideo,
+			expected:    `{"prebid":{"type":"video"}}`,
+		},
+		{
+			description: "Null exts count as missing",
+			ext:         `null`,
+			bidType:     openrtb_ext.BidTypeBanner,
+			expected:    `{"prebid":{"type":"banner"}}`,
+		},
+		{
+			description: "Empty exts count as missing",
+			ext:         ` { } `,
+			bidType:     openrtb_ext.BidTypeNative,
+			expected:    `{"prebid":{"type":"native"}}`,
+		},
+		{
+			description: "Populated exts are kept",
+			ext:         `{"mime":"image/png"}`,
+			bidType:     openrtb_ext.BidTypeBanner,
+			expected:    `{"mime":"image/png"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		bid := &openrtb.Bid{ID: "one-bid"}
+		if tc.ext != "" {
+			bid.Ext = json.RawMessage(tc.ext)
+		}
+		seatBid := &PBSOrtbSeatBid{
+			Bids: []*PBSOrtbBid{{Bid: bid, BidType: tc.bidType}},
+		}
+		normalizeBidExts(seatBid)
+
+		assertJSONEqual(t, tc.description, tc.expected, seatBid.Bids[0].Bid.Ext)
+	}
+}
+
+func TestNormalizeBidExtsNilSeatBid(t *testing.T) {
+	normalizeBidExts(nil)
+}
+
+func assertJSONEqual(t *testing.T, description string, expected string, actual []byte) {
+	t.Helper()
+	var expectedValue, actualValue interface{}
+	if err := json.Unmarshal([]byte(expected), &expectedValue); err != nil {
+		t.Fatalf("%s: bad expected JSON %s: %v", description, expected, err)
+	}
+	if err := json.Unmarshal(actual, &actualValue); err != nil {
+		t.Errorf("%s: expected %s. Got invalid JSON %s", description, expected, actual)
+		return
+	}
+	expectedJSON, _ := json.Marshal(expectedValue)
+	actualJSON, _ := json.Marshal(actualValue)
+	if string(expectedJSON) != string(actualJSON) {
+		t.Errorf("%s: expected %s. Got %s", description, expected, actual)
+	}
+}
@@ -0,0 +1,74 @@
+package exchange
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// inferBidTypes fills in the BidType of Bids which omit it, so that the response's ext.prebid.type can be used for targeting.
+// If the imp the Bid was made for only allows one media type, that's the Bid's type. If it allows several,
+// the markup decides: VAST or DAAST is video (or audio, if the imp doesn't allow video), native JSON with assets
+// is native, and anything else is banner. Types are only inferred if the imp allows them.
+//
+// Bids which already have a type, or whose type can't be told, are left alone. So are Bids without an openrtb.Bid,
+// which validation rejects.
+func inferBidTypes(request *openrtb.BidRequest, seatBid *PBSOrtbSeatBid) {
+	if seatBid == nil {
+		return
+	}
+	for _, bid := range seatBid.Bids {
+		if bid.Bid == nil || bid.BidType != "" {
+			continue
+		}
+		bid.BidType = inferBidType(request, bid)
+	}
+}
+
+// inferBidType returns the media type which inferBidTypes would give the Bid, or "" if it can't be told.
+func inferBidType(request *openrtb.BidRequest, bid *PBSOrtbBid) openrtb_ext.BidType {
+	if mediaType := bidMediaType(request, bid); mediaType != "" {
+		return mediaType
+	}
+	for i := 0; i < len(request.Imp); i++ {
+		if request.Imp[i].ID == bid.Bid.ImpID {
+			return markupMediaType(&request.Imp[i], bid.Bid.AdM)
+		}
+	}
+	return ""
+}
+
+// markupMediaType returns the media type which the markup looks like, out of those the multi-format imp allows.
+// It returns "" if the markup is empty, or doesn't match any of them.
+func markupMediaType(imp *openrtb.Imp, markup string) openrtb_ext.BidType {
+	markup = strings.TrimSpace(markup)
+	if markup == "" {
+		return ""
+	}
+	if root := xmlRootElement(markup); root == "VAST" || root == "DAAST" {
+		if imp.Video != nil {
+			return openrtb_ext.BidTypeVideo
+		}
+		if imp.Audio != nil {
+			return openrtb_ext.BidTypeAudio
+		}
+		return ""
+	}
+	if imp.Native != nil && strings.HasPrefix(markup, "{") {
+		var response nativeResponse
+		if err := json.Unmarshal([]byte(markup), &response); err == nil {
+			if response.Native != nil {
+				response = *response.Native
+			}
+			if len(response.Assets) > 0 {
+				return openrtb_ext.BidTypeNative
+			}
+		}
+	}
+	if imp.Banner != nil {
+		return openrtb_ext.BidTypeBanner
+	}
+	return ""
+}
@@ -0,0 +1,129 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+func TestInferBidTypesFromSingleTypeImps(t *testing.T) {
+	request := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{
+			{ID: "banner", Banner: &openrtb.Banner{}},
+			{ID: "video", Video: &openrtb.Video{}},
+			{ID: "audio", Audio: &openrtb.Audio{}},
+			{ID: "native", Native: &openrtb.Native{}},
+		},
+	}
+	seatBid := &PBSOrtbSeatBid{
+		Bids: []*PBSOrtbBid{
+			newTypedBid("banner", "", "<div>an ad</div>"),
+			newTypedBid("video", "", "<VAST></VAST>"),
+			newTypedBid("audio", "", "<DAAST></DAAST>"),
+			newTypedBid("native", "", `{"assets":[]}`),
+			newTypedBid("missing-imp", "", "<div>an ad</div>"),
+		},
+	}
+	inferBidTypes(request, seatBid)
+
+	assertBidTypes(t, seatBid, openrtb_ext.BidTypeBanner, openrtb_ext.BidTypeVideo, openrtb_ext.BidTypeAudio, openrtb_ext.BidTypeNative, "")
+}
+
+func TestInferBidTypesFromMarkup(t *testing.T) {
+	request := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{
+			{ID: "banner-video-native", Banner: &openrtb.Banner{}, Video: &openrtb.Video{}, Native: &openrtb.Native{}},
+			{ID: "banner-audio", Banner: &openrtb.Banner{}, Audio: &openrtb.Audio{}},
+			{ID: "video-native", Video: &openrtb.Video{}, Native: &openrtb.Native{}},
+		},
+	}
+	seatBid := &PBSOrtbSeatBid{
+		Bids: []*PBSOrtbBid{
+			newTypedBid("banner-video-native", "", "<div>an ad</div>"),
+			newTypedBid("banner-video-native", "", `  <?xml version="1.0"?><VAST version="3.0"></VAST>`),
+			newTypedBid("banner-video-native", "", `{"native":{"assets":[{"id":1,"title":{"text":"a title"}}]}}`),
+			newTypedBid("banner-video-native", "", `{"link":{"url":"https://example.com"}}`),
+			newTypedBid("banner-audio", "", "<VAST></VAST>"),
+			newTypedBid("video-native", "", "<div>an ad</div>"),
+			newTypedBid("video-native", "", ""),
+		},
+	}
+	inferBidTypes(request, seatBid)
+
+	assertBidTypes(t, seatBid,
+		openrtb_ext.BidTypeBanner,
+		openrtb_ext.BidTypeVideo,
+		openrtb_ext.BidTypeNative,
+		openrtb_ext.BidTypeBanner,
+		openrtb_ext.BidTypeAudio,
+		"",
+		"")
+}
+
+func TestInferBidTypesKeepsExistingTypes(t *testing.T) {
+	request := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{
+			{ID: "banner", Banner: &openrtb.Banner{}},
+			{ID: "banner-video", Banner: &openrtb.Banner{}, Video: &openrtb.Video{}},
+		},
+	}
+	seatBid := &PBSOrtbSeatBid{
+		Bids: []*PBSOrtbBid{
+			newTypedBid("banner", openrtb_ext.BidTypeVideo, "<div>an ad</div>"),
+			newTypedBid("banner-video", openrtb_ext.BidTypeBanner, "<VAST></VAST>"),
+		},
+	}
+	inferBidTypes(request, seatBid)
+
+	assertBidTypes(t, seatBid, openrtb_ext.BidTypeVideo, openrtb_ext.BidTypeBanner)
+}
+
+func TestInferBidTypesSkipsEmptyBids(t *testing.T) {
+	request := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{
+			{ID: "banner", Banner: &openrtb.Banner{}},
+		},
+	}
+	seatBid := &PBSOrtbSeatBid{
+		Bids: []*PBSOrtbBid{
+			newTypedBid("banner", "", "<div>an ad</div>"),
+			{},
+			newTypedBid("banner", "", "<div>another ad</div>"),
+		},
+	}
+	inferBidTypes(request, seatBid)
+
+	if seatBid.Bids[0].BidType != openrtb_ext.BidTypeBanner || seatBid.Bids[2].BidType != openrtb_ext.BidTypeBanner {
+		t.Errorf("Expected the valid bids to be banners. Got %q and %q", seatBid.Bids[0].BidType, seatBid.Bids[2].BidType)
+	}
+	if seatBid.Bids[1].BidType != "" {
+		t.Errorf("Expected the empty bid to be left alone. Got %q", seatBid.Bids[1].BidType)
+	}
+}
+
+func TestInferBidTypesNilSeatBid(t *testing.T) {
+	inferBidTypes(&openrtb.BidRequest{}, nil)
+}
+
+func newTypedBid(impID string, bidType openrtb_ext.BidType, adm string) *PBSOrtbBid {
+	return &PBSOrtbBid{
+		Bid: &openrtb.Bid{
+			ImpID: impID,
+			AdM:   adm,
+		},
+		BidType: bidType,
+	}
+}
+
+func assertBidTypes(t *testing.T, seatBid *PBSOrtbSeatBid, expected ...openrtb_ext.BidType) {
+	t.Helper()
+	if len(seatBid.Bids) != len(expected) {
+		t.Fatalf("Expected %d bids. Got %d", len(expected), len(seatBid.Bids))
+	}
+	for i, bid := range seatBid.Bids {
+		if bid.BidType != expected[i] {
+			t.Errorf("Bid %d on imp %s: expected type %q. Got %q", i, bid.Bid.ImpID, expected[i], bid.BidType)
+		}
+	}
+}
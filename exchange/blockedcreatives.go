@@ -0,0 +1,89 @@
+package exchange
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/pbsmetrics"
+)
+
+// BlockedCreatives is the set of creative IDs which Prebid Server's operators have blocked, for example because
+// ad ops found a bad creative. Bids whose crid is blocked are rejected. Changes take effect on the next auction.
+//
+// It's a BidValidator, and safe for concurrent use. A nil *BlockedCreatives blocks nothing.
+type BlockedCreatives struct {
+	// writeMutex serializes updates. Reads never wait for it.
+	writeMutex sync.Mutex
+	crids      atomic.Value // Should only hold map[string]struct{}
+}
+
+// NewBlockedCreatives returns a set in which the given creative IDs start out blocked.
+func NewBlockedCreatives(crids ...string) *BlockedCreatives {
+	b := &BlockedCreatives{}
+	set := make(map[string]struct{}, len(crids))
+	for _, crid := range crids {
+		set[crid] = struct{}{}
+	}
+	b.crids.Store(set)
+	return b
+}
+
+// Set blocks the creative ID if blocked is true, and unblocks it otherwise.
+func (b *BlockedCreatives) Set(crid string, blocked bool) {
+	b.writeMutex.Lock()
+	defer b.writeMutex.Unlock()
+
+	current := b.load()
+	if _, ok := current[crid]; ok == blocked {
+		return
+	}
+	// Auctions may be reading the current set, so it's copied rather than changed.
+	updated := make(map[string]struct{}, len(current)+1)
+	for id := range current {
+		updated[id] = struct{}{}
+	}
+	if blocked {
+		updated[crid] = struct{}{}
+	} else {
+		delete(updated, crid)
+	}
+	b.crids.Store(updated)
+}
+
+// Contains returns true if the creative ID is blocked.
+func (b *BlockedCreatives) Contains(crid string) bool {
+	if b == nil {
+		return false
+	}
+	_, ok := b.load()[crid]
+	return ok
+}
+
+// List returns the blocked creative IDs, sorted.
+func (b *BlockedCreatives) List() []string {
+	if b == nil {
+		return nil
+	}
+	current := b.load()
+	list := make([]string, 0, len(current))
+	for crid := range current {
+		list = append(list, crid)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Validate rejects the Bid if its crid is blocked.
+func (b *BlockedCreatives) Validate(request *openrtb.BidRequest, bid *PBSOrtbBid) error {
+	if bid.Bid.CrID != "" && b.Contains(bid.Bid.CrID) {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionBlockedCreative, "Bid \"%s\" has creative \"%s\", which is blocked by the host", bid.Bid.ID, bid.Bid.CrID)
+	}
+	return nil
+}
+
+func (b *BlockedCreatives) load() map[string]struct{} {
+	crids, _ := b.crids.Load().(map[string]struct{})
+	return crids
+}
@@ -0,0 +1,96 @@
+package exchange
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/pbsmetrics"
+)
+
+func TestBlockedCreativesSet(t *testing.T) {
+	blocked := NewBlockedCreatives("old-creative")
+	blocked.Set("bad-creative", true)
+	blocked.Set("bad-creative", true)
+	blocked.Set("old-creative", false)
+	blocked.Set("unknown-creative", false)
+
+	if !blocked.Contains("bad-creative") {
+		t.Errorf("bad-creative should be blocked")
+	}
+	if blocked.Contains("old-creative") {
+		t.Errorf("old-creative should have been unblocked")
+	}
+	if expected := []string{"bad-creative"}; !reflect.DeepEqual(blocked.List(), expected) {
+		t.Errorf("Expected %v to be blocked. Got %v", expected, blocked.List())
+	}
+}
+
+func TestBlockedCreativesAreRejected(t *testing.T) {
+	blocked := NewBlockedCreatives("bad-creative")
+	brq := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{{ID: "thisImp", Banner: &openrtb.Banner{}}},
+	}
+	brw := &BidResponseWrapper{
+		AdapterBids: &PBSOrtbSeatBid{
+			Bids: []*PBSOrtbBid{
+				{Bid: &openrtb.Bid{ID: "bad-bid", ImpID: "thisImp", Price: 0.45, CrID: "bad-creative", AdM: "<div>ad</div>"}},
+				{Bid: &openrtb.Bid{ID: "good-bid", ImpID: "thisImp", Price: 0.45, CrID: "good-creative", AdM: "<div>ad</div>"}},
+			},
+		},
+	}
+	errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{}, nil, []BidValidator{blocked})
+
+	assertBidIDs(t, brw.AdapterBids, []string{"good-bid"})
+	if len(errs) != 1 {
+		t.Fatalf("Expected 1 error. Got %v", errs)
+	}
+	if rejection, ok := errs[0].(*BidRejectionError); !ok || rejection.Reason != pbsmetrics.BidRejectionBlockedCreative {
+		t.Errorf("Expected a %s rejection. Got %v", pbsmetrics.BidRejectionBlockedCreative, errs[0])
+	}
+}
+
+func TestBlockedCreativesTakeEffectImmediately(t *testing.T) {
+	blocked := NewBlockedCreatives()
+	bid := &PBSOrtbBid{Bid: &openrtb.Bid{ID: "one-bid", CrID: "some-creative"}}
+	if err := blocked.Validate(&openrtb.BidRequest{}, bid); err != nil {
+		t.Errorf("Unblocked creatives should pass. Got %v", err)
+	}
+	blocked.Set("some-creative", true)
+	if err := blocked.Validate(&openrtb.BidRequest{}, bid); err == nil {
+		t.Errorf("Creatives should be rejected as soon as they're blocked")
+	}
+}
+
+func TestNilBlockedCreatives(t *testing.T) {
+	var blocked *BlockedCreatives
+	if blocked.Contains("some-creative") {
+		t.Errorf("A nil set shouldn't block any creatives")
+	}
+	if len(blocked.List()) != 0 {
+		t.Errorf("A nil set shouldn't list any creatives. Got %v", blocked.List())
+	}
+	if err := blocked.Validate(&openrtb.BidRequest{}, &PBSOrtbBid{Bid: &openrtb.Bid{CrID: "some-creative"}}); err != nil {
+		t.Errorf("A nil set shouldn't reject any bids. Got %v", err)
+	}
+}
+
+func TestBlockedCreativesConcurrentUse(t *testing.T) {
+	blocked := NewBlockedCreatives()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			blocked.Set("some-creative", i%2 == 0)
+		}(i)
+		go func() {
+			defer wg.Done()
+			blocked.Contains("some-creative")
+			blocked.List()
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,74 @@
+package exchange
+
+import (
+	"github.com/prebid/prebid-server/currencies"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+)
+
+// dedupeCategories makes sure that each Imp has at most one Bid in each IAB content category.
+// This supports competitive separation, where publishers don't want two ads from the same category to run together.
+//
+// Each Bid's category is the first entry of its "cat" array. Bids which don't define a category are never removed.
+// When two Bids share a category, the higher priced one survives. If their prices tie, the one seen first survives.
+// Bidders are processed in the order given, so callers should pass the randomized bidder list to keep ties fair.
+//
+// Prices from SeatBids in different currencies are converted before they're compared, so this should run after
+// the Bids have been converted into the response currency. Bids whose prices can't be converted are both kept.
+//
+// Removed Bids are excised from the seatBids in place. The returned errors explain why each one was removed,
+// and are keyed by the Bidder which made the removed Bid.
+func dedupeCategories(bidders []openrtb_ext.BidderName, seatBids map[openrtb_ext.BidderName]*PBSOrtbSeatBid, conversions currencies.Conversions) map[openrtb_ext.BidderName][]error {
+	type categoryWinner struct {
+		bidder   openrtb_ext.BidderName
+		bid      *PBSOrtbBid
+		currency string
+	}
+
+	// Keyed by impID, then by category
+	winners := make(map[string]map[string]categoryWinner)
+	removed := make(map[*PBSOrtbBid]struct{})
+	errs := make(map[openrtb_ext.BidderName][]error)
+
+	for _, bidderName := range bidders {
+		seatBid := seatBids[bidderName]
+		if seatBid == nil {
+			continue
+		}
+		currency := seatCurrency(seatBid)
+		for _, bid := range seatBid.Bids {
+			if len(bid.Bid.Cat) == 0 {
+				continue
+			}
+			category := bid.Bid.Cat[0]
+			winnersByCategory, ok := winners[bid.Bid.ImpID]
+			if !ok {
+				winnersByCategory = make(map[string]categoryWinner)
+				winners[bid.Bid.ImpID] = winnersByCategory
+			}
+			current, ok := winnersByCategory[category]
+			if !ok {
+				winnersByCategory[category] = categoryWinner{bidder: bidderName, bid: bid, currency: currency}
+				continue
+			}
+			rate, err := conversions.GetRate(currency, current.currency)
+			if err != nil {
+				continue
+			}
+
+			loser, loserBidder, winner := bid, bidderName, current.bid
+			if bid.Bid.Price*rate > current.bid.Bid.Price {
+				loser, loserBidder, winner = current.bid, current.bidder, bid
+				winnersByCategory[category] = categoryWinner{bidder: bidderName, bid: bid, currency: currency}
+			}
+			removed[loser] = struct{}{}
+			errs[loserBidder] = append(errs[loserBidder], newBidRejection(loser.Bid.ID, pbsmetrics.BidRejectionDuplicateCategory, "Bid \"%s\" was removed because bid \"%s\" has a higher price in category \"%s\" for imp \"%s\"", loser.Bid.ID, winner.Bid.ID, category, bid.Bid.ImpID))
+		}
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+	removeBids(seatBids, removed)
+	return errs
+}
@@ -0,0 +1,164 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/currencies"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+func TestDedupeCategoriesKeepsHighestPrice(t *testing.T) {
+	seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Bids: []*PBSOrtbBid{
+				newCategoryBid("apn-bid", "my-imp", 0.3, "IAB1"),
+			},
+		},
+		openrtb_ext.BidderRubicon: {
+			Bids: []*PBSOrtbBid{
+				newCategoryBid("rubi-bid", "my-imp", 0.5, "IAB1"),
+				newCategoryBid("rubi-other-imp", "other-imp", 0.1, "IAB1"),
+			},
+		},
+	}
+	errs := dedupeCategories([]openrtb_ext.BidderName{openrtb_ext.BidderAppnexus, openrtb_ext.BidderRubicon}, seatBids, currencies.NewConversionCache(nil))
+
+	assertBidIDs(t, seatBids[openrtb_ext.BidderAppnexus], []string{})
+	assertBidIDs(t, seatBids[openrtb_ext.BidderRubicon], []string{"rubi-bid", "rubi-other-imp"})
+	assertNumDedupeErrors(t, errs, openrtb_ext.BidderAppnexus, 1)
+	assertNumDedupeErrors(t, errs, openrtb_ext.BidderRubicon, 0)
+}
+
+func TestDedupeCategoriesTies(t *testing.T) {
+	seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Bids: []*PBSOrtbBid{
+				newCategoryBid("apn-bid", "my-imp", 0.5, "IAB1"),
+			},
+		},
+		openrtb_ext.BidderRubicon: {
+			Bids: []*PBSOrtbBid{
+				newCategoryBid("rubi-bid", "my-imp", 0.5, "IAB1"),
+			},
+		},
+	}
+	errs := dedupeCategories([]openrtb_ext.BidderName{openrtb_ext.BidderRubicon, openrtb_ext.BidderAppnexus}, seatBids, currencies.NewConversionCache(nil))
+
+	assertBidIDs(t, seatBids[openrtb_ext.BidderRubicon], []string{"rubi-bid"})
+	assertBidIDs(t, seatBids[openrtb_ext.BidderAppnexus], []string{})
+	assertNumDedupeErrors(t, errs, openrtb_ext.BidderAppnexus, 1)
+}
+
+func TestDedupeCategoriesMissingCategories(t *testing.T) {
+	seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Bids: []*PBSOrtbBid{
+				newCategoryBid("apn-bid", "my-imp", 0.3),
+				newCategoryBid("apn-other-bid", "my-imp", 0.4),
+			},
+		},
+	}
+	errs := dedupeCategories([]openrtb_ext.BidderName{openrtb_ext.BidderAppnexus}, seatBids, currencies.NewConversionCache(nil))
+
+	assertBidIDs(t, seatBids[openrtb_ext.BidderAppnexus], []string{"apn-bid", "apn-other-bid"})
+	if len(errs) != 0 {
+		t.Errorf("Expected no errors. Got %v", errs)
+	}
+}
+
+func TestDedupeCategoriesUsesFirstCategory(t *testing.T) {
+	seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Bids: []*PBSOrtbBid{
+				newCategoryBid("apn-bid", "my-imp", 0.3, "IAB1", "IAB2"),
+				newCategoryBid("apn-other-bid", "my-imp", 0.4, "IAB2", "IAB1"),
+				newCategoryBid("apn-third-bid", "my-imp", 0.2, "IAB1"),
+			},
+		},
+	}
+	errs := dedupeCategories([]openrtb_ext.BidderName{openrtb_ext.BidderAppnexus}, seatBids, currencies.NewConversionCache(nil))
+
+	assertBidIDs(t, seatBids[openrtb_ext.BidderAppnexus], []string{"apn-bid", "apn-other-bid"})
+	assertNumDedupeErrors(t, errs, openrtb_ext.BidderAppnexus, 1)
+}
+
+func TestDedupeCategoriesConvertsPrices(t *testing.T) {
+	seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Bids: []*PBSOrtbBid{
+				newCategoryBid("apn-bid", "my-imp", 0.5, "IAB1"),
+			},
+		},
+		openrtb_ext.BidderRubicon: {
+			Bids: []*PBSOrtbBid{
+				newCategoryBid("rubi-bid", "my-imp", 0.4, "IAB1"),
+			},
+			Currency: "GBP",
+		},
+	}
+	rates := currencies.NewRates(time.Now(), map[string]map[string]float64{
+		"GBP": {"USD": 1.5},
+	})
+	errs := dedupeCategories([]openrtb_ext.BidderName{openrtb_ext.BidderAppnexus, openrtb_ext.BidderRubicon}, seatBids, currencies.NewConversionCache(rates))
+
+	// 0.4 GBP is worth 0.6 USD, so the appnexus bid is the cheaper one.
+	assertBidIDs(t, seatBids[openrtb_ext.BidderAppnexus], []string{})
+	assertBidIDs(t, seatBids[openrtb_ext.BidderRubicon], []string{"rubi-bid"})
+	assertNumDedupeErrors(t, errs, openrtb_ext.BidderAppnexus, 1)
+}
+
+func TestDedupeCategoriesUnconvertiblePrices(t *testing.T) {
+	seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Bids: []*PBSOrtbBid{
+				newCategoryBid("apn-bid", "my-imp", 0.5, "IAB1"),
+			},
+		},
+		openrtb_ext.BidderRubicon: {
+			Bids: []*PBSOrtbBid{
+				newCategoryBid("rubi-bid", "my-imp", 0.4, "IAB1"),
+			},
+			Currency: "GBP",
+		},
+	}
+	errs := dedupeCategories([]openrtb_ext.BidderName{openrtb_ext.BidderAppnexus, openrtb_ext.BidderRubicon}, seatBids, currencies.NewConversionCache(nil))
+
+	assertBidIDs(t, seatBids[openrtb_ext.BidderAppnexus], []string{"apn-bid"})
+	assertBidIDs(t, seatBids[openrtb_ext.BidderRubicon], []string{"rubi-bid"})
+	if len(errs) != 0 {
+		t.Errorf("Bids which can't be compared shouldn't be removed. Got %v", errs)
+	}
+}
+
+func newCategoryBid(id string, impID string, price float64, categories ...string) *PBSOrtbBid {
+	return &PBSOrtbBid{
+		Bid: &openrtb.Bid{
+			ID:    id,
+			ImpID: impID,
+			Price: price,
+			Cat:   categories,
+		},
+	}
+}
+
+func assertBidIDs(t *testing.T, seatBid *PBSOrtbSeatBid, expected []string) {
+	t.Helper()
+	if len(seatBid.Bids) != len(expected) {
+		t.Errorf("Expected %d bids. Got %d", len(expected), len(seatBid.Bids))
+		return
+	}
+	for i, id := range expected {
+		if seatBid.Bids[i].Bid.ID != id {
+			t.Errorf("Expected bid %d to have ID %s. Got %s", i, id, seatBid.Bids[i].Bid.ID)
+		}
+	}
+}
+
+func assertNumDedupeErrors(t *testing.T, errs map[openrtb_ext.BidderName][]error, bidder openrtb_ext.BidderName, expected int) {
+	t.Helper()
+	if len(errs[bidder]) != expected {
+		t.Errorf("Expected %d errors for %s. Got %d", expected, bidder, len(errs[bidder]))
+	}
+}
@@ -0,0 +1,67 @@
+package exchange
+
+import (
+	"math"
+
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// secondPriceAuction is the request.at which asks for a second-price auction.
+const secondPriceAuction = 2
+
+// secondPriceIncrement is added to the second-highest price to get the winner's clearing price.
+const secondPriceIncrement = 0.01
+
+// applySecondPriceClearing sets the ClearingPrice of the top Bid on each Imp when the request asks for a
+// second-price auction. That's the second-highest price on the Imp plus the secondPriceIncrement, but never more
+// than the top Bid's own price. If the Imp only got one Bid, the first price applies.
+//
+// Bids which tie for the top price clear at that price, so every one of them is given it.
+// This should run after the Bids have been converted into a common currency and any of them have been removed.
+func applySecondPriceClearing(seatBids map[openrtb_ext.BidderName]*PBSOrtbSeatBid, auctionType int64) {
+	if auctionType != secondPriceAuction {
+		return
+	}
+
+	type topPrices struct {
+		first  float64
+		second float64
+		count  int
+	}
+	pricesByImp := make(map[string]*topPrices)
+	for _, seatBid := range seatBids {
+		if seatBid == nil {
+			continue
+		}
+		for _, bid := range seatBid.Bids {
+			prices, ok := pricesByImp[bid.Bid.ImpID]
+			if !ok {
+				prices = &topPrices{}
+				pricesByImp[bid.Bid.ImpID] = prices
+			}
+			prices.count++
+			if prices.count == 1 || bid.Bid.Price > prices.first {
+				prices.second, prices.first = prices.first, bid.Bid.Price
+			} else if prices.count == 2 || bid.Bid.Price > prices.second {
+				prices.second = bid.Bid.Price
+			}
+		}
+	}
+
+	for _, seatBid := range seatBids {
+		if seatBid == nil {
+			continue
+		}
+		for _, bid := range seatBid.Bids {
+			prices := pricesByImp[bid.Bid.ImpID]
+			if bid.Bid.Price != prices.first {
+				continue
+			}
+			if prices.count == 1 {
+				bid.ClearingPrice = prices.first
+			} else {
+				bid.ClearingPrice = math.Min(prices.second+secondPriceIncrement, prices.first)
+			}
+		}
+	}
+}
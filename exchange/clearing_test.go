@@ -0,0 +1,105 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+func TestSecondPriceSingleBid(t *testing.T) {
+	bid := newClearingBid("only-bid", "my-imp", 1.5)
+	applySecondPriceClearing(map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {Bids: []*PBSOrtbBid{bid}},
+	}, 2)
+
+	assertClearingPrice(t, bid, 1.5)
+}
+
+func TestSecondPriceTwoBids(t *testing.T) {
+	winner := newClearingBid("winner", "my-imp", 1.5)
+	runnerUp := newClearingBid("runner-up", "my-imp", 1.2)
+	loser := newClearingBid("loser", "my-imp", 0.8)
+	otherImp := newClearingBid("other-imp", "other-imp", 3)
+	applySecondPriceClearing(map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {Bids: []*PBSOrtbBid{loser, winner}},
+		openrtb_ext.BidderRubicon:  {Bids: []*PBSOrtbBid{runnerUp, otherImp}},
+	}, 2)
+
+	assertClearingPrice(t, winner, 1.21)
+	assertClearingPrice(t, runnerUp, 0)
+	assertClearingPrice(t, loser, 0)
+	assertClearingPrice(t, otherImp, 3)
+}
+
+func TestSecondPriceNeverAboveFirstPrice(t *testing.T) {
+	winner := newClearingBid("winner", "my-imp", 1.205)
+	runnerUp := newClearingBid("runner-up", "my-imp", 1.2)
+	applySecondPriceClearing(map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {Bids: []*PBSOrtbBid{winner, runnerUp}},
+	}, 2)
+
+	assertClearingPrice(t, winner, 1.205)
+}
+
+func TestSecondPriceTies(t *testing.T) {
+	first := newClearingBid("first", "my-imp", 1.5)
+	second := newClearingBid("second", "my-imp", 1.5)
+	loser := newClearingBid("loser", "my-imp", 1)
+	applySecondPriceClearing(map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {Bids: []*PBSOrtbBid{first, loser}},
+		openrtb_ext.BidderRubicon:  {Bids: []*PBSOrtbBid{second}},
+	}, 2)
+
+	assertClearingPrice(t, first, 1.5)
+	assertClearingPrice(t, second, 1.5)
+	assertClearingPrice(t, loser, 0)
+}
+
+func TestFirstPriceHasNoClearingPrice(t *testing.T) {
+	winner := newClearingBid("winner", "my-imp", 1.5)
+	runnerUp := newClearingBid("runner-up", "my-imp", 1.2)
+	applySecondPriceClearing(map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {Bids: []*PBSOrtbBid{winner, runnerUp}},
+	}, 1)
+
+	assertClearingPrice(t, winner, 0)
+	assertClearingPrice(t, runnerUp, 0)
+}
+
+func TestRoundedPricesUseClearingPrice(t *testing.T) {
+	// The prices are off the bucket edges, since floats like 1.2 can round down into the bucket below.
+	winner := newClearingBid("winner", "my-imp", 1.55)
+	runnerUp := newClearingBid("runner-up", "my-imp", 1.25)
+	seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {Bids: []*PBSOrtbBid{winner}},
+		openrtb_ext.BidderRubicon:  {Bids: []*PBSOrtbBid{runnerUp}},
+	}
+	applySecondPriceClearing(seatBids, 2)
+	auc := NewAuction(seatBids, 1, false)
+	auc.SetRoundedPrices(openrtb_ext.PriceGranularityFromString("med"), nil)
+
+	if auc.roundedPrices[winner] != "1.20" {
+		t.Errorf("The winner should be rounded by its clearing price. Got %s", auc.roundedPrices[winner])
+	}
+	if auc.roundedPrices[runnerUp] != "1.20" {
+		t.Errorf("The runner-up should be rounded by its own price. Got %s", auc.roundedPrices[runnerUp])
+	}
+}
+
+func newClearingBid(id string, impID string, price float64) *PBSOrtbBid {
+	return &PBSOrtbBid{
+		Bid: &openrtb.Bid{
+			ID:    id,
+			ImpID: impID,
+			Price: price,
+		},
+	}
+}
+
+func assertClearingPrice(t *testing.T, bid *PBSOrtbBid, expected float64) {
+	t.Helper()
+	if diff := bid.ClearingPrice - expected; diff > 1e-9 || diff < -1e-9 {
+		t.Errorf("Expected bid %s to clear at %f. Got %f", bid.Bid.ID, expected, bid.ClearingPrice)
+	}
+}
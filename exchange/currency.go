@@ -0,0 +1,153 @@
+package exchange
+
+import (
+	"strings"
+
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/currencies"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// convertToRequestCurrency converts every Bid into a single currency from the request.cur,
+// so that the auction compares prices fairly when publishers accept several currencies.
+//
+// The selection must be one of the config.CurrencySelection values. Currencies which some SeatBid can't be
+// converted into are skipped. This returns the currency which the Bids were converted into, or an empty
+// string if none of the request.cur currencies worked. In that case, the Bids are left alone.
+// Converted Bids remember their original price and currency.
+func convertToRequestCurrency(requestCurrencies []string, seatBids map[openrtb_ext.BidderName]*PBSOrtbSeatBid, conversions currencies.Conversions, selection string) string {
+	target := ""
+	targetValue := 0.0
+	for _, candidate := range requestCurrencies {
+		candidate = strings.ToUpper(candidate)
+		value, ok := topConvertedPrice(seatBids, candidate, conversions)
+		if !ok {
+			continue
+		}
+		if selection != config.CurrencySelectionHighestValue {
+			target = candidate
+			break
+		}
+		if target == "" || value > targetValue {
+			target = candidate
+			targetValue = value
+		}
+	}
+	if target == "" {
+		return ""
+	}
+
+	for _, seatBid := range seatBids {
+		if seatBid == nil || len(seatBid.Bids) == 0 {
+			continue
+		}
+		// topConvertedPrice already proved that these rates exist.
+		convertSeatBid(seatBid, target, conversions)
+	}
+	return target
+}
+
+// convertSeatBid converts every Bid in the SeatBid into the target currency. It returns false,
+// and leaves the SeatBid alone, if there's no rate between the SeatBid's currency and the target.
+func convertSeatBid(seatBid *PBSOrtbSeatBid, target string, conversions currencies.Conversions) bool {
+	if seatBid == nil {
+		return true
+	}
+	from := seatCurrency(seatBid)
+	target = strings.ToUpper(target)
+	rate, err := conversions.GetRate(from, target)
+	if err != nil {
+		return false
+	}
+	seatBid.Currency = target
+	if from == target {
+		return true
+	}
+	for _, bid := range seatBid.Bids {
+		if bid.Bid == nil {
+			continue
+		}
+		// Publishers need the quoted price to reconcile with the bidder's billing.
+		bid.OriginalPrice = bid.Bid.Price
+		bid.OriginalCurrency = from
+		bid.Bid.Price = bid.Bid.Price * rate
+	}
+	return true
+}
+
+// preferCurrency returns the requestCurrencies with the preferred one moved to the front, so that
+// config.CurrencySelectionFirst tries it before the others. They're returned as-is if the preferred one isn't there.
+func preferCurrency(requestCurrencies []string, preferred string) []string {
+	for i, candidate := range requestCurrencies {
+		if i == 0 || !strings.EqualFold(candidate, preferred) {
+			continue
+		}
+		reordered := make([]string, 0, len(requestCurrencies))
+		reordered = append(reordered, candidate)
+		reordered = append(reordered, requestCurrencies[:i]...)
+		return append(reordered, requestCurrencies[i+1:]...)
+	}
+	return requestCurrencies
+}
+
+// topConvertedPrice returns the highest Bid price after converting every SeatBid into the target currency.
+// It returns false if some SeatBid can't be converted.
+func topConvertedPrice(seatBids map[openrtb_ext.BidderName]*PBSOrtbSeatBid, target string, conversions currencies.Conversions) (float64, bool) {
+	top := 0.0
+	for _, seatBid := range seatBids {
+		if seatBid == nil || len(seatBid.Bids) == 0 {
+			continue
+		}
+		rate, err := conversions.GetRate(seatCurrency(seatBid), target)
+		if err != nil {
+			return 0, false
+		}
+		for _, bid := range seatBid.Bids {
+			if price := bid.Bid.Price * rate; price > top {
+				top = price
+			}
+		}
+	}
+	return top, true
+}
+
+// applyCurrencyAdjustments multiplies the price of every Bid in the SeatBid by the adjustment for its currency.
+// This should run before the Bids are validated or converted, like the request's bidadjustmentfactors,
+// so that floors and the auction see the adjusted prices. SeatBids in unlisted currencies are left alone,
+// and so are Bids without an openrtb.Bid, which validation rejects.
+func applyCurrencyAdjustments(seatBid *PBSOrtbSeatBid, adjustments map[string]float64) {
+	if seatBid == nil || len(adjustments) == 0 {
+		return
+	}
+	multiplier, ok := adjustments[seatCurrency(seatBid)]
+	if !ok {
+		return
+	}
+	for _, bid := range seatBid.Bids {
+		if bid.Bid == nil {
+			continue
+		}
+		bid.Bid.Price = bid.Bid.Price * multiplier
+	}
+}
+
+// setFinalPrices records every Bid's price as its FinalPrice. This should run once the Bids have been adjusted,
+// converted into the response currency and rounded, so that analytics don't need to repeat those steps.
+func setFinalPrices(seatBids map[openrtb_ext.BidderName]*PBSOrtbSeatBid) {
+	for _, seatBid := range seatBids {
+		if seatBid == nil {
+			continue
+		}
+		for _, bid := range seatBid.Bids {
+			bid.FinalPrice = bid.Bid.Price
+		}
+	}
+}
+
+// seatCurrency returns the upper-cased currency of the SeatBid, which is USD by default.
+func seatCurrency(seatBid *PBSOrtbSeatBid) string {
+	if seatBid.Currency == "" {
+		return "USD"
+	}
+	return strings.ToUpper(seatBid.Currency)
+}
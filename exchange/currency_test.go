@@ -0,0 +1,286 @@
+package exchange
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/currencies"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+func TestConvertToFirstRequestCurrency(t *testing.T) {
+	seatBids := newCurrencySeatBids()
+	target := convertToRequestCurrency([]string{"eur", "GBP"}, seatBids, newTestConversions(), config.CurrencySelectionFirst)
+
+	assertTargetCurrency(t, seatBids, target, "EUR")
+	assertPrice(t, seatBids[openrtb_ext.BidderAppnexus], 1.7)
+	assertPrice(t, seatBids[openrtb_ext.BidderRubicon], 1)
+}
+
+func TestConvertToHighestValueRequestCurrency(t *testing.T) {
+	seatBids := newCurrencySeatBids()
+	target := convertToRequestCurrency([]string{"GBP", "EUR"}, seatBids, newTestConversions(), config.CurrencySelectionHighestValue)
+
+	assertTargetCurrency(t, seatBids, target, "EUR")
+}
+
+func TestConvertSkipsUnconvertibleCurrencies(t *testing.T) {
+	seatBids := newCurrencySeatBids()
+	target := convertToRequestCurrency([]string{"JPY", "GBP"}, seatBids, newTestConversions(), config.CurrencySelectionFirst)
+
+	assertTargetCurrency(t, seatBids, target, "GBP")
+	assertPrice(t, seatBids[openrtb_ext.BidderAppnexus], 1.6)
+	assertPrice(t, seatBids[openrtb_ext.BidderRubicon], 0.9)
+}
+
+func TestConvertSkipsCurrenciesWithInvalidRates(t *testing.T) {
+	seatBids := newCurrencySeatBids()
+	conversions := currencies.NewConversionCache(currencies.NewRates(time.Time{}, map[string]map[string]float64{
+		"USD": {
+			"EUR": 0,
+			"GBP": 0.8,
+		},
+		"EUR": {
+			"GBP": 0.9,
+		},
+	}))
+	target := convertToRequestCurrency([]string{"EUR", "GBP"}, seatBids, conversions, config.CurrencySelectionFirst)
+
+	assertTargetCurrency(t, seatBids, target, "GBP")
+	assertPrice(t, seatBids[openrtb_ext.BidderAppnexus], 1.6)
+}
+
+func TestConvertRemembersOriginalPrices(t *testing.T) {
+	seatBids := newCurrencySeatBids()
+	convertToRequestCurrency([]string{"EUR", "USD"}, seatBids, newTestConversions(), config.CurrencySelectionFirst)
+
+	converted := seatBids[openrtb_ext.BidderAppnexus].Bids[0]
+	if converted.OriginalCurrency != "USD" || converted.OriginalPrice != 2 {
+		t.Errorf("Expected the converted bid to remember 2 USD. Got %f %s", converted.OriginalPrice, converted.OriginalCurrency)
+	}
+	unconverted := seatBids[openrtb_ext.BidderRubicon].Bids[0]
+	if unconverted.OriginalCurrency != "" || unconverted.OriginalPrice != 0 {
+		t.Errorf("Bids which weren't converted shouldn't have an original price. Got %f %s", unconverted.OriginalPrice, unconverted.OriginalCurrency)
+	}
+}
+
+func TestConvertWithoutUsableCurrencies(t *testing.T) {
+	seatBids := newCurrencySeatBids()
+	target := convertToRequestCurrency([]string{"JPY"}, seatBids, newTestConversions(), config.CurrencySelectionFirst)
+
+	if target != "" {
+		t.Errorf("Expected no target currency. Got %s", target)
+	}
+	if seatBids[openrtb_ext.BidderAppnexus].Currency != "USD" {
+		t.Errorf("Bids should be left alone if no currency works. Got %s", seatBids[openrtb_ext.BidderAppnexus].Currency)
+	}
+	assertPrice(t, seatBids[openrtb_ext.BidderAppnexus], 2)
+}
+
+func TestConvertToAccountDefaultCurrency(t *testing.T) {
+	seatBids := newCurrencySeatBids()
+	// The EUR seat is already in a request currency, but the account wants its reports in GBP.
+	target := convertToRequestCurrency(preferCurrency([]string{"EUR", "gbp"}, "GBP"), seatBids, newTestConversions(), config.CurrencySelectionAccountDefault)
+
+	assertTargetCurrency(t, seatBids, target, "GBP")
+	assertPrice(t, seatBids[openrtb_ext.BidderAppnexus], 1.6)
+	assertPrice(t, seatBids[openrtb_ext.BidderRubicon], 0.9)
+}
+
+func TestAccountDefaultCurrencyFallsBackToFirst(t *testing.T) {
+	seatBids := newCurrencySeatBids()
+	target := convertToRequestCurrency(preferCurrency([]string{"EUR", "JPY"}, "JPY"), seatBids, newTestConversions(), config.CurrencySelectionAccountDefault)
+
+	assertTargetCurrency(t, seatBids, target, "EUR")
+}
+
+func TestPreferCurrency(t *testing.T) {
+	testCases := []struct {
+		description string
+		currencies  []string
+		preferred   string
+		expected    []string
+	}{
+		{
+			description: "The preferred currency moves to the front",
+			currencies:  []string{"USD", "EUR", "GBP"},
+			preferred:   "GBP",
+			expected:    []string{"GBP", "USD", "EUR"},
+		},
+		{
+			description: "Currencies are compared case-insensitively",
+			currencies:  []string{"USD", "eur"},
+			preferred:   "EUR",
+			expected:    []string{"eur", "USD"},
+		},
+		{
+			description: "The order is kept if the preferred currency is first",
+			currencies:  []string{"EUR", "USD"},
+			preferred:   "EUR",
+			expected:    []string{"EUR", "USD"},
+		},
+		{
+			description: "The order is kept if the preferred currency is missing",
+			currencies:  []string{"EUR", "USD"},
+			preferred:   "JPY",
+			expected:    []string{"EUR", "USD"},
+		},
+	}
+
+	for _, tc := range testCases {
+		actual := preferCurrency(tc.currencies, tc.preferred)
+		if strings.Join(actual, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("%s: expected %v. Got %v", tc.description, tc.expected, actual)
+		}
+	}
+}
+
+func TestCurrencyAdjustments(t *testing.T) {
+	seatBids := newCurrencySeatBids()
+	adjustments := map[string]float64{"EUR": 0.9}
+	for _, seatBid := range seatBids {
+		applyCurrencyAdjustments(seatBid, adjustments)
+	}
+
+	assertPrice(t, seatBids[openrtb_ext.BidderAppnexus], 2)
+	assertPrice(t, seatBids[openrtb_ext.BidderRubicon], 0.9)
+}
+
+func TestCurrencyAdjustmentsStack(t *testing.T) {
+	seatBids := newCurrencySeatBids()
+	// The bidder's own adjustment was applied when its Bids came back.
+	seatBids[openrtb_ext.BidderRubicon].Bids[0].Bid.Price *= 0.8
+	for _, seatBid := range seatBids {
+		applyCurrencyAdjustments(seatBid, map[string]float64{"EUR": 0.9})
+	}
+	assertPrice(t, seatBids[openrtb_ext.BidderRubicon], 0.72)
+
+	target := convertToRequestCurrency([]string{"GBP"}, seatBids, newTestConversions(), config.CurrencySelectionFirst)
+
+	assertTargetCurrency(t, seatBids, target, "GBP")
+	assertPrice(t, seatBids[openrtb_ext.BidderAppnexus], 1.6)
+	assertPrice(t, seatBids[openrtb_ext.BidderRubicon], 0.648)
+}
+
+func TestCurrencyAdjustmentsDefaultCurrency(t *testing.T) {
+	seatBid := &PBSOrtbSeatBid{
+		Bids: []*PBSOrtbBid{{
+			Bid: &openrtb.Bid{ID: "some-bid", Price: 2},
+		}},
+	}
+	applyCurrencyAdjustments(seatBid, map[string]float64{"USD": 1.1})
+
+	assertPrice(t, seatBid, 2.2)
+}
+
+func TestCurrencyAdjustmentsSkipEmptyBids(t *testing.T) {
+	seatBid := &PBSOrtbSeatBid{
+		Bids: []*PBSOrtbBid{
+			{},
+			{Bid: &openrtb.Bid{ID: "some-bid", Price: 2}},
+		},
+	}
+	applyCurrencyAdjustments(seatBid, map[string]float64{"USD": 1.1})
+
+	if seatBid.Bids[1].Bid.Price != 2.2 {
+		t.Errorf("Expected the valid bid to be adjusted to 2.2. Got %f", seatBid.Bids[1].Bid.Price)
+	}
+}
+
+func TestFinalPrices(t *testing.T) {
+	seatBids := newCurrencySeatBids()
+	// Both bidders' own adjustments were applied when their Bids came back.
+	seatBids[openrtb_ext.BidderAppnexus].Bids[0].Bid.Price *= 0.9
+	seatBids[openrtb_ext.BidderRubicon].Bids[0].Bid.Price *= 0.8
+	for _, seatBid := range seatBids {
+		applyCurrencyAdjustments(seatBid, map[string]float64{"EUR": 0.9})
+	}
+	convertToRequestCurrency([]string{"GBP"}, seatBids, newTestConversions(), config.CurrencySelectionFirst)
+	roundBidPrices(&openrtb.BidRequest{}, seatBids, config.PriceRounding{Mode: config.PriceRoundingHalfUp, Precision: 2}, 0, nil)
+	setFinalPrices(seatBids)
+
+	// 2 USD * 0.9 * 0.8 GBP/USD = 1.44 GBP
+	assertFinalPrice(t, seatBids[openrtb_ext.BidderAppnexus], 1.44)
+	// 1 EUR * 0.8 * 0.9 * 0.9 GBP/EUR = 0.648 GBP, rounded to 0.65
+	assertFinalPrice(t, seatBids[openrtb_ext.BidderRubicon], 0.65)
+	if original := seatBids[openrtb_ext.BidderRubicon].Bids[0].OriginalPrice; original < 0.7199 || original > 0.7201 {
+		t.Errorf("Expected the original price to be the adjusted one the bidder quoted. Got %f", original)
+	}
+}
+
+func TestFinalPricesWithoutConversion(t *testing.T) {
+	seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Bids: []*PBSOrtbBid{{
+				Bid: &openrtb.Bid{ID: "apn-bid", Price: 1.5},
+			}},
+		},
+		openrtb_ext.BidderRubicon: nil,
+	}
+	setFinalPrices(seatBids)
+
+	assertFinalPrice(t, seatBids[openrtb_ext.BidderAppnexus], 1.5)
+}
+
+func TestCurrencyAdjustmentsNilSeatBid(t *testing.T) {
+	applyCurrencyAdjustments(nil, map[string]float64{"USD": 1.1})
+}
+
+// newCurrencySeatBids makes a USD seat and an EUR seat, each with a single Bid.
+func newCurrencySeatBids() map[openrtb_ext.BidderName]*PBSOrtbSeatBid {
+	return map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Currency: "USD",
+			Bids: []*PBSOrtbBid{{
+				Bid: &openrtb.Bid{ID: "apn-bid", Price: 2},
+			}},
+		},
+		openrtb_ext.BidderRubicon: {
+			Currency: "EUR",
+			Bids: []*PBSOrtbBid{{
+				Bid: &openrtb.Bid{ID: "rubi-bid", Price: 1},
+			}},
+		},
+	}
+}
+
+func newTestConversions() currencies.Conversions {
+	return currencies.NewConversionCache(currencies.NewRates(time.Time{}, map[string]map[string]float64{
+		"USD": {
+			"EUR": 0.85,
+			"GBP": 0.8,
+		},
+		"EUR": {
+			"GBP": 0.9,
+		},
+	}))
+}
+
+func assertTargetCurrency(t *testing.T, seatBids map[openrtb_ext.BidderName]*PBSOrtbSeatBid, target string, expected string) {
+	t.Helper()
+	if target != expected {
+		t.Errorf("Expected target currency %s. Got %s", expected, target)
+	}
+	for bidder, seatBid := range seatBids {
+		if seatBid.Currency != expected {
+			t.Errorf("Expected %s bids to be in %s. Got %s", bidder, expected, seatBid.Currency)
+		}
+	}
+}
+
+func assertFinalPrice(t *testing.T, seatBid *PBSOrtbSeatBid, expected float64) {
+	t.Helper()
+	if price := seatBid.Bids[0].FinalPrice; price < expected-0.0001 || price > expected+0.0001 {
+		t.Errorf("Expected final price %f. Got %f", expected, price)
+	}
+}
+
+func assertPrice(t *testing.T, seatBid *PBSOrtbSeatBid, expected float64) {
+	t.Helper()
+	if price := seatBid.Bids[0].Bid.Price; price < expected-0.0001 || price > expected+0.0001 {
+		t.Errorf("Expected price %f. Got %f", expected, price)
+	}
+}
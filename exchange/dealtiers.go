@@ -0,0 +1,49 @@
+package exchange
+
+import (
+	"strconv"
+
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// applyDealTiers marks the Bids which satisfy their Bidder's deal tier config, so that targeting can emit their tier.
+//
+// Only Bids which carry a DealID are considered. A Bid satisfies the tier if its DealPriority is positive
+// and at least the configured MinDealTier. Bids which don't are left alone, and still compete on price as usual.
+//
+// dealTiers is keyed by the name used in the request, so aliases get their own config.
+func applyDealTiers(seatBids map[openrtb_ext.BidderName]*PBSOrtbSeatBid, dealTiers map[string]openrtb_ext.DealTier) {
+	if len(dealTiers) == 0 {
+		return
+	}
+	for bidderName, seatBid := range seatBids {
+		dealTier, ok := dealTiers[string(bidderName)]
+		if !ok || seatBid == nil {
+			continue
+		}
+		for _, bid := range seatBid.Bids {
+			if bid.Bid.DealID == "" || bid.DealPriority <= 0 || bid.DealPriority < dealTier.MinDealTier {
+				continue
+			}
+			bid.DealTierSatisfied = true
+			bid.DealTier = dealTier.Prefix + strconv.Itoa(bid.DealPriority)
+		}
+	}
+}
+
+// outranks returns true if the bid should win the auction over the current winner.
+//
+// Normally the higher price wins. If preferDeals is true, Bids which satisfied their Bidder's deal tier beat all the others,
+// even at a lower price. Between two of those, the higher DealPriority wins, and the price breaks ties.
+// applyDealTiers must be called first.
+func outranks(bid *PBSOrtbBid, current *PBSOrtbBid, preferDeals bool) bool {
+	if preferDeals {
+		if bid.DealTierSatisfied != current.DealTierSatisfied {
+			return bid.DealTierSatisfied
+		}
+		if bid.DealTierSatisfied && bid.DealPriority != current.DealPriority {
+			return bid.DealPriority > current.DealPriority
+		}
+	}
+	return bid.Bid.Price > current.Bid.Price
+}
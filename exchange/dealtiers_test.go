@@ -0,0 +1,185 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+func TestDealTiers(t *testing.T) {
+	seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Bids: []*PBSOrtbBid{
+				newDealBid("satisfied", "deal-1", 5),
+				newDealBid("exact", "deal-2", 3),
+				newDealBid("too-low", "deal-3", 2),
+				newDealBid("no-deal", "", 9),
+				newDealBid("no-priority", "deal-4", 0),
+			},
+		},
+		openrtb_ext.BidderRubicon: {
+			Bids: []*PBSOrtbBid{
+				newDealBid("unconfigured", "deal-5", 5),
+			},
+		},
+	}
+	applyDealTiers(seatBids, map[string]openrtb_ext.DealTier{
+		"appnexus": {
+			Prefix:      "tier",
+			MinDealTier: 3,
+		},
+	})
+
+	appnexusBids := seatBids[openrtb_ext.BidderAppnexus].Bids
+	assertDealTier(t, appnexusBids[0], true, "tier5")
+	assertDealTier(t, appnexusBids[1], true, "tier3")
+	assertDealTier(t, appnexusBids[2], false, "")
+	assertDealTier(t, appnexusBids[3], false, "")
+	assertDealTier(t, appnexusBids[4], false, "")
+	assertDealTier(t, seatBids[openrtb_ext.BidderRubicon].Bids[0], false, "")
+}
+
+func TestDealTierTargeting(t *testing.T) {
+	bid := newDealBid("satisfied", "deal-1", 5)
+	bid.Bid.ImpID = "my-imp"
+	bid.Bid.Price = 1
+	seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Bids: []*PBSOrtbBid{bid},
+		},
+	}
+	applyDealTiers(seatBids, map[string]openrtb_ext.DealTier{
+		"appnexus": {
+			Prefix:      "tier",
+			MinDealTier: 1,
+		},
+	})
+
+	targData := &TargetData{
+		PriceGranularity: openrtb_ext.PriceGranularityFromString("med"),
+		IncludeWinners:   true,
+	}
+	auc := NewAuction(seatBids, 1, false)
+	auc.SetRoundedPrices(targData.PriceGranularity, nil)
+	targData.SetTargeting(auc, false)
+
+	if tier := bid.BidTargets[string(openrtb_ext.HbDealTierKey)]; tier != "tier5" {
+		t.Errorf("Expected the %s targeting key to be tier5. Got %s", openrtb_ext.HbDealTierKey, tier)
+	}
+}
+
+func TestPreferDeals(t *testing.T) {
+	testCases := []struct {
+		description    string
+		appnexusBids   []*PBSOrtbBid
+		rubiconBids    []*PBSOrtbBid
+		preferDeals    bool
+		expectedWinner string
+	}{
+		{
+			description:    "Deals beat open bids, even at a lower price",
+			appnexusBids:   []*PBSOrtbBid{newPricedDealBid("deal", "deal-1", 5, 1.0)},
+			rubiconBids:    []*PBSOrtbBid{newPricedDealBid("open", "", 0, 3.0)},
+			preferDeals:    true,
+			expectedWinner: "deal",
+		},
+		{
+			description:    "Deals rank by priority before price",
+			appnexusBids:   []*PBSOrtbBid{newPricedDealBid("cheap-priority", "deal-1", 8, 1.0)},
+			rubiconBids:    []*PBSOrtbBid{newPricedDealBid("expensive", "deal-2", 5, 3.0)},
+			preferDeals:    true,
+			expectedWinner: "cheap-priority",
+		},
+		{
+			description:    "Deals with the same priority rank by price",
+			appnexusBids:   []*PBSOrtbBid{newPricedDealBid("cheap", "deal-1", 5, 1.0)},
+			rubiconBids:    []*PBSOrtbBid{newPricedDealBid("expensive", "deal-2", 5, 3.0)},
+			preferDeals:    true,
+			expectedWinner: "expensive",
+		},
+		{
+			description:    "Deals which don't satisfy their tier compete as open bids",
+			appnexusBids:   []*PBSOrtbBid{newPricedDealBid("low-tier", "deal-1", 2, 1.0)},
+			rubiconBids:    []*PBSOrtbBid{newPricedDealBid("open", "", 0, 3.0)},
+			preferDeals:    true,
+			expectedWinner: "open",
+		},
+		{
+			description:    "Open auctions rank by price",
+			appnexusBids:   []*PBSOrtbBid{newPricedDealBid("cheap", "", 0, 1.0)},
+			rubiconBids:    []*PBSOrtbBid{newPricedDealBid("expensive", "", 0, 3.0)},
+			preferDeals:    true,
+			expectedWinner: "expensive",
+		},
+		{
+			description:    "Deals only win on price unless the request prefers them",
+			appnexusBids:   []*PBSOrtbBid{newPricedDealBid("deal", "deal-1", 5, 1.0)},
+			rubiconBids:    []*PBSOrtbBid{newPricedDealBid("open", "", 0, 3.0)},
+			expectedWinner: "open",
+		},
+	}
+
+	for _, tc := range testCases {
+		seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+			openrtb_ext.BidderAppnexus: {Bids: tc.appnexusBids},
+			openrtb_ext.BidderRubicon:  {Bids: tc.rubiconBids},
+		}
+		dealTier := openrtb_ext.DealTier{Prefix: "tier", MinDealTier: 3}
+		applyDealTiers(seatBids, map[string]openrtb_ext.DealTier{
+			"appnexus": dealTier,
+			"rubicon":  dealTier,
+		})
+		auc := NewAuction(seatBids, 1, tc.preferDeals)
+
+		if winner := auc.winningBids["my-imp"]; winner == nil || winner.Bid.ID != tc.expectedWinner {
+			t.Errorf("%s: expected %s to win. Got %v", tc.description, tc.expectedWinner, winner)
+		}
+	}
+}
+
+func TestPreferDealsPerBidder(t *testing.T) {
+	seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Bids: []*PBSOrtbBid{
+				newPricedDealBid("open", "", 0, 3.0),
+				newPricedDealBid("deal", "deal-1", 5, 1.0),
+			},
+		},
+	}
+	applyDealTiers(seatBids, map[string]openrtb_ext.DealTier{
+		"appnexus": {Prefix: "tier", MinDealTier: 3},
+	})
+	auc := NewAuction(seatBids, 1, true)
+
+	if winner := auc.winningBidsByBidder["my-imp"][openrtb_ext.BidderAppnexus]; winner == nil || winner.Bid.ID != "deal" {
+		t.Errorf("Expected the deal to be the bidder's top bid. Got %v", winner)
+	}
+}
+
+func newPricedDealBid(id string, dealID string, priority int, price float64) *PBSOrtbBid {
+	bid := newDealBid(id, dealID, priority)
+	bid.Bid.ImpID = "my-imp"
+	bid.Bid.Price = price
+	return bid
+}
+
+func newDealBid(id string, dealID string, priority int) *PBSOrtbBid {
+	return &PBSOrtbBid{
+		Bid: &openrtb.Bid{
+			ID:     id,
+			DealID: dealID,
+		},
+		DealPriority: priority,
+	}
+}
+
+func assertDealTier(t *testing.T, bid *PBSOrtbBid, satisfied bool, tier string) {
+	t.Helper()
+	if bid.DealTierSatisfied != satisfied {
+		t.Errorf("Bid %s: expected DealTierSatisfied to be %t", bid.Bid.ID, satisfied)
+	}
+	if bid.DealTier != tier {
+		t.Errorf("Bid %s: expected DealTier %q. Got %q", bid.Bid.ID, tier, bid.DealTier)
+	}
+}
@@ -0,0 +1,74 @@
+package exchange
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// eventURLs builds the win and imp event URLs which tell the /event endpoint about a Bid.
+type eventURLs struct {
+	endpoint string
+	// accounts is nil if every account gets event URLs.
+	accounts map[string]struct{}
+}
+
+// newEventURLs returns nil if the host hasn't enabled events.
+func newEventURLs(cfg config.Events, externalURL string) *eventURLs {
+	if !cfg.Enabled {
+		return nil
+	}
+	ev := &eventURLs{
+		endpoint: strings.TrimSuffix(externalURL, "/") + "/event",
+	}
+	if len(cfg.Accounts) > 0 {
+		ev.accounts = make(map[string]struct{}, len(cfg.Accounts))
+		for _, account := range cfg.Accounts {
+			ev.accounts[account] = struct{}{}
+		}
+	}
+	return ev
+}
+
+// enabledFor returns true if Bids on the account's requests should get event URLs.
+func (ev *eventURLs) enabledFor(accountID string) bool {
+	if ev == nil {
+		return false
+	}
+	if ev.accounts == nil {
+		return true
+	}
+	_, ok := ev.accounts[accountID]
+	return ok
+}
+
+// makeURL returns the URL which reports an event of the given type ("win" or "imp") for the Bid.
+func (ev *eventURLs) makeURL(eventType string, bidID string, bidder openrtb_ext.BidderName, accountID string) string {
+	query := url.Values{}
+	query.Set("t", eventType)
+	query.Set("b", bidID)
+	query.Set("a", accountID)
+	query.Set("bidder", string(bidder))
+	return ev.endpoint + "?" + query.Encode()
+}
+
+// addEventURLs gives every Bid in the seatBids its win and imp event URLs, if the account has events enabled.
+// This should run after the Bids are validated, so that rejected Bids never get event URLs.
+func addEventURLs(seatBids map[openrtb_ext.BidderName]*PBSOrtbSeatBid, ev *eventURLs, accountID string) {
+	if !ev.enabledFor(accountID) {
+		return
+	}
+	for bidderName, seatBid := range seatBids {
+		if seatBid == nil {
+			continue
+		}
+		for _, bid := range seatBid.Bids {
+			bid.Events = &openrtb_ext.ExtBidPrebidEvents{
+				Win: ev.makeURL("win", bid.Bid.ID, bidderName, accountID),
+				Imp: ev.makeURL("imp", bid.Bid.ID, bidderName, accountID),
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+func TestAddEventURLs(t *testing.T) {
+	seatBids := newEventTestSeatBids()
+	ev := newEventURLs(config.Events{Enabled: true}, "https://prebid.host.com/")
+
+	addEventURLs(seatBids, ev, "my-account")
+
+	events := seatBids[openrtb_ext.BidderAppnexus].Bids[0].Events
+	if events == nil {
+		t.Fatalf("Expected the bid to get event URLs.")
+	}
+	if events.Win != "https://prebid.host.com/event?a=my-account&b=apn-bid&bidder=appnexus&t=win" {
+		t.Errorf("Bad win URL: %s", events.Win)
+	}
+	if events.Imp != "https://prebid.host.com/event?a=my-account&b=apn-bid&bidder=appnexus&t=imp" {
+		t.Errorf("Bad imp URL: %s", events.Imp)
+	}
+}
+
+func TestAddEventURLsEscapes(t *testing.T) {
+	seatBids := newEventTestSeatBids()
+	seatBids[openrtb_ext.BidderAppnexus].Bids[0].Bid.ID = "bid&id=1"
+	ev := newEventURLs(config.Events{Enabled: true}, "https://prebid.host.com")
+
+	addEventURLs(seatBids, ev, "my account")
+
+	if url := seatBids[openrtb_ext.BidderAppnexus].Bids[0].Events.Win; url != "https://prebid.host.com/event?a=my+account&b=bid%26id%3D1&bidder=appnexus&t=win" {
+		t.Errorf("Bad win URL: %s", url)
+	}
+}
+
+func TestAddEventURLsDisabled(t *testing.T) {
+	seatBids := newEventTestSeatBids()
+	addEventURLs(seatBids, newEventURLs(config.Events{}, "https://prebid.host.com"), "my-account")
+	if events := seatBids[openrtb_ext.BidderAppnexus].Bids[0].Events; events != nil {
+		t.Errorf("Bids should not get event URLs unless the host enables them. Got %v", events)
+	}
+}
+
+func TestAddEventURLsAccounts(t *testing.T) {
+	ev := newEventURLs(config.Events{Enabled: true, Accounts: []string{"my-account"}}, "https://prebid.host.com")
+
+	seatBids := newEventTestSeatBids()
+	addEventURLs(seatBids, ev, "other-account")
+	if events := seatBids[openrtb_ext.BidderAppnexus].Bids[0].Events; events != nil {
+		t.Errorf("Accounts which aren't enabled should not get event URLs. Got %v", events)
+	}
+
+	seatBids = newEventTestSeatBids()
+	addEventURLs(seatBids, ev, "my-account")
+	if events := seatBids[openrtb_ext.BidderAppnexus].Bids[0].Events; events == nil {
+		t.Errorf("Enabled accounts should get event URLs.")
+	}
+}
+
+func newEventTestSeatBids() map[openrtb_ext.BidderName]*PBSOrtbSeatBid {
+	return map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Bids: []*PBSOrtbBid{{
+				Bid: &openrtb.Bid{ID: "apn-bid", ImpID: "my-imp", Price: 0.3},
+			}},
+		},
+	}
+}
@@ -4,18 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/rand"
 	"net/http"
 	"runtime/debug"
 	"strings"
 	"time"
 
 	"github.com/golang/glog"
-	"golang.org/x/text/currency"
 
 	"github.com/mxmCherry/openrtb"
 
 	"github.com/prebid/prebid-server/adapters"
 	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/currencies"
 	"github.com/prebid/prebid-server/errortypes"
 	"github.com/prebid/prebid-server/gdpr"
 	"github.com/prebid/prebid-server/openrtb_ext"
@@ -35,6 +36,33 @@ type IdFetcher interface {
 	GetId(bidder openrtb_ext.BidderName) (string, bool)
 }
 
+// Plugins let Prebid Server hosts customize the Exchange without forking it.
+// The zero value adds no customizations.
+type Plugins struct {
+	// BidValidators run on every Bid, in order, after Prebid Server's own checks pass.
+	// Bids rejected by any of them are removed from the auction.
+	BidValidators []BidValidator
+	// CreativeRewriters run on every valid Bid, in order, before it's cached or returned.
+	CreativeRewriters []CreativeRewriter
+	// SeatBidPreprocessors run on every Bidder's SeatBid, in order, before its Bids are validated.
+	SeatBidPreprocessors []SeatBidPreprocessor
+	// FloorProvider decides the floor of every Imp, before the Bidders are called.
+	// If nil, each Imp's floor is its own bidfloor.
+	FloorProvider FloorProvider
+	// DisabledBidders are skipped in every auction until they're enabled again.
+	// If nil, bidders can't be switched off at runtime.
+	DisabledBidders *DisabledBidders
+	// TargetingKeyGenerator makes the targeting keys of the top Bids, when the request asks for targeting.
+	// If nil, the standard hb_* keys are used.
+	TargetingKeyGenerator TargetingKeyGenerator
+	// BlockedCreatives reject Bids with their crids, along with the host's bid_validation.blocked_creative_ids.
+	// If nil, creatives can't be blocked at runtime.
+	BlockedCreatives *BlockedCreatives
+	// RejectionLogger logs each rejected Bid along with the ID of its request, for debugging why auctions drop Bids.
+	// If nil, rejections aren't logged.
+	RejectionLogger RejectionLogger
+}
+
 type exchange struct {
 	adapterMap          map[openrtb_ext.BidderName]AdaptedBidder
 	me                  pbsmetrics.MetricsEngine
@@ -43,12 +71,64 @@ type exchange struct {
 	gDPR                gdpr.Permissions
 	UsersyncIfAmbiguous bool
 	defaultTTLs         config.DefaultTTLs
+	currencyConverter   *currencies.RateConverter
+	currencySelection   string
+	// currencyAdjustments multiply the prices of Bids in each currency, keyed by upper-case ISO 4217 code.
+	currencyAdjustments map[string]float64
+	maxBidsPerImp       int
+	maxBidsPerBidder    int
+	bidValidation       config.BidValidation
+	priceRounding       config.PriceRounding
+	bidValidators       []BidValidator
+	creativeRewriters   []CreativeRewriter
+	// Keyed by core bidder. Each bidder's list starts with a check of the media types in its bidder-info file.
+	bidderValidators map[openrtb_ext.BidderName][]BidValidator
+	// openrtbVersions are the OpenRTB versions which core bidders declare for their responses in their bidder-info files.
+	// Bidders which don't declare one aren't listed.
+	openrtbVersions map[openrtb_ext.BidderName]string
+	// bidderCurrencies are the currencies which core bidders are limited to by the host's config.
+	// Bidders which may use any currency aren't listed.
+	bidderCurrencies map[openrtb_ext.BidderName][]string
+	// seatBidPreprocessors run on each SeatBid before it's validated, in the order which the host gave them.
+	seatBidPreprocessors []SeatBidPreprocessor
+	// floorProvider decides the floor of each Imp in an auction's request.
+	floorProvider FloorProvider
+	// allowedBidders are the core bidders which may take part in auctions. If nil, every bidder may.
+	allowedBidders map[openrtb_ext.BidderName]struct{}
+	// disabledBidders are the core bidders which operators have switched off at runtime. It may be nil.
+	disabledBidders *DisabledBidders
+	// targetingKeyGenerator makes the targeting keys of each auction's top Bids. If nil, the hb_* keys are used.
+	targetingKeyGenerator TargetingKeyGenerator
+	// ratesStaleness is nil if the currency rates never go stale.
+	ratesStaleness *currencies.StalenessCheck
+	// fallbackRates is nil unless the host has a file of rates to use when the fetched ones can't convert a currency.
+	fallbackRates currencies.Conversions
+	// events is nil if the host hasn't enabled event URLs.
+	events *eventURLs
+	// capTTLAtDefault makes the defaultTTLs an upper bound on how long Bids are cached.
+	capTTLAtDefault bool
+	// maxCacheTTL is the longest any Bid is cached for, in seconds. It's 0 if the host doesn't cap the TTLs.
+	maxCacheTTL int64
+	// signingSecret is empty if the host doesn't sign cached creatives.
+	signingSecret string
+	// dedupeCreatives makes Bids with identical cached creatives share a cache key.
+	dedupeCreatives bool
+	// accounts holds the publisher accounts' overrides of the host config, keyed by account ID.
+	accounts map[string]config.Account
+	// rejectionLogger is nil if the host doesn't log rejected Bids.
+	rejectionLogger RejectionLogger
 }
 
 // Container to pass out response ext data from the GetAllBids goroutines back into the main thread
 type SeatResponseExtra struct {
 	ResponseTimeMillis int
 	Errors             []openrtb_ext.ExtBidderError
+	Warnings           []openrtb_ext.ExtBidderError
+	// RejectedBids are only put in the response if the request asks for debugging info.
+	RejectedBids []openrtb_ext.ExtRejectedBid
+	// RejectionsByImp holds the reasons why the Bidder's Bids on each Imp were rejected.
+	// It's only collected if the host wants the no-bid reasons in the response.
+	RejectionsByImp map[string][]pbsmetrics.BidRejectionReason
 }
 
 type BidResponseWrapper struct {
@@ -57,7 +137,7 @@ type BidResponseWrapper struct {
 	Bidder       openrtb_ext.BidderName
 }
 
-func NewExchange(client *http.Client, cache prebid_cache_client.Client, cfg *config.Configuration, metricsEngine pbsmetrics.MetricsEngine, infos adapters.BidderInfos, gDPR gdpr.Permissions) Exchange {
+func NewExchange(client *http.Client, cache prebid_cache_client.Client, cfg *config.Configuration, metricsEngine pbsmetrics.MetricsEngine, infos adapters.BidderInfos, gDPR gdpr.Permissions, plugins Plugins) Exchange {
 	e := new(exchange)
 
 	e.adapterMap = newAdapterMap(client, cfg, infos)
@@ -67,6 +147,81 @@ func NewExchange(client *http.Client, cache prebid_cache_client.Client, cfg *con
 	e.gDPR = gDPR
 	e.UsersyncIfAmbiguous = cfg.GDPR.UsersyncIfAmbiguous
 	e.defaultTTLs = cfg.CacheURL.DefaultTTLs
+	e.capTTLAtDefault = cfg.CacheURL.CapTTLAtDefault
+	e.maxCacheTTL = int64(cfg.CacheURL.MaxTTLSeconds)
+	e.signingSecret = cfg.CacheURL.SigningSecret
+	e.dedupeCreatives = cfg.CacheURL.DedupeCreatives
+	e.accounts = cfg.Accounts
+	e.currencyConverter = currencies.NewRateConverter(client, cfg.CurrencyConverter.FetchURL, cfg.CurrencyConverter.FetchInterval())
+	e.currencySelection = cfg.CurrencyConverter.TargetSelection
+	// The config's keys may have been lower-cased.
+	e.currencyAdjustments = make(map[string]float64, len(cfg.CurrencyConverter.BidAdjustments))
+	for cur, multiplier := range cfg.CurrencyConverter.BidAdjustments {
+		e.currencyAdjustments[strings.ToUpper(cur)] = multiplier
+	}
+	if cfg.CurrencyConverter.FetchIntervalSeconds > 0 && cfg.CurrencyConverter.StaleRatesSeconds > 0 {
+		e.ratesStaleness = &currencies.StalenessCheck{
+			MaxAge: cfg.CurrencyConverter.StaleRatesAge(),
+			Policy: currencies.StalenessPolicy(cfg.CurrencyConverter.StaleRatesPolicy),
+		}
+		if e.ratesStaleness.Policy == currencies.StalenessPolicyStatic {
+			staticRates, err := currencies.LoadRatesFile(cfg.CurrencyConverter.StaticRatesFile)
+			if err != nil {
+				glog.Errorf("Failed to load the static currency rates. Stale rates will not be converted: %v", err)
+			}
+			e.ratesStaleness.StaticRates = staticRates
+		}
+	}
+	if cfg.CurrencyConverter.FallbackRatesFile != "" {
+		if fallbackRates, err := currencies.LoadRatesFile(cfg.CurrencyConverter.FallbackRatesFile); err != nil {
+			glog.Errorf("Failed to load the fallback currency rates. Currencies will only be converted with the fetched rates: %v", err)
+		} else {
+			e.fallbackRates = fallbackRates
+		}
+	}
+	e.maxBidsPerImp = cfg.MaxBidsPerImp
+	e.maxBidsPerBidder = cfg.MaxBidsPerBidder
+	e.bidValidation = cfg.BidValidation
+	e.priceRounding = cfg.PriceRounding
+	e.events = newEventURLs(cfg.Events, cfg.ExternalURL)
+	e.bidValidators = plugins.BidValidators
+	blockedCreatives := plugins.BlockedCreatives
+	if blockedCreatives == nil && len(cfg.BidValidation.BlockedCreativeIDs) > 0 {
+		blockedCreatives = NewBlockedCreatives()
+	}
+	if blockedCreatives != nil {
+		for _, crid := range cfg.BidValidation.BlockedCreativeIDs {
+			blockedCreatives.Set(crid, true)
+		}
+		e.bidValidators = append([]BidValidator{blockedCreatives}, e.bidValidators...)
+	}
+	e.creativeRewriters = plugins.CreativeRewriters
+	e.seatBidPreprocessors = plugins.SeatBidPreprocessors
+	e.floorProvider = plugins.FloorProvider
+	e.disabledBidders = plugins.DisabledBidders
+	e.targetingKeyGenerator = plugins.TargetingKeyGenerator
+	e.rejectionLogger = plugins.RejectionLogger
+	if e.floorProvider == nil {
+		e.floorProvider = impFloorProvider{}
+	}
+	e.bidderValidators = make(map[openrtb_ext.BidderName][]BidValidator, len(e.adapterMap))
+	e.openrtbVersions = make(map[openrtb_ext.BidderName]string)
+	e.bidderCurrencies = make(map[openrtb_ext.BidderName][]string)
+	for bidderName := range e.adapterMap {
+		e.bidderValidators[bidderName] = BidderValidators(bidderName, infos[string(bidderName)], e.bidValidators)
+		if info := infos[string(bidderName)]; info.OpenRTB != nil && info.OpenRTB.Version != "" {
+			e.openrtbVersions[bidderName] = info.OpenRTB.Version
+		}
+		if currencies := cfg.Adapters[strings.ToLower(string(bidderName))].Currencies; len(currencies) > 0 {
+			e.bidderCurrencies[bidderName] = currencies
+		}
+	}
+	if len(cfg.AllowedBidders) > 0 {
+		e.allowedBidders = make(map[openrtb_ext.BidderName]struct{}, len(cfg.AllowedBidders))
+		for _, bidder := range cfg.AllowedBidders {
+			e.allowedBidders[openrtb_ext.BidderName(bidder)] = struct{}{}
+		}
+	}
 	return e
 }
 
@@ -81,9 +236,14 @@ func (e *exchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidReque
 		}
 	}
 
+	// Floors must be decided before the request is copied for each bidder.
+	floorErrs := applyFloors(bidRequest, e.floorProvider)
+
 	// Slice of BidRequests, each a copy of the original cleaned to only contain bidder data for the named bidder
 	blabels := make(map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels)
 	cleanRequests, aliases, errs := CleanOpenRTBRequests(ctx, bidRequest, usersyncs, blabels, labels, e.gDPR, e.UsersyncIfAmbiguous)
+	errs = append(errs, floorErrs...)
+	excludedBidders := e.removeDisallowedBidders(cleanRequests, aliases)
 
 	// List of bidders we have requests for.
 	liveAdapters := make([]openrtb_ext.BidderName, len(cleanRequests))
@@ -99,6 +259,13 @@ func (e *exchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidReque
 	shouldCacheBids := false
 	shouldCacheVAST := false
 	var bidAdjustmentFactors map[string]float64
+	var mediaTypeBidAdjustmentFactors map[string]map[openrtb_ext.BidType]float64
+	var validation *openrtb_ext.ExtRequestValidation
+	var dealTiers map[string]openrtb_ext.DealTier
+	shouldDedupeCategories := false
+	preferDeals := false
+	maxBidsPerImp := e.maxBidsPerImp
+	debug := bidRequest.Test == 1
 	if len(bidRequest.Ext) > 0 {
 		var requestExt openrtb_ext.ExtRequest
 		err := json.Unmarshal(bidRequest.Ext, &requestExt)
@@ -106,6 +273,15 @@ func (e *exchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidReque
 			return nil, fmt.Errorf("Error decoding Request.ext : %s", err.Error())
 		}
 		bidAdjustmentFactors = requestExt.Prebid.BidAdjustmentFactors
+		mediaTypeBidAdjustmentFactors = requestExt.Prebid.MediaTypeBidAdjustmentFactors
+		validation = requestExt.Prebid.Validation
+		dealTiers = requestExt.Prebid.DealTiers
+		debug = debug || requestExt.Prebid.Debug
+		shouldDedupeCategories = requestExt.Prebid.DedupeCategories
+		// Requests may lower the host's limit, but not raise it.
+		if requestExt.Prebid.MaxBidsPerImp > 0 && (maxBidsPerImp == 0 || requestExt.Prebid.MaxBidsPerImp < maxBidsPerImp) {
+			maxBidsPerImp = requestExt.Prebid.MaxBidsPerImp
+		}
 		if requestExt.Prebid.Cache != nil {
 			shouldCacheBids = requestExt.Prebid.Cache.Bids != nil
 			shouldCacheVAST = requestExt.Prebid.Cache.VastXML != nil
@@ -116,7 +292,11 @@ func (e *exchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidReque
 				PriceGranularity:  requestExt.Prebid.Targeting.PriceGranularity,
 				IncludeWinners:    requestExt.Prebid.Targeting.IncludeWinners,
 				IncludeBidderKeys: requestExt.Prebid.Targeting.IncludeBidderKeys,
+
+				MediaTypePriceGranularity: requestExt.Prebid.Targeting.MediaTypePriceGranularity,
+				KeyGenerator:              e.targetingKeyGenerator,
 			}
+			preferDeals = requestExt.Prebid.Targeting.PreferDeals
 			if shouldCacheBids {
 				targData.IncludeCacheBids = true
 			}
@@ -131,18 +311,146 @@ func (e *exchange) HoldAuction(ctx context.Context, bidRequest *openrtb.BidReque
 	auctionCtx, cancel := e.makeAuctionContext(ctx, shouldCacheBids)
 	defer cancel()
 
-	adapterBids, adapterExtra := e.getAllBids(auctionCtx, cleanRequests, aliases, bidAdjustmentFactors, blabels)
-	auc := NewAuction(adapterBids, len(bidRequest.Imp))
+	accountID, _ := toAccountId(bidRequest)
+	bidValidation := e.bidValidationFor(accountID)
+	// The request can't authorize itself to skip validation, so the account's permission is checked here.
+	validation, skipWarning := e.authorizeValidationSkip(validation, accountID)
+
+	// Every bidder's Bids are converted with the same rates, even if they're refreshed mid-auction.
+	conversions, ratesDecision := e.latestConversions(time.Now())
+	// Debug requests always get the rejected Bids, since they asked for them.
+	trackRejections := debug || sampleRejections(bidValidation.RejectionTelemetrySampling, rand.Intn)
+	adapterBids, adapterExtra := e.getAllBids(auctionCtx, cleanRequests, aliases, bidAdjustmentFactors, mediaTypeBidAdjustmentFactors, validation, bidValidation, conversions, blabels, trackRejections)
+	var prebidWarnings []error
+	if ratesDecision != nil {
+		prebidWarnings = append(prebidWarnings, &errortypes.Warning{Message: ratesDecision.Message})
+	}
+	if skipWarning != nil {
+		prebidWarnings = append(prebidWarnings, skipWarning)
+	}
+	if len(prebidWarnings) > 0 {
+		adapterExtra["prebid"] = &SeatResponseExtra{Warnings: ErrsToBidderErrors(prebidWarnings)}
+	}
+	for bidderName, exclusionWarnings := range excludedBidders {
+		adapterExtra[bidderName] = &SeatResponseExtra{Warnings: ErrsToBidderErrors(exclusionWarnings)}
+	}
+	for bidderName, mediaTypeWarnings := range limitMediaTypes(adapterBids, e.accounts[accountID].MediaTypes) {
+		adapterExtra[bidderName].Warnings = append(adapterExtra[bidderName].Warnings, ErrsToBidderErrors(mediaTypeWarnings)...)
+	}
+	// Publishers who accept several currencies still need the Bids to be comparable.
+	conversionCache := currencies.NewConversionCache(conversions)
+	responseCurrency := ""
+	var currencySelection *openrtb_ext.ExtResponseCurrencySelection
+	if len(bidRequest.Cur) > 1 {
+		candidates := bidRequest.Cur
+		if e.currencySelection == config.CurrencySelectionAccountDefault {
+			candidates = preferCurrency(candidates, bidValidation.DefaultCurrency)
+		}
+		responseCurrency = convertToRequestCurrency(candidates, adapterBids, conversionCache, e.currencySelection)
+		currencySelection = &openrtb_ext.ExtResponseCurrencySelection{Policy: e.currencySelection, Target: responseCurrency}
+	} else if len(bidRequest.Cur) == 0 && bidValidation.DefaultCurrency != "" && !strings.EqualFold(bidValidation.DefaultCurrency, "USD") {
+		// OpenRTB assumes USD, so the response has to say if the account's default currency was used instead.
+		responseCurrency = bidValidation.DefaultCurrency
+	}
+	// Categories are deduped once the prices are in one currency, so that the cheaper Bid is the one removed.
+	if shouldDedupeCategories {
+		for bidderName, dedupeErrs := range dedupeCategories(liveAdapters, adapterBids, conversionCache) {
+			adapterExtra[bidderName].Errors = append(adapterExtra[bidderName].Errors, ErrsToBidderErrors(dedupeErrs)...)
+			if trackRejections {
+				adapterExtra[bidderName].RejectedBids = append(adapterExtra[bidderName].RejectedBids, makeExtRejectedBids(dedupeErrs)...)
+			}
+		}
+	}
+	for bidderName, roundingErrs := range roundBidPrices(bidRequest, adapterBids, e.priceRounding, bidValidation.FloorTolerance, conversionCache) {
+		adapterExtra[bidderName].Errors = append(adapterExtra[bidderName].Errors, ErrsToBidderErrors(roundingErrs)...)
+		if trackRejections {
+			adapterExtra[bidderName].RejectedBids = append(adapterExtra[bidderName].RejectedBids, makeExtRejectedBids(roundingErrs)...)
+		}
+	}
+	setFinalPrices(adapterBids)
+	applyDealTiers(adapterBids, dealTiers)
+	for bidderName, limitWarnings := range limitBidsPerImp(liveAdapters, adapterBids, maxBidsPerImp) {
+		adapterExtra[bidderName].Warnings = append(adapterExtra[bidderName].Warnings, ErrsToBidderErrors(limitWarnings)...)
+	}
+	for bidderName, limitWarnings := range limitBidsPerBidder(adapterBids, e.maxBidsPerBidder) {
+		adapterExtra[bidderName].Warnings = append(adapterExtra[bidderName].Warnings, ErrsToBidderErrors(limitWarnings)...)
+	}
+	addEventURLs(adapterBids, e.events, accountID)
+	applySecondPriceClearing(adapterBids, bidRequest.AT)
+	auc := NewAuction(adapterBids, len(bidRequest.Imp), preferDeals)
 	if targData != nil {
-		auc.SetRoundedPrices(targData.PriceGranularity)
-		cacheErrs := auc.doCache(ctx, e.cache, targData.IncludeCacheBids, targData.IncludeCacheVast, bidRequest, 60, &e.defaultTTLs)
+		auc.SetRoundedPrices(targData.PriceGranularity, targData.MediaTypePriceGranularity)
+		applyCacheTTLs(adapterBids, bidRequest, &e.defaultTTLs, e.capTTLAtDefault, e.maxCacheTTL)
+		cacheErrs := auc.doCache(ctx, e.cache, targData.IncludeCacheBids, targData.IncludeCacheVast, 60, e.dedupeCreatives)
 		if len(cacheErrs) > 0 {
 			errs = append(errs, cacheErrs...)
 		}
 		targData.SetTargeting(auc, bidRequest.App != nil)
 	}
 	// Build the response
-	return e.buildBidResponse(ctx, liveAdapters, adapterBids, bidRequest, resolvedRequest, adapterExtra, errs)
+	bidResponse, err := e.buildBidResponse(ctx, liveAdapters, adapterBids, bidRequest, resolvedRequest, adapterExtra, errs, debug, ratesDecision, currencySelection)
+	if bidResponse != nil && responseCurrency != "" {
+		bidResponse.Cur = responseCurrency
+	}
+	return bidResponse, err
+}
+
+// bidValidationFor returns the host's bid validation config, with any overrides from the publisher's account.
+func (e *exchange) bidValidationFor(accountID string) config.BidValidation {
+	bidValidation := e.bidValidation
+	account, ok := e.accounts[accountID]
+	if !ok {
+		return bidValidation
+	}
+	if account.DefaultCurrency != "" {
+		bidValidation.DefaultCurrency = account.DefaultCurrency
+	}
+	if len(account.BannerMIMEs) > 0 {
+		bidValidation.BannerMIMEs = account.BannerMIMEs
+	}
+	if account.ValidationMode != "" {
+		bidValidation.Mode = account.ValidationMode
+	}
+	return bidValidation
+}
+
+// authorizeValidationSkip returns the request's validation settings, with skipall turned off unless the publisher's
+// account is allowed to skip bid validation. If it was turned off, the returned warning explains why.
+func (e *exchange) authorizeValidationSkip(validation *openrtb_ext.ExtRequestValidation, accountID string) (*openrtb_ext.ExtRequestValidation, error) {
+	if validation == nil || !validation.SkipAll || e.accounts[accountID].AllowSkipValidation {
+		return validation, nil
+	}
+	authorized := *validation
+	authorized.SkipAll = false
+	return &authorized, &errortypes.Warning{
+		Message: fmt.Sprintf("request.ext.prebid.validation.skipall was ignored, because account \"%s\" isn't allowed to skip bid validation", accountID),
+	}
+}
+
+// removeDisallowedBidders deletes the requests for bidders which the host doesn't allow, or which have been disabled,
+// so that they're never called. Aliases follow their core bidder. The returned warnings are keyed by the name used in the request.
+func (e *exchange) removeDisallowedBidders(cleanRequests map[openrtb_ext.BidderName]*openrtb.BidRequest, aliases map[string]string) map[openrtb_ext.BidderName][]error {
+	if e.allowedBidders == nil && e.disabledBidders == nil {
+		return nil
+	}
+	var excluded map[openrtb_ext.BidderName][]error
+	for bidderName := range cleanRequests {
+		coreBidder := ResolveBidder(string(bidderName), aliases)
+		var message string
+		if _, ok := e.allowedBidders[coreBidder]; !ok && e.allowedBidders != nil {
+			message = fmt.Sprintf("Bidder %s was not called because this Prebid Server doesn't allow it", coreBidder)
+		} else if e.disabledBidders.Contains(coreBidder) {
+			message = fmt.Sprintf("Bidder %s was not called because it has been disabled", coreBidder)
+		} else {
+			continue
+		}
+		if excluded == nil {
+			excluded = make(map[openrtb_ext.BidderName][]error)
+		}
+		excluded[bidderName] = []error{&errortypes.Warning{Message: message}}
+		delete(cleanRequests, bidderName)
+	}
+	return excluded
 }
 
 func (e *exchange) makeAuctionContext(ctx context.Context, needsCache bool) (auctionCtx context.Context, cancel func()) {
@@ -157,7 +465,8 @@ func (e *exchange) makeAuctionContext(ctx context.Context, needsCache bool) (auc
 }
 
 // This piece sends all the requests to the bidder adapters and gathers the results.
-func (e *exchange) getAllBids(ctx context.Context, cleanRequests map[openrtb_ext.BidderName]*openrtb.BidRequest, aliases map[string]string, bidAdjustments map[string]float64, blabels map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels) (map[openrtb_ext.BidderName]*PBSOrtbSeatBid, map[openrtb_ext.BidderName]*SeatResponseExtra) {
+// If trackRejections is false, invalid Bids are still removed, but they aren't recorded in the metrics or the debug ext.
+func (e *exchange) getAllBids(ctx context.Context, cleanRequests map[openrtb_ext.BidderName]*openrtb.BidRequest, aliases map[string]string, bidAdjustments map[string]float64, mediaTypeBidAdjustments map[string]map[openrtb_ext.BidType]float64, validation *openrtb_ext.ExtRequestValidation, bidValidation config.BidValidation, conversions currencies.Conversions, blabels map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels, trackRejections bool) (map[openrtb_ext.BidderName]*PBSOrtbSeatBid, map[openrtb_ext.BidderName]*SeatResponseExtra) {
 	// Set up pointers to the bid results
 	adapterBids := make(map[openrtb_ext.BidderName]*PBSOrtbSeatBid, len(cleanRequests))
 	adapterExtra := make(map[openrtb_ext.BidderName]*SeatResponseExtra, len(cleanRequests))
@@ -180,26 +489,100 @@ func (e *exchange) getAllBids(ctx context.Context, cleanRequests map[openrtb_ext
 			}()
 			start := time.Now()
 
-			adjustmentFactor := 1.0
+			adjustments := BidAdjustments{
+				Factor:           1.0,
+				MediaTypeFactors: mediaTypeBidAdjustments[string(aName)],
+			}
 			if givenAdjustment, ok := bidAdjustments[string(aName)]; ok {
-				adjustmentFactor = givenAdjustment
+				adjustments.Factor = givenAdjustment
+			}
+			bidderCtx := ctx
+			if deadline, ok := impTimeoutDeadline(ctx, request, start); ok {
+				var cancelBidder context.CancelFunc
+				bidderCtx, cancelBidder = context.WithDeadline(ctx, deadline)
+				defer cancelBidder()
 			}
-			bids, err := e.adapterMap[coreBidder].RequestBid(ctx, request, aName, adjustmentFactor)
+			bids, err := e.adapterMap[coreBidder].RequestBid(bidderCtx, request, aName, adjustments)
 
 			// Add in time reporting
 			elapsed := time.Since(start)
 			brw.AdapterBids = bids
+			useDefaultCurrency := bids != nil && len(request.Cur) == 0 && bidValidation.DefaultCurrency != ""
+			if useDefaultCurrency && (bids.Currency == "" || bids.DefaultedCurrency) {
+				// Neither the request nor the bidder named a currency, so the Bids are in the account's default one.
+				bids.Currency = bidValidation.DefaultCurrency
+				bids.DefaultedCurrency = false
+			}
+			if bids != nil {
+				bids.OpenRTBVersion = e.openrtbVersions[coreBidder]
+				bids.AllowedCurrencies = e.bidderCurrencies[coreBidder]
+			}
+			// The bidder's adjustment has already been applied, so the currency's stacks on top of it.
+			applyCurrencyAdjustments(bids, e.currencyAdjustments)
+			conversionCache := currencies.NewConversionCache(conversions)
+			if useDefaultCurrency {
+				// Bids in another currency are converted into the account's default one. If there's no rate,
+				// validation rejects them instead.
+				convertSeatBid(bids, bidValidation.DefaultCurrency, conversionCache)
+			}
+			if preprocessErr := brw.PreprocessSeatBid(request, e.seatBidPreprocessors); preprocessErr != nil {
+				err = append(err, preprocessErr)
+			}
+			seatSize := 0
+			if bids != nil {
+				seatSize = len(bids.Bids)
+			}
+			var impsByBidID map[string]string
+			if bidValidation.ReportNoBidReasons {
+				impsByBidID = mapBidsToImps(bids)
+			}
 			// validate bids ASAP, so we don't waste time on invalid bids.
-			err2 := brw.ValidateBids(request)
+			var err2 []error
+			if seatErr := resolveSeat(brw.AdapterBids, aName, coreBidder, bidValidation.MissingSeat == config.MissingSeatDrop); seatErr != nil {
+				err2 = append(err2, seatErr)
+			}
+			// Infer missing types first, so the validators can check the Bids against them.
+			inferBidTypes(request, brw.AdapterBids)
+			var validationErrs, warnings []error
+			// Trusted demand skips validation entirely. HoldAuction has already checked that the account allows it.
+			if validation == nil || !validation.SkipAll {
+				validationErrs, warnings = brw.ValidateBids(request, validation, bidValidation, conversionCache, e.validatorsFor(coreBidder))
+			}
+			err2 = append(err2, validationErrs...)
+			err2 = append(err2, brw.RewriteCreatives(request, validation, bidValidation, conversionCache, e.creativeRewriters)...)
+			stripBidExts(brw.AdapterBids, bidValidation.StripBidExt)
+			if bidValidation.NormalizeBidExt {
+				normalizeBidExts(brw.AdapterBids)
+			}
+			signCreatives(brw.AdapterBids, e.signingSecret)
+			newRequestLogger(request.ID, e.rejectionLogger).logRejections(aName, err2)
 			if len(err2) > 0 {
+				if trackRejections {
+					recordRejectedBids(e.me, *bidlabels, err2, seatSize)
+				}
 				err = append(err, err2...)
 			}
+			recordResponseSize(e.me, *bidlabels, brw.AdapterBids)
+			inferBidSizes(request, brw.AdapterBids)
 			// Structure to record extra tracking data generated during bidding
 			ae := new(SeatResponseExtra)
+			if len(warnings) > 0 {
+				ae.Warnings = ErrsToBidderErrors(warnings)
+			}
+			if trackRejections {
+				ae.RejectedBids = makeExtRejectedBids(err2)
+			}
+			if bidValidation.ReportNoBidReasons {
+				ae.RejectionsByImp = rejectionsByImp(err2, impsByBidID)
+			}
 			ae.ResponseTimeMillis = int(elapsed / time.Millisecond)
 			// Timing statistics
 			e.me.RecordAdapterTime(*bidlabels, time.Since(start))
-			serr := ErrsToBidderErrors(err)
+			reportedErrs := err
+			if bidValidation.SummarizeRejections {
+				reportedErrs = summarizeRejections(err)
+			}
+			serr := ErrsToBidderErrors(reportedErrs)
 			bidlabels.AdapterBids = BidsToMetric(brw.AdapterBids)
 			bidlabels.AdapterErrors = ErrorsToMetric(err)
 			// Append any bid validation errors to the error list
@@ -226,6 +609,31 @@ func (e *exchange) getAllBids(ctx context.Context, cleanRequests map[openrtb_ext
 	return adapterBids, adapterExtra
 }
 
+// validatorsFor returns the BidValidators which should run on the Bids from the given core bidder.
+func (e *exchange) validatorsFor(coreBidder openrtb_ext.BidderName) []BidValidator {
+	if validators, ok := e.bidderValidators[coreBidder]; ok {
+		return validators
+	}
+	return e.bidValidators
+}
+
+// latestConversions returns the currency conversion rates which an auction should use, after applying the staleness policy.
+// Conversions which those rates can't make use the fallback rates, unless the policy rejected stale rates.
+// The decision is nil unless the rates were stale or missing. Callers should wrap the result in a ConversionCache.
+func (e *exchange) latestConversions(now time.Time) (currencies.Conversions, *currencies.StalenessDecision) {
+	var rates *currencies.Rates
+	var lastUpdated time.Time
+	if e.currencyConverter != nil {
+		rates = e.currencyConverter.Rates()
+		lastUpdated = e.currencyConverter.LastUpdated()
+	}
+	conversions, decision := e.ratesStaleness.Apply(rates, lastUpdated, now)
+	if e.fallbackRates != nil && (decision == nil || decision.Policy != currencies.StalenessPolicyReject) {
+		conversions = currencies.NewFallbackConversions(conversions, e.fallbackRates)
+	}
+	return conversions, decision
+}
+
 func RecoverSafely(inner func(openrtb_ext.BidderName, openrtb_ext.BidderName, *openrtb.BidRequest, *pbsmetrics.AdapterLabels), chBids chan *BidResponseWrapper) func(openrtb_ext.BidderName, openrtb_ext.BidderName, *openrtb.BidRequest, *pbsmetrics.AdapterLabels) {
 	return func(aName openrtb_ext.BidderName, coreBidder openrtb_ext.BidderName, request *openrtb.BidRequest, bidlabels *pbsmetrics.AdapterLabels) {
 		defer func() {
@@ -248,6 +656,48 @@ func BidsToMetric(bids *PBSOrtbSeatBid) pbsmetrics.AdapterBid {
 	return pbsmetrics.AdapterBidPresent
 }
 
+// recordRejectedBids records the reason for each Bid which ValidateBids removed from a SeatBid of seatSize Bids.
+// Errors which don't explain their reason must have come from the host's BidValidators.
+func recordRejectedBids(me pbsmetrics.MetricsEngine, labels pbsmetrics.AdapterLabels, errs []error, seatSize int) {
+	for _, err := range errs {
+		rejection, ok := err.(*BidRejectionError)
+		if !ok {
+			me.RecordAdapterBidRejected(labels, pbsmetrics.BidRejectionCustom)
+			continue
+		}
+		for i := 0; i < rejectedBidCount(rejection, seatSize); i++ {
+			me.RecordAdapterBidRejected(labels, rejection.Reason)
+		}
+	}
+}
+
+// rejectedBidCount returns the number of Bids which the rejection removed from a SeatBid of seatSize Bids.
+func rejectedBidCount(rejection *BidRejectionError, seatSize int) int {
+	// Unsupported seat currencies reject every Bid in the seat at once. Per-bid currency checks name their Bid.
+	if rejection.Reason == pbsmetrics.BidRejectionCurrencyNotAllowed && rejection.BidID == "" {
+		return seatSize
+	}
+	return 1
+}
+
+// recordResponseSize records the size of the Bids which are left in the seatBid.
+// This should be called after the Bids have been validated, so that rejected Bids aren't counted.
+//
+// The markup and extensions make up nearly all of a Bid, so the size is estimated from those
+// rather than serializing every Bid in the auction.
+func recordResponseSize(me pbsmetrics.MetricsEngine, labels pbsmetrics.AdapterLabels, seatBid *PBSOrtbSeatBid) {
+	if seatBid == nil {
+		return
+	}
+	size := 0
+	for _, bid := range seatBid.Bids {
+		if bid.Bid != nil {
+			size += len(bid.Bid.AdM) + len(bid.Bid.Ext)
+		}
+	}
+	me.RecordAdapterResponseSize(labels, size)
+}
+
 func ErrorsToMetric(errs []error) map[pbsmetrics.AdapterError]struct{} {
 	if len(errs) == 0 {
 		return nil
@@ -281,7 +731,7 @@ func ErrsToBidderErrors(errs []error) []openrtb_ext.ExtBidderError {
 }
 
 // This piece takes all the bids supplied by the adapters and crafts an openRTB response to send back to the requester
-func (e *exchange) buildBidResponse(ctx context.Context, liveAdapters []openrtb_ext.BidderName, adapterBids map[openrtb_ext.BidderName]*PBSOrtbSeatBid, bidRequest *openrtb.BidRequest, resolvedRequest json.RawMessage, adapterExtra map[openrtb_ext.BidderName]*SeatResponseExtra, errList []error) (*openrtb.BidResponse, error) {
+func (e *exchange) buildBidResponse(ctx context.Context, liveAdapters []openrtb_ext.BidderName, adapterBids map[openrtb_ext.BidderName]*PBSOrtbSeatBid, bidRequest *openrtb.BidRequest, resolvedRequest json.RawMessage, adapterExtra map[openrtb_ext.BidderName]*SeatResponseExtra, errList []error, debug bool, ratesDecision *currencies.StalenessDecision, currencySelection *openrtb_ext.ExtResponseCurrencySelection) (*openrtb.BidResponse, error) {
 	bidResponse := new(openrtb.BidResponse)
 
 	bidResponse.ID = bidRequest.ID
@@ -302,16 +752,19 @@ func (e *exchange) buildBidResponse(ctx context.Context, liveAdapters []openrtb_
 
 	bidResponse.SeatBid = seatBids
 
-	bidResponseExt := e.makeExtBidResponse(adapterBids, adapterExtra, bidRequest, resolvedRequest, errList)
+	bidResponseExt := e.makeExtBidResponse(adapterBids, adapterExtra, bidRequest, resolvedRequest, errList, debug, ratesDecision, currencySelection)
 	ext, err := json.Marshal(bidResponseExt)
 	bidResponse.Ext = ext
 	return bidResponse, err
 }
 
 // Extract all the data from the SeatBids and build the ExtBidResponse
-func (e *exchange) makeExtBidResponse(adapterBids map[openrtb_ext.BidderName]*PBSOrtbSeatBid, adapterExtra map[openrtb_ext.BidderName]*SeatResponseExtra, req *openrtb.BidRequest, resolvedRequest json.RawMessage, errList []error) *openrtb_ext.ExtBidResponse {
+// If debug is true, the rejected Bids, any stale currency rates and the currency selection are added to the debug ext.
+// Test requests always get debug info.
+func (e *exchange) makeExtBidResponse(adapterBids map[openrtb_ext.BidderName]*PBSOrtbSeatBid, adapterExtra map[openrtb_ext.BidderName]*SeatResponseExtra, req *openrtb.BidRequest, resolvedRequest json.RawMessage, errList []error, debug bool, ratesDecision *currencies.StalenessDecision, currencySelection *openrtb_ext.ExtResponseCurrencySelection) *openrtb_ext.ExtBidResponse {
 	bidResponseExt := &openrtb_ext.ExtBidResponse{
 		Errors:             make(map[openrtb_ext.BidderName][]openrtb_ext.ExtBidderError, len(adapterBids)),
+		Warnings:           make(map[openrtb_ext.BidderName][]openrtb_ext.ExtBidderError),
 		ResponseTimeMillis: make(map[openrtb_ext.BidderName]int, len(adapterBids)),
 	}
 	if req.Test == 1 {
@@ -322,6 +775,20 @@ func (e *exchange) makeExtBidResponse(adapterBids map[openrtb_ext.BidderName]*PB
 			glog.Errorf("Error unmarshalling bid request snapshot: %v", err)
 		}
 	}
+	if debug {
+		if bidResponseExt.Debug == nil {
+			bidResponseExt.Debug = &openrtb_ext.ExtResponseDebug{}
+		}
+		bidResponseExt.Debug.RejectedBids = make(map[openrtb_ext.BidderName][]openrtb_ext.ExtRejectedBid)
+		if ratesDecision != nil {
+			bidResponseExt.Debug.CurrencyRates = &openrtb_ext.ExtResponseCurrencyRates{
+				Policy:     string(ratesDecision.Policy),
+				AgeSeconds: int64(ratesDecision.Age / time.Second),
+				Message:    ratesDecision.Message,
+			}
+		}
+		bidResponseExt.Debug.CurrencySelection = currencySelection
+	}
 
 	for a, b := range adapterBids {
 		if b != nil {
@@ -334,6 +801,9 @@ func (e *exchange) makeExtBidResponse(adapterBids map[openrtb_ext.BidderName]*PB
 		if len(adapterExtra[a].Errors) > 0 {
 			bidResponseExt.Errors[a] = adapterExtra[a].Errors
 		}
+		if debug && len(adapterExtra[a].RejectedBids) > 0 {
+			bidResponseExt.Debug.RejectedBids[a] = adapterExtra[a].RejectedBids
+		}
 		if len(errList) > 0 {
 			bidResponseExt.Errors["prebid"] = ErrsToBidderErrors(errList)
 		}
@@ -341,6 +811,17 @@ func (e *exchange) makeExtBidResponse(adapterBids map[openrtb_ext.BidderName]*PB
 		// Defering the filling of bidResponseExt.Usersync[a] until later
 
 	}
+	// Bidders which the host excluded have warnings, but no adapterBids.
+	for a, extra := range adapterExtra {
+		if len(extra.Warnings) > 0 {
+			bidResponseExt.Warnings[a] = extra.Warnings
+		}
+	}
+	if noBidReasons := makeExtNoBidReasons(adapterBids, adapterExtra); len(noBidReasons) > 0 {
+		bidResponseExt.Prebid = &openrtb_ext.ExtResponsePrebid{
+			NoBidReasons: noBidReasons,
+		}
+	}
 	return bidResponseExt
 }
 
@@ -385,8 +866,16 @@ func (e *exchange) makeBid(Bids []*PBSOrtbBid, adapter openrtb_ext.BidderName) (
 		bidExt := &openrtb_ext.ExtBid{
 			Bidder: thisBid.Bid.Ext,
 			Prebid: &openrtb_ext.ExtBidPrebid{
-				Targeting: thisBid.BidTargets,
-				Type:      thisBid.BidType,
+				Targeting:         thisBid.BidTargets,
+				Type:              thisBid.BidType,
+				Video:             thisBid.BidVideo,
+				Meta:              thisBid.BidMeta,
+				OriginalBidCPM:    thisBid.OriginalPrice,
+				OriginalBidCur:    thisBid.OriginalCurrency,
+				DealPriority:      thisBid.DealPriority,
+				DealTierSatisfied: thisBid.DealTierSatisfied,
+				Events:            thisBid.Events,
+				ClearingPrice:     thisBid.ClearingPrice,
 			},
 		}
 
@@ -400,92 +889,3 @@ func (e *exchange) makeBid(Bids []*PBSOrtbBid, adapter openrtb_ext.BidderName) (
 	}
 	return bids, errList
 }
-
-// ValidateBids will run some validation checks on the returned bids and excise any invalid bids
-func (brw *BidResponseWrapper) ValidateBids(request *openrtb.BidRequest) (err []error) {
-	// Exit early if there is nothing to do.
-	if brw.AdapterBids == nil || len(brw.AdapterBids.Bids) == 0 {
-		return
-	}
-
-	err = make([]error, 0, len(brw.AdapterBids.Bids))
-
-	// By design, default currency is USD.
-	if cerr := validateCurrency(request.Cur, brw.AdapterBids.Currency); cerr != nil {
-		brw.AdapterBids.Bids = nil
-		err = append(err, cerr)
-		return
-	}
-
-	validBids := make([]*PBSOrtbBid, 0, len(brw.AdapterBids.Bids))
-	for _, bid := range brw.AdapterBids.Bids {
-		if ok, berr := validateBid(bid); ok {
-			validBids = append(validBids, bid)
-		} else {
-			err = append(err, berr)
-		}
-	}
-	if len(validBids) != len(brw.AdapterBids.Bids) {
-		// If all bids are valid, the two slices should be equal. Otherwise replace the list of bids with the valid bids.
-		brw.AdapterBids.Bids = validBids
-	}
-	return err
-}
-
-// validateCurrency will run currency validation checks and return true if it passes, false otherwise.
-func validateCurrency(requestAllowedCurrencies []string, bidCurrency string) error {
-	// Default currency is `USD` by design.
-	defaultCurrency := "USD"
-	// Make sure bid currency is a valid ISO currency code
-	if bidCurrency == "" {
-		// If bid currency is not set, then consider it's default currency.
-		bidCurrency = defaultCurrency
-	}
-	currencyUnit, cerr := currency.ParseISO(bidCurrency)
-	if cerr != nil {
-		return cerr
-	}
-	// Make sure the bid currency is allowed from bid request via `cur` field.
-	// If `cur` field array from bid request is empty, then consider it accepts the default currency.
-	currencyAllowed := false
-	if len(requestAllowedCurrencies) == 0 {
-		requestAllowedCurrencies = []string{defaultCurrency}
-	}
-	for _, allowedCurrency := range requestAllowedCurrencies {
-		if strings.ToUpper(allowedCurrency) == currencyUnit.String() {
-			currencyAllowed = true
-			break
-		}
-	}
-	if currencyAllowed == false {
-		return fmt.Errorf(
-			"Bid currency is not allowed. Was '%s', wants: ['%s']",
-			currencyUnit.String(),
-			strings.Join(requestAllowedCurrencies, "', '"),
-		)
-	}
-
-	return nil
-}
-
-// validateBid will run the supplied bid through validation checks and return true if it passes, false otherwise.
-func validateBid(bid *PBSOrtbBid) (bool, error) {
-	if bid.Bid == nil {
-		return false, fmt.Errorf("Empty bid object submitted.")
-	}
-	// These are the three required fields for bids
-	if bid.Bid.ID == "" {
-		return false, fmt.Errorf("Bid missing required field 'id'")
-	}
-	if bid.Bid.ImpID == "" {
-		return false, fmt.Errorf("Bid \"%s\" missing required field 'impid'", bid.Bid.ID)
-	}
-	if bid.Bid.Price <= 0.0 {
-		return false, fmt.Errorf("Bid \"%s\" does not contain a positive 'price'", bid.Bid.ID)
-	}
-	if bid.Bid.CrID == "" {
-		return false, fmt.Errorf("Bid \"%s\" missing creative ID", bid.Bid.ID)
-	}
-
-	return true, nil
-}
@@ -19,6 +19,7 @@ import (
 	"github.com/buger/jsonparser"
 	"github.com/mxmCherry/openrtb"
 	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/currencies"
 	"github.com/prebid/prebid-server/gdpr"
 	"github.com/prebid/prebid-server/openrtb_ext"
 	"github.com/prebid/prebid-server/pbsmetrics"
@@ -42,7 +43,7 @@ func TestNewExchange(t *testing.T) {
 		},
 	}
 
-	e := NewExchange(server.Client(), nil, cfg, pbsmetrics.NewMetrics(metrics.NewRegistry(), knownAdapters), adapters.ParseBidderInfos("../static/bidder-info", openrtb_ext.BidderList()), gdpr.AlwaysAllow{}).(*exchange)
+	e := NewExchange(server.Client(), nil, cfg, pbsmetrics.NewMetrics(metrics.NewRegistry(), knownAdapters), adapters.ParseBidderInfos("../static/bidder-info", openrtb_ext.BidderList()), gdpr.AlwaysAllow{}, Plugins{}).(*exchange)
 	for _, bidderName := range knownAdapters {
 		if _, ok := e.adapterMap[bidderName]; !ok {
 			t.Errorf("NewExchange produced an Exchange without bidder %s", bidderName)
@@ -82,7 +83,7 @@ func TestRaceIntegration(t *testing.T) {
 	}
 
 	theMetrics := pbsmetrics.NewMetrics(metrics.NewRegistry(), openrtb_ext.BidderList())
-	ex := NewExchange(server.Client(), &wellBehavedCache{}, cfg, theMetrics, adapters.ParseBidderInfos("../static/bidder-info", openrtb_ext.BidderList()), gdpr.AlwaysAllow{})
+	ex := NewExchange(server.Client(), &wellBehavedCache{}, cfg, theMetrics, adapters.ParseBidderInfos("../static/bidder-info", openrtb_ext.BidderList()), gdpr.AlwaysAllow{}, Plugins{})
 	_, err := ex.HoldAuction(context.Background(), newRaceCheckingRequest(t), &emptyUsersync{}, pbsmetrics.Labels{})
 	if err != nil {
 		t.Errorf("HoldAuction returned unexpected error: %v", err)
@@ -191,7 +192,7 @@ func TestPanicRecoveryHighLevel(t *testing.T) {
 			Endpoint: server.URL,
 		}
 	}
-	e := NewExchange(server.Client(), nil, cfg, pbsmetrics.NewMetrics(metrics.NewRegistry(), openrtb_ext.BidderList()), adapters.ParseBidderInfos("../static/bidder-info", openrtb_ext.BidderList()), gdpr.AlwaysAllow{}).(*exchange)
+	e := NewExchange(server.Client(), nil, cfg, pbsmetrics.NewMetrics(metrics.NewRegistry(), openrtb_ext.BidderList()), adapters.ParseBidderInfos("../static/bidder-info", openrtb_ext.BidderList()), gdpr.AlwaysAllow{}, Plugins{}).(*exchange)
 
 	e.adapterMap[openrtb_ext.BidderBeachfront] = panicingAdapter{}
 	e.adapterMap[openrtb_ext.BidderAppnexus] = panicingAdapter{}
@@ -231,6 +232,183 @@ func TestPanicRecoveryHighLevel(t *testing.T) {
 
 }
 
+func TestAllowedBidders(t *testing.T) {
+	appnexus := &countingAdapter{}
+	rubicon := &countingAdapter{}
+	e := &exchange{
+		adapterMap: map[openrtb_ext.BidderName]AdaptedBidder{
+			openrtb_ext.BidderAppnexus: appnexus,
+			openrtb_ext.BidderRubicon:  rubicon,
+		},
+		me:   &metricsConf.DummyMetricsEngine{},
+		gDPR: gdpr.AlwaysAllow{},
+		allowedBidders: map[openrtb_ext.BidderName]struct{}{
+			openrtb_ext.BidderAppnexus: {},
+		},
+	}
+	request := &openrtb.BidRequest{
+		Site: &openrtb.Site{
+			Page: "www.some.domain.com",
+		},
+		Imp: []openrtb.Imp{{
+			ID:     "some-imp-id",
+			Banner: &openrtb.Banner{},
+			Ext:    json.RawMessage(`{"appnexus":{"placementId":1},"rubicon":{"accountId":1},"rubi-alias":{"accountId":1}}`),
+		}},
+		Ext: json.RawMessage(`{"prebid":{"aliases":{"rubi-alias":"rubicon"}}}`),
+	}
+
+	response, err := e.HoldAuction(context.Background(), request, &emptyUsersync{}, pbsmetrics.Labels{})
+	if err != nil {
+		t.Fatalf("HoldAuction returned unexpected error: %v", err)
+	}
+	if appnexus.calls != 1 {
+		t.Errorf("appnexus is allowed, so it should be called once. Got %d calls", appnexus.calls)
+	}
+	if rubicon.calls != 0 {
+		t.Errorf("rubicon is not allowed, so it should never be called. Got %d calls", rubicon.calls)
+	}
+
+	var responseExt openrtb_ext.ExtBidResponse
+	if err := json.Unmarshal(response.Ext, &responseExt); err != nil {
+		t.Fatalf("Failed to unmarshal the response ext: %v", err)
+	}
+	for _, excluded := range []openrtb_ext.BidderName{"rubicon", "rubi-alias"} {
+		if len(responseExt.Warnings[excluded]) != 1 {
+			t.Errorf("Expected a warning for %s. Got %v", excluded, responseExt.Warnings[excluded])
+		}
+	}
+	if len(responseExt.Warnings[openrtb_ext.BidderAppnexus]) != 0 {
+		t.Errorf("Expected no warnings for appnexus. Got %v", responseExt.Warnings[openrtb_ext.BidderAppnexus])
+	}
+}
+
+func TestDisabledBidders(t *testing.T) {
+	appnexus := &countingAdapter{}
+	rubicon := &countingAdapter{}
+	disabled := NewDisabledBidders(openrtb_ext.BidderRubicon)
+	e := &exchange{
+		adapterMap: map[openrtb_ext.BidderName]AdaptedBidder{
+			openrtb_ext.BidderAppnexus: appnexus,
+			openrtb_ext.BidderRubicon:  rubicon,
+		},
+		me:              &metricsConf.DummyMetricsEngine{},
+		gDPR:            gdpr.AlwaysAllow{},
+		disabledBidders: disabled,
+	}
+	request := &openrtb.BidRequest{
+		Site: &openrtb.Site{
+			Page: "www.some.domain.com",
+		},
+		Imp: []openrtb.Imp{{
+			ID:     "some-imp-id",
+			Banner: &openrtb.Banner{},
+			Ext:    json.RawMessage(`{"appnexus":{"placementId":1},"rubicon":{"accountId":1}}`),
+		}},
+	}
+
+	response, err := e.HoldAuction(context.Background(), request, &emptyUsersync{}, pbsmetrics.Labels{})
+	if err != nil {
+		t.Fatalf("HoldAuction returned unexpected error: %v", err)
+	}
+	if appnexus.calls != 1 {
+		t.Errorf("appnexus is enabled, so it should be called once. Got %d calls", appnexus.calls)
+	}
+	if rubicon.calls != 0 {
+		t.Errorf("rubicon is disabled, so it should never be called. Got %d calls", rubicon.calls)
+	}
+	var responseExt openrtb_ext.ExtBidResponse
+	if err := json.Unmarshal(response.Ext, &responseExt); err != nil {
+		t.Fatalf("Failed to unmarshal the response ext: %v", err)
+	}
+	if len(responseExt.Warnings[openrtb_ext.BidderRubicon]) != 1 {
+		t.Errorf("Expected a warning for rubicon. Got %v", responseExt.Warnings[openrtb_ext.BidderRubicon])
+	}
+
+	// Enabling it again should take effect on the next auction.
+	disabled.Set(openrtb_ext.BidderRubicon, false)
+	if _, err := e.HoldAuction(context.Background(), request, &emptyUsersync{}, pbsmetrics.Labels{}); err != nil {
+		t.Fatalf("HoldAuction returned unexpected error: %v", err)
+	}
+	if rubicon.calls != 1 {
+		t.Errorf("rubicon was enabled again, so it should be called once. Got %d calls", rubicon.calls)
+	}
+}
+
+func TestSkipAllValidation(t *testing.T) {
+	skipTestCases := []struct {
+		description   string
+		allowSkip     bool
+		validationExt string
+		expectedBids  int
+		expectWarning bool
+	}{
+		{
+			description:   "Allowed accounts can skip validation",
+			allowSkip:     true,
+			validationExt: `{"skipall":true}`,
+			expectedBids:  1,
+		},
+		{
+			description:   "Requests can't skip validation without the account's permission",
+			validationExt: `{"skipall":true}`,
+			expectedBids:  0,
+			expectWarning: true,
+		},
+		{
+			description:   "Allowed accounts are still validated unless they ask to skip it",
+			allowSkip:     true,
+			validationExt: `{}`,
+			expectedBids:  0,
+		},
+	}
+
+	for _, tc := range skipTestCases {
+		e := &exchange{
+			adapterMap: map[openrtb_ext.BidderName]AdaptedBidder{
+				// The bid has no crid, so it would normally be rejected.
+				openrtb_ext.BidderAppnexus: &fixedBidder{bids: []*openrtb.Bid{{ID: "trusted-bid", ImpID: "some-imp-id", Price: 1, AdM: "<div>an ad</div>"}}},
+			},
+			me:   &metricsConf.DummyMetricsEngine{},
+			gDPR: gdpr.AlwaysAllow{},
+			accounts: map[string]config.Account{
+				"trusted-account": {AllowSkipValidation: tc.allowSkip},
+			},
+		}
+		request := &openrtb.BidRequest{
+			Site: &openrtb.Site{
+				Page:      "www.some.domain.com",
+				Publisher: &openrtb.Publisher{ID: "trusted-account"},
+			},
+			Imp: []openrtb.Imp{{
+				ID:     "some-imp-id",
+				Banner: &openrtb.Banner{},
+				Ext:    json.RawMessage(`{"appnexus":{"placementId":1}}`),
+			}},
+			Ext: json.RawMessage(`{"prebid":{"validation":` + tc.validationExt + `}}`),
+		}
+
+		response, err := e.HoldAuction(context.Background(), request, &emptyUsersync{}, pbsmetrics.Labels{})
+		if err != nil {
+			t.Fatalf("%s: HoldAuction returned unexpected error: %v", tc.description, err)
+		}
+		bids := 0
+		for _, seatBid := range response.SeatBid {
+			bids += len(seatBid.Bid)
+		}
+		if bids != tc.expectedBids {
+			t.Errorf("%s: expected %d bids. Got %d", tc.description, tc.expectedBids, bids)
+		}
+		var responseExt openrtb_ext.ExtBidResponse
+		if err := json.Unmarshal(response.Ext, &responseExt); err != nil {
+			t.Fatalf("%s: failed to unmarshal the response ext: %v", tc.description, err)
+		}
+		if hasWarning := len(responseExt.Warnings["prebid"]) > 0; hasWarning != tc.expectWarning {
+			t.Errorf("%s: expected a prebid warning: %t. Got %v", tc.description, tc.expectWarning, responseExt.Warnings["prebid"])
+		}
+	}
+}
+
 func TestTimeoutComputation(t *testing.T) {
 	cacheTimeMillis := 10
 	ex := exchange{
@@ -248,6 +426,370 @@ func TestTimeoutComputation(t *testing.T) {
 	}
 }
 
+func TestRecordRejectedBids(t *testing.T) {
+	me := &rejectionRecordingMetrics{}
+	labels := pbsmetrics.AdapterLabels{Adapter: openrtb_ext.BidderAppnexus}
+	errs := []error{
+		newBidRejection("one-bid", pbsmetrics.BidRejectionEmptyMarkup, "empty"),
+		newBidRejection("another-bid", pbsmetrics.BidRejectionBelowFloor, "too cheap"),
+		newBidRejection("third-bid", pbsmetrics.BidRejectionBelowFloor, "also too cheap"),
+		errors.New("rejected by the host"),
+	}
+	recordRejectedBids(me, labels, errs, 5)
+
+	assertRejectionCount(t, me, pbsmetrics.BidRejectionEmptyMarkup, 1)
+	assertRejectionCount(t, me, pbsmetrics.BidRejectionBelowFloor, 2)
+	assertRejectionCount(t, me, pbsmetrics.BidRejectionCustom, 1)
+	assertRejectionCount(t, me, pbsmetrics.BidRejectionBlockedDomain, 0)
+	for _, adapter := range me.adapters {
+		if adapter != openrtb_ext.BidderAppnexus {
+			t.Errorf("Rejections should be recorded for %s. Got %s", openrtb_ext.BidderAppnexus, adapter)
+		}
+	}
+}
+
+func TestRecordRejectedSeat(t *testing.T) {
+	me := &rejectionRecordingMetrics{}
+	errs := []error{
+		newBidRejection("", pbsmetrics.BidRejectionCurrencyNotAllowed, "bad currency"),
+	}
+	recordRejectedBids(me, pbsmetrics.AdapterLabels{Adapter: openrtb_ext.BidderAppnexus}, errs, 3)
+
+	assertRejectionCount(t, me, pbsmetrics.BidRejectionCurrencyNotAllowed, 3)
+}
+
+func TestRecordRejectedBidCurrency(t *testing.T) {
+	me := &rejectionRecordingMetrics{}
+	errs := []error{
+		newBidRejection("bad-bid", pbsmetrics.BidRejectionCurrencyNotAllowed, "bad currency"),
+	}
+	recordRejectedBids(me, pbsmetrics.AdapterLabels{Adapter: openrtb_ext.BidderAppnexus}, errs, 3)
+
+	assertRejectionCount(t, me, pbsmetrics.BidRejectionCurrencyNotAllowed, 1)
+}
+
+func TestRecordResponseSize(t *testing.T) {
+	me := &rejectionRecordingMetrics{}
+	labels := pbsmetrics.AdapterLabels{Adapter: openrtb_ext.BidderAppnexus}
+	bid := &openrtb.Bid{ID: "one-bid", ImpID: "some-imp", Price: 0.5, AdM: "<div>an ad</div>", Ext: json.RawMessage(`{"some":"ext"}`)}
+	bidSize := len(bid.AdM) + len(bid.Ext)
+	seatBid := &PBSOrtbSeatBid{
+		Bids: []*PBSOrtbBid{{Bid: bid}, {Bid: bid}},
+	}
+	recordResponseSize(me, labels, seatBid)
+	recordResponseSize(me, labels, &PBSOrtbSeatBid{})
+	recordResponseSize(me, labels, nil)
+
+	if len(me.sizes) != 2 {
+		t.Fatalf("Expected a size for each seat which got a response. Got %v", me.sizes)
+	}
+	if me.sizes[0] != 2*bidSize {
+		t.Errorf("Expected the size of both bids' markup and exts, %d bytes. Got %d", 2*bidSize, me.sizes[0])
+	}
+	if me.sizes[1] != 0 {
+		t.Errorf("Expected an empty seat to have size 0. Got %d", me.sizes[1])
+	}
+}
+
+func TestRejectionSampling(t *testing.T) {
+	for _, trackRejections := range []bool{true, false} {
+		me := &rejectionRecordingMetrics{}
+		e := &exchange{
+			adapterMap: map[openrtb_ext.BidderName]AdaptedBidder{
+				openrtb_ext.BidderAppnexus: &fixedBidder{bids: []*openrtb.Bid{
+					{ID: "good-bid", ImpID: "some-imp", Price: 1, CrID: "some-creative", AdM: "<div>an ad</div>"},
+					{ID: "bad-bid", ImpID: "some-imp", Price: 1, CrID: "some-creative"},
+				}},
+			},
+			me: me,
+		}
+		cleanRequests := map[openrtb_ext.BidderName]*openrtb.BidRequest{
+			openrtb_ext.BidderAppnexus: {Imp: newTestImps("some-imp")},
+		}
+		blabels := map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels{
+			openrtb_ext.BidderAppnexus: {Adapter: openrtb_ext.BidderAppnexus},
+		}
+
+		adapterBids, adapterExtra := e.getAllBids(context.Background(), cleanRequests, nil, nil, nil, nil, config.BidValidation{}, nil, blabels, trackRejections)
+
+		// The invalid bid must be removed whether or not it's sampled.
+		assertBidIDs(t, adapterBids[openrtb_ext.BidderAppnexus], []string{"good-bid"})
+		if len(adapterExtra[openrtb_ext.BidderAppnexus].Errors) != 1 {
+			t.Errorf("Expected the rejection to be reported in the errors. Got %v", adapterExtra[openrtb_ext.BidderAppnexus].Errors)
+		}
+		expectedRecords := 0
+		if trackRejections {
+			expectedRecords = 1
+		}
+		assertRejectionCount(t, me, pbsmetrics.BidRejectionEmptyMarkup, expectedRecords)
+		if len(adapterExtra[openrtb_ext.BidderAppnexus].RejectedBids) != expectedRecords {
+			t.Errorf("Expected %d rejected bids in the debug info. Got %v", expectedRecords, adapterExtra[openrtb_ext.BidderAppnexus].RejectedBids)
+		}
+	}
+}
+
+func TestSampleRejections(t *testing.T) {
+	neverZero := func(n int) int { return n - 1 }
+	alwaysZero := func(n int) int { return 0 }
+
+	if !sampleRejections(0, neverZero) || !sampleRejections(1, neverZero) {
+		t.Errorf("Every auction should be sampled if the rate is 0 or 1.")
+	}
+	if sampleRejections(100, neverZero) {
+		t.Errorf("The auction should not be sampled unless it's the 1 in 100.")
+	}
+	if !sampleRejections(100, alwaysZero) {
+		t.Errorf("The 1 in 100 auction should be sampled.")
+	}
+}
+
+func TestRejectedBidsDebug(t *testing.T) {
+	errs := []error{
+		newBidRejection("one-bid", pbsmetrics.BidRejectionBelowFloor, "too cheap"),
+		errors.New("rejected by the host"),
+	}
+	adapterBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {},
+	}
+	adapterExtra := map[openrtb_ext.BidderName]*SeatResponseExtra{
+		openrtb_ext.BidderAppnexus: {
+			RejectedBids: makeExtRejectedBids(errs),
+		},
+	}
+	e := &exchange{}
+
+	ext := e.makeExtBidResponse(adapterBids, adapterExtra, &openrtb.BidRequest{}, nil, nil, false, nil, nil)
+	if ext.Debug != nil {
+		t.Errorf("Rejected bids should not be in the response unless debug is on. Got %v", ext.Debug)
+	}
+
+	ext = e.makeExtBidResponse(adapterBids, adapterExtra, &openrtb.BidRequest{}, nil, nil, true, nil, nil)
+	if ext.Debug == nil {
+		t.Fatalf("Rejected bids should be in the response if debug is on.")
+	}
+	rejected := ext.Debug.RejectedBids[openrtb_ext.BidderAppnexus]
+	if len(rejected) != 1 {
+		t.Fatalf("Expected 1 rejected bid. Got %v", rejected)
+	}
+	expected := openrtb_ext.ExtRejectedBid{BidID: "one-bid", Reason: string(pbsmetrics.BidRejectionBelowFloor), Message: "too cheap"}
+	if rejected[0] != expected {
+		t.Errorf("Expected %v. Got %v", expected, rejected[0])
+	}
+}
+
+func TestStaleRatesDebug(t *testing.T) {
+	e := &exchange{
+		currencyConverter: currencies.NewRateConverter(&http.Client{}, "", time.Duration(0)),
+		ratesStaleness: &currencies.StalenessCheck{
+			MaxAge: time.Hour,
+			Policy: currencies.StalenessPolicyReject,
+		},
+	}
+	conversions, decision := e.latestConversions(time.Now())
+	if decision == nil {
+		t.Fatalf("Rates which were never fetched should be stale.")
+	}
+	if _, err := conversions.GetRate("USD", "EUR"); err == nil {
+		t.Errorf("The reject policy should stop currency conversions.")
+	}
+
+	ext := e.makeExtBidResponse(nil, nil, &openrtb.BidRequest{}, nil, nil, true, decision, nil)
+	if ext.Debug == nil || ext.Debug.CurrencyRates == nil {
+		t.Fatalf("The staleness decision should be in the debug ext.")
+	}
+	if ext.Debug.CurrencyRates.Policy != "reject" {
+		t.Errorf("Expected the reject policy. Got %s", ext.Debug.CurrencyRates.Policy)
+	}
+}
+
+func TestCurrencySelectionDebug(t *testing.T) {
+	e := &exchange{}
+	selection := &openrtb_ext.ExtResponseCurrencySelection{
+		Policy: config.CurrencySelectionAccountDefault,
+		Target: "GBP",
+	}
+
+	ext := e.makeExtBidResponse(nil, nil, &openrtb.BidRequest{}, nil, nil, false, nil, selection)
+	if ext.Debug != nil {
+		t.Errorf("The currency selection should not be in the response unless debug is on. Got %v", ext.Debug)
+	}
+
+	ext = e.makeExtBidResponse(nil, nil, &openrtb.BidRequest{}, nil, nil, true, nil, selection)
+	if ext.Debug == nil || ext.Debug.CurrencySelection == nil {
+		t.Fatalf("The currency selection should be in the debug ext.")
+	}
+	if ext.Debug.CurrencySelection.Target != "GBP" || ext.Debug.CurrencySelection.Policy != "account_default" {
+		t.Errorf("Expected the account_default policy to pick GBP. Got %v", ext.Debug.CurrencySelection)
+	}
+}
+
+func TestFallbackRates(t *testing.T) {
+	e := &exchange{
+		currencyConverter: currencies.NewRateConverter(&http.Client{}, "", time.Duration(0)),
+		fallbackRates:     currencies.NewRates(time.Time{}, map[string]map[string]float64{"USD": {"EUR": 0.9}}),
+	}
+	conversions, _ := e.latestConversions(time.Now())
+	if rate, err := conversions.GetRate("USD", "EUR"); err != nil || rate != 0.9 {
+		t.Errorf("The fallback rates should be used if no rates were fetched. Got %f, %v", rate, err)
+	}
+
+	e.ratesStaleness = &currencies.StalenessCheck{
+		MaxAge: time.Hour,
+		Policy: currencies.StalenessPolicyReject,
+	}
+	conversions, _ = e.latestConversions(time.Now())
+	if _, err := conversions.GetRate("USD", "EUR"); err == nil {
+		t.Errorf("The reject policy should stop currency conversions, even with fallback rates.")
+	}
+}
+
+func TestBidValidationForAccount(t *testing.T) {
+	e := &exchange{
+		bidValidation: config.BidValidation{
+			DefaultCurrency: "USD",
+			MaxAdmSize:      100,
+			BannerMIMEs:     []string{"image/png"},
+		},
+		accounts: map[string]config.Account{
+			"eu-publisher": {
+				DefaultCurrency: "EUR",
+			},
+			"other-publisher": {},
+			"strict-publisher": {
+				BannerMIMEs: []string{"image/jpeg"},
+			},
+			"auditing-publisher": {
+				ValidationMode: config.ValidationModePermissive,
+			},
+		},
+	}
+	if eu := e.bidValidationFor("eu-publisher"); eu.DefaultCurrency != "EUR" || eu.MaxAdmSize != 100 {
+		t.Errorf("Expected the account to default to EUR and keep the other host config. Got %#v", eu)
+	}
+	if other := e.bidValidationFor("other-publisher"); other.DefaultCurrency != "USD" {
+		t.Errorf("Accounts without a default currency should use the host's. Got %s", other.DefaultCurrency)
+	}
+	if strict := e.bidValidationFor("strict-publisher"); len(strict.BannerMIMEs) != 1 || strict.BannerMIMEs[0] != "image/jpeg" {
+		t.Errorf("Expected the account's banner MIME types to replace the host's. Got %v", strict.BannerMIMEs)
+	}
+	if other := e.bidValidationFor("other-publisher"); len(other.BannerMIMEs) != 1 || other.BannerMIMEs[0] != "image/png" {
+		t.Errorf("Accounts without banner MIME types should use the host's. Got %v", other.BannerMIMEs)
+	}
+	if auditing := e.bidValidationFor("auditing-publisher"); auditing.Mode != config.ValidationModePermissive {
+		t.Errorf("Expected the account's validation mode to be permissive. Got %q", auditing.Mode)
+	}
+	if other := e.bidValidationFor("other-publisher"); other.Mode != "" {
+		t.Errorf("Accounts without a validation mode should use the host's. Got %q", other.Mode)
+	}
+	if unknown := e.bidValidationFor("unknown"); unknown.DefaultCurrency != "USD" {
+		t.Errorf("Unknown accounts should use the host's default currency. Got %s", unknown.DefaultCurrency)
+	}
+	if e.bidValidation.DefaultCurrency != "USD" {
+		t.Errorf("The host config shouldn't be changed. Got %s", e.bidValidation.DefaultCurrency)
+	}
+}
+
+func TestEmptyBidsOnlyRejectThemselves(t *testing.T) {
+	e := &exchange{
+		adapterMap: map[openrtb_ext.BidderName]AdaptedBidder{
+			openrtb_ext.BidderAppnexus: &fixedBidder{bids: []*openrtb.Bid{
+				{ID: "good-bid", ImpID: "some-imp", Price: 1, CrID: "some-creative", AdM: "<div>an ad</div>"},
+				nil,
+				{ID: "other-good-bid", ImpID: "some-imp", Price: 1, CrID: "some-creative", AdM: "<div>an ad</div>"},
+			}},
+		},
+		me: &rejectionRecordingMetrics{},
+	}
+	cleanRequests := map[openrtb_ext.BidderName]*openrtb.BidRequest{
+		openrtb_ext.BidderAppnexus: {ID: "some-request", Imp: newTestImps("some-imp")},
+	}
+	blabels := map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels{
+		openrtb_ext.BidderAppnexus: {Adapter: openrtb_ext.BidderAppnexus},
+	}
+
+	seatBids, extra := e.getAllBids(context.Background(), cleanRequests, nil, nil, nil, nil, config.BidValidation{}, nil, blabels, false)
+
+	if seatBid := seatBids[openrtb_ext.BidderAppnexus]; seatBid == nil || len(seatBid.Bids) != 2 {
+		t.Fatalf("Expected the valid bids to be kept. Got %#v", seatBid)
+	}
+	if errs := extra[openrtb_ext.BidderAppnexus].Errors; len(errs) != 1 {
+		t.Errorf("Expected one error for the empty bid. Got %v", errs)
+	}
+}
+
+func TestAccountDefaultCurrencyOnlyFillsUnsetSeats(t *testing.T) {
+	bids := []*openrtb.Bid{{ID: "some-bid", ImpID: "some-imp", Price: 1, CrID: "some-creative", AdM: "<div>an ad</div>"}}
+	e := &exchange{
+		adapterMap: map[openrtb_ext.BidderName]AdaptedBidder{
+			openrtb_ext.BidderAppnexus: &fixedBidder{bids: bids},
+			openrtb_ext.BidderRubicon:  &fixedBidder{bids: bids, currency: "USD"},
+			openrtb_ext.BidderPubmatic: &fixedBidder{bids: bids, currency: "JPY"},
+		},
+		me: &rejectionRecordingMetrics{},
+	}
+	cleanRequests := map[openrtb_ext.BidderName]*openrtb.BidRequest{
+		openrtb_ext.BidderAppnexus: {ID: "some-request", Imp: newTestImps("some-imp")},
+		openrtb_ext.BidderRubicon:  {ID: "some-request", Imp: newTestImps("some-imp")},
+		openrtb_ext.BidderPubmatic: {ID: "some-request", Imp: newTestImps("some-imp")},
+	}
+	blabels := map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels{
+		openrtb_ext.BidderAppnexus: {Adapter: openrtb_ext.BidderAppnexus},
+		openrtb_ext.BidderRubicon:  {Adapter: openrtb_ext.BidderRubicon},
+		openrtb_ext.BidderPubmatic: {Adapter: openrtb_ext.BidderPubmatic},
+	}
+
+	seatBids, _ := e.getAllBids(context.Background(), cleanRequests, nil, nil, nil, nil, config.BidValidation{DefaultCurrency: "EUR"}, newTestConversions(), blabels, false)
+
+	if seatBids[openrtb_ext.BidderAppnexus].Currency != "EUR" || len(seatBids[openrtb_ext.BidderAppnexus].Bids) != 1 {
+		t.Fatalf("Bids without a currency should be in the account's default currency. Got %#v", seatBids[openrtb_ext.BidderAppnexus])
+	}
+	if seatBids[openrtb_ext.BidderAppnexus].Bids[0].OriginalCurrency != "" {
+		t.Errorf("Bids without a currency shouldn't be converted. Got %#v", seatBids[openrtb_ext.BidderAppnexus].Bids[0])
+	}
+	// The bidder said its Bids are in USD, so they're converted into EUR.
+	if seatBids[openrtb_ext.BidderRubicon].Currency != "EUR" || len(seatBids[openrtb_ext.BidderRubicon].Bids) != 1 {
+		t.Fatalf("USD bids should be converted for an account which defaults to EUR. Got %#v", seatBids[openrtb_ext.BidderRubicon])
+	}
+	converted := seatBids[openrtb_ext.BidderRubicon].Bids[0]
+	if converted.Bid.Price != 0.85 || converted.OriginalPrice != 1 || converted.OriginalCurrency != "USD" {
+		t.Errorf("Expected the USD bid to be converted to 0.85 EUR. Got %#v", converted)
+	}
+	// There's no rate from JPY, so those Bids can't be used.
+	if len(seatBids[openrtb_ext.BidderPubmatic].Bids) != 0 {
+		t.Errorf("JPY bids should be rejected by an account which defaults to EUR. Got %#v", seatBids[openrtb_ext.BidderPubmatic])
+	}
+}
+
+// rejectionRecordingMetrics remembers the bid rejections and response sizes which it was asked to record.
+type rejectionRecordingMetrics struct {
+	metricsConf.DummyMetricsEngine
+	adapters []openrtb_ext.BidderName
+	reasons  []pbsmetrics.BidRejectionReason
+	sizes    []int
+}
+
+func (me *rejectionRecordingMetrics) RecordAdapterBidRejected(labels pbsmetrics.AdapterLabels, reason pbsmetrics.BidRejectionReason) {
+	me.adapters = append(me.adapters, labels.Adapter)
+	me.reasons = append(me.reasons, reason)
+}
+
+func (me *rejectionRecordingMetrics) RecordAdapterResponseSize(labels pbsmetrics.AdapterLabels, bytes int) {
+	me.sizes = append(me.sizes, bytes)
+}
+
+func assertRejectionCount(t *testing.T, me *rejectionRecordingMetrics, reason pbsmetrics.BidRejectionReason, expected int) {
+	t.Helper()
+	count := 0
+	for _, recorded := range me.reasons {
+		if recorded == reason {
+			count++
+		}
+	}
+	if count != expected {
+		t.Errorf("Expected %d rejections for %s. Got %d", expected, reason, count)
+	}
+}
+
 // TestExchangeJSON executes tests for all the *.json files in exchangetest.
 func TestExchangeJSON(t *testing.T) {
 	if specFiles, err := ioutil.ReadDir("./exchangetest"); err == nil {
@@ -425,8 +967,9 @@ type bidderSeatBid struct {
 // bidderBid is basically a subset of pbsOrtbBid from exchange/bidder.go.
 // See the comment on bidderSeatBid for more info.
 type bidderBid struct {
-	Bid  *openrtb.Bid `json:"ortbBid,omitempty"`
-	Type string       `json:"bidType,omitempty"`
+	Bid   *openrtb.Bid                   `json:"ortbBid,omitempty"`
+	Type  string                         `json:"bidType,omitempty"`
+	Video *openrtb_ext.ExtBidPrebidVideo `json:"bidVideo,omitempty"`
 }
 
 type mockIdFetcher map[string]string
@@ -446,11 +989,11 @@ type validatingBidder struct {
 	mockResponses map[string]bidderResponse
 }
 
-func (b *validatingBidder) RequestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustment float64) (seatBid *PBSOrtbSeatBid, errs []error) {
+func (b *validatingBidder) RequestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustments BidAdjustments) (seatBid *PBSOrtbSeatBid, errs []error) {
 	if expectedRequest, ok := b.expectations[string(name)]; ok {
 		if expectedRequest != nil {
-			if expectedRequest.BidAdjustment != bidAdjustment {
-				b.t.Errorf("%s: Bidder %s got wrong bid adjustment. Expected %f, got %f", b.fileName, name, expectedRequest.BidAdjustment, bidAdjustment)
+			if expectedRequest.BidAdjustment != bidAdjustments.Factor {
+				b.t.Errorf("%s: Bidder %s got wrong bid adjustment. Expected %f, got %f", b.fileName, name, expectedRequest.BidAdjustment, bidAdjustments.Factor)
 			}
 			diffOrtbRequests(b.t, fmt.Sprintf("Request to %s in %s", string(name), b.fileName), &expectedRequest.OrtbRequest, request)
 		}
@@ -463,8 +1006,9 @@ func (b *validatingBidder) RequestBid(ctx context.Context, request *openrtb.BidR
 			bids := make([]*PBSOrtbBid, len(mockResponse.SeatBid.Bids))
 			for i := 0; i < len(bids); i++ {
 				bids[i] = &PBSOrtbBid{
-					Bid:     mockResponse.SeatBid.Bids[i].Bid,
-					BidType: openrtb_ext.BidType(mockResponse.SeatBid.Bids[i].Type),
+					Bid:      mockResponse.SeatBid.Bids[i].Bid,
+					BidType:  openrtb_ext.BidType(mockResponse.SeatBid.Bids[i].Type),
+					BidVideo: mockResponse.SeatBid.Bids[i].Video,
 				}
 			}
 
@@ -596,8 +1140,37 @@ func (e *mockUsersync) GetId(bidder openrtb_ext.BidderName) (id string, exists b
 	return
 }
 
+// countingAdapter makes no bids, but remembers how often it was asked for them.
+type countingAdapter struct {
+	calls int
+}
+
+func (a *countingAdapter) RequestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustments BidAdjustments) (*PBSOrtbSeatBid, []error) {
+	a.calls++
+	return &PBSOrtbSeatBid{}, nil
+}
+
+// fixedBidder always returns the same bids.
+type fixedBidder struct {
+	bids     []*openrtb.Bid
+	currency string
+}
+
+func (b *fixedBidder) RequestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustments BidAdjustments) (*PBSOrtbSeatBid, []error) {
+	seatBid := &PBSOrtbSeatBid{Currency: b.currency}
+	for _, bid := range b.bids {
+		if bid == nil {
+			seatBid.Bids = append(seatBid.Bids, &PBSOrtbBid{})
+			continue
+		}
+		copied := *bid
+		seatBid.Bids = append(seatBid.Bids, &PBSOrtbBid{Bid: &copied})
+	}
+	return seatBid, nil
+}
+
 type panicingAdapter struct{}
 
-func (panicingAdapter) RequestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustment float64) (posb *PBSOrtbSeatBid, errs []error) {
+func (panicingAdapter) RequestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustments BidAdjustments) (posb *PBSOrtbSeatBid, errs []error) {
 	panic("Panic! Panic! The world is ending!")
 }
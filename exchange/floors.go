@@ -0,0 +1,56 @@
+package exchange
+
+import (
+	"fmt"
+
+	"github.com/mxmCherry/openrtb"
+)
+
+// FloorProvider decides the floor price of each Imp, for example by asking a dynamic floors service.
+//
+// Prebid Server hosts can supply their own FloorProvider through the Plugins given to NewExchange.
+// By default, each Imp's floor is its own imp.bidfloor and imp.bidfloorcur.
+// Implementations must be threadsafe, since auctions run concurrently.
+type FloorProvider interface {
+	// FloorFor returns the floor price of the imp and its currency. An empty currency means USD.
+	// If it returns an error, the imp keeps its own bidfloor, and the error is reported in response.ext.errors.prebid.
+	//
+	// Neither the imp nor the request should be mutated.
+	FloorFor(imp *openrtb.Imp, request *openrtb.BidRequest) (float64, string, error)
+}
+
+// FloorProviderFunc adapts an ordinary function into a FloorProvider.
+type FloorProviderFunc func(imp *openrtb.Imp, request *openrtb.BidRequest) (float64, string, error)
+
+// FloorFor calls f(imp, request).
+func (f FloorProviderFunc) FloorFor(imp *openrtb.Imp, request *openrtb.BidRequest) (float64, string, error) {
+	return f(imp, request)
+}
+
+// impFloorProvider is the default FloorProvider. It uses the floor from the imp itself.
+type impFloorProvider struct{}
+
+func (impFloorProvider) FloorFor(imp *openrtb.Imp, request *openrtb.BidRequest) (float64, string, error) {
+	return imp.BidFloor, imp.BidFloorCur, nil
+}
+
+// applyFloors asks the provider for the floor of every Imp in the request, and writes it into the imp.bidfloor
+// and imp.bidfloorcur. This should be called before the request is split up for the Bidders, so that they're told
+// the floors which their Bids will be held to. The returned errors explain which imps kept their own floor.
+func applyFloors(request *openrtb.BidRequest, provider FloorProvider) []error {
+	if provider == nil {
+		return nil
+	}
+	var errs []error
+	for i := 0; i < len(request.Imp); i++ {
+		imp := &request.Imp[i]
+		floor, currency, err := provider.FloorFor(imp, request)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Failed to get the floor of imp \"%s\", so its bidfloor was used: %v", imp.ID, err))
+			continue
+		}
+		imp.BidFloor = floor
+		imp.BidFloorCur = currency
+	}
+	return errs
+}
@@ -0,0 +1,99 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/pbsmetrics"
+)
+
+func TestFloorProviderIsEnforced(t *testing.T) {
+	request := newFloorTestRequest()
+	// The floors service knows better than the publisher's ad server
+	dynamicFloors := FloorProviderFunc(func(imp *openrtb.Imp, request *openrtb.BidRequest) (float64, string, error) {
+		return 0.75, "USD", nil
+	})
+
+	if errs := applyFloors(request, dynamicFloors); len(errs) != 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+	brw := newFloorTestWrapper(0.6, 0.9)
+	errs, _ := brw.ValidateBids(request, nil, config.BidValidation{}, nil, nil)
+
+	assertBidIDs(t, brw.AdapterBids, []string{"expensive-bid"})
+	if len(errs) != 1 {
+		t.Fatalf("Expected 1 error. Got %v", errs)
+	}
+	if rejection, ok := errs[0].(*BidRejectionError); !ok || rejection.Reason != pbsmetrics.BidRejectionBelowFloor {
+		t.Errorf("Expected a %s rejection. Got %v", pbsmetrics.BidRejectionBelowFloor, errs[0])
+	}
+}
+
+func TestFloorProviderCurrency(t *testing.T) {
+	request := newFloorTestRequest()
+	euroFloors := FloorProviderFunc(func(imp *openrtb.Imp, request *openrtb.BidRequest) (float64, string, error) {
+		return 1.2, "EUR", nil
+	})
+
+	applyFloors(request, euroFloors)
+
+	if request.Imp[0].BidFloor != 1.2 || request.Imp[0].BidFloorCur != "EUR" {
+		t.Errorf("Expected the imp to have the provider's floor of 1.2 EUR. Got %f %s", request.Imp[0].BidFloor, request.Imp[0].BidFloorCur)
+	}
+}
+
+func TestFloorProviderErrors(t *testing.T) {
+	request := newFloorTestRequest()
+	failingFloors := FloorProviderFunc(func(imp *openrtb.Imp, request *openrtb.BidRequest) (float64, string, error) {
+		return 0, "", errors.New("floors service timed out")
+	})
+
+	errs := applyFloors(request, failingFloors)
+
+	if len(errs) != 1 {
+		t.Fatalf("Expected 1 error. Got %v", errs)
+	}
+	if expected := `Failed to get the floor of imp "some-imp", so its bidfloor was used: floors service timed out`; errs[0].Error() != expected {
+		t.Errorf("Expected message %q. Got %q", expected, errs[0].Error())
+	}
+	if request.Imp[0].BidFloor != 0.5 {
+		t.Errorf("Expected the imp to keep its own floor of 0.5. Got %f", request.Imp[0].BidFloor)
+	}
+}
+
+func TestDefaultFloorProvider(t *testing.T) {
+	request := newFloorTestRequest()
+
+	if errs := applyFloors(request, impFloorProvider{}); len(errs) != 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+	brw := newFloorTestWrapper(0.6, 0.9)
+	errs, _ := brw.ValidateBids(request, nil, config.BidValidation{}, nil, nil)
+
+	if len(errs) != 0 {
+		t.Errorf("Expected both bids to beat the imp's own floor. Got %v", errs)
+	}
+	assertBidIDs(t, brw.AdapterBids, []string{"cheap-bid", "expensive-bid"})
+}
+
+func newFloorTestRequest() *openrtb.BidRequest {
+	return &openrtb.BidRequest{
+		Imp: []openrtb.Imp{{
+			ID:       "some-imp",
+			BidFloor: 0.5,
+		}},
+	}
+}
+
+func newFloorTestWrapper(cheapPrice float64, expensivePrice float64) *BidResponseWrapper {
+	return &BidResponseWrapper{
+		AdapterBids: &PBSOrtbSeatBid{
+			Bids: []*PBSOrtbBid{
+				{Bid: &openrtb.Bid{ID: "cheap-bid", ImpID: "some-imp", Price: cheapPrice, CrID: "some-creative", AdM: "<div>an ad</div>"}},
+				{Bid: &openrtb.Bid{ID: "expensive-bid", ImpID: "some-imp", Price: expensivePrice, CrID: "some-creative", AdM: "<div>an ad</div>"}},
+			},
+		},
+	}
+}
@@ -0,0 +1,83 @@
+package exchange
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// DisabledBidders is the set of core bidders which Prebid Server's operators have switched off at runtime,
+// for example because they're misbehaving. Disabled bidders aren't called in auctions, and requests for them
+// get a warning instead. Changes take effect on the next auction.
+//
+// It's safe for concurrent use. A nil *DisabledBidders has no bidders disabled.
+type DisabledBidders struct {
+	// writeMutex serializes updates. Reads never wait for it.
+	writeMutex sync.Mutex
+	bidders    atomic.Value // Should only hold map[openrtb_ext.BidderName]struct{}
+}
+
+// NewDisabledBidders returns a set in which the given bidders start out disabled.
+func NewDisabledBidders(bidders ...openrtb_ext.BidderName) *DisabledBidders {
+	d := &DisabledBidders{}
+	set := make(map[openrtb_ext.BidderName]struct{}, len(bidders))
+	for _, bidder := range bidders {
+		set[bidder] = struct{}{}
+	}
+	d.bidders.Store(set)
+	return d
+}
+
+// Set disables the bidder if disabled is true, and enables it otherwise.
+func (d *DisabledBidders) Set(bidder openrtb_ext.BidderName, disabled bool) {
+	d.writeMutex.Lock()
+	defer d.writeMutex.Unlock()
+
+	current := d.load()
+	if _, ok := current[bidder]; ok == disabled {
+		return
+	}
+	// Auctions may be reading the current set, so it's copied rather than changed.
+	updated := make(map[openrtb_ext.BidderName]struct{}, len(current)+1)
+	for name := range current {
+		updated[name] = struct{}{}
+	}
+	if disabled {
+		updated[bidder] = struct{}{}
+	} else {
+		delete(updated, bidder)
+	}
+	d.bidders.Store(updated)
+}
+
+// Contains returns true if the bidder is disabled.
+func (d *DisabledBidders) Contains(bidder openrtb_ext.BidderName) bool {
+	if d == nil {
+		return false
+	}
+	_, ok := d.load()[bidder]
+	return ok
+}
+
+// List returns the disabled bidders, sorted by name.
+func (d *DisabledBidders) List() []openrtb_ext.BidderName {
+	if d == nil {
+		return nil
+	}
+	current := d.load()
+	list := make([]openrtb_ext.BidderName, 0, len(current))
+	for bidder := range current {
+		list = append(list, bidder)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i] < list[j]
+	})
+	return list
+}
+
+func (d *DisabledBidders) load() map[openrtb_ext.BidderName]struct{} {
+	bidders, _ := d.bidders.Load().(map[openrtb_ext.BidderName]struct{})
+	return bidders
+}
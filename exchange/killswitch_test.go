@@ -0,0 +1,64 @@
+package exchange
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+func TestDisabledBiddersSet(t *testing.T) {
+	disabled := NewDisabledBidders(openrtb_ext.BidderRubicon)
+	disabled.Set(openrtb_ext.BidderAppnexus, true)
+	disabled.Set(openrtb_ext.BidderAppnexus, true)
+	disabled.Set(openrtb_ext.BidderRubicon, false)
+	disabled.Set(openrtb_ext.BidderIx, false)
+
+	if !disabled.Contains(openrtb_ext.BidderAppnexus) {
+		t.Errorf("appnexus should be disabled")
+	}
+	if disabled.Contains(openrtb_ext.BidderRubicon) {
+		t.Errorf("rubicon should have been enabled again")
+	}
+	if expected := []openrtb_ext.BidderName{openrtb_ext.BidderAppnexus}; !reflect.DeepEqual(disabled.List(), expected) {
+		t.Errorf("Expected %v to be disabled. Got %v", expected, disabled.List())
+	}
+}
+
+func TestDisabledBiddersListIsSorted(t *testing.T) {
+	disabled := NewDisabledBidders(openrtb_ext.BidderRubicon, openrtb_ext.BidderAppnexus, openrtb_ext.BidderIx)
+
+	expected := []openrtb_ext.BidderName{openrtb_ext.BidderAppnexus, openrtb_ext.BidderIx, openrtb_ext.BidderRubicon}
+	if !reflect.DeepEqual(disabled.List(), expected) {
+		t.Errorf("Expected %v. Got %v", expected, disabled.List())
+	}
+}
+
+func TestNilDisabledBidders(t *testing.T) {
+	var disabled *DisabledBidders
+	if disabled.Contains(openrtb_ext.BidderAppnexus) {
+		t.Errorf("A nil set shouldn't disable any bidders")
+	}
+	if len(disabled.List()) != 0 {
+		t.Errorf("A nil set shouldn't list any bidders. Got %v", disabled.List())
+	}
+}
+
+func TestDisabledBiddersConcurrentUse(t *testing.T) {
+	disabled := NewDisabledBidders()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			disabled.Set(openrtb_ext.BidderAppnexus, i%2 == 0)
+		}(i)
+		go func() {
+			defer wg.Done()
+			disabled.Contains(openrtb_ext.BidderAppnexus)
+			disabled.List()
+		}()
+	}
+	wg.Wait()
+}
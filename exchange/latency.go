@@ -0,0 +1,92 @@
+package exchange
+
+import (
+	"context"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/errortypes"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// withLatencyBudget limits an AdaptedBidder to a fraction of the time which is left in the auction.
+// This lets hosts give faster bidders more of the timeout, and cut off the slower ones before they hold up the auction.
+// Budgets outside of (0, 1) don't make the deadline any tighter, so the bidder is returned as is.
+func withLatencyBudget(bidder AdaptedBidder, budget float64) AdaptedBidder {
+	if budget <= 0 || budget >= 1 {
+		return bidder
+	}
+	return &latencyBudgetBidder{
+		bidder: bidder,
+		budget: budget,
+	}
+}
+
+type latencyBudgetBidder struct {
+	bidder AdaptedBidder
+	budget float64
+}
+
+// RequestBid calls the wrapped bidder with a deadline at the end of its budget.
+// If the budget runs out before the wrapped bidder returns, any Bids which it made are discarded.
+func (b *latencyBudgetBidder) RequestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustments BidAdjustments) (*PBSOrtbSeatBid, []error) {
+	start := time.Now()
+	deadline, ok := budgetDeadline(ctx, b.budget, start)
+	if !ok {
+		return b.bidder.RequestBid(ctx, request, name, bidAdjustments)
+	}
+	budgetCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	seatBid, errs := b.bidder.RequestBid(budgetCtx, request, name, bidAdjustments)
+	if budgetCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		// The BidderAdapter reports its own timeouts, so the budget's is only added if it hasn't.
+		if !hasBidderTimeout(errs) {
+			errs = append(errs, &errortypes.BidderTimeout{Bidder: string(name), Elapsed: time.Since(start)})
+		}
+		return nil, errs
+	}
+	return seatBid, errs
+}
+
+// hasBidderTimeout returns true if any of the errors is an errortypes.BidderTimeout.
+func hasBidderTimeout(errs []error) bool {
+	for _, err := range errs {
+		if _, ok := err.(*errortypes.BidderTimeout); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// budgetDeadline returns the time at which a bidder which started at the given time has used up its budget.
+// It's false if the ctx has no deadline, since there's no timeout to take a fraction of.
+func budgetDeadline(ctx context.Context, budget float64, start time.Time) (time.Time, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return time.Time{}, false
+	}
+	return start.Add(time.Duration(float64(deadline.Sub(start)) * budget)), true
+}
+
+// impTimeoutDeadline returns the deadline for a bidder which started at the given time, if any imp in its
+// request has an imp.ext.prebid.tmax hint. The shortest hint wins. It's false if no imp has a positive hint,
+// or if the ctx's own deadline is sooner, since the hints may only shorten the auction.
+func impTimeoutDeadline(ctx context.Context, request *openrtb.BidRequest, start time.Time) (time.Time, bool) {
+	var shortest int64
+	for i := 0; i < len(request.Imp); i++ {
+		tmax, err := jsonparser.GetInt(request.Imp[i].Ext, "prebid", "tmax")
+		if err == nil && tmax > 0 && (shortest == 0 || tmax < shortest) {
+			shortest = tmax
+		}
+	}
+	if shortest == 0 {
+		return time.Time{}, false
+	}
+	deadline := start.Add(time.Duration(shortest) * time.Millisecond)
+	if ctxDeadline, ok := ctx.Deadline(); ok && !deadline.Before(ctxDeadline) {
+		return time.Time{}, false
+	}
+	return deadline, true
+}
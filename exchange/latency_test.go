@@ -0,0 +1,215 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/errortypes"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+	metricsConf "github.com/prebid/prebid-server/pbsmetrics/config"
+)
+
+func TestBudgetDeadline(t *testing.T) {
+	start := time.Now()
+	ctx, cancel := context.WithDeadline(context.Background(), start.Add(200*time.Millisecond))
+	defer cancel()
+
+	deadline, ok := budgetDeadline(ctx, 0.25, start)
+	if !ok {
+		t.Fatalf("A context with a deadline should have a budget deadline.")
+	}
+	if expected := start.Add(50 * time.Millisecond); !deadline.Equal(expected) {
+		t.Errorf("Expected the deadline %v. Got %v", expected, deadline)
+	}
+
+	if _, ok := budgetDeadline(context.Background(), 0.25, start); ok {
+		t.Errorf("A context without a deadline shouldn't have a budget deadline.")
+	}
+}
+
+func TestLatencyBudgetDeadline(t *testing.T) {
+	inner := &slowBidder{}
+	bidder := withLatencyBudget(inner, 0.5)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	bidder.RequestBid(ctx, &openrtb.BidRequest{}, openrtb_ext.BidderAppnexus, BidAdjustments{})
+
+	auctionDeadline, _ := ctx.Deadline()
+	if !inner.deadline.Before(auctionDeadline.Add(-400 * time.Millisecond)) {
+		t.Errorf("The bidder should get about half of the auction timeout. Its deadline was %v, and the auction's was %v", inner.deadline, auctionDeadline)
+	}
+}
+
+func TestLatencyBudgetExceeded(t *testing.T) {
+	bidder := withLatencyBudget(&slowBidder{delay: time.Second}, 0.1)
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	seatBid, errs := bidder.RequestBid(ctx, &openrtb.BidRequest{}, openrtb_ext.BidderAppnexus, BidAdjustments{})
+
+	if seatBid != nil {
+		t.Errorf("Bidders which exceed their budget shouldn't contribute any bids. Got %v", seatBid.Bids)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("Expected 1 error. Got %v", errs)
+	}
+	if _, ok := errs[0].(*errortypes.BidderTimeout); !ok {
+		t.Errorf("Expected a BidderTimeout. Got %v", errs[0])
+	}
+}
+
+func TestLatencyBudgetExceededReportsOneTimeout(t *testing.T) {
+	bidder := withLatencyBudget(&slowBidder{delay: time.Second, reportsTimeouts: true}, 0.1)
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	seatBid, errs := bidder.RequestBid(ctx, &openrtb.BidRequest{}, openrtb_ext.BidderAppnexus, BidAdjustments{})
+
+	if seatBid != nil {
+		t.Errorf("Bidders which exceed their budget shouldn't contribute any bids. Got %v", seatBid.Bids)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("Expected only the bidder's own timeout. Got %v", errs)
+	}
+	if _, ok := errs[0].(*errortypes.BidderTimeout); !ok {
+		t.Errorf("Expected a BidderTimeout. Got %v", errs[0])
+	}
+}
+
+func TestLatencyBudgetMet(t *testing.T) {
+	bidder := withLatencyBudget(&slowBidder{}, 0.5)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	seatBid, errs := bidder.RequestBid(ctx, &openrtb.BidRequest{}, openrtb_ext.BidderAppnexus, BidAdjustments{})
+
+	if seatBid == nil || len(seatBid.Bids) != 1 {
+		t.Errorf("Bidders which stay within their budget should keep their bids. Got %v", seatBid)
+	}
+	if len(errs) != 0 {
+		t.Errorf("Expected no errors. Got %v", errs)
+	}
+}
+
+func TestNoLatencyBudget(t *testing.T) {
+	inner := &slowBidder{}
+	if bidder := withLatencyBudget(inner, 0); bidder != inner {
+		t.Errorf("Bidders without a budget shouldn't be wrapped.")
+	}
+	if bidder := withLatencyBudget(inner, 1); bidder != inner {
+		t.Errorf("Bidders with the whole timeout as their budget shouldn't be wrapped.")
+	}
+}
+
+func TestImpTimeoutDeadline(t *testing.T) {
+	start := time.Now()
+	ctx, cancel := context.WithDeadline(context.Background(), start.Add(time.Second))
+	defer cancel()
+
+	testCases := []struct {
+		description      string
+		impExts          []string
+		expectedDeadline time.Duration
+		expectedOK       bool
+	}{
+		{
+			description: "Imps without a hint use the auction's deadline",
+			impExts:     []string{`{"bidder":{}}`, ``},
+		},
+		{
+			description:      "A hint narrows the deadline",
+			impExts:          []string{`{"prebid":{"tmax":200},"bidder":{}}`},
+			expectedDeadline: 200 * time.Millisecond,
+			expectedOK:       true,
+		},
+		{
+			description:      "The shortest hint wins",
+			impExts:          []string{`{"prebid":{"tmax":300}}`, `{"prebid":{"tmax":100}}`, `{"prebid":{}}`},
+			expectedDeadline: 100 * time.Millisecond,
+			expectedOK:       true,
+		},
+		{
+			description: "Hints can't extend the auction's deadline",
+			impExts:     []string{`{"prebid":{"tmax":5000}}`},
+		},
+		{
+			description: "Non-positive hints are ignored",
+			impExts:     []string{`{"prebid":{"tmax":0}}`, `{"prebid":{"tmax":-10}}`},
+		},
+	}
+
+	for _, tc := range testCases {
+		request := &openrtb.BidRequest{}
+		for i, ext := range tc.impExts {
+			request.Imp = append(request.Imp, openrtb.Imp{ID: fmt.Sprintf("imp-%d", i), Ext: json.RawMessage(ext)})
+		}
+		deadline, ok := impTimeoutDeadline(ctx, request, start)
+		if ok != tc.expectedOK {
+			t.Errorf("%s: expected ok to be %t. Got %t", tc.description, tc.expectedOK, ok)
+			continue
+		}
+		if ok && !deadline.Equal(start.Add(tc.expectedDeadline)) {
+			t.Errorf("%s: expected the deadline %v. Got %v", tc.description, start.Add(tc.expectedDeadline), deadline)
+		}
+	}
+}
+
+func TestImpTimeoutNarrowsBidderDeadline(t *testing.T) {
+	bidder := &slowBidder{}
+	e := &exchange{
+		adapterMap: map[openrtb_ext.BidderName]AdaptedBidder{
+			openrtb_ext.BidderAppnexus: bidder,
+		},
+		me: &metricsConf.DummyMetricsEngine{},
+	}
+	cleanRequests := map[openrtb_ext.BidderName]*openrtb.BidRequest{
+		openrtb_ext.BidderAppnexus: {
+			Imp: []openrtb.Imp{{ID: "some-imp", Ext: json.RawMessage(`{"prebid":{"tmax":100},"bidder":{}}`)}},
+		},
+	}
+	blabels := map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels{
+		openrtb_ext.BidderAppnexus: {Adapter: openrtb_ext.BidderAppnexus},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	e.getAllBids(ctx, cleanRequests, nil, nil, nil, nil, config.BidValidation{}, nil, blabels, true)
+
+	auctionDeadline, _ := ctx.Deadline()
+	if !bidder.deadline.Before(auctionDeadline.Add(-800 * time.Millisecond)) {
+		t.Errorf("The bidder should get about 100ms from its imp's tmax. Its deadline was %v, and the auction's was %v", bidder.deadline, auctionDeadline)
+	}
+}
+
+// slowBidder takes the given delay to make one bid, unless its context ends first.
+// It remembers the deadline it was given.
+type slowBidder struct {
+	delay    time.Duration
+	deadline time.Time
+	// reportsTimeouts makes the bidder return a BidderTimeout if the ctx is done first, like the BidderAdapter does.
+	reportsTimeouts bool
+}
+
+func (b *slowBidder) RequestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustments BidAdjustments) (*PBSOrtbSeatBid, []error) {
+	b.deadline, _ = ctx.Deadline()
+	seatBid := &PBSOrtbSeatBid{
+		Bids: []*PBSOrtbBid{{
+			Bid: &openrtb.Bid{ID: "slow-bid", ImpID: "some-imp", Price: 1},
+		}},
+	}
+	select {
+	case <-time.After(b.delay):
+	case <-ctx.Done():
+		if b.reportsTimeouts {
+			return seatBid, []error{&errortypes.BidderTimeout{Bidder: string(name)}}
+		}
+	}
+	return seatBid, nil
+}
@@ -32,7 +32,7 @@ type adaptedAdapter struct {
 //
 // This is not ideal. OpenRTB provides a superset of the legacy data structures.
 // For requests which use those features, the best we can do is respond with "no bid".
-func (bidder *adaptedAdapter) RequestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustment float64) (*PBSOrtbSeatBid, []error) {
+func (bidder *adaptedAdapter) RequestBid(ctx context.Context, request *openrtb.BidRequest, name openrtb_ext.BidderName, bidAdjustments BidAdjustments) (*PBSOrtbSeatBid, []error) {
 	legacyRequest, legacyBidder, errs := bidder.toLegacyAdapterInputs(request, name)
 	if legacyRequest == nil || legacyBidder == nil {
 		return nil, errs
@@ -44,7 +44,7 @@ func (bidder *adaptedAdapter) RequestBid(ctx context.Context, request *openrtb.B
 	}
 
 	for i := 0; i < len(legacyBids); i++ {
-		legacyBids[i].Price = legacyBids[i].Price * bidAdjustment
+		legacyBids[i].Price = legacyBids[i].Price * bidAdjustments.FactorFor(openrtb_ext.BidType(legacyBids[i].CreativeMediaType))
 	}
 
 	finalResponse, moreErrs := toNewResponse(legacyBids, legacyBidder, name)
@@ -56,7 +56,7 @@ func TestSiteVideo(t *testing.T) {
 	mockAdapter := mockLegacyAdapter{}
 
 	exchangeBidder := adaptLegacyAdapter(&mockAdapter)
-	_, errs := exchangeBidder.RequestBid(context.Background(), ortbRequest, openrtb_ext.BidderRubicon, 1.0)
+	_, errs := exchangeBidder.RequestBid(context.Background(), ortbRequest, openrtb_ext.BidderRubicon, BidAdjustments{Factor: 1.0})
 	if len(errs) > 0 {
 		t.Errorf("Unexpected error requesting bids: %v", errs)
 	}
@@ -89,7 +89,7 @@ func TestAppBanner(t *testing.T) {
 	mockAdapter := mockLegacyAdapter{}
 
 	exchangeBidder := adaptLegacyAdapter(&mockAdapter)
-	_, errs := exchangeBidder.RequestBid(context.Background(), ortbRequest, openrtb_ext.BidderRubicon, 1.0)
+	_, errs := exchangeBidder.RequestBid(context.Background(), ortbRequest, openrtb_ext.BidderRubicon, BidAdjustments{Factor: 1.0})
 	if len(errs) > 0 {
 		t.Errorf("Unexpected error requesting bids: %v", errs)
 	}
@@ -134,7 +134,7 @@ func TestBidTransforms(t *testing.T) {
 	}
 
 	exchangeBidder := adaptLegacyAdapter(&mockAdapter)
-	seatBid, errs := exchangeBidder.RequestBid(context.Background(), newAppOrtbRequest(), openrtb_ext.BidderRubicon, bidAdjustment)
+	seatBid, errs := exchangeBidder.RequestBid(context.Background(), newAppOrtbRequest(), openrtb_ext.BidderRubicon, BidAdjustments{Factor: bidAdjustment})
 	if len(errs) != 1 {
 		t.Fatalf("Bad error count. Expected 1, got %d", len(errs))
 	}
@@ -282,7 +282,7 @@ func TestErrorResponse(t *testing.T) {
 	}
 
 	exchangeBidder := adaptLegacyAdapter(&mockAdapter)
-	_, errs := exchangeBidder.RequestBid(context.Background(), ortbRequest, openrtb_ext.BidderRubicon, 1.0)
+	_, errs := exchangeBidder.RequestBid(context.Background(), ortbRequest, openrtb_ext.BidderRubicon, BidAdjustments{Factor: 1.0})
 	if len(errs) != 1 {
 		t.Fatalf("Bad error count. Expected 1, got %d", len(errs))
 	}
@@ -320,7 +320,7 @@ func TestWithTargeting(t *testing.T) {
 		}},
 	}
 	exchangeBidder := adaptLegacyAdapter(&mockAdapter)
-	bid, errs := exchangeBidder.RequestBid(context.Background(), ortbRequest, openrtb_ext.BidderFacebook, 1.0)
+	bid, errs := exchangeBidder.RequestBid(context.Background(), ortbRequest, openrtb_ext.BidderFacebook, BidAdjustments{Factor: 1.0})
 	if len(errs) != 0 {
 		t.Fatalf("This should not produce errors. Got %v", errs)
 	}
@@ -0,0 +1,154 @@
+package exchange
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/prebid/prebid-server/errortypes"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// limitBidsPerImp makes sure that each Imp keeps at most maxBids Bids, summed across all the bidders.
+// This keeps the response small, and cuts down on the number of Bids which get cached.
+//
+// The highest priced Bids survive, so this should run after the Bids have been converted into a common currency.
+// If prices tie, the one seen first survives. Bidders are processed in the order given, so callers should pass
+// the randomized bidder list to keep ties fair. A maxBids of 0 or less means that there is no limit.
+//
+// Dropped Bids are excised from the seatBids in place. They were valid Bids, so the returned errors are
+// *errortypes.Warnings rather than rejections. They are keyed by the Bidder which made the dropped Bid.
+func limitBidsPerImp(bidders []openrtb_ext.BidderName, seatBids map[openrtb_ext.BidderName]*PBSOrtbSeatBid, maxBids int) map[openrtb_ext.BidderName][]error {
+	if maxBids <= 0 {
+		return nil
+	}
+
+	type impBid struct {
+		bidder openrtb_ext.BidderName
+		bid    *PBSOrtbBid
+	}
+
+	bidsByImp := make(map[string][]impBid)
+	for _, bidderName := range bidders {
+		seatBid := seatBids[bidderName]
+		if seatBid == nil {
+			continue
+		}
+		for _, bid := range seatBid.Bids {
+			bidsByImp[bid.Bid.ImpID] = append(bidsByImp[bid.Bid.ImpID], impBid{bidder: bidderName, bid: bid})
+		}
+	}
+
+	removed := make(map[*PBSOrtbBid]struct{})
+	warnings := make(map[openrtb_ext.BidderName][]error)
+	for impID, bids := range bidsByImp {
+		if len(bids) <= maxBids {
+			continue
+		}
+		sort.SliceStable(bids, func(i, j int) bool {
+			return bids[i].bid.Bid.Price > bids[j].bid.Bid.Price
+		})
+		for _, dropped := range bids[maxBids:] {
+			removed[dropped.bid] = struct{}{}
+			warnings[dropped.bidder] = append(warnings[dropped.bidder], &errortypes.Warning{
+				Message: fmt.Sprintf("Bid \"%s\" was dropped because imp \"%s\" only keeps its top %d bids", dropped.bid.Bid.ID, impID, maxBids),
+			})
+		}
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+	removeBids(seatBids, removed)
+	return warnings
+}
+
+// limitBidsPerBidder makes sure that each bidder keeps at most maxBids Bids, summed across all the Imps.
+// Unlike limitBidsPerImp, this bounds how much of the response a single bidder can take up.
+//
+// Each bidder's highest priced Bids survive. If prices tie, the one seen first survives. A maxBids of 0 or less
+// means that there is no limit. Dropped Bids are excised from the seatBids in place, and reported as *errortypes.Warnings
+// keyed by the Bidder which made them.
+func limitBidsPerBidder(seatBids map[openrtb_ext.BidderName]*PBSOrtbSeatBid, maxBids int) map[openrtb_ext.BidderName][]error {
+	if maxBids <= 0 {
+		return nil
+	}
+
+	removed := make(map[*PBSOrtbBid]struct{})
+	warnings := make(map[openrtb_ext.BidderName][]error)
+	for bidderName, seatBid := range seatBids {
+		if seatBid == nil || len(seatBid.Bids) <= maxBids {
+			continue
+		}
+		bids := make([]*PBSOrtbBid, len(seatBid.Bids))
+		copy(bids, seatBid.Bids)
+		sort.SliceStable(bids, func(i, j int) bool {
+			return bids[i].Bid.Price > bids[j].Bid.Price
+		})
+		for _, dropped := range bids[maxBids:] {
+			removed[dropped] = struct{}{}
+			warnings[bidderName] = append(warnings[bidderName], &errortypes.Warning{
+				Message: fmt.Sprintf("Bid \"%s\" was dropped because bidder %s only keeps its top %d bids", dropped.Bid.ID, bidderName, maxBids),
+			})
+		}
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+	removeBids(seatBids, removed)
+	return warnings
+}
+
+// limitMediaTypes drops the Bids whose media type isn't one of the enabled ones, for accounts which are limited
+// to some media types. Bids whose type is still unknown after inference are kept. If enabled is empty, every type is allowed.
+// Dropped Bids are excised from the seatBids in place, and reported as *errortypes.Warnings keyed by the Bidder which made them.
+func limitMediaTypes(seatBids map[openrtb_ext.BidderName]*PBSOrtbSeatBid, enabled []string) map[openrtb_ext.BidderName][]error {
+	if len(enabled) == 0 {
+		return nil
+	}
+	enabledTypes := make(map[openrtb_ext.BidType]struct{}, len(enabled))
+	for _, mediaType := range enabled {
+		enabledTypes[openrtb_ext.BidType(mediaType)] = struct{}{}
+	}
+
+	removed := make(map[*PBSOrtbBid]struct{})
+	warnings := make(map[openrtb_ext.BidderName][]error)
+	for bidderName, seatBid := range seatBids {
+		if seatBid == nil {
+			continue
+		}
+		for _, bid := range seatBid.Bids {
+			if bid.BidType == "" {
+				continue
+			}
+			if _, ok := enabledTypes[bid.BidType]; !ok {
+				removed[bid] = struct{}{}
+				warnings[bidderName] = append(warnings[bidderName], &errortypes.Warning{
+					Message: fmt.Sprintf("Bid \"%s\" was dropped because the account doesn't run %s ads", bid.Bid.ID, bid.BidType),
+				})
+			}
+		}
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+	removeBids(seatBids, removed)
+	return warnings
+}
+
+// removeBids excises the removed Bids from the seatBids in place.
+func removeBids(seatBids map[openrtb_ext.BidderName]*PBSOrtbSeatBid, removed map[*PBSOrtbBid]struct{}) {
+	for _, seatBid := range seatBids {
+		if seatBid == nil {
+			continue
+		}
+		keptBids := make([]*PBSOrtbBid, 0, len(seatBid.Bids))
+		for _, bid := range seatBid.Bids {
+			if _, ok := removed[bid]; !ok {
+				keptBids = append(keptBids, bid)
+			}
+		}
+		seatBid.Bids = keptBids
+	}
+}
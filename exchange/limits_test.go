@@ -0,0 +1,230 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/errortypes"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+func TestLimitBidsPerImpKeepsHighestPrices(t *testing.T) {
+	seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Bids: []*PBSOrtbBid{
+				newCategoryBid("apn-low", "my-imp", 0.1),
+				newCategoryBid("apn-high", "my-imp", 0.9),
+			},
+		},
+		openrtb_ext.BidderRubicon: {
+			Bids: []*PBSOrtbBid{
+				newCategoryBid("rubi-mid", "my-imp", 0.5),
+				newCategoryBid("rubi-other-imp", "other-imp", 0.05),
+			},
+		},
+	}
+	warnings := limitBidsPerImp([]openrtb_ext.BidderName{openrtb_ext.BidderAppnexus, openrtb_ext.BidderRubicon}, seatBids, 2)
+
+	assertBidIDs(t, seatBids[openrtb_ext.BidderAppnexus], []string{"apn-high"})
+	assertBidIDs(t, seatBids[openrtb_ext.BidderRubicon], []string{"rubi-mid", "rubi-other-imp"})
+	assertNumDedupeErrors(t, warnings, openrtb_ext.BidderAppnexus, 1)
+	assertNumDedupeErrors(t, warnings, openrtb_ext.BidderRubicon, 0)
+	if _, ok := warnings[openrtb_ext.BidderAppnexus][0].(*errortypes.Warning); !ok {
+		t.Errorf("Dropped bids should be reported as warnings. Got %T", warnings[openrtb_ext.BidderAppnexus][0])
+	}
+}
+
+func TestLimitBidsPerImpTies(t *testing.T) {
+	seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Bids: []*PBSOrtbBid{
+				newCategoryBid("apn-bid", "my-imp", 0.5),
+			},
+		},
+		openrtb_ext.BidderRubicon: {
+			Bids: []*PBSOrtbBid{
+				newCategoryBid("rubi-bid", "my-imp", 0.5),
+			},
+		},
+	}
+	warnings := limitBidsPerImp([]openrtb_ext.BidderName{openrtb_ext.BidderRubicon, openrtb_ext.BidderAppnexus}, seatBids, 1)
+
+	assertBidIDs(t, seatBids[openrtb_ext.BidderRubicon], []string{"rubi-bid"})
+	assertBidIDs(t, seatBids[openrtb_ext.BidderAppnexus], []string{})
+	assertNumDedupeErrors(t, warnings, openrtb_ext.BidderAppnexus, 1)
+}
+
+func TestLimitBidsPerImpAboveBidCount(t *testing.T) {
+	seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Bids: []*PBSOrtbBid{
+				newCategoryBid("apn-bid", "my-imp", 0.3),
+				newCategoryBid("apn-other-bid", "my-imp", 0.4),
+			},
+		},
+	}
+	warnings := limitBidsPerImp([]openrtb_ext.BidderName{openrtb_ext.BidderAppnexus}, seatBids, 5)
+
+	assertBidIDs(t, seatBids[openrtb_ext.BidderAppnexus], []string{"apn-bid", "apn-other-bid"})
+	if len(warnings) != 0 {
+		t.Errorf("Expected no warnings. Got %v", warnings)
+	}
+}
+
+func TestLimitBidsPerImpUnlimited(t *testing.T) {
+	seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Bids: []*PBSOrtbBid{
+				newCategoryBid("apn-bid", "my-imp", 0.3),
+				newCategoryBid("apn-other-bid", "my-imp", 0.4),
+			},
+		},
+	}
+	warnings := limitBidsPerImp([]openrtb_ext.BidderName{openrtb_ext.BidderAppnexus}, seatBids, 0)
+
+	assertBidIDs(t, seatBids[openrtb_ext.BidderAppnexus], []string{"apn-bid", "apn-other-bid"})
+	if len(warnings) != 0 {
+		t.Errorf("Expected no warnings. Got %v", warnings)
+	}
+}
+
+func TestLimitBidsPerBidderAcrossImps(t *testing.T) {
+	seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Bids: []*PBSOrtbBid{
+				newCategoryBid("apn-low", "my-imp", 0.1),
+				newCategoryBid("apn-high", "other-imp", 0.9),
+				newCategoryBid("apn-mid", "third-imp", 0.5),
+			},
+		},
+		openrtb_ext.BidderRubicon: {
+			Bids: []*PBSOrtbBid{
+				newCategoryBid("rubi-low", "my-imp", 0.05),
+				newCategoryBid("rubi-high", "other-imp", 0.2),
+			},
+		},
+	}
+	warnings := limitBidsPerBidder(seatBids, 2)
+
+	assertBidIDs(t, seatBids[openrtb_ext.BidderAppnexus], []string{"apn-high", "apn-mid"})
+	assertBidIDs(t, seatBids[openrtb_ext.BidderRubicon], []string{"rubi-low", "rubi-high"})
+	assertNumDedupeErrors(t, warnings, openrtb_ext.BidderAppnexus, 1)
+	assertNumDedupeErrors(t, warnings, openrtb_ext.BidderRubicon, 0)
+	if _, ok := warnings[openrtb_ext.BidderAppnexus][0].(*errortypes.Warning); !ok {
+		t.Errorf("Dropped bids should be reported as warnings. Got %T", warnings[openrtb_ext.BidderAppnexus][0])
+	}
+}
+
+func TestLimitBidsPerBidderTies(t *testing.T) {
+	seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Bids: []*PBSOrtbBid{
+				newCategoryBid("apn-first", "my-imp", 0.5),
+				newCategoryBid("apn-second", "other-imp", 0.5),
+			},
+		},
+	}
+	warnings := limitBidsPerBidder(seatBids, 1)
+
+	assertBidIDs(t, seatBids[openrtb_ext.BidderAppnexus], []string{"apn-first"})
+	assertNumDedupeErrors(t, warnings, openrtb_ext.BidderAppnexus, 1)
+}
+
+func TestLimitBidsPerBidderUnlimited(t *testing.T) {
+	seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Bids: []*PBSOrtbBid{
+				newCategoryBid("apn-bid", "my-imp", 0.3),
+				newCategoryBid("apn-other-bid", "other-imp", 0.4),
+			},
+		},
+	}
+	warnings := limitBidsPerBidder(seatBids, 0)
+
+	assertBidIDs(t, seatBids[openrtb_ext.BidderAppnexus], []string{"apn-bid", "apn-other-bid"})
+	if len(warnings) != 0 {
+		t.Errorf("Expected no warnings. Got %v", warnings)
+	}
+}
+
+func TestLimitMediaTypes(t *testing.T) {
+	accountTestCases := []struct {
+		description      string
+		enabled          []string
+		expectedAppnexus []string
+		expectedRubicon  []string
+		expectedWarnings int
+	}{
+		{
+			description:      "Banner-only accounts drop video and native bids",
+			enabled:          []string{"banner"},
+			expectedAppnexus: []string{"apn-banner", "apn-unknown"},
+			expectedRubicon:  []string{"rubi-banner"},
+			expectedWarnings: 2,
+		},
+		{
+			description:      "Video accounts drop banner bids",
+			enabled:          []string{"video"},
+			expectedAppnexus: []string{"apn-video", "apn-unknown"},
+			expectedRubicon:  []string{},
+			expectedWarnings: 3,
+		},
+		{
+			description:      "Accounts may run several types",
+			enabled:          []string{"banner", "native"},
+			expectedAppnexus: []string{"apn-banner", "apn-unknown"},
+			expectedRubicon:  []string{"rubi-banner", "rubi-native"},
+			expectedWarnings: 1,
+		},
+		{
+			description:      "Accounts without a list run every type",
+			expectedAppnexus: []string{"apn-banner", "apn-video", "apn-unknown"},
+			expectedRubicon:  []string{"rubi-banner", "rubi-native"},
+		},
+	}
+
+	for _, tc := range accountTestCases {
+		seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+			openrtb_ext.BidderAppnexus: {
+				Bids: []*PBSOrtbBid{
+					newMediaTypeBid("apn-banner", openrtb_ext.BidTypeBanner),
+					newMediaTypeBid("apn-video", openrtb_ext.BidTypeVideo),
+					newMediaTypeBid("apn-unknown", ""),
+				},
+			},
+			openrtb_ext.BidderRubicon: {
+				Bids: []*PBSOrtbBid{
+					newMediaTypeBid("rubi-banner", openrtb_ext.BidTypeBanner),
+					newMediaTypeBid("rubi-native", openrtb_ext.BidTypeNative),
+				},
+			},
+		}
+		warnings := limitMediaTypes(seatBids, tc.enabled)
+
+		assertBidIDs(t, seatBids[openrtb_ext.BidderAppnexus], tc.expectedAppnexus)
+		assertBidIDs(t, seatBids[openrtb_ext.BidderRubicon], tc.expectedRubicon)
+		numWarnings := 0
+		for _, bidderWarnings := range warnings {
+			for _, warning := range bidderWarnings {
+				if _, ok := warning.(*errortypes.Warning); !ok {
+					t.Errorf("%s: dropped bids should be reported as warnings. Got %T", tc.description, warning)
+				}
+				numWarnings++
+			}
+		}
+		if numWarnings != tc.expectedWarnings {
+			t.Errorf("%s: expected %d warnings. Got %v", tc.description, tc.expectedWarnings, warnings)
+		}
+	}
+}
+
+func newMediaTypeBid(id string, bidType openrtb_ext.BidType) *PBSOrtbBid {
+	return &PBSOrtbBid{
+		Bid: &openrtb.Bid{
+			ID:    id,
+			ImpID: "my-imp",
+			Price: 0.5,
+		},
+		BidType: bidType,
+	}
+}
@@ -0,0 +1,46 @@
+package exchange
+
+import (
+	"fmt"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// SeatBidPreprocessor edits a Bidder's SeatBid before it's validated, for example to move a vendor-specific
+// bid.ext value into the standard fields. This saves hosts from forking the adapter to do it.
+//
+// Prebid Server hosts can supply their own SeatBidPreprocessors through the Plugins given to NewExchange.
+// Implementations must be threadsafe, since SeatBids from different Bidders are preprocessed concurrently.
+type SeatBidPreprocessor interface {
+	// Preprocess may mutate the seatBid, including its Bids. Since validation runs afterwards, it doesn't need
+	// to leave the Bids valid. If it returns an error, the preprocessors after it are skipped.
+	// The error message will be user-facing in the API, under response.ext.errors.{bidderName}.
+	//
+	// The bidder is the name used in the request, which may be an alias. The request is the one which
+	// was sent to the Bidder. It should not be mutated.
+	Preprocess(seatBid *PBSOrtbSeatBid, bidder openrtb_ext.BidderName, request *openrtb.BidRequest) error
+}
+
+// SeatBidPreprocessorFunc adapts an ordinary function into a SeatBidPreprocessor.
+type SeatBidPreprocessorFunc func(seatBid *PBSOrtbSeatBid, bidder openrtb_ext.BidderName, request *openrtb.BidRequest) error
+
+// Preprocess calls f(seatBid, bidder, request).
+func (f SeatBidPreprocessorFunc) Preprocess(seatBid *PBSOrtbSeatBid, bidder openrtb_ext.BidderName, request *openrtb.BidRequest) error {
+	return f(seatBid, bidder, request)
+}
+
+// PreprocessSeatBid runs the preprocessors on the Bidder's SeatBid, in order. It stops at the first one which fails,
+// since the preprocessors after it may rely on its changes. This should be called before ValidateBids.
+// The returned error explains which preprocessor failed. SeatBids which the Bidder didn't return aren't preprocessed.
+func (brw *BidResponseWrapper) PreprocessSeatBid(request *openrtb.BidRequest, preprocessors []SeatBidPreprocessor) error {
+	if brw.AdapterBids == nil {
+		return nil
+	}
+	for i, preprocessor := range preprocessors {
+		if err := preprocessor.Preprocess(brw.AdapterBids, brw.Bidder, request); err != nil {
+			return fmt.Errorf("SeatBid preprocessor %d of %d failed: %v", i+1, len(preprocessors), err)
+		}
+	}
+	return nil
+}
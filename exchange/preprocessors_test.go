@@ -0,0 +1,119 @@
+package exchange
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/buger/jsonparser"
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+func TestPreprocessorChangesAreValidated(t *testing.T) {
+	brw := newPreprocessorTestWrapper()
+	// This bidder puts its creative ID in a vendor-specific ext field.
+	normalizeCrID := SeatBidPreprocessorFunc(func(seatBid *PBSOrtbSeatBid, bidder openrtb_ext.BidderName, request *openrtb.BidRequest) error {
+		for _, bid := range seatBid.Bids {
+			if crID, err := jsonparser.GetString(bid.Bid.Ext, "vendor_crid"); err == nil {
+				bid.Bid.CrID = crID
+			}
+		}
+		return nil
+	})
+
+	if err := brw.PreprocessSeatBid(newPreprocessorTestRequest(), []SeatBidPreprocessor{normalizeCrID}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	errs, _ := brw.ValidateBids(newPreprocessorTestRequest(), nil, config.BidValidation{}, nil, nil)
+
+	if len(errs) != 0 {
+		t.Errorf("Expected the preprocessed bid to pass validation. Got %v", errs)
+	}
+	assertBidIDs(t, brw.AdapterBids, []string{"one-bid"})
+}
+
+func TestPreprocessorsRunInOrder(t *testing.T) {
+	brw := newPreprocessorTestWrapper()
+	var order []string
+	first := SeatBidPreprocessorFunc(func(seatBid *PBSOrtbSeatBid, bidder openrtb_ext.BidderName, request *openrtb.BidRequest) error {
+		order = append(order, "first")
+		return nil
+	})
+	second := SeatBidPreprocessorFunc(func(seatBid *PBSOrtbSeatBid, bidder openrtb_ext.BidderName, request *openrtb.BidRequest) error {
+		order = append(order, "second")
+		if bidder != openrtb_ext.BidderAppnexus {
+			t.Errorf("Expected the preprocessor to be told the bidder. Got %s", bidder)
+		}
+		return nil
+	})
+
+	for i := 0; i < 5; i++ {
+		order = nil
+		brw.PreprocessSeatBid(newPreprocessorTestRequest(), []SeatBidPreprocessor{first, second})
+		if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+			t.Fatalf("Expected the preprocessors to run in the order given. Got %v", order)
+		}
+	}
+}
+
+func TestPreprocessorErrors(t *testing.T) {
+	brw := newPreprocessorTestWrapper()
+	calls := 0
+	failing := SeatBidPreprocessorFunc(func(seatBid *PBSOrtbSeatBid, bidder openrtb_ext.BidderName, request *openrtb.BidRequest) error {
+		return errors.New("unexpected ext")
+	})
+	counting := SeatBidPreprocessorFunc(func(seatBid *PBSOrtbSeatBid, bidder openrtb_ext.BidderName, request *openrtb.BidRequest) error {
+		calls++
+		return nil
+	})
+
+	err := brw.PreprocessSeatBid(newPreprocessorTestRequest(), []SeatBidPreprocessor{failing, counting})
+
+	if err == nil || err.Error() != "SeatBid preprocessor 1 of 2 failed: unexpected ext" {
+		t.Errorf("Expected the error to name the failed preprocessor. Got %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("Preprocessors after a failure should not run. Got %d calls", calls)
+	}
+}
+
+func TestPreprocessorsSkipMissingSeats(t *testing.T) {
+	brw := &BidResponseWrapper{Bidder: openrtb_ext.BidderAppnexus}
+	calls := 0
+	counting := SeatBidPreprocessorFunc(func(seatBid *PBSOrtbSeatBid, bidder openrtb_ext.BidderName, request *openrtb.BidRequest) error {
+		calls++
+		return nil
+	})
+
+	if err := brw.PreprocessSeatBid(newPreprocessorTestRequest(), []SeatBidPreprocessor{counting}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("Bidders which didn't return a SeatBid should not be preprocessed. Got %d calls", calls)
+	}
+}
+
+func newPreprocessorTestRequest() *openrtb.BidRequest {
+	return &openrtb.BidRequest{
+		Imp: []openrtb.Imp{{ID: "thisImp"}},
+	}
+}
+
+func newPreprocessorTestWrapper() *BidResponseWrapper {
+	return &BidResponseWrapper{
+		Bidder: openrtb_ext.BidderAppnexus,
+		AdapterBids: &PBSOrtbSeatBid{
+			Bids: []*PBSOrtbBid{{
+				Bid: &openrtb.Bid{
+					ID:    "one-bid",
+					ImpID: "thisImp",
+					Price: 0.45,
+					AdM:   "some-markup",
+					Ext:   json.RawMessage(`{"vendor_crid":"thisCreative"}`),
+				},
+			}},
+		},
+	}
+}
@@ -0,0 +1,56 @@
+package exchange
+
+import (
+	"fmt"
+
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// RejectionLogger logs a message about an auction's rejected Bids, like fmt.Printf. Hosts should pass something which
+// logs at a debug level, e.g. glog.V(2).Infof, since an auction may reject many Bids.
+type RejectionLogger func(format string, args ...interface{})
+
+// requestLogger logs the rejections in one auction, prefixed by the ID of its request so they can be correlated.
+// A nil *requestLogger logs nothing.
+type requestLogger struct {
+	requestID string
+	logf      RejectionLogger
+}
+
+// newRequestLogger returns nil if logf is nil, so that hosts who don't log rejections don't pay for it.
+func newRequestLogger(requestID string, logf RejectionLogger) *requestLogger {
+	if logf == nil {
+		return nil
+	}
+	return &requestLogger{
+		requestID: requestID,
+		logf:      logf,
+	}
+}
+
+// Debugf logs the message with the request ID.
+func (l *requestLogger) Debugf(format string, args ...interface{}) {
+	if l == nil {
+		return
+	}
+	l.logf("Request %s: %s", l.requestID, fmt.Sprintf(format, args...))
+}
+
+// logRejections logs each error in errs, which came from validating the bidder's SeatBid.
+// BidRejectionErrors are logged with their Bid ID and reason.
+func (l *requestLogger) logRejections(bidder openrtb_ext.BidderName, errs []error) {
+	if l == nil {
+		return
+	}
+	for _, err := range errs {
+		if rejection, ok := err.(*BidRejectionError); ok {
+			if rejection.BidID == "" {
+				l.Debugf("%s seat rejected (%s): %s", bidder, rejection.Reason, rejection.Message)
+			} else {
+				l.Debugf("%s bid \"%s\" rejected (%s): %s", bidder, rejection.BidID, rejection.Reason, rejection.Message)
+			}
+		} else {
+			l.Debugf("%s: %v", bidder, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+)
+
+func TestRequestLoggerLogsRequestID(t *testing.T) {
+	logs := &recordingLogger{}
+	logger := newRequestLogger("some-request", logs.Logf)
+	logger.logRejections(openrtb_ext.BidderAppnexus, []error{
+		newBidRejection("bad-bid", pbsmetrics.BidRejectionEmptyMarkup, "Bid \"bad-bid\" has no markup"),
+		newBidRejection("", pbsmetrics.BidRejectionCurrencyNotAllowed, "Bid currency is not allowed"),
+		errors.New("some other error"),
+	})
+
+	if len(logs.lines) != 3 {
+		t.Fatalf("Expected a log line for each error. Got %v", logs.lines)
+	}
+	for _, line := range logs.lines {
+		if !strings.HasPrefix(line, "Request some-request: appnexus") {
+			t.Errorf("Expected the line to start with the request ID and bidder. Got %s", line)
+		}
+	}
+	if !strings.Contains(logs.lines[0], "bad-bid") || !strings.Contains(logs.lines[0], string(pbsmetrics.BidRejectionEmptyMarkup)) {
+		t.Errorf("Expected the bid ID and reason in the log. Got %s", logs.lines[0])
+	}
+}
+
+func TestRequestLoggerIsNoOpByDefault(t *testing.T) {
+	logger := newRequestLogger("some-request", nil)
+	if logger != nil {
+		t.Errorf("Expected a nil logger without a RejectionLogger. Got %v", logger)
+	}
+	// A nil logger must be safe to use.
+	logger.Debugf("some message")
+	logger.logRejections(openrtb_ext.BidderAppnexus, []error{errors.New("some error")})
+}
+
+func TestRejectionLoggerInAuction(t *testing.T) {
+	logs := &recordingLogger{}
+	e := &exchange{
+		adapterMap: map[openrtb_ext.BidderName]AdaptedBidder{
+			openrtb_ext.BidderAppnexus: &fixedBidder{bids: []*openrtb.Bid{
+				{ID: "good-bid", ImpID: "some-imp", Price: 1, CrID: "some-creative", AdM: "<div>an ad</div>"},
+				{ID: "bad-bid", ImpID: "some-imp", Price: 1, CrID: "some-creative"},
+			}},
+		},
+		me:              &rejectionRecordingMetrics{},
+		rejectionLogger: logs.Logf,
+	}
+	cleanRequests := map[openrtb_ext.BidderName]*openrtb.BidRequest{
+		openrtb_ext.BidderAppnexus: {ID: "some-request", Imp: newTestImps("some-imp")},
+	}
+	blabels := map[openrtb_ext.BidderName]*pbsmetrics.AdapterLabels{
+		openrtb_ext.BidderAppnexus: {Adapter: openrtb_ext.BidderAppnexus},
+	}
+
+	e.getAllBids(context.Background(), cleanRequests, nil, nil, nil, nil, config.BidValidation{}, nil, blabels, false)
+
+	if len(logs.lines) != 1 {
+		t.Fatalf("Expected the rejection to be logged once. Got %v", logs.lines)
+	}
+	if !strings.HasPrefix(logs.lines[0], "Request some-request: ") || !strings.Contains(logs.lines[0], "bad-bid") {
+		t.Errorf("Expected the log to carry the request ID and the rejected bid. Got %s", logs.lines[0])
+	}
+}
+
+type recordingLogger struct {
+	mutex sync.Mutex
+	lines []string
+}
+
+func (l *recordingLogger) Logf(format string, args ...interface{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
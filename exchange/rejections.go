@@ -0,0 +1,167 @@
+package exchange
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+)
+
+// BidRejectionError explains why Prebid Server removed a Bid from the auction.
+//
+// Errors which apply to a whole SeatBid, like an unsupported seat currency, have an empty BidID.
+type BidRejectionError struct {
+	BidID   string
+	Reason  pbsmetrics.BidRejectionReason
+	Message string
+}
+
+func (err *BidRejectionError) Error() string {
+	return err.Message
+}
+
+// newBidRejection returns a BidRejectionError whose Message is built from format and args, like fmt.Errorf.
+func newBidRejection(bidID string, reason pbsmetrics.BidRejectionReason, format string, args ...interface{}) error {
+	return &BidRejectionError{
+		BidID:   bidID,
+		Reason:  reason,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// sampleRejections decides whether an auction records detailed telemetry about its rejected Bids, if the host
+// samples 1 in every rate auctions. intn should behave like rand.Intn. A rate of 0 or 1 samples every auction.
+func sampleRejections(rate int, intn func(int) int) bool {
+	return rate <= 1 || intn(rate) == 0
+}
+
+// makeExtRejectedBids describes the BidRejectionErrors in errs for the response's debug ext. Other errors are skipped.
+func makeExtRejectedBids(errs []error) []openrtb_ext.ExtRejectedBid {
+	var rejected []openrtb_ext.ExtRejectedBid
+	for _, err := range errs {
+		if rejection, ok := err.(*BidRejectionError); ok {
+			rejected = append(rejected, openrtb_ext.ExtRejectedBid{
+				BidID:   rejection.BidID,
+				Reason:  string(rejection.Reason),
+				Message: rejection.Message,
+			})
+		}
+	}
+	return rejected
+}
+
+// summarizeRejections collapses the BidRejectionErrors in errs which share a Reason into a single error,
+// so that a Bidder which returns many invalid Bids doesn't flood the response and the logs.
+// Reasons which only rejected one Bid keep their original error. Other errors are left alone.
+// The summaries take the place of the first error with their Reason, so the order of the reasons is kept.
+func summarizeRejections(errs []error) []error {
+	counts := make(map[pbsmetrics.BidRejectionReason]int)
+	for _, err := range errs {
+		if rejection, ok := err.(*BidRejectionError); ok && rejection.BidID != "" {
+			counts[rejection.Reason]++
+		}
+	}
+
+	summarized := make([]error, 0, len(errs))
+	for _, err := range errs {
+		rejection, ok := err.(*BidRejectionError)
+		if !ok || rejection.BidID == "" || counts[rejection.Reason] == 1 {
+			summarized = append(summarized, err)
+			continue
+		}
+		if count := counts[rejection.Reason]; count > 1 {
+			summarized = append(summarized, newBidRejection("", rejection.Reason, "%d bids rejected: %s. The first was: %s", count, rejection.Reason, rejection.Message))
+			// Later errors with the same reason are covered by this summary.
+			counts[rejection.Reason] = 0
+		}
+	}
+	return summarized
+}
+
+// mapBidsToImps returns the ID of the Imp which each of the seatBid's Bids was for, keyed by the Bid's ID.
+func mapBidsToImps(seatBid *PBSOrtbSeatBid) map[string]string {
+	if seatBid == nil {
+		return nil
+	}
+	impsByBidID := make(map[string]string, len(seatBid.Bids))
+	for _, bid := range seatBid.Bids {
+		if bid == nil || bid.Bid == nil {
+			continue
+		}
+		if _, ok := impsByBidID[bid.Bid.ID]; !ok {
+			impsByBidID[bid.Bid.ID] = bid.Bid.ImpID
+		}
+	}
+	return impsByBidID
+}
+
+// rejectionsByImp groups the reasons of the BidRejectionErrors in errs by the Imp which each rejected Bid was for.
+// The impsByBidID should come from mapBidsToImps, before the Bids were validated.
+// A rejection of the whole SeatBid counts against every Imp which the Bidder bid on. Other errors are skipped.
+func rejectionsByImp(errs []error, impsByBidID map[string]string) map[string][]pbsmetrics.BidRejectionReason {
+	var byImp map[string][]pbsmetrics.BidRejectionReason
+	for _, err := range errs {
+		rejection, ok := err.(*BidRejectionError)
+		if !ok {
+			continue
+		}
+		if byImp == nil {
+			byImp = make(map[string][]pbsmetrics.BidRejectionReason)
+		}
+		if rejection.BidID == "" {
+			for _, impID := range impsByBidID {
+				byImp[impID] = append(byImp[impID], rejection.Reason)
+			}
+		} else if impID, ok := impsByBidID[rejection.BidID]; ok {
+			byImp[impID] = append(byImp[impID], rejection.Reason)
+		}
+	}
+	return byImp
+}
+
+// makeExtNoBidReasons counts why each Bidder's Bids were rejected from the Imps which ended up without any Bids.
+// The reasons for each Imp are sorted by bidder, then by reason, so that the response is stable.
+func makeExtNoBidReasons(adapterBids map[openrtb_ext.BidderName]*PBSOrtbSeatBid, adapterExtra map[openrtb_ext.BidderName]*SeatResponseExtra) map[string][]openrtb_ext.ExtNoBidReason {
+	impsWithBids := make(map[string]struct{})
+	for _, seatBid := range adapterBids {
+		if seatBid == nil {
+			continue
+		}
+		for _, bid := range seatBid.Bids {
+			impsWithBids[bid.Bid.ImpID] = struct{}{}
+		}
+	}
+
+	var noBidReasons map[string][]openrtb_ext.ExtNoBidReason
+	for bidder, extra := range adapterExtra {
+		for impID, reasons := range extra.RejectionsByImp {
+			if _, ok := impsWithBids[impID]; ok {
+				continue
+			}
+			if noBidReasons == nil {
+				noBidReasons = make(map[string][]openrtb_ext.ExtNoBidReason)
+			}
+			counts := make(map[pbsmetrics.BidRejectionReason]int, len(reasons))
+			for _, reason := range reasons {
+				counts[reason]++
+			}
+			for reason, count := range counts {
+				noBidReasons[impID] = append(noBidReasons[impID], openrtb_ext.ExtNoBidReason{
+					Bidder: bidder,
+					Reason: string(reason),
+					Count:  count,
+				})
+			}
+		}
+	}
+	for _, reasons := range noBidReasons {
+		sort.Slice(reasons, func(i, j int) bool {
+			if reasons[i].Bidder != reasons[j].Bidder {
+				return reasons[i].Bidder < reasons[j].Bidder
+			}
+			return reasons[i].Reason < reasons[j].Reason
+		})
+	}
+	return noBidReasons
+}
@@ -0,0 +1,148 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+)
+
+func TestSummarizeRejectionsCollapsesDuplicates(t *testing.T) {
+	errs := []error{
+		newBidRejection("bid-1", pbsmetrics.BidRejectionMissingCreativeID, "Bid \"bid-1\" missing creative ID"),
+		newBidRejection("bid-2", pbsmetrics.BidRejectionMissingCreativeID, "Bid \"bid-2\" missing creative ID"),
+		newBidRejection("bid-3", pbsmetrics.BidRejectionMissingCreativeID, "Bid \"bid-3\" missing creative ID"),
+	}
+
+	summarized := summarizeRejections(errs)
+
+	if len(summarized) != 1 {
+		t.Fatalf("Expected 1 error. Got %v", summarized)
+	}
+	expected := "3 bids rejected: missing_crid. The first was: Bid \"bid-1\" missing creative ID"
+	if summarized[0].Error() != expected {
+		t.Errorf("Expected %q. Got %q", expected, summarized[0].Error())
+	}
+	if rejection, ok := summarized[0].(*BidRejectionError); !ok || rejection.Reason != pbsmetrics.BidRejectionMissingCreativeID {
+		t.Errorf("The summary should keep the rejection reason. Got %v", summarized[0])
+	}
+}
+
+func TestSummarizeRejectionsKeepsDistinctReasons(t *testing.T) {
+	other := errors.New("bidder timed out")
+	seatRejection := newBidRejection("", pbsmetrics.BidRejectionCurrencyNotAllowed, "bad currency")
+	errs := []error{
+		other,
+		newBidRejection("bid-1", pbsmetrics.BidRejectionBelowFloor, "too cheap"),
+		newBidRejection("bid-2", pbsmetrics.BidRejectionMissingCreativeID, "no crid"),
+		newBidRejection("bid-3", pbsmetrics.BidRejectionBelowFloor, "also too cheap"),
+		seatRejection,
+	}
+
+	summarized := summarizeRejections(errs)
+
+	if len(summarized) != 4 {
+		t.Fatalf("Expected 4 errors. Got %v", summarized)
+	}
+	if summarized[0] != other {
+		t.Errorf("Errors which aren't rejections should be kept. Got %v", summarized[0])
+	}
+	if summarized[1].Error() != "2 bids rejected: below_floor. The first was: too cheap" {
+		t.Errorf("Expected the floor rejections to be summarized. Got %v", summarized[1])
+	}
+	if summarized[2] != errs[2] {
+		t.Errorf("Reasons with one bid should keep their error. Got %v", summarized[2])
+	}
+	if summarized[3] != seatRejection {
+		t.Errorf("Seat rejections should be kept. Got %v", summarized[3])
+	}
+}
+
+func TestRejectionsByImp(t *testing.T) {
+	seatBid := &PBSOrtbSeatBid{
+		Bids: []*PBSOrtbBid{
+			{Bid: &openrtb.Bid{ID: "bid-1", ImpID: "imp-1"}},
+			{Bid: &openrtb.Bid{ID: "bid-2", ImpID: "imp-2"}},
+		},
+	}
+	errs := []error{
+		errors.New("bidder timed out"),
+		newBidRejection("bid-1", pbsmetrics.BidRejectionBelowFloor, "too cheap"),
+		newBidRejection("unknown-bid", pbsmetrics.BidRejectionBelowFloor, "not one of ours"),
+		newBidRejection("", pbsmetrics.BidRejectionCurrencyNotAllowed, "bad currency"),
+	}
+
+	byImp := rejectionsByImp(errs, mapBidsToImps(seatBid))
+
+	if len(byImp) != 2 {
+		t.Fatalf("Expected reasons for 2 imps. Got %v", byImp)
+	}
+	if len(byImp["imp-1"]) != 2 || byImp["imp-1"][0] != pbsmetrics.BidRejectionBelowFloor || byImp["imp-1"][1] != pbsmetrics.BidRejectionCurrencyNotAllowed {
+		t.Errorf("Unexpected reasons for imp-1: %v", byImp["imp-1"])
+	}
+	if len(byImp["imp-2"]) != 1 || byImp["imp-2"][0] != pbsmetrics.BidRejectionCurrencyNotAllowed {
+		t.Errorf("The seat rejection should apply to imp-2. Got %v", byImp["imp-2"])
+	}
+}
+
+func TestRejectionsByImpWithoutRejections(t *testing.T) {
+	byImp := rejectionsByImp([]error{errors.New("bidder timed out")}, map[string]string{"bid-1": "imp-1"})
+	if byImp != nil {
+		t.Errorf("Expected no reasons. Got %v", byImp)
+	}
+}
+
+func TestMakeExtNoBidReasons(t *testing.T) {
+	adapterBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Bids: []*PBSOrtbBid{
+				{Bid: &openrtb.Bid{ID: "apn-bid", ImpID: "imp-with-bids"}},
+			},
+		},
+	}
+	adapterExtra := map[openrtb_ext.BidderName]*SeatResponseExtra{
+		openrtb_ext.BidderAppnexus: {},
+		openrtb_ext.BidderRubicon: {
+			RejectionsByImp: map[string][]pbsmetrics.BidRejectionReason{
+				"imp-with-bids":    {pbsmetrics.BidRejectionBelowFloor},
+				"imp-without-bids": {pbsmetrics.BidRejectionMissingCreativeID, pbsmetrics.BidRejectionBelowFloor, pbsmetrics.BidRejectionMissingCreativeID},
+			},
+		},
+		openrtb_ext.BidderAdform: {
+			RejectionsByImp: map[string][]pbsmetrics.BidRejectionReason{
+				"imp-without-bids": {pbsmetrics.BidRejectionCurrencyNotAllowed},
+			},
+		},
+	}
+
+	noBidReasons := makeExtNoBidReasons(adapterBids, adapterExtra)
+
+	if len(noBidReasons) != 1 {
+		t.Fatalf("Only imps without bids should have reasons. Got %v", noBidReasons)
+	}
+	expected := []openrtb_ext.ExtNoBidReason{
+		{Bidder: openrtb_ext.BidderAdform, Reason: string(pbsmetrics.BidRejectionCurrencyNotAllowed), Count: 1},
+		{Bidder: openrtb_ext.BidderRubicon, Reason: string(pbsmetrics.BidRejectionBelowFloor), Count: 1},
+		{Bidder: openrtb_ext.BidderRubicon, Reason: string(pbsmetrics.BidRejectionMissingCreativeID), Count: 2},
+	}
+	actual := noBidReasons["imp-without-bids"]
+	if len(actual) != len(expected) {
+		t.Fatalf("Expected %v. Got %v", expected, actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("Reason %d: expected %v. Got %v", i, expected[i], actual[i])
+		}
+	}
+}
+
+func TestMakeExtNoBidReasonsDisabled(t *testing.T) {
+	adapterExtra := map[openrtb_ext.BidderName]*SeatResponseExtra{
+		openrtb_ext.BidderRubicon: {},
+	}
+	if noBidReasons := makeExtNoBidReasons(nil, adapterExtra); noBidReasons != nil {
+		t.Errorf("Expected no reasons when none were collected. Got %v", noBidReasons)
+	}
+}
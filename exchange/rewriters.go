@@ -0,0 +1,66 @@
+package exchange
+
+import (
+	"github.com/mxmCherry/openrtb"
+
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/currencies"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+)
+
+// CreativeRewriter edits a Bid's creative before it's cached or returned, for example to inject a measurement pixel
+// or to fill in click macros. Bids reach the rewriters after they pass validation.
+//
+// Prebid Server hosts can supply their own CreativeRewriters through the Plugins given to NewExchange.
+// Implementations must be threadsafe, since Bids from different Bidders are rewritten concurrently.
+type CreativeRewriter interface {
+	// Rewrite may mutate the bid. If it returns an error, the Bid is removed from the auction, since it may be half-rewritten.
+	// The error message will be user-facing in the API, under response.ext.errors.{bidderName}.
+	//
+	// The request is the one which was sent to the Bidder. It should not be mutated.
+	Rewrite(bid *PBSOrtbBid, request *openrtb.BidRequest) error
+}
+
+// CreativeRewriterFunc adapts an ordinary function into a CreativeRewriter.
+type CreativeRewriterFunc func(bid *PBSOrtbBid, request *openrtb.BidRequest) error
+
+// Rewrite calls f(bid, request).
+func (f CreativeRewriterFunc) Rewrite(bid *PBSOrtbBid, request *openrtb.BidRequest) error {
+	return f(bid, request)
+}
+
+// RewriteCreatives runs the rewriters on each of the valid Bids, in order. Rewritten Bids go through Prebid Server's
+// own checks again, so that rewriters can't slip invalid markup past them. Bids which fail either step are removed.
+//
+// This should be called after ValidateBids, with the same arguments. The returned errors explain why Bids were removed.
+// It replaces brw.AdapterBids.Bids with a new slice of the remaining Bids.
+func (brw *BidResponseWrapper) RewriteCreatives(request *openrtb.BidRequest, validation *openrtb_ext.ExtRequestValidation, hostValidation config.BidValidation, conversions currencies.Conversions, rewriters []CreativeRewriter) (errs []error) {
+	if len(rewriters) == 0 || brw.AdapterBids == nil || len(brw.AdapterBids.Bids) == 0 {
+		return nil
+	}
+	defaultValidator := newDefaultBidValidator(request, brw.AdapterBids, validation, hostValidation, conversions)
+
+	rewrittenBids := make([]*PBSOrtbBid, 0, len(brw.AdapterBids.Bids))
+	for _, bid := range brw.AdapterBids.Bids {
+		if err := runCreativeRewriters(rewriters, request, bid); err != nil {
+			errs = append(errs, err)
+		} else if err := defaultValidator.Validate(request, bid); err != nil {
+			errs = append(errs, err)
+		} else {
+			rewrittenBids = append(rewrittenBids, bid)
+		}
+	}
+	brw.AdapterBids.Bids = rewrittenBids
+	return errs
+}
+
+// runCreativeRewriters returns a rejection for the first rewriter which fails on the bid, or nil if they all succeed.
+func runCreativeRewriters(rewriters []CreativeRewriter, request *openrtb.BidRequest, bid *PBSOrtbBid) error {
+	for _, rewriter := range rewriters {
+		if err := rewriter.Rewrite(bid, request); err != nil {
+			return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionRewriteFailed, "Bid \"%s\" could not be rewritten: %v", bid.Bid.ID, err)
+		}
+	}
+	return nil
+}
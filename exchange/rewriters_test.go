@@ -0,0 +1,97 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/pbsmetrics"
+)
+
+func TestRewriteCreativesInjectsPixel(t *testing.T) {
+	brw := newRewriterTestWrapper("<div>ad</div>")
+	pixel := CreativeRewriterFunc(func(bid *PBSOrtbBid, request *openrtb.BidRequest) error {
+		bid.Bid.AdM += `<img src="https://tracker.com/pixel?bid=` + bid.Bid.ID + `">`
+		return nil
+	})
+
+	errs := brw.RewriteCreatives(newRewriterTestRequest(), nil, config.BidValidation{}, nil, []CreativeRewriter{pixel})
+
+	if len(errs) != 0 {
+		t.Fatalf("Expected no errors. Got %v", errs)
+	}
+	assertBidIDs(t, brw.AdapterBids, []string{"one-bid"})
+	if adm := brw.AdapterBids.Bids[0].Bid.AdM; adm != `<div>ad</div><img src="https://tracker.com/pixel?bid=one-bid">` {
+		t.Errorf("Expected the pixel to be injected. Got %s", adm)
+	}
+}
+
+func TestRewriteCreativesRevalidates(t *testing.T) {
+	brw := newRewriterTestWrapper("<div>ad</div>")
+	insecurePixel := CreativeRewriterFunc(func(bid *PBSOrtbBid, request *openrtb.BidRequest) error {
+		bid.Bid.AdM += `<img src="http://tracker.com/pixel">`
+		return nil
+	})
+
+	errs := brw.RewriteCreatives(newRewriterTestRequest(), nil, config.BidValidation{}, nil, []CreativeRewriter{insecurePixel})
+
+	assertBidIDs(t, brw.AdapterBids, []string{})
+	assertRewriteRejection(t, errs, pbsmetrics.BidRejectionInsecureMarkup)
+}
+
+func TestRewriteCreativesErrors(t *testing.T) {
+	brw := newRewriterTestWrapper("<div>ad</div>")
+	calls := 0
+	failing := CreativeRewriterFunc(func(bid *PBSOrtbBid, request *openrtb.BidRequest) error {
+		return errors.New("no macros found")
+	})
+	counting := CreativeRewriterFunc(func(bid *PBSOrtbBid, request *openrtb.BidRequest) error {
+		calls++
+		return nil
+	})
+
+	errs := brw.RewriteCreatives(newRewriterTestRequest(), nil, config.BidValidation{}, nil, []CreativeRewriter{failing, counting})
+
+	assertBidIDs(t, brw.AdapterBids, []string{})
+	assertRewriteRejection(t, errs, pbsmetrics.BidRejectionRewriteFailed)
+	if calls != 0 {
+		t.Errorf("Rewriters after a failure should not run. Got %d calls", calls)
+	}
+}
+
+func newRewriterTestRequest() *openrtb.BidRequest {
+	secure := int8(1)
+	return &openrtb.BidRequest{
+		Imp: []openrtb.Imp{{
+			ID:     "thisImp",
+			Secure: &secure,
+		}},
+	}
+}
+
+func newRewriterTestWrapper(adm string) *BidResponseWrapper {
+	return &BidResponseWrapper{
+		AdapterBids: &PBSOrtbSeatBid{
+			Bids: []*PBSOrtbBid{{
+				Bid: &openrtb.Bid{
+					ID:    "one-bid",
+					ImpID: "thisImp",
+					Price: 0.45,
+					CrID:  "thisCreative",
+					AdM:   adm,
+				},
+			}},
+		},
+	}
+}
+
+func assertRewriteRejection(t *testing.T, errs []error, reason pbsmetrics.BidRejectionReason) {
+	t.Helper()
+	if len(errs) != 1 {
+		t.Fatalf("Expected 1 error. Got %v", errs)
+	}
+	if rejection, ok := errs[0].(*BidRejectionError); !ok || rejection.Reason != reason {
+		t.Errorf("Expected a %s rejection. Got %v", reason, errs[0])
+	}
+}
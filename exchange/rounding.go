@@ -0,0 +1,92 @@
+package exchange
+
+import (
+	"math"
+
+	"github.com/mxmCherry/openrtb"
+	"golang.org/x/text/currency"
+
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/currencies"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+)
+
+// roundingTolerance absorbs floating point error, so that prices like 0.29 aren't truncated to 0.28.
+const roundingTolerance = 1e-9
+
+// roundBidPrices rounds every Bid's price to the configured precision, so that the targeting keys are consistent
+// with the publisher's ad server. This should run after the Bids have been converted into the response currency.
+//
+// Currencies with fewer minor units than the configured precision, like JPY, are rounded to their own minor units.
+// If the rounding mode is empty, prices are left alone.
+//
+// Validation has already run, so Bids which rounding takes to zero, or below a floor which they had cleared,
+// are removed here. Their rejections are returned by bidder.
+func roundBidPrices(request *openrtb.BidRequest, seatBids map[openrtb_ext.BidderName]*PBSOrtbSeatBid, rounding config.PriceRounding, floorTolerance float64, conversions currencies.Conversions) map[openrtb_ext.BidderName][]error {
+	if rounding.Mode == "" {
+		return nil
+	}
+	if conversions == nil {
+		conversions = currencies.NewConversionCache(nil)
+	}
+	impsByID := make(map[string]*openrtb.Imp, len(request.Imp))
+	for i := 0; i < len(request.Imp); i++ {
+		impsByID[request.Imp[i].ID] = &request.Imp[i]
+	}
+
+	removed := make(map[*PBSOrtbBid]struct{})
+	errs := make(map[openrtb_ext.BidderName][]error)
+	for bidderName, seatBid := range seatBids {
+		if seatBid == nil {
+			continue
+		}
+		cur := seatCurrency(seatBid)
+		precision := pricePrecision(cur, rounding.Precision)
+		for _, bid := range seatBid.Bids {
+			imp := impsByID[bid.Bid.ImpID]
+			// Bids which skipped the floor check, or were let through by the tolerance, shouldn't be removed for it now.
+			clearedFloor := validateBidFloor(bid, imp, cur, conversions, floorTolerance) == nil
+			wasPositive := bid.Bid.Price > 0
+			bid.Bid.Price = roundPrice(bid.Bid.Price, precision, rounding.Mode)
+
+			var err error
+			if wasPositive && bid.Bid.Price <= 0 {
+				err = newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionNonPositivePrice, "Bid \"%s\" price was rounded to %f", bid.Bid.ID, bid.Bid.Price)
+			} else if clearedFloor && validateBidFloor(bid, imp, cur, conversions, floorTolerance) != nil {
+				err = newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionBelowFloor, "Bid \"%s\" price was rounded to %f, below the floor of imp \"%s\"", bid.Bid.ID, bid.Bid.Price, bid.Bid.ImpID)
+			}
+			if err != nil {
+				removed[bid] = struct{}{}
+				errs[bidderName] = append(errs[bidderName], err)
+			}
+		}
+	}
+
+	if len(removed) == 0 {
+		return nil
+	}
+	removeBids(seatBids, removed)
+	return errs
+}
+
+// pricePrecision returns the number of decimal places which prices in the currency should keep.
+func pricePrecision(cur string, precision int) int {
+	unit, err := currency.ParseISO(cur)
+	if err != nil {
+		return precision
+	}
+	if minorUnits, _ := currency.Standard.Rounding(unit); minorUnits < precision {
+		return minorUnits
+	}
+	return precision
+}
+
+// roundPrice rounds the price to the given number of decimal places. The mode must be one of the config.PriceRounding values.
+func roundPrice(price float64, precision int, mode string) float64 {
+	scale := math.Pow10(precision)
+	if mode == config.PriceRoundingTruncate {
+		return math.Floor(price*scale+roundingTolerance) / scale
+	}
+	return math.Floor(price*scale+0.5+roundingTolerance) / scale
+}
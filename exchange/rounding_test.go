@@ -0,0 +1,137 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+)
+
+func TestRoundPrice(t *testing.T) {
+	roundingTestCases := []struct {
+		price     float64
+		precision int
+		mode      string
+		expected  float64
+	}{
+		{price: 1.234, precision: 2, mode: config.PriceRoundingHalfUp, expected: 1.23},
+		{price: 1.235, precision: 2, mode: config.PriceRoundingHalfUp, expected: 1.24},
+		{price: 1.005, precision: 2, mode: config.PriceRoundingHalfUp, expected: 1.01},
+		{price: 1.239, precision: 2, mode: config.PriceRoundingTruncate, expected: 1.23},
+		{price: 0.29, precision: 2, mode: config.PriceRoundingTruncate, expected: 0.29},
+		{price: 1.5, precision: 0, mode: config.PriceRoundingHalfUp, expected: 2},
+		{price: 1.5, precision: 0, mode: config.PriceRoundingTruncate, expected: 1},
+	}
+
+	for _, tc := range roundingTestCases {
+		if actual := roundPrice(tc.price, tc.precision, tc.mode); actual != tc.expected {
+			t.Errorf("Expected %f rounded %s to %d places to be %f. Got %f", tc.price, tc.mode, tc.precision, tc.expected, actual)
+		}
+	}
+}
+
+func TestRoundBidPrices(t *testing.T) {
+	seatBids := newRoundingSeatBids()
+	roundBidPrices(&openrtb.BidRequest{}, seatBids, config.PriceRounding{Mode: config.PriceRoundingHalfUp, Precision: 2}, 0, nil)
+
+	assertPrice(t, seatBids[openrtb_ext.BidderAppnexus], 1.24)
+	// JPY has no minor units, so it can't keep two decimal places.
+	assertPrice(t, seatBids[openrtb_ext.BidderRubicon], 124)
+}
+
+func TestRoundBidPricesTruncates(t *testing.T) {
+	seatBids := newRoundingSeatBids()
+	roundBidPrices(&openrtb.BidRequest{}, seatBids, config.PriceRounding{Mode: config.PriceRoundingTruncate, Precision: 1}, 0, nil)
+
+	assertPrice(t, seatBids[openrtb_ext.BidderAppnexus], 1.2)
+	assertPrice(t, seatBids[openrtb_ext.BidderRubicon], 123)
+}
+
+func TestRoundBidPricesDisabled(t *testing.T) {
+	seatBids := newRoundingSeatBids()
+	roundBidPrices(&openrtb.BidRequest{}, seatBids, config.PriceRounding{Precision: 2}, 0, nil)
+
+	assertPrice(t, seatBids[openrtb_ext.BidderAppnexus], 1.2351)
+	assertPrice(t, seatBids[openrtb_ext.BidderRubicon], 123.51)
+}
+
+func TestRoundBidPricesRemovesZeroPrices(t *testing.T) {
+	seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Bids: []*PBSOrtbBid{
+				{Bid: &openrtb.Bid{ID: "tiny-bid", ImpID: "my-imp", Price: 0.004}},
+				{Bid: &openrtb.Bid{ID: "good-bid", ImpID: "my-imp", Price: 0.014}},
+			},
+		},
+	}
+	errs := roundBidPrices(&openrtb.BidRequest{}, seatBids, config.PriceRounding{Mode: config.PriceRoundingTruncate, Precision: 2}, 0, nil)
+
+	assertPrice(t, seatBids[openrtb_ext.BidderAppnexus], 0.01)
+	assertRoundingRejection(t, errs[openrtb_ext.BidderAppnexus], "tiny-bid", pbsmetrics.BidRejectionNonPositivePrice)
+}
+
+func TestRoundBidPricesRemovesBidsBelowFloor(t *testing.T) {
+	request := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{{ID: "my-imp", BidFloor: 1.235}},
+	}
+	seatBids := map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Bids: []*PBSOrtbBid{
+				{Bid: &openrtb.Bid{ID: "floor-bid", ImpID: "my-imp", Price: 1.2351}},
+				{Bid: &openrtb.Bid{ID: "good-bid", ImpID: "my-imp", Price: 1.25}},
+			},
+		},
+	}
+	errs := roundBidPrices(request, seatBids, config.PriceRounding{Mode: config.PriceRoundingTruncate, Precision: 2}, 0, nil)
+
+	assertPrice(t, seatBids[openrtb_ext.BidderAppnexus], 1.25)
+	assertRoundingRejection(t, errs[openrtb_ext.BidderAppnexus], "floor-bid", pbsmetrics.BidRejectionBelowFloor)
+}
+
+func TestRoundBidPricesKeepsBidsWhichMissedTheFloor(t *testing.T) {
+	request := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{{ID: "my-imp", BidFloor: 2}},
+	}
+	seatBids := newRoundingSeatBids()
+	delete(seatBids, openrtb_ext.BidderRubicon)
+	// The floor check was skipped, so rounding shouldn't be what removes the Bid.
+	errs := roundBidPrices(request, seatBids, config.PriceRounding{Mode: config.PriceRoundingTruncate, Precision: 2}, 0, nil)
+
+	assertPrice(t, seatBids[openrtb_ext.BidderAppnexus], 1.23)
+	if len(errs) != 0 {
+		t.Errorf("Expected no rejections. Got %v", errs)
+	}
+}
+
+func assertRoundingRejection(t *testing.T, errs []error, bidID string, reason pbsmetrics.BidRejectionReason) {
+	t.Helper()
+	if len(errs) != 1 {
+		t.Fatalf("Expected 1 rejection. Got %v", errs)
+	}
+	rejection, ok := errs[0].(*BidRejectionError)
+	if !ok {
+		t.Fatalf("Expected a BidRejectionError. Got %T", errs[0])
+	}
+	if rejection.BidID != bidID || rejection.Reason != reason {
+		t.Errorf("Expected bid \"%s\" to be rejected for %s. Got bid \"%s\" for %s", bidID, reason, rejection.BidID, rejection.Reason)
+	}
+}
+
+func newRoundingSeatBids() map[openrtb_ext.BidderName]*PBSOrtbSeatBid {
+	return map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {
+			Bids: []*PBSOrtbBid{{
+				Bid: &openrtb.Bid{ID: "apn-bid", ImpID: "my-imp", Price: 1.2351},
+			}},
+			Currency: "USD",
+		},
+		openrtb_ext.BidderRubicon: {
+			Bids: []*PBSOrtbBid{{
+				Bid: &openrtb.Bid{ID: "rubi-bid", ImpID: "my-imp", Price: 123.51},
+			}},
+			Currency: "JPY",
+		},
+	}
+}
@@ -1,8 +1,37 @@
 package exchange
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+)
 
 // ExtSeatBid defines the contract for bidresponse.seatbid.ext
 type ExtSeatBid struct {
 	Bidder json.RawMessage `json:"bidder,omitempty"`
 }
+
+// resolveSeat makes sure that the seatBid is attributed to the bidder which made it.
+//
+// The seat resolves if it's the bidder's name, or the name of the core bidder which it aliases.
+// Otherwise, if drop is true, all of the seatBid's Bids are removed and the returned error explains why.
+// If drop is false, the seatBid is assigned to the bidder.
+func resolveSeat(seatBid *PBSOrtbSeatBid, bidder openrtb_ext.BidderName, coreBidder openrtb_ext.BidderName, drop bool) error {
+	if seatBid == nil || len(seatBid.Bids) == 0 {
+		return nil
+	}
+	if seatBid.Seat == string(bidder) || seatBid.Seat == string(coreBidder) {
+		return nil
+	}
+	if drop {
+		seat := seatBid.Seat
+		seatBid.Bids = nil
+		if seat == "" {
+			return newBidRejection("", pbsmetrics.BidRejectionMissingSeat, "Bids from %s were removed because the seatbid didn't declare a seat", bidder)
+		}
+		return newBidRejection("", pbsmetrics.BidRejectionMissingSeat, "Bids from %s were removed because the seatbid's seat \"%s\" isn't a known bidder name", bidder, seat)
+	}
+	seatBid.Seat = string(bidder)
+	return nil
+}
@@ -0,0 +1,85 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+)
+
+func TestResolveSeatAssignsMissingSeat(t *testing.T) {
+	seatBid := newSeatBidForSeat("")
+	if err := resolveSeat(seatBid, "districtm", openrtb_ext.BidderAppnexus, false); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if seatBid.Seat != "districtm" {
+		t.Errorf("Expected the seat to be assigned to districtm. Got %s", seatBid.Seat)
+	}
+	assertBidIDs(t, seatBid, []string{"bid"})
+}
+
+func TestResolveSeatDropsMissingSeat(t *testing.T) {
+	seatBid := newSeatBidForSeat("")
+	err := resolveSeat(seatBid, openrtb_ext.BidderAppnexus, openrtb_ext.BidderAppnexus, true)
+	assertSeatRejection(t, err)
+	assertBidIDs(t, seatBid, []string{})
+}
+
+func TestResolveSeatDropsUnknownSeat(t *testing.T) {
+	seatBid := newSeatBidForSeat("some-other-seat")
+	err := resolveSeat(seatBid, openrtb_ext.BidderAppnexus, openrtb_ext.BidderAppnexus, true)
+	assertSeatRejection(t, err)
+	assertBidIDs(t, seatBid, []string{})
+}
+
+func TestResolveSeatKeepsPresentSeat(t *testing.T) {
+	for _, drop := range []bool{true, false} {
+		seatBid := newSeatBidForSeat("appnexus")
+		if err := resolveSeat(seatBid, "districtm", openrtb_ext.BidderAppnexus, drop); err != nil {
+			t.Errorf("Unexpected error with drop=%t: %v", drop, err)
+		}
+		if seatBid.Seat != "appnexus" {
+			t.Errorf("Expected the seat to stay appnexus with drop=%t. Got %s", drop, seatBid.Seat)
+		}
+		assertBidIDs(t, seatBid, []string{"bid"})
+	}
+}
+
+func TestResolveSeatIgnoresEmptySeatBids(t *testing.T) {
+	if err := resolveSeat(nil, openrtb_ext.BidderAppnexus, openrtb_ext.BidderAppnexus, true); err != nil {
+		t.Errorf("Unexpected error for a nil seatbid: %v", err)
+	}
+	if err := resolveSeat(&PBSOrtbSeatBid{}, openrtb_ext.BidderAppnexus, openrtb_ext.BidderAppnexus, true); err != nil {
+		t.Errorf("Unexpected error for a seatbid without bids: %v", err)
+	}
+}
+
+func newSeatBidForSeat(seat string) *PBSOrtbSeatBid {
+	return &PBSOrtbSeatBid{
+		Seat: seat,
+		Bids: []*PBSOrtbBid{
+			{
+				Bid: &openrtb.Bid{
+					ID:    "bid",
+					ImpID: "imp",
+					Price: 1,
+				},
+			},
+		},
+	}
+}
+
+func assertSeatRejection(t *testing.T, err error) {
+	t.Helper()
+	rejection, ok := err.(*BidRejectionError)
+	if !ok {
+		t.Fatalf("Expected a *BidRejectionError. Got %#v", err)
+	}
+	if rejection.BidID != "" {
+		t.Errorf("Expected a seat-level rejection. Got one for bid %s", rejection.BidID)
+	}
+	if rejection.Reason != pbsmetrics.BidRejectionMissingSeat {
+		t.Errorf("Expected reason %s. Got %s", pbsmetrics.BidRejectionMissingSeat, rejection.Reason)
+	}
+}
@@ -0,0 +1,58 @@
+package exchange
+
+import (
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+// inferBidSizes fills in the W and H of banner Bids which omit them, if the imp they were made for only allows one size.
+// Many Bidders expect Prebid Server to infer the size from the imp, but the ad server needs it to pick a line item.
+//
+// Bids on imps with several sizes are left alone, since there's no way to tell which one the creative uses.
+// Bids which define either their W or H are left alone too.
+func inferBidSizes(request *openrtb.BidRequest, seatBid *PBSOrtbSeatBid) {
+	if seatBid == nil {
+		return
+	}
+	for _, bid := range seatBid.Bids {
+		if bid.Bid.W != 0 || bid.Bid.H != 0 {
+			continue
+		}
+		if bidMediaType(request, bid) != openrtb_ext.BidTypeBanner {
+			continue
+		}
+		for i := 0; i < len(request.Imp); i++ {
+			if request.Imp[i].ID != bid.Bid.ImpID {
+				continue
+			}
+			if w, h, ok := onlyBannerSize(request.Imp[i].Banner); ok {
+				bid.Bid.W, bid.Bid.H = w, h
+			}
+			break
+		}
+	}
+}
+
+// onlyBannerSize returns the banner's size, if it allows exactly one.
+func onlyBannerSize(banner *openrtb.Banner) (w uint64, h uint64, ok bool) {
+	if banner == nil {
+		return 0, 0, false
+	}
+	sizes := make([]openrtb.Format, 0, len(banner.Format)+1)
+	if banner.W != nil && banner.H != nil && *banner.W != 0 && *banner.H != 0 {
+		sizes = append(sizes, openrtb.Format{W: *banner.W, H: *banner.H})
+	}
+	for _, format := range banner.Format {
+		if format.W == 0 || format.H == 0 {
+			continue
+		}
+		if len(sizes) > 0 && sizes[0].W == format.W && sizes[0].H == format.H {
+			continue
+		}
+		sizes = append(sizes, format)
+	}
+	if len(sizes) != 1 {
+		return 0, 0, false
+	}
+	return sizes[0].W, sizes[0].H, true
+}
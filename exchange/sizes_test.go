@@ -0,0 +1,88 @@
+package exchange
+
+import (
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/openrtb_ext"
+)
+
+func TestInferBidSizes(t *testing.T) {
+	width, height := uint64(728), uint64(90)
+	request := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{
+			{
+				ID: "single-format",
+				Banner: &openrtb.Banner{
+					Format: []openrtb.Format{{W: 300, H: 250}},
+				},
+			},
+			{
+				ID: "banner-size",
+				Banner: &openrtb.Banner{
+					W: &width,
+					H: &height,
+				},
+			},
+			{
+				ID: "multi-format",
+				Banner: &openrtb.Banner{
+					Format: []openrtb.Format{{W: 300, H: 250}, {W: 300, H: 600}},
+				},
+			},
+			{
+				ID: "video",
+				Video: &openrtb.Video{
+					W: 640,
+					H: 480,
+				},
+			},
+		},
+	}
+	seatBid := &PBSOrtbSeatBid{
+		Bids: []*PBSOrtbBid{
+			newSizedBid("single-format", openrtb_ext.BidTypeBanner, 0, 0),
+			newSizedBid("banner-size", "", 0, 0),
+			newSizedBid("multi-format", openrtb_ext.BidTypeBanner, 0, 0),
+			newSizedBid("single-format", openrtb_ext.BidTypeBanner, 320, 50),
+			newSizedBid("video", openrtb_ext.BidTypeVideo, 0, 0),
+		},
+	}
+	inferBidSizes(request, seatBid)
+
+	assertBidSize(t, seatBid.Bids[0], 300, 250)
+	assertBidSize(t, seatBid.Bids[1], 728, 90)
+	assertBidSize(t, seatBid.Bids[2], 0, 0)
+	assertBidSize(t, seatBid.Bids[3], 320, 50)
+	assertBidSize(t, seatBid.Bids[4], 0, 0)
+}
+
+func TestOnlyBannerSizeIgnoresDuplicates(t *testing.T) {
+	width, height := uint64(300), uint64(250)
+	w, h, ok := onlyBannerSize(&openrtb.Banner{
+		W:      &width,
+		H:      &height,
+		Format: []openrtb.Format{{W: 300, H: 250}},
+	})
+	if !ok || w != 300 || h != 250 {
+		t.Errorf("Expected the banner to allow only 300x250. Got %dx%d, %t", w, h, ok)
+	}
+}
+
+func newSizedBid(impID string, bidType openrtb_ext.BidType, w uint64, h uint64) *PBSOrtbBid {
+	return &PBSOrtbBid{
+		Bid: &openrtb.Bid{
+			ImpID: impID,
+			W:     w,
+			H:     h,
+		},
+		BidType: bidType,
+	}
+}
+
+func assertBidSize(t *testing.T, bid *PBSOrtbBid, w uint64, h uint64) {
+	t.Helper()
+	if bid.Bid.W != w || bid.Bid.H != h {
+		t.Errorf("Expected the bid on imp %s to be %dx%d. Got %dx%d", bid.Bid.ImpID, w, h, bid.Bid.W, bid.Bid.H)
+	}
+}
@@ -22,6 +22,44 @@ type TargetData struct {
 	IncludeBidderKeys bool
 	IncludeCacheBids  bool
 	IncludeCacheVast  bool
+	// MediaTypePriceGranularity overrides the PriceGranularity for Bids of each media type.
+	MediaTypePriceGranularity map[openrtb_ext.BidType]openrtb_ext.PriceGranularity
+	// KeyGenerator makes the targeting keys of each top Bid. If nil, the standard hb_* keys are used.
+	KeyGenerator TargetingKeyGenerator
+}
+
+// TargetingKeyGenerator makes the targeting keys which are sent to the publisher's ad server for a Bid.
+//
+// Prebid Server hosts can supply their own TargetingKeyGenerator through the Plugins given to NewExchange,
+// for example to rename the keys for a bespoke ad server setup. By default, the standard hb_* keys are used.
+// Implementations must be threadsafe, since auctions run concurrently.
+type TargetingKeyGenerator interface {
+	// TargetingKeys returns the keys for one Bidder's top Bid on an Imp. It's only called on valid Bids.
+	// The targData says which keys the request asked for. Neither it nor the bid should be mutated.
+	TargetingKeys(targData *TargetData, bid *TargetingBid) map[string]string
+}
+
+// TargetingKeyGeneratorFunc adapts an ordinary function into a TargetingKeyGenerator.
+type TargetingKeyGeneratorFunc func(targData *TargetData, bid *TargetingBid) map[string]string
+
+// TargetingKeys calls f(targData, bid).
+func (f TargetingKeyGeneratorFunc) TargetingKeys(targData *TargetData, bid *TargetingBid) map[string]string {
+	return f(targData, bid)
+}
+
+// TargetingBid is what the auction knows about a Bidder's top Bid on an Imp when its targeting keys are made.
+type TargetingBid struct {
+	Bid    *PBSOrtbBid
+	Bidder openrtb_ext.BidderName
+	// IsOverallWinner is true if the Bid won the Imp.
+	IsOverallWinner bool
+	// PriceBucket is the Bid's price, rounded into the request's price granularity. It's empty if the price couldn't be rounded.
+	PriceBucket string
+	// CacheID and VASTCacheID are empty if the Bid or its VAST weren't cached.
+	CacheID     string
+	VASTCacheID string
+	// IsApp is true if the request came from an app, rather than a site.
+	IsApp bool
 }
 
 // SetTargeting writes all the targeting params into the bids.
@@ -31,44 +69,66 @@ type TargetData struct {
 // it's ok if those stay in the auction. For now, this method implements a very naive cache strategy.
 // In the future, we should implement a more clever retry & backoff strategy to balance the success rate & performance.
 func (targData *TargetData) SetTargeting(auc *Auction, isApp bool) {
+	var generator TargetingKeyGenerator = hbTargetingKeyGenerator{}
+	if targData.KeyGenerator != nil {
+		generator = targData.KeyGenerator
+	}
 	for impId, topBidsPerImp := range auc.winningBidsByBidder {
 		overallWinner := auc.winningBids[impId]
 		for bidderName, topBidPerBidder := range topBidsPerImp {
-			isOverallWinner := overallWinner == topBidPerBidder
-
-			targets := make(map[string]string, 10)
-			if cpm, ok := auc.roundedPrices[topBidPerBidder]; ok {
-				targData.addKeys(targets, openrtb_ext.HbpbConstantKey, cpm, bidderName, isOverallWinner)
-			}
-			targData.addKeys(targets, openrtb_ext.HbBidderConstantKey, string(bidderName), bidderName, isOverallWinner)
-			if hbSize := makeHbSize(topBidPerBidder.Bid); hbSize != "" {
-				targData.addKeys(targets, openrtb_ext.HbSizeConstantKey, hbSize, bidderName, isOverallWinner)
-			}
-			if cacheID, ok := auc.cacheIds[topBidPerBidder.Bid]; ok {
-				targData.addKeys(targets, openrtb_ext.HbCacheKey, cacheID, bidderName, isOverallWinner)
-			}
-			if vastID, ok := auc.vastCacheIds[topBidPerBidder.Bid]; ok {
-				targData.addKeys(targets, openrtb_ext.HbVastCacheKey, vastID, bidderName, isOverallWinner)
-			}
-			if deal := topBidPerBidder.Bid.DealID; len(deal) > 0 {
-				targData.addKeys(targets, openrtb_ext.HbDealIdConstantKey, deal, bidderName, isOverallWinner)
-			}
-
-			if bidderName == "audienceNetwork" {
-				targets[string(openrtb_ext.HbCreativeLoadMethodConstantKey)] = openrtb_ext.HbCreativeLoadMethodDemandSDK
-			} else {
-				targets[string(openrtb_ext.HbCreativeLoadMethodConstantKey)] = openrtb_ext.HbCreativeLoadMethodHTML
-			}
-
-			if isApp {
-				targData.addKeys(targets, openrtb_ext.HbEnvKey, openrtb_ext.HbEnvKeyApp, bidderName, isOverallWinner)
-			}
-
-			topBidPerBidder.BidTargets = targets
+			topBidPerBidder.BidTargets = generator.TargetingKeys(targData, &TargetingBid{
+				Bid:             topBidPerBidder,
+				Bidder:          bidderName,
+				IsOverallWinner: overallWinner == topBidPerBidder,
+				PriceBucket:     auc.roundedPrices[topBidPerBidder],
+				CacheID:         auc.cacheIds[topBidPerBidder.Bid],
+				VASTCacheID:     auc.vastCacheIds[topBidPerBidder.Bid],
+				IsApp:           isApp,
+			})
 		}
 	}
 }
 
+// hbTargetingKeyGenerator is the default TargetingKeyGenerator. It makes the standard hb_* keys.
+type hbTargetingKeyGenerator struct{}
+
+func (hbTargetingKeyGenerator) TargetingKeys(targData *TargetData, bid *TargetingBid) map[string]string {
+	bidderName := bid.Bidder
+	isOverallWinner := bid.IsOverallWinner
+
+	targets := make(map[string]string, 10)
+	if bid.PriceBucket != "" {
+		targData.addKeys(targets, openrtb_ext.HbpbConstantKey, bid.PriceBucket, bidderName, isOverallWinner)
+	}
+	targData.addKeys(targets, openrtb_ext.HbBidderConstantKey, string(bidderName), bidderName, isOverallWinner)
+	if hbSize := makeHbSize(bid.Bid.Bid); hbSize != "" {
+		targData.addKeys(targets, openrtb_ext.HbSizeConstantKey, hbSize, bidderName, isOverallWinner)
+	}
+	if bid.CacheID != "" {
+		targData.addKeys(targets, openrtb_ext.HbCacheKey, bid.CacheID, bidderName, isOverallWinner)
+	}
+	if bid.VASTCacheID != "" {
+		targData.addKeys(targets, openrtb_ext.HbVastCacheKey, bid.VASTCacheID, bidderName, isOverallWinner)
+	}
+	if deal := bid.Bid.Bid.DealID; len(deal) > 0 {
+		targData.addKeys(targets, openrtb_ext.HbDealIdConstantKey, deal, bidderName, isOverallWinner)
+	}
+	if bid.Bid.DealTierSatisfied {
+		targData.addKeys(targets, openrtb_ext.HbDealTierKey, bid.Bid.DealTier, bidderName, isOverallWinner)
+	}
+
+	if bidderName == "audienceNetwork" {
+		targets[string(openrtb_ext.HbCreativeLoadMethodConstantKey)] = openrtb_ext.HbCreativeLoadMethodDemandSDK
+	} else {
+		targets[string(openrtb_ext.HbCreativeLoadMethodConstantKey)] = openrtb_ext.HbCreativeLoadMethodHTML
+	}
+
+	if bid.IsApp {
+		targData.addKeys(targets, openrtb_ext.HbEnvKey, openrtb_ext.HbEnvKeyApp, bidderName, isOverallWinner)
+	}
+	return targets
+}
+
 func (targData *TargetData) addKeys(keys map[string]string, key openrtb_ext.TargetingKey, value string, bidderName openrtb_ext.BidderName, overallWinner bool) {
 	if targData.IncludeBidderKeys {
 		keys[key.BidderKey(bidderName, maxKeyLength)] = value
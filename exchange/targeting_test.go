@@ -25,17 +25,20 @@ var mockBids = map[openrtb_ext.BidderName][]*openrtb.Bid{
 		ImpID: "some-imp",
 		Price: 0.5,
 		CrID:  "1",
+		AdM:   "some-markup",
 	}, {
 		ID:    "winning-bid",
 		ImpID: "some-imp",
 		Price: 0.7,
 		CrID:  "2",
+		AdM:   "some-markup",
 	}},
 	openrtb_ext.BidderRubicon: {{
 		ID:    "contending-bid",
 		ImpID: "some-imp",
 		Price: 0.6,
 		CrID:  "3",
+		AdM:   "some-markup",
 	}},
 }
 
@@ -164,9 +167,11 @@ func buildImps(t *testing.T, mockBids map[openrtb_ext.BidderName][]*openrtb.Bid)
 
 	imps := make([]openrtb.Imp, 0, len(impIds))
 	for impId := range impIds {
+		// The mock bids are banners, which the imps need to offer.
 		imps = append(imps, openrtb.Imp{
-			ID:  impId,
-			Ext: impExt,
+			ID:     impId,
+			Banner: &openrtb.Banner{},
+			Ext:    impExt,
 		})
 	}
 	return imps
@@ -228,3 +233,75 @@ func (f *mockFetcher) GetId(bidder openrtb_ext.BidderName) (string, bool) {
 func mockServer(w http.ResponseWriter, req *http.Request) {
 	w.Write([]byte("{}"))
 }
+
+func TestDefaultTargetingKeys(t *testing.T) {
+	auc, winner, loser := newTargetingTestAuction()
+	targData := &TargetData{
+		PriceGranularity:  openrtb_ext.PriceGranularityFromString("med"),
+		IncludeWinners:    true,
+		IncludeBidderKeys: true,
+	}
+	auc.SetRoundedPrices(targData.PriceGranularity, nil)
+	targData.SetTargeting(auc, true)
+
+	assertTargetingKey(t, winner, string(openrtb_ext.HbpbConstantKey), true)
+	assertTargetingKey(t, winner, string(openrtb_ext.HbBidderConstantKey), true)
+	assertTargetingKey(t, winner, openrtb_ext.HbpbConstantKey.BidderKey(openrtb_ext.BidderAppnexus, maxKeyLength), true)
+	assertTargetingKey(t, winner, string(openrtb_ext.HbEnvKey), true)
+	assertTargetingKey(t, loser, string(openrtb_ext.HbpbConstantKey), false)
+	assertTargetingKey(t, loser, openrtb_ext.HbpbConstantKey.BidderKey(openrtb_ext.BidderRubicon, maxKeyLength), true)
+	if price := winner.BidTargets[string(openrtb_ext.HbpbConstantKey)]; price != "0.70" {
+		t.Errorf("Expected the winner's hb_pb to be 0.70. Got %s", price)
+	}
+	if size := winner.BidTargets[string(openrtb_ext.HbSizeConstantKey)]; size != "300x250" {
+		t.Errorf("Expected the winner's hb_size to be 300x250. Got %s", size)
+	}
+}
+
+func TestCustomTargetingKeys(t *testing.T) {
+	auc, winner, loser := newTargetingTestAuction()
+	targData := &TargetData{
+		PriceGranularity: openrtb_ext.PriceGranularityFromString("med"),
+		IncludeWinners:   true,
+		KeyGenerator: TargetingKeyGeneratorFunc(func(targData *TargetData, bid *TargetingBid) map[string]string {
+			keys := map[string]string{
+				"pbs_price_" + string(bid.Bidder): bid.PriceBucket,
+			}
+			if targData.IncludeWinners && bid.IsOverallWinner {
+				keys["pbs_winner"] = string(bid.Bidder)
+			}
+			return keys
+		}),
+	}
+	auc.SetRoundedPrices(targData.PriceGranularity, nil)
+	targData.SetTargeting(auc, false)
+
+	if len(winner.BidTargets) != 2 || winner.BidTargets["pbs_winner"] != "appnexus" || winner.BidTargets["pbs_price_appnexus"] != "0.70" {
+		t.Errorf("Expected the winner to only have the custom keys. Got %v", winner.BidTargets)
+	}
+	if len(loser.BidTargets) != 1 || loser.BidTargets["pbs_price_rubicon"] != "0.60" {
+		t.Errorf("Expected the loser to only have its custom price key. Got %v", loser.BidTargets)
+	}
+}
+
+// newTargetingTestAuction prices the Bids off the bucket edges, since floats like 0.7 can round down into the bucket below.
+func newTargetingTestAuction() (auc *Auction, winner *PBSOrtbBid, loser *PBSOrtbBid) {
+	winner = &PBSOrtbBid{
+		Bid: &openrtb.Bid{ID: "winning-bid", ImpID: "some-imp", Price: 0.75, W: 300, H: 250},
+	}
+	loser = &PBSOrtbBid{
+		Bid: &openrtb.Bid{ID: "contending-bid", ImpID: "some-imp", Price: 0.65},
+	}
+	auc = NewAuction(map[openrtb_ext.BidderName]*PBSOrtbSeatBid{
+		openrtb_ext.BidderAppnexus: {Bids: []*PBSOrtbBid{winner}},
+		openrtb_ext.BidderRubicon:  {Bids: []*PBSOrtbBid{loser}},
+	}, 1, false)
+	return
+}
+
+func assertTargetingKey(t *testing.T, bid *PBSOrtbBid, key string, expected bool) {
+	t.Helper()
+	if _, ok := bid.BidTargets[key]; ok != expected {
+		t.Errorf("Bid %s has wrong key: %s. Expected? %t, Exists? %t", bid.Bid.ID, key, expected, ok)
+	}
+}
@@ -0,0 +1,1344 @@
+package exchange
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/buger/jsonparser"
+	"github.com/mxmCherry/openrtb"
+	nativeRequests "github.com/mxmCherry/openrtb/native/request"
+	"golang.org/x/text/currency"
+
+	"github.com/prebid/prebid-server/adapters"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/currencies"
+	"github.com/prebid/prebid-server/errortypes"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
+)
+
+// BidValidator checks the Bids returned by a Bidder. Bids which fail validation are removed from the auction.
+//
+// Prebid Server hosts can supply their own BidValidators through the Plugins given to NewExchange.
+// These run after Prebid Server's own checks, so they can assume that the Bid has an ID, a CrID, a positive price and an ImpID from the request.
+// The price may also be zero if the host allows zero-price Bids.
+// Implementations must be threadsafe, since Bids from different Bidders are validated concurrently.
+type BidValidator interface {
+	// Validate returns an error describing why the bid is invalid, or nil if it's valid.
+	// The error message will be user-facing in the API, under response.ext.errors.{bidderName}.
+	//
+	// The request is the one which was sent to the Bidder. It should not be mutated.
+	Validate(request *openrtb.BidRequest, bid *PBSOrtbBid) error
+}
+
+// BidValidatorFunc adapts an ordinary function into a BidValidator.
+type BidValidatorFunc func(request *openrtb.BidRequest, bid *PBSOrtbBid) error
+
+// Validate calls f(request, bid).
+func (f BidValidatorFunc) Validate(request *openrtb.BidRequest, bid *PBSOrtbBid) error {
+	return f(request, bid)
+}
+
+// ValidateBids will run some validation checks on the returned bids and excise any invalid bids
+// The validation options come from request.ext.prebid.validation, and may be nil. The host's options apply to every request.
+// The conversions are used to compare bid prices across currencies, and should be scoped to the current auction.
+// The validators run on each bid which passes Prebid Server's own checks.
+//
+// Bids rejected by Prebid Server's own checks are explained by *BidRejectionErrors.
+// The validators' errors are returned as-is.
+// The warnings are *errortypes.Warnings about Bids which were kept, but which the caller may want to know about.
+//
+// This replaces brw.AdapterBids.Bids with a new slice of the valid bids. The only other thing it mutates is the Bids' meta,
+// which gets its advertiserDomains backfilled from the bid.adomain if the host asks for that.
+// In the permissive mode, no Bids are removed. Each error which would have rejected one is returned as a warning instead.
+// It's safe to validate different BidResponseWrappers on different goroutines, even if they share the request,
+// the validators, and the Bids' underlying Conversions. A ConversionCache must not be shared, since it isn't threadsafe.
+func (brw *BidResponseWrapper) ValidateBids(request *openrtb.BidRequest, validation *openrtb_ext.ExtRequestValidation, hostValidation config.BidValidation, conversions currencies.Conversions, validators []BidValidator) (err []error, warnings []error) {
+	// Exit early if there is nothing to do.
+	if brw.AdapterBids == nil || len(brw.AdapterBids.Bids) == 0 {
+		return
+	}
+	if hostValidation.BackfillAdvertiserDomains {
+		backfillAdvertiserDomains(brw.AdapterBids.Bids)
+	}
+	validBids, seatCurrency, err, warnings := filterValidBids(request, brw.AdapterBids, validation, hostValidation, conversions, validators)
+	if hostValidation.Mode == config.ValidationModePermissive {
+		for _, rejection := range err {
+			warnings = append(warnings, &errortypes.Warning{
+				Message: fmt.Sprintf("%s. It was kept because bid validation is permissive", rejection.Error()),
+			})
+		}
+		return nil, warnings
+	}
+	brw.AdapterBids.Bids = validBids
+	brw.AdapterBids.Currency = seatCurrency
+	return
+}
+
+// filterValidBids returns a new slice with the valid bids from the seatBid and the currency which they're in, along with
+// the errors explaining why the others are invalid and any warnings about the valid ones. The currency is the seatBid's,
+// unless config.CurrencyCheckBid picked another. It doesn't mutate its arguments, and holds no state between calls.
+func filterValidBids(request *openrtb.BidRequest, seatBid *PBSOrtbSeatBid, validation *openrtb_ext.ExtRequestValidation, hostValidation config.BidValidation, conversions currencies.Conversions, validators []BidValidator) ([]*PBSOrtbBid, string, []error, []error) {
+	checkBidCurrencies := hostValidation.CurrencyCheck == config.CurrencyCheckBid
+	seatCurrency := seatBid.Currency
+	if checkBidCurrencies {
+		seatCurrency = perBidSeatCurrency(request.Cur, seatBid, hostValidation)
+	} else if cerr := validateCurrency(request.Cur, seatBid.Currency, hostValidation.ExtraCurrencies, hostValidation.DefaultCurrency, seatBid.AllowedCurrencies); cerr != nil {
+		return nil, seatCurrency, []error{cerr}, nil
+	}
+
+	// The Bids are checked against the currency which they'll end up in.
+	currencySeatBid := *seatBid
+	currencySeatBid.Currency = seatCurrency
+	defaultValidator := newDefaultBidValidator(request, &currencySeatBid, validation, hostValidation, conversions)
+
+	errs := make([]error, 0, len(seatBid.Bids))
+	var warnings []error
+	validBids := make([]*PBSOrtbBid, 0, len(seatBid.Bids))
+	for _, bid := range seatBid.Bids {
+		var cerr error
+		if checkBidCurrencies {
+			// Bids without a currency hint are in the bidder's currency, not the one which was picked for the seat.
+			cerr = validateBidCurrencyAllowed(bid, request.Cur, seatBid.Currency, hostValidation, seatBid.AllowedCurrencies)
+		}
+		if cerr != nil {
+			errs = append(errs, cerr)
+		} else if verr := defaultValidator.Validate(request, bid); verr != nil {
+			errs = append(errs, verr)
+		} else if verr := runBidValidators(validators, request, bid); verr != nil {
+			errs = append(errs, verr)
+		} else {
+			validBids = append(validBids, bid)
+			if bid.Bid.Price == 0 {
+				warnings = append(warnings, &errortypes.Warning{
+					Message: fmt.Sprintf("Bid \"%s\" has a zero 'price'. It was kept because the host allows zero-price bids", bid.Bid.ID),
+				})
+			}
+			if warning := defaultValidator.belowFloorWarning(bid); warning != nil {
+				warnings = append(warnings, warning)
+			}
+		}
+	}
+	validBids, dupeErrs := dedupeBidIDs(validBids, hostValidation.DuplicateBidIDs == config.DuplicateBidIDsKeepHighestPrice)
+	return validBids, seatCurrency, append(errs, dupeErrs...), warnings
+}
+
+// perBidSeatCurrency picks the currency which a SeatBid's Bids should be in, for config.CurrencyCheckBid.
+// That's the seat currency if it's allowed. Otherwise, it's the first allowed currency which one of the Bids names
+// in its bid.ext "cur", so that those Bids can survive. If none are allowed, the seat currency is kept.
+func perBidSeatCurrency(requestCurrencies []string, seatBid *PBSOrtbSeatBid, hostValidation config.BidValidation) string {
+	if validateCurrency(requestCurrencies, seatBid.Currency, hostValidation.ExtraCurrencies, hostValidation.DefaultCurrency, seatBid.AllowedCurrencies) == nil {
+		return seatBid.Currency
+	}
+	for _, bid := range seatBid.Bids {
+		hint := bidCurrencyHint(bid)
+		if hint != "" && validateCurrency(requestCurrencies, hint, hostValidation.ExtraCurrencies, hostValidation.DefaultCurrency, seatBid.AllowedCurrencies) == nil {
+			return strings.ToUpper(hint)
+		}
+	}
+	return seatBid.Currency
+}
+
+// validateBidCurrencyAllowed runs validateCurrency on a single Bid, for config.CurrencyCheckBid. The Bid is in the
+// currency from its bid.ext "cur", if it has one, and the seatCurrency otherwise. That should be the currency which
+// the bidder gave the SeatBid, rather than the one picked by perBidSeatCurrency.
+func validateBidCurrencyAllowed(bid *PBSOrtbBid, requestCurrencies []string, seatCurrency string, hostValidation config.BidValidation, bidderCurrencies []string) error {
+	bidCurrency := bidCurrencyHint(bid)
+	if bidCurrency == "" {
+		bidCurrency = seatCurrency
+	}
+	if err := validateCurrency(requestCurrencies, bidCurrency, hostValidation.ExtraCurrencies, hostValidation.DefaultCurrency, bidderCurrencies); err != nil {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionCurrencyNotAllowed, "Bid \"%s\": %s", bid.Bid.ID, err.Error())
+	}
+	return nil
+}
+
+// bidCurrencyHint returns the currency from the Bid's bid.ext "cur", or an empty string if it doesn't have one.
+func bidCurrencyHint(bid *PBSOrtbBid) string {
+	hint, err := jsonparser.GetString(bid.Bid.Ext, "cur")
+	if err != nil {
+		return ""
+	}
+	return hint
+}
+
+// dedupeBidIDs makes sure that no two Bids share an ID, since Bid IDs key the cache and the events.
+// IDs only need to be unique within a SeatBid, so this should be called on one SeatBid's Bids at a time.
+//
+// Of the Bids which share an ID, the first survives. If keepHighestPrice is true, the highest priced survives instead,
+// and ties go to the first. The survivors keep their order. The returned errors explain why the others were removed.
+func dedupeBidIDs(bids []*PBSOrtbBid, keepHighestPrice bool) ([]*PBSOrtbBid, []error) {
+	survivors := make(map[string]*PBSOrtbBid, len(bids))
+	hasDuplicates := false
+	for _, bid := range bids {
+		current, ok := survivors[bid.Bid.ID]
+		if !ok {
+			survivors[bid.Bid.ID] = bid
+			continue
+		}
+		hasDuplicates = true
+		if keepHighestPrice && bid.Bid.Price > current.Bid.Price {
+			survivors[bid.Bid.ID] = bid
+		}
+	}
+	if !hasDuplicates {
+		return bids, nil
+	}
+
+	var errs []error
+	deduped := make([]*PBSOrtbBid, 0, len(survivors))
+	for _, bid := range bids {
+		if survivors[bid.Bid.ID] == bid {
+			deduped = append(deduped, bid)
+		} else {
+			errs = append(errs, newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionDuplicateID, "Bid \"%s\" with price %f was removed because another bid in the seat has the same ID", bid.Bid.ID, bid.Bid.Price))
+		}
+	}
+	return deduped, errs
+}
+
+// BidderValidators returns the BidValidators which run on a core bidder's Bids. If the bidder's info declares
+// its capabilities, these start with a check that the Bids use its supported media types. The host's validators follow.
+func BidderValidators(bidder openrtb_ext.BidderName, info adapters.BidderInfo, validators []BidValidator) []BidValidator {
+	if info.Capabilities == nil {
+		return validators
+	}
+	return append([]BidValidator{&mediaTypeValidator{bidder: bidder, capabilities: info.Capabilities}}, validators...)
+}
+
+// mediaTypeValidator rejects Bids whose media type isn't one which the Bidder supports on the request's platform,
+// according to its static/bidder-info/{bidder}.yaml file. Those Bids would fail to render.
+type mediaTypeValidator struct {
+	bidder       openrtb_ext.BidderName
+	capabilities *adapters.CapabilitiesInfo
+}
+
+func (v *mediaTypeValidator) Validate(request *openrtb.BidRequest, bid *PBSOrtbBid) error {
+	platform := v.capabilities.Site
+	if request.App != nil {
+		platform = v.capabilities.App
+	}
+	if platform == nil {
+		return nil
+	}
+	mediaType := bidMediaType(request, bid)
+	if mediaType == "" {
+		return nil
+	}
+	for _, supported := range platform.MediaTypes {
+		if mediaType == supported {
+			return nil
+		}
+	}
+	supported := make([]string, len(platform.MediaTypes))
+	for i, supportedType := range platform.MediaTypes {
+		supported[i] = string(supportedType)
+	}
+	return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionUnsupportedMediaType, "Bid \"%s\" is type %s but bidder %s only supports %s", bid.Bid.ID, mediaType, v.bidder, strings.Join(supported, ", "))
+}
+
+// bidMediaType returns the Bid's type. If the Bidder didn't say, it falls back to the bid.mtype, then the imp's type.
+// This returns an empty string if the imp offers several types, since the Bid could be for any of them.
+func bidMediaType(request *openrtb.BidRequest, bid *PBSOrtbBid) openrtb_ext.BidType {
+	if bid.BidType != "" {
+		return bid.BidType
+	}
+	if mediaType := bid.BidMType.BidType(); mediaType != "" {
+		return mediaType
+	}
+	for i := 0; i < len(request.Imp); i++ {
+		imp := &request.Imp[i]
+		if imp.ID != bid.Bid.ImpID {
+			continue
+		}
+		var mediaTypes []openrtb_ext.BidType
+		if imp.Banner != nil {
+			mediaTypes = append(mediaTypes, openrtb_ext.BidTypeBanner)
+		}
+		if imp.Video != nil {
+			mediaTypes = append(mediaTypes, openrtb_ext.BidTypeVideo)
+		}
+		if imp.Audio != nil {
+			mediaTypes = append(mediaTypes, openrtb_ext.BidTypeAudio)
+		}
+		if imp.Native != nil {
+			mediaTypes = append(mediaTypes, openrtb_ext.BidTypeNative)
+		}
+		if len(mediaTypes) == 1 {
+			return mediaTypes[0]
+		}
+		return ""
+	}
+	return ""
+}
+
+// runBidValidators returns the error from the first validator which rejects the bid, or nil if they all accept it.
+func runBidValidators(validators []BidValidator, request *openrtb.BidRequest, bid *PBSOrtbBid) error {
+	for _, validator := range validators {
+		if err := validator.Validate(request, bid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaultBidValidator runs the checks which Prebid Server makes on every Bid from a single SeatBid.
+type defaultBidValidator struct {
+	checkMarkup    bool
+	allowZeroPrice bool
+	checkSecure    bool
+	checkNURL      bool
+	checkVAST      bool
+	checkNative    bool
+	maxAdmSize     int
+	maxCrIDLength  int
+	requiredMeta   []string
+	bannerMIMEs    []string
+	requiredMacros map[string][]string
+	coppa          bool
+	coppaAttrs     []int
+	dnt            bool
+	dntAttrs       []int
+	seatCurrency   string
+	seatVersion    string
+	impsByID       map[string]*openrtb.Imp
+	// Bids are only checked against the floors of the imps in this map
+	flooredImps    map[string]*openrtb.Imp
+	floorTolerance float64
+	maxPrice       float64
+	maxPriceCur    string
+	device         *openrtb.Device
+	maxInstlRatio  float64
+	languages      []string
+	conversions    currencies.Conversions
+}
+
+func newDefaultBidValidator(request *openrtb.BidRequest, seatBid *PBSOrtbSeatBid, validation *openrtb_ext.ExtRequestValidation, hostValidation config.BidValidation, conversions currencies.Conversions) *defaultBidValidator {
+	if conversions == nil {
+		conversions = currencies.NewConversionCache(nil)
+	}
+
+	impsByID := make(map[string]*openrtb.Imp, len(request.Imp))
+	for i := 0; i < len(request.Imp); i++ {
+		impsByID[request.Imp[i].ID] = &request.Imp[i]
+	}
+	flooredImps := impsByID
+	if validation != nil && validation.SkipFloorCheck {
+		flooredImps = nil
+	}
+
+	seatCurrency := seatBid.Currency
+	if seatCurrency == "" {
+		seatCurrency = hostValidation.DefaultCurrency
+	}
+	var languages []string
+	if validation != nil {
+		languages = validation.Languages
+	}
+
+	return &defaultBidValidator{
+		checkMarkup:    validation == nil || !validation.SkipMarkupCheck,
+		allowZeroPrice: hostValidation.AllowZeroPriceBids,
+		checkSecure:    !hostValidation.SkipSecureMarkupCheck && (validation == nil || !validation.SkipSecureCheck),
+		checkNURL:      hostValidation.CheckSecureNURL && (validation == nil || !validation.SkipSecureCheck),
+		checkVAST:      hostValidation.CheckVAST,
+		checkNative:    hostValidation.CheckNativeAssets,
+		maxAdmSize:     hostValidation.MaxAdmSize,
+		maxCrIDLength:  hostValidation.MaxCrIDLength,
+		requiredMeta:   hostValidation.RequiredBidMeta,
+		bannerMIMEs:    hostValidation.BannerMIMEs,
+		requiredMacros: hostValidation.RequiredMacros,
+		coppa:          request.Regs != nil && request.Regs.COPPA == 1,
+		coppaAttrs:     hostValidation.COPPAProhibitedAttributes,
+		dnt:            hostValidation.CheckDNT && request.Device != nil && request.Device.DNT == 1,
+		dntAttrs:       hostValidation.DNTProhibitedAttributes,
+		seatCurrency:   seatCurrency,
+		seatVersion:    seatBid.OpenRTBVersion,
+		impsByID:       impsByID,
+		flooredImps:    flooredImps,
+		floorTolerance: hostValidation.FloorTolerance,
+		maxPrice:       hostValidation.MaxBidPrice,
+		maxPriceCur:    ceilingCurrency(request.Cur, hostValidation.DefaultCurrency),
+		device:         request.Device,
+		maxInstlRatio:  hostValidation.MaxInterstitialRatio,
+		languages:      languages,
+		conversions:    conversions,
+	}
+}
+
+func (v *defaultBidValidator) Validate(request *openrtb.BidRequest, bid *PBSOrtbBid) error {
+	if ok, err := validateBid(bid, v.checkMarkup, v.allowZeroPrice); !ok {
+		return err
+	}
+	if err := validateBidImpID(bid, v.impsByID); err != nil {
+		return err
+	}
+	if err := validateBidImpMediaType(bid, v.impsByID[bid.Bid.ImpID]); err != nil {
+		return err
+	}
+	if err := validateBidOpenRTBVersion(bid, v.seatVersion); err != nil {
+		return err
+	}
+	if err := validateBidMType(bid, v.impsByID[bid.Bid.ImpID]); err != nil {
+		return err
+	}
+	if err := validateBidAdmSize(bid, v.maxAdmSize); err != nil {
+		return err
+	}
+	if err := validateBidCrIDLength(bid, v.maxCrIDLength); err != nil {
+		return err
+	}
+	if err := validateBidSize(bid, v.impsByID[bid.Bid.ImpID]); err != nil {
+		return err
+	}
+	if err := validateBidInterstitialSize(bid, v.impsByID[bid.Bid.ImpID], v.device, v.maxInstlRatio); err != nil {
+		return err
+	}
+	if err := validateBidCurrency(bid, v.seatCurrency); err != nil {
+		return err
+	}
+	if err := validateBidFloor(bid, v.flooredImps[bid.Bid.ImpID], v.seatCurrency, v.conversions, v.floorTolerance); err != nil {
+		return err
+	}
+	if err := validateBidCeiling(bid, v.maxPrice, v.seatCurrency, v.maxPriceCur, v.conversions); err != nil {
+		return err
+	}
+	if err := validateBidDeal(bid, v.impsByID[bid.Bid.ImpID]); err != nil {
+		return err
+	}
+	if err := validateBidDuration(bid, v.impsByID[bid.Bid.ImpID]); err != nil {
+		return err
+	}
+	if err := validateBidMIME(bid, v.impsByID[bid.Bid.ImpID]); err != nil {
+		return err
+	}
+	mediaType := bidMediaType(request, bid)
+	if err := validateBidBannerMIME(bid, mediaType, v.bannerMIMEs); err != nil {
+		return err
+	}
+	if err := validateBidAttributes(bid, v.impsByID[bid.Bid.ImpID], mediaType); err != nil {
+		return err
+	}
+	if err := validateBidAudio(bid, v.impsByID[bid.Bid.ImpID], mediaType); err != nil {
+		return err
+	}
+	if err := validateBidNativeMarkup(bid, v.impsByID[bid.Bid.ImpID], mediaType); err != nil {
+		return err
+	}
+	if err := validateBidMacros(bid, mediaType, v.requiredMacros); err != nil {
+		return err
+	}
+	if v.checkSecure {
+		if err := validateBidSecurity(bid, v.impsByID[bid.Bid.ImpID]); err != nil {
+			return err
+		}
+	}
+	if v.checkNURL {
+		if err := validateBidSecureNURL(bid, v.impsByID[bid.Bid.ImpID]); err != nil {
+			return err
+		}
+	}
+	if v.checkVAST {
+		if err := validateBidVAST(bid, v.impsByID[bid.Bid.ImpID]); err != nil {
+			return err
+		}
+	}
+	if err := validateBidVASTWrapper(bid, v.impsByID[bid.Bid.ImpID]); err != nil {
+		return err
+	}
+	if v.checkNative {
+		if err := validateBidNativeAssets(bid, v.impsByID[bid.Bid.ImpID]); err != nil {
+			return err
+		}
+	}
+	if err := validateBidMeta(bid, v.requiredMeta); err != nil {
+		return err
+	}
+	if v.coppa {
+		if err := validateBidCOPPA(bid, v.coppaAttrs); err != nil {
+			return err
+		}
+	}
+	if v.dnt {
+		if err := validateBidDNT(bid, v.dntAttrs); err != nil {
+			return err
+		}
+	}
+	if err := validateBidAdvertiserDomains(bid, request.BAdv); err != nil {
+		return err
+	}
+	if err := validateBidCategories(bid, request.BCat); err != nil {
+		return err
+	}
+	if err := validateBidLanguage(bid, v.languages); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validateCurrency will run currency validation checks and return true if it passes, false otherwise.
+// The extraCurrencies are accepted in addition to the ISO 4217 codes, for bidders which use custom or crypto currencies.
+// The defaultCurrency is assumed if the request or the bid doesn't name one. If it's empty, that's USD.
+// If the bidderCurrencies aren't empty, the bid currency must be one of them too.
+func validateCurrency(requestAllowedCurrencies []string, bidCurrency string, extraCurrencies []string, defaultCurrency string, bidderCurrencies []string) error {
+	if defaultCurrency == "" {
+		defaultCurrency = "USD"
+	}
+	// Make sure bid currency is a valid ISO currency code
+	if bidCurrency == "" {
+		// If bid currency is not set, then consider it's default currency.
+		bidCurrency = defaultCurrency
+	}
+	bidCurrency = strings.ToUpper(bidCurrency)
+	if !containsCurrency(extraCurrencies, bidCurrency) {
+		currencyUnit, cerr := currency.ParseISO(bidCurrency)
+		if cerr != nil {
+			return newBidRejection("", pbsmetrics.BidRejectionCurrencyNotAllowed, "%s", cerr.Error())
+		}
+		bidCurrency = currencyUnit.String()
+	}
+	// Make sure the bid currency is allowed from bid request via `cur` field.
+	// If `cur` field array from bid request is empty, then consider it accepts the default currency.
+	currencyAllowed := false
+	if len(requestAllowedCurrencies) == 0 {
+		requestAllowedCurrencies = []string{defaultCurrency}
+	}
+	for _, allowedCurrency := range requestAllowedCurrencies {
+		if strings.ToUpper(allowedCurrency) == bidCurrency {
+			currencyAllowed = true
+			break
+		}
+	}
+	if currencyAllowed == false {
+		return newBidRejection("", pbsmetrics.BidRejectionCurrencyNotAllowed,
+			"Bid currency is not allowed. Was '%s', wants: ['%s']",
+			bidCurrency,
+			strings.Join(requestAllowedCurrencies, "', '"),
+		)
+	}
+	if len(bidderCurrencies) > 0 && !containsCurrency(bidderCurrencies, bidCurrency) {
+		return newBidRejection("", pbsmetrics.BidRejectionCurrencyNotAllowed,
+			"Bid currency is not allowed for this bidder. Was '%s', wants: ['%s']",
+			bidCurrency,
+			strings.Join(bidderCurrencies, "', '"),
+		)
+	}
+
+	return nil
+}
+
+// containsCurrency returns true if the currencies contain the code, ignoring case.
+func containsCurrency(currencies []string, code string) bool {
+	for _, candidate := range currencies {
+		if strings.EqualFold(candidate, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateBid will run the supplied bid through validation checks and return true if it passes, false otherwise.
+// If checkMarkup is true, bids which can't be rendered because they have neither an adm nor an nurl are rejected.
+// If allowZeroPrice is true, bids with a zero price pass. Negative prices are always rejected.
+//
+// The checks themselves live in openrtb_ext.ValidateBid, so that services outside the exchange can share them.
+func validateBid(bid *PBSOrtbBid, checkMarkup bool, allowZeroPrice bool) (bool, error) {
+	err := openrtb_ext.ValidateBid(bid.Bid, openrtb_ext.BidValidationOptions{
+		SkipMarkupCheck: !checkMarkup,
+		AllowZeroPrice:  allowZeroPrice,
+	})
+	if err == nil {
+		return true, nil
+	}
+	bidID := ""
+	if bid.Bid != nil {
+		bidID = bid.Bid.ID
+	}
+	if invalid, ok := err.(*openrtb_ext.InvalidBidError); ok {
+		return false, newBidRejection(bidID, pbsmetrics.BidRejectionReason(invalid.Problem), "%s", invalid.Message)
+	}
+	return false, err
+}
+
+// validateBidImpID makes sure that a Bid is for one of the request's Imps. Bids for other Imps can't be matched
+// to an ad slot, and usually come from bugs in the adapter.
+func validateBidImpID(bid *PBSOrtbBid, impsByID map[string]*openrtb.Imp) error {
+	if _, ok := impsByID[bid.Bid.ImpID]; !ok {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionUnknownImpID, "Bid \"%s\" has impid \"%s\", which is not in the request", bid.Bid.ID, bid.Bid.ImpID)
+	}
+	return nil
+}
+
+// validateBidImpMediaType rejects Bids whose declared type isn't offered by the imp they were made for,
+// such as a video Bid on a banner-only imp. These usually come from a bug in the bidder's adapter.
+// Bids which don't declare a type take the imp's, so they can't mismatch.
+func validateBidImpMediaType(bid *PBSOrtbBid, imp *openrtb.Imp) error {
+	if imp == nil || bid.BidType == "" {
+		return nil
+	}
+	if !impOffers(imp, bid.BidType) {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionMediaTypeMismatch, "Bid \"%s\" is type %s, but imp \"%s\" doesn't offer it", bid.Bid.ID, bid.BidType, imp.ID)
+	}
+	return nil
+}
+
+// validateBidOpenRTBVersion makes sure that Bids only use the fields of the OpenRTB version which their Bidder declares
+// for its responses. Fields from newer versions can't be interpreted, since the Bidder can't have meant them.
+// If the Bidder didn't declare a version, every field is allowed.
+func validateBidOpenRTBVersion(bid *PBSOrtbBid, version string) error {
+	if version != adapters.OpenRTBVersion25 {
+		return nil
+	}
+	if bid.BidMType != 0 {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionOpenRTBVersion, "Bid \"%s\" has a bid.mtype, which OpenRTB %s responses don't have", bid.Bid.ID, version)
+	}
+	if bid.BidDur != 0 {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionOpenRTBVersion, "Bid \"%s\" has a bid.dur, which OpenRTB %s responses don't have", bid.Bid.ID, version)
+	}
+	return nil
+}
+
+// validateBidMType makes sure that Bids which give an OpenRTB 2.6 bid.mtype agree with their ext.prebid.type,
+// and are for an imp which offers that type. Bids without an mtype are left to the usual type inference.
+func validateBidMType(bid *PBSOrtbBid, imp *openrtb.Imp) error {
+	if bid.BidMType == 0 {
+		return nil
+	}
+	mediaType := bid.BidMType.BidType()
+	if mediaType == "" {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionMediaTypeMismatch, "Bid \"%s\" has an unknown mtype %d", bid.Bid.ID, bid.BidMType)
+	}
+	if bid.BidType != "" && bid.BidType != mediaType {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionMediaTypeMismatch, "Bid \"%s\" has mtype %d (%s), which contradicts its type %s", bid.Bid.ID, bid.BidMType, mediaType, bid.BidType)
+	}
+	if imp != nil && !impOffers(imp, mediaType) {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionMediaTypeMismatch, "Bid \"%s\" has mtype %d (%s), but imp \"%s\" doesn't offer it", bid.Bid.ID, bid.BidMType, mediaType, imp.ID)
+	}
+	return nil
+}
+
+// impOffers returns true if the imp has an object for the media type.
+func impOffers(imp *openrtb.Imp, mediaType openrtb_ext.BidType) bool {
+	switch mediaType {
+	case openrtb_ext.BidTypeBanner:
+		return imp.Banner != nil
+	case openrtb_ext.BidTypeVideo:
+		return imp.Video != nil
+	case openrtb_ext.BidTypeAudio:
+		return imp.Audio != nil
+	case openrtb_ext.BidTypeNative:
+		return imp.Native != nil
+	}
+	return false
+}
+
+// validateBidAdmSize rejects Bids whose adm is longer than maxSize bytes. A maxSize of 0 means there's no limit.
+func validateBidAdmSize(bid *PBSOrtbBid, maxSize int) error {
+	if maxSize > 0 && len(bid.Bid.AdM) > maxSize {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionMarkupTooLarge, "Bid \"%s\" has an adm of %d bytes, which exceeds the limit of %d bytes", bid.Bid.ID, len(bid.Bid.AdM), maxSize)
+	}
+	return nil
+}
+
+// validateBidCrIDLength rejects Bids whose crid is longer than maxLength bytes. A maxLength of 0 means there's no limit.
+func validateBidCrIDLength(bid *PBSOrtbBid, maxLength int) error {
+	if maxLength > 0 && len(bid.Bid.CrID) > maxLength {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionCreativeIDTooLong, "Bid \"%s\" has a crid of %d bytes, which exceeds the limit of %d bytes", bid.Bid.ID, len(bid.Bid.CrID), maxLength)
+	}
+	return nil
+}
+
+// validateBidSize makes sure that banner bids fit one of the sizes offered by the imp they were made for.
+// Bids with no width or height are left alone, since some formats are fluid.
+func validateBidSize(bid *PBSOrtbBid, imp *openrtb.Imp) error {
+	if imp == nil || imp.Banner == nil || bid.BidType != openrtb_ext.BidTypeBanner {
+		return nil
+	}
+	if bid.Bid.W == 0 || bid.Bid.H == 0 {
+		return nil
+	}
+	if imp.Banner.W != nil && imp.Banner.H != nil && *imp.Banner.W == bid.Bid.W && *imp.Banner.H == bid.Bid.H {
+		return nil
+	}
+	for _, format := range imp.Banner.Format {
+		if format.W == bid.Bid.W && format.H == bid.Bid.H {
+			return nil
+		}
+	}
+	return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionInvalidSize, "Bid \"%s\" has size %dx%d, which imp \"%s\" does not allow", bid.Bid.ID, bid.Bid.W, bid.Bid.H, imp.ID)
+}
+
+// validateBidInterstitialSize makes sure that Bids on interstitial imps aren't more than maxRatio times the width
+// or height of the device's screen, since they'd overflow it. A maxRatio of 0 means there's no limit.
+// Bids without a size, and requests whose device doesn't declare its screen size, pass.
+func validateBidInterstitialSize(bid *PBSOrtbBid, imp *openrtb.Imp, device *openrtb.Device, maxRatio float64) error {
+	if maxRatio <= 0 || imp == nil || imp.Instl != 1 || device == nil || device.W == 0 || device.H == 0 {
+		return nil
+	}
+	if bid.Bid.W == 0 || bid.Bid.H == 0 {
+		return nil
+	}
+	if float64(bid.Bid.W) > maxRatio*float64(device.W) || float64(bid.Bid.H) > maxRatio*float64(device.H) {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionInvalidSize, "Bid \"%s\" has size %dx%d, which overflows the %dx%d screen of interstitial imp \"%s\"", bid.Bid.ID, bid.Bid.W, bid.Bid.H, device.W, device.H, imp.ID)
+	}
+	return nil
+}
+
+// validateBidCurrency makes sure that a Bid doesn't claim a different currency than its SeatBid.
+// Bidders which aggregate several demand sources may set "cur" in the bid.ext. If they don't, the Bid is
+// assumed to be in the seat currency.
+func validateBidCurrency(bid *PBSOrtbBid, seatCurrency string) error {
+	bidCurrency, err := jsonparser.GetString(bid.Bid.Ext, "cur")
+	if err != nil || bidCurrency == "" {
+		return nil
+	}
+	if seatCurrency == "" {
+		seatCurrency = "USD"
+	}
+	if !strings.EqualFold(bidCurrency, seatCurrency) {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionCurrencyMismatch, "Bid \"%s\" has currency '%s', which differs from its seat currency '%s'", bid.Bid.ID, bidCurrency, seatCurrency)
+	}
+	return nil
+}
+
+// validateBidFloor makes sure that a Bid's price, converted from the seat currency to the floor's currency,
+// is at least its floor. Bids for one of the imp's deals are held to that deal's bidfloor, and other Bids to the
+// imp.bidfloor. Floors which aren't positive don't apply.
+// Bids less than the tolerance below the floor, as a fraction of it, pass too. See belowFloorWarning.
+func validateBidFloor(bid *PBSOrtbBid, imp *openrtb.Imp, seatCurrency string, conversions currencies.Conversions, tolerance float64) error {
+	floor, floorCurrency, deal := bidFloor(bid, imp)
+	if floor <= 0 {
+		return nil
+	}
+	price, err := floorCurrencyPrice(bid, floorCurrency, seatCurrency, conversions)
+	if err != nil {
+		return newBidRejection(bid.Bid.ID, conversionRejectionReason(err), "Bid \"%s\" could not be compared to the floor of imp \"%s\": %v", bid.Bid.ID, imp.ID, err)
+	}
+	if price < floor*(1-tolerance) {
+		if deal != nil {
+			return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionBelowFloor, "Bid \"%s\" price %f below deal \"%s\" floor %f", bid.Bid.ID, price, deal.ID, floor)
+		}
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionBelowFloor, "Bid \"%s\" price %f below imp floor %f", bid.Bid.ID, price, floor)
+	}
+	return nil
+}
+
+// validateBidCeiling rejects Bids whose price, converted from the seat currency into the ceilingCurrency, is above maxPrice.
+// A maxPrice of 0 means there's no ceiling. Bids which can't be converted are rejected, since they can't be proven sane.
+func validateBidCeiling(bid *PBSOrtbBid, maxPrice float64, seatCurrency string, ceilingCurrency string, conversions currencies.Conversions) error {
+	if maxPrice <= 0 {
+		return nil
+	}
+	if seatCurrency == "" {
+		seatCurrency = "USD"
+	}
+	rate, err := conversions.GetRate(strings.ToUpper(seatCurrency), ceilingCurrency)
+	if err != nil {
+		return newBidRejection(bid.Bid.ID, conversionRejectionReason(err), "Bid \"%s\" could not be compared to the price ceiling: %v", bid.Bid.ID, err)
+	}
+	if price := bid.Bid.Price * rate; price > maxPrice {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionPriceTooHigh, "Bid \"%s\" price %f %s exceeds the ceiling of %f %s", bid.Bid.ID, price, ceilingCurrency, maxPrice, ceilingCurrency)
+	}
+	return nil
+}
+
+// ceilingCurrency returns the currency which the host's max_bid_price is in: the request's first currency,
+// or the default currency if the request doesn't list any.
+func ceilingCurrency(requestCurrencies []string, defaultCurrency string) string {
+	if len(requestCurrencies) > 0 {
+		return strings.ToUpper(requestCurrencies[0])
+	}
+	if defaultCurrency != "" {
+		return strings.ToUpper(defaultCurrency)
+	}
+	return "USD"
+}
+
+// conversionRejectionReason tells bad rates data apart from currencies which have no rate at all,
+// so that ops can see when the rates source needs fixing.
+func conversionRejectionReason(err error) pbsmetrics.BidRejectionReason {
+	if _, ok := err.(*currencies.InvalidRateError); ok {
+		return pbsmetrics.BidRejectionInvalidRate
+	}
+	return pbsmetrics.BidRejectionCurrencyUnconvertible
+}
+
+// belowFloorWarning returns a warning if a valid Bid is below its floor, which means the floor tolerance let it through.
+func (v *defaultBidValidator) belowFloorWarning(bid *PBSOrtbBid) error {
+	if v.floorTolerance <= 0 {
+		return nil
+	}
+	floor, floorCurrency, _ := bidFloor(bid, v.flooredImps[bid.Bid.ImpID])
+	if floor <= 0 {
+		return nil
+	}
+	price, err := floorCurrencyPrice(bid, floorCurrency, v.seatCurrency, v.conversions)
+	if err != nil || price >= floor {
+		return nil
+	}
+	return &errortypes.Warning{
+		Message: fmt.Sprintf("Bid \"%s\" price %f is below imp floor %f. It was kept because it's within the host's floor tolerance", bid.Bid.ID, price, floor),
+	}
+}
+
+// bidFloor returns the floor which a Bid is held to, and its currency. If the Bid is for one of the imp's deals,
+// that deal is returned too, and its floor applies instead of the imp's. Bids for deals which the imp didn't offer
+// are treated as open market Bids. The floor is 0 if the imp is nil.
+func bidFloor(bid *PBSOrtbBid, imp *openrtb.Imp) (float64, string, *openrtb.Deal) {
+	if imp == nil {
+		return 0, "", nil
+	}
+	if imp.PMP != nil && bid.Bid.DealID != "" {
+		for i := 0; i < len(imp.PMP.Deals); i++ {
+			if deal := &imp.PMP.Deals[i]; deal.ID == bid.Bid.DealID {
+				return deal.BidFloor, deal.BidFloorCur, deal
+			}
+		}
+	}
+	return imp.BidFloor, imp.BidFloorCur, nil
+}
+
+// floorCurrencyPrice converts the Bid's price from the seat currency into the floor currency.
+func floorCurrencyPrice(bid *PBSOrtbBid, floorCurrency string, seatCurrency string, conversions currencies.Conversions) (float64, error) {
+	if seatCurrency == "" {
+		seatCurrency = "USD"
+	}
+	if floorCurrency == "" {
+		floorCurrency = "USD"
+	}
+	rate, err := conversions.GetRate(strings.ToUpper(seatCurrency), strings.ToUpper(floorCurrency))
+	if err != nil {
+		return 0, err
+	}
+	return bid.Bid.Price * rate, nil
+}
+
+// validateBidDeal makes sure that Bids on private auction imps are for one of the deals which the imp offered.
+// Imps which aren't private auctions accept open market Bids, so their Bids aren't checked.
+func validateBidDeal(bid *PBSOrtbBid, imp *openrtb.Imp) error {
+	if imp == nil || imp.PMP == nil || imp.PMP.PrivateAuction != 1 {
+		return nil
+	}
+	if bid.Bid.DealID == "" {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionInvalidDeal, "Bid \"%s\" has no deal ID, but imp \"%s\" is a private auction", bid.Bid.ID, imp.ID)
+	}
+	for _, deal := range imp.PMP.Deals {
+		if deal.ID == bid.Bid.DealID {
+			return nil
+		}
+	}
+	return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionInvalidDeal, "Bid \"%s\" has deal ID \"%s\", which imp \"%s\" did not offer", bid.Bid.ID, bid.Bid.DealID, imp.ID)
+}
+
+// validateBidDuration makes sure that video Bids on video imps declare a positive duration, so that publishers can
+// fit them into ad pods. If the imp sets a minduration or maxduration, the Bid's duration must be within them.
+func validateBidDuration(bid *PBSOrtbBid, imp *openrtb.Imp) error {
+	if imp == nil || imp.Video == nil || bid.BidType != openrtb_ext.BidTypeVideo {
+		return nil
+	}
+	duration := bidDuration(bid)
+	if duration <= 0 {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionInvalidDuration, "Bid \"%s\" has no video duration, which imp \"%s\" requires", bid.Bid.ID, imp.ID)
+	}
+	if (imp.Video.MinDuration > 0 && duration < imp.Video.MinDuration) || (imp.Video.MaxDuration > 0 && duration > imp.Video.MaxDuration) {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionInvalidDuration, "Bid \"%s\" has duration %ds, which is outside the %d-%ds range of imp \"%s\"", bid.Bid.ID, duration, imp.Video.MinDuration, imp.Video.MaxDuration, imp.ID)
+	}
+	return nil
+}
+
+// bidDuration returns the Bid's duration in seconds, from its ext.prebid.video or, failing that, its OpenRTB 2.6 bid.dur.
+// It returns 0 if the Bid gives neither.
+func bidDuration(bid *PBSOrtbBid) int64 {
+	if bid.BidVideo != nil && bid.BidVideo.Duration > 0 {
+		return int64(bid.BidVideo.Duration)
+	}
+	return bid.BidDur
+}
+
+// validateBidAudio makes sure that audio Bids on audio imps have VAST or DAAST markup, and a positive duration
+// within the imp's minduration and maxduration. Bids which only have a nurl pass the markup check, since their
+// markup isn't known yet. Unlike validateBidVAST, only the root element is checked, so this is cheap enough to always run.
+func validateBidAudio(bid *PBSOrtbBid, imp *openrtb.Imp, mediaType openrtb_ext.BidType) error {
+	if imp == nil || imp.Audio == nil || mediaType != openrtb_ext.BidTypeAudio {
+		return nil
+	}
+	if bid.Bid.AdM != "" {
+		root := xmlRootElement(bid.Bid.AdM)
+		if root != "VAST" && root != "DAAST" {
+			return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionInvalidVAST, "Bid \"%s\" on audio imp \"%s\" doesn't have VAST or DAAST markup", bid.Bid.ID, imp.ID)
+		}
+	}
+	duration := bidDuration(bid)
+	if duration <= 0 {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionInvalidDuration, "Bid \"%s\" has no audio duration, which imp \"%s\" requires", bid.Bid.ID, imp.ID)
+	}
+	if (imp.Audio.MinDuration > 0 && duration < imp.Audio.MinDuration) || (imp.Audio.MaxDuration > 0 && duration > imp.Audio.MaxDuration) {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionInvalidDuration, "Bid \"%s\" has duration %ds, which is outside the %d-%ds range of imp \"%s\"", bid.Bid.ID, duration, imp.Audio.MinDuration, imp.Audio.MaxDuration, imp.ID)
+	}
+	return nil
+}
+
+// xmlRootElement returns the name of the first element in the markup, or "" if it doesn't start with an XML element.
+func xmlRootElement(markup string) string {
+	decoder := xml.NewDecoder(strings.NewReader(markup))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			return t.Name.Local
+		case xml.CharData:
+			if strings.TrimSpace(string(t)) != "" {
+				return ""
+			}
+		}
+	}
+}
+
+// validateBidMIME makes sure that video Bids which declare their creative's MIME type in the bid.ext "mime"
+// use one of the imp's video.mimes, since players may fail on other types. Bids which don't declare one pass,
+// because the type can't be inferred reliably.
+func validateBidMIME(bid *PBSOrtbBid, imp *openrtb.Imp) error {
+	if imp == nil || imp.Video == nil || len(imp.Video.MIMEs) == 0 || bid.BidType != openrtb_ext.BidTypeVideo {
+		return nil
+	}
+	mime, err := jsonparser.GetString(bid.Bid.Ext, "mime")
+	if err != nil || mime == "" {
+		return nil
+	}
+	for _, allowed := range imp.Video.MIMEs {
+		if strings.EqualFold(mime, allowed) {
+			return nil
+		}
+	}
+	return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionInvalidMIME, "Bid \"%s\" has MIME type \"%s\", which imp \"%s\" does not accept", bid.Bid.ID, mime, imp.ID)
+}
+
+// validateBidBannerMIME makes sure that banner Bids which declare their creative's MIME type in the bid.ext "mime"
+// use one of the allowed types. If none are allowed, the host doesn't restrict banner creatives, so every Bid passes.
+func validateBidBannerMIME(bid *PBSOrtbBid, mediaType openrtb_ext.BidType, allowed []string) error {
+	if len(allowed) == 0 || mediaType != openrtb_ext.BidTypeBanner {
+		return nil
+	}
+	mime, err := jsonparser.GetString(bid.Bid.Ext, "mime")
+	if err != nil || mime == "" {
+		return nil
+	}
+	for _, allowedMIME := range allowed {
+		if strings.EqualFold(mime, allowedMIME) {
+			return nil
+		}
+	}
+	return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionInvalidMIME, "Bid \"%s\" has MIME type \"%s\", which banner creatives may not use", bid.Bid.ID, mime)
+}
+
+// validateBidMacros makes sure that the Bid's adm contains each of the macros which the host requires for its media type.
+// Bids whose media type is unknown, or which have no adm, e.g. because their markup comes from the nurl, pass.
+func validateBidMacros(bid *PBSOrtbBid, mediaType openrtb_ext.BidType, required map[string][]string) error {
+	if len(required) == 0 || mediaType == "" || bid.Bid.AdM == "" {
+		return nil
+	}
+	for _, macro := range required[string(mediaType)] {
+		if !strings.Contains(bid.Bid.AdM, macro) {
+			return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionMissingMacro, "Bid \"%s\" has %s markup without the macro %s, which the host requires", bid.Bid.ID, mediaType, macro)
+		}
+	}
+	return nil
+}
+
+// validateBidAttributes makes sure that none of the Bid's creative attributes are blocked by the battr of the imp
+// object for its media type. If the media type is unknown, the Bid could be for any of the imp's objects, so all
+// of their battr apply. Bids which don't declare any attributes pass.
+func validateBidAttributes(bid *PBSOrtbBid, imp *openrtb.Imp, mediaType openrtb_ext.BidType) error {
+	if imp == nil || len(bid.Bid.Attr) == 0 {
+		return nil
+	}
+	var blocked []openrtb.CreativeAttribute
+	if imp.Banner != nil && (mediaType == "" || mediaType == openrtb_ext.BidTypeBanner) {
+		blocked = append(blocked, imp.Banner.BAttr...)
+	}
+	if imp.Video != nil && (mediaType == "" || mediaType == openrtb_ext.BidTypeVideo) {
+		blocked = append(blocked, imp.Video.BAttr...)
+	}
+	if imp.Audio != nil && (mediaType == "" || mediaType == openrtb_ext.BidTypeAudio) {
+		blocked = append(blocked, imp.Audio.BAttr...)
+	}
+	if imp.Native != nil && (mediaType == "" || mediaType == openrtb_ext.BidTypeNative) {
+		blocked = append(blocked, imp.Native.BAttr...)
+	}
+	for _, attr := range bid.Bid.Attr {
+		for _, blockedAttr := range blocked {
+			if attr == blockedAttr {
+				return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionBlockedAttribute, "Bid \"%s\" has creative attribute %d, which imp \"%s\" blocks", bid.Bid.ID, attr, imp.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// insecureResource matches http:// URLs which the browser would load from the markup: src, href, and similar attributes,
+// CSS url() values, and element text such as the URLs in VAST tags. This doesn't match URLs which are only used as
+// identifiers, like xmlns="http://www.w3.org/2000/svg", since those don't trigger mixed content warnings.
+var insecureResource = regexp.MustCompile(`(?i)(?:\b(?:src|href|srcset|poster|data|action|background)\s*=\s*["']?|url\(\s*["']?|>\s*(?:<!\[CDATA\[\s*)?)http://`)
+
+// validateBidSecurity makes sure that Bids on secure imps don't load insecure resources,
+// which would cause mixed content warnings on HTTPS pages.
+func validateBidSecurity(bid *PBSOrtbBid, imp *openrtb.Imp) error {
+	if imp == nil || imp.Secure == nil || *imp.Secure != 1 {
+		return nil
+	}
+	if insecureResource.MatchString(bid.Bid.AdM) {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionInsecureMarkup, "Bid \"%s\" has markup with http:// resources, but imp \"%s\" is secure", bid.Bid.ID, imp.ID)
+	}
+	return nil
+}
+
+// validateBidSecureNURL makes sure that Bids on secure imps don't have an http:// nurl, since browsers would block the win notice.
+// Bids without a nurl pass.
+func validateBidSecureNURL(bid *PBSOrtbBid, imp *openrtb.Imp) error {
+	if imp == nil || imp.Secure == nil || *imp.Secure != 1 {
+		return nil
+	}
+	if len(bid.Bid.NURL) >= len("http://") && strings.EqualFold(bid.Bid.NURL[:len("http://")], "http://") {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionInsecureNURL, "Bid \"%s\" has an http:// nurl, but imp \"%s\" is secure", bid.Bid.ID, imp.ID)
+	}
+	return nil
+}
+
+// validateBidVAST makes sure that video Bids on video imps have well-formed XML markup with a <VAST> root element,
+// so that broken VAST doesn't get cached and fail in the player. Wrappers pass too, since they're <VAST> documents
+// which point at another ad through their <VASTAdTagURI>. Bids with no adm are served from their nurl, so they're skipped.
+func validateBidVAST(bid *PBSOrtbBid, imp *openrtb.Imp) error {
+	if imp == nil || imp.Video == nil || bid.BidType != openrtb_ext.BidTypeVideo || bid.Bid.AdM == "" {
+		return nil
+	}
+	decoder := xml.NewDecoder(strings.NewReader(bid.Bid.AdM))
+	foundRoot := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionInvalidVAST, "Bid \"%s\" has malformed VAST: %v", bid.Bid.ID, err)
+		}
+		if start, ok := token.(xml.StartElement); ok && !foundRoot {
+			if start.Name.Local != "VAST" {
+				return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionInvalidVAST, "Bid \"%s\" has markup with root element <%s>, but VAST was expected", bid.Bid.ID, start.Name.Local)
+			}
+			foundRoot = true
+		}
+	}
+	if !foundRoot {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionInvalidVAST, "Bid \"%s\" has markup without a VAST element", bid.Bid.ID)
+	}
+	return nil
+}
+
+// validateBidVASTWrapper rejects video Bids whose VAST has <Wrapper> ads but no <InLine> ones, if the imp disallows wrappers
+// with imp.ext.prebid.disallowvastwrappers. Wrappers add another round trip before the video plays, which some players can't afford.
+// VAST which can't be parsed is left to validateBidVAST, since it's impossible to tell whether it's a wrapper.
+func validateBidVASTWrapper(bid *PBSOrtbBid, imp *openrtb.Imp) error {
+	if imp == nil || imp.Video == nil || bid.BidType != openrtb_ext.BidTypeVideo || bid.Bid.AdM == "" {
+		return nil
+	}
+	if disallowed, err := jsonparser.GetBoolean(imp.Ext, "prebid", "disallowvastwrappers"); err != nil || !disallowed {
+		return nil
+	}
+	decoder := xml.NewDecoder(strings.NewReader(bid.Bid.AdM))
+	hasWrapper := false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			switch start.Name.Local {
+			case "InLine":
+				return nil
+			case "Wrapper":
+				hasWrapper = true
+			}
+		}
+	}
+	if hasWrapper {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionVASTWrapper, "Bid \"%s\" has a VAST wrapper with no inline ad, but imp \"%s\" disallows wrappers", bid.Bid.ID, imp.ID)
+	}
+	return nil
+}
+
+// nativeResponse holds the parts of a native adm which validateBidNativeAssets needs.
+// Native 1.0 and 1.1 responses wrap everything in a "native" object, so that's read too.
+type nativeResponse struct {
+	Native *nativeResponse       `json:"native"`
+	Assets []nativeResponseAsset `json:"assets"`
+}
+
+type nativeResponseAsset struct {
+	ID    *int64          `json:"id"`
+	Title json.RawMessage `json:"title"`
+	Img   json.RawMessage `json:"img"`
+	Video json.RawMessage `json:"video"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// validateBidNativeMarkup makes sure that native Bids on native imps have markup which looks like a native response:
+// a JSON object with an "assets" key, or a "native" one for Native 1.0 and 1.1. Some bidders return HTML instead,
+// which the native renderer can't show. Bids with no adm are served from their nurl, so they're skipped.
+func validateBidNativeMarkup(bid *PBSOrtbBid, imp *openrtb.Imp, mediaType openrtb_ext.BidType) error {
+	if imp == nil || imp.Native == nil || mediaType != openrtb_ext.BidTypeNative || bid.Bid.AdM == "" {
+		return nil
+	}
+	var response map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(bid.Bid.AdM), &response); err != nil {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionInvalidNative, "Bid \"%s\" has malformed native markup: %v", bid.Bid.ID, err)
+	}
+	_, hasNative := response["native"]
+	_, hasAssets := response["assets"]
+	if !hasNative && !hasAssets {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionInvalidNative, "Bid \"%s\" has native markup without \"native\" or \"assets\"", bid.Bid.ID)
+	}
+	return nil
+}
+
+// validateBidNativeAssets makes sure that native Bids on native imps include every asset which the imp's native request
+// marks as required. Response assets are matched to the request's by ID. Assets without an ID match the first required asset
+// of the same type. Bids with no adm are served from their nurl, so they're skipped.
+func validateBidNativeAssets(bid *PBSOrtbBid, imp *openrtb.Imp) error {
+	if imp == nil || imp.Native == nil || bid.BidType != openrtb_ext.BidTypeNative || bid.Bid.AdM == "" {
+		return nil
+	}
+	var request nativeRequests.Request
+	if err := json.Unmarshal([]byte(imp.Native.Request), &request); err != nil {
+		return nil
+	}
+	var response nativeResponse
+	if err := json.Unmarshal([]byte(bid.Bid.AdM), &response); err != nil {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionInvalidNative, "Bid \"%s\" has malformed native markup: %v", bid.Bid.ID, err)
+	}
+	if response.Native != nil {
+		response = *response.Native
+	}
+
+	returnedIDs := make(map[int64]bool, len(response.Assets))
+	returnedTypes := make(map[string]int, len(response.Assets))
+	for _, asset := range response.Assets {
+		if asset.ID != nil {
+			returnedIDs[*asset.ID] = true
+		} else {
+			returnedTypes[nativeResponseAssetType(asset)]++
+		}
+	}
+	for _, asset := range request.Assets {
+		if asset.Required != 1 || returnedIDs[asset.ID] {
+			continue
+		}
+		assetType := nativeRequestAssetType(asset)
+		if returnedTypes[assetType] > 0 {
+			returnedTypes[assetType]--
+			continue
+		}
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionInvalidNative, "Bid \"%s\" is missing the required %s asset %d of imp \"%s\"", bid.Bid.ID, assetType, asset.ID, imp.ID)
+	}
+	return nil
+}
+
+func nativeRequestAssetType(asset nativeRequests.Asset) string {
+	switch {
+	case asset.Title != nil:
+		return "title"
+	case asset.Img != nil:
+		return "img"
+	case asset.Video != nil:
+		return "video"
+	case asset.Data != nil:
+		return "data"
+	}
+	return ""
+}
+
+func nativeResponseAssetType(asset nativeResponseAsset) string {
+	switch {
+	case len(asset.Title) > 0:
+		return "title"
+	case len(asset.Img) > 0:
+		return "img"
+	case len(asset.Video) > 0:
+		return "video"
+	case len(asset.Data) > 0:
+		return "data"
+	}
+	return ""
+}
+
+// backfillAdvertiserDomains copies each Bid's adomain into its meta.advertiserDomains, unless the Bidder already set them.
+func backfillAdvertiserDomains(bids []*PBSOrtbBid) {
+	for _, bid := range bids {
+		if bid == nil || bid.Bid == nil || len(bid.Bid.ADomain) == 0 {
+			continue
+		}
+		if bid.BidMeta == nil {
+			bid.BidMeta = &openrtb_ext.ExtBidPrebidMeta{}
+		}
+		if len(bid.BidMeta.AdvertiserDomains) == 0 {
+			bid.BidMeta.AdvertiserDomains = bid.Bid.ADomain
+		}
+	}
+}
+
+// validateBidMeta makes sure that the Bid's ext.prebid.meta defines each of the required keys.
+// Keys are matched against the JSON names in the response, so empty values count as missing.
+func validateBidMeta(bid *PBSOrtbBid, requiredKeys []string) error {
+	if len(requiredKeys) == 0 {
+		return nil
+	}
+	var meta []byte
+	if bid.BidMeta != nil {
+		var err error
+		if meta, err = json.Marshal(bid.BidMeta); err != nil {
+			return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionMissingMeta, "Bid \"%s\" has a meta which can't be read: %v", bid.Bid.ID, err)
+		}
+	}
+	var missing []string
+	for _, key := range requiredKeys {
+		if _, _, _, err := jsonparser.Get(meta, key); err != nil {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionMissingMeta, "Bid \"%s\" is missing required ext.prebid.meta fields: %s", bid.Bid.ID, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// validateBidCOPPA rejects Bids which aren't allowed on requests that are subject to COPPA: ones whose meta says
+// they used behavioral targeting, and ones with any of the host's prohibited creative attributes.
+func validateBidCOPPA(bid *PBSOrtbBid, prohibitedAttributes []int) error {
+	if bid.BidMeta != nil && bid.BidMeta.BehavioralTargeting {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionCOPPA, "Bid \"%s\" used behavioral targeting, which COPPA prohibits", bid.Bid.ID)
+	}
+	for _, attr := range bid.Bid.Attr {
+		for _, prohibited := range prohibitedAttributes {
+			if int(attr) == prohibited {
+				return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionCOPPA, "Bid \"%s\" has creative attribute %d, which the host prohibits under COPPA", bid.Bid.ID, attr)
+			}
+		}
+	}
+	return nil
+}
+
+// validateBidDNT rejects Bids which track the user, on requests whose device.dnt is 1: ones whose meta says
+// they used behavioral targeting, and ones with any of the host's prohibited creative attributes.
+func validateBidDNT(bid *PBSOrtbBid, prohibitedAttributes []int) error {
+	if bid.BidMeta != nil && bid.BidMeta.BehavioralTargeting {
+		return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionDNT, "Bid \"%s\" used behavioral targeting, but the device asked not to be tracked", bid.Bid.ID)
+	}
+	for _, attr := range bid.Bid.Attr {
+		for _, prohibited := range prohibitedAttributes {
+			if int(attr) == prohibited {
+				return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionDNT, "Bid \"%s\" has creative attribute %d, which the host prohibits under Do-Not-Track", bid.Bid.ID, attr)
+			}
+		}
+	}
+	return nil
+}
+
+// validateBidAdvertiserDomains makes sure that none of the Bid's adomain entries were blocked by the request.badv.
+// Subdomains of a blocked domain are blocked too, so "ads.evil.com" is blocked by "evil.com".
+// Bids which don't declare their adomain can't be checked, so they pass.
+func validateBidAdvertiserDomains(bid *PBSOrtbBid, blockedDomains []string) error {
+	if len(blockedDomains) == 0 {
+		return nil
+	}
+	for _, domain := range bid.Bid.ADomain {
+		domain = normalizeDomain(domain)
+		for _, blocked := range blockedDomains {
+			blocked = normalizeDomain(blocked)
+			if blocked == "" {
+				continue
+			}
+			if domain == blocked || strings.HasSuffix(domain, "."+blocked) {
+				return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionBlockedDomain, "Bid \"%s\" has advertiser domain \"%s\", which is blocked by request.badv entry \"%s\"", bid.Bid.ID, domain, blocked)
+			}
+		}
+	}
+	return nil
+}
+
+// validateBidCategories makes sure that none of the Bid's IAB content categories were blocked by the request.bcat.
+// Categories must match exactly. Bids which don't declare any categories pass.
+func validateBidCategories(bid *PBSOrtbBid, blockedCategories []string) error {
+	if len(blockedCategories) == 0 {
+		return nil
+	}
+	for _, category := range bid.Bid.Cat {
+		for _, blocked := range blockedCategories {
+			if category == blocked {
+				return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionBlockedCategory, "Bid \"%s\" has category \"%s\", which is blocked by request.bcat", bid.Bid.ID, category)
+			}
+		}
+	}
+	return nil
+}
+
+// validateBidLanguage makes sure that a Bid's creative is in one of the allowed languages. Bids declare their languages
+// in the bid.ext "language", or the "langs" list for creatives in several. Those in several pass if any of them is allowed.
+// Only the primary language subtag is compared, so "en-GB" matches "en". Bids which don't declare a language pass.
+func validateBidLanguage(bid *PBSOrtbBid, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+	languages := bidLanguages(bid)
+	if len(languages) == 0 {
+		return nil
+	}
+	for _, language := range languages {
+		for _, allowedLanguage := range allowed {
+			if strings.EqualFold(primaryLanguage(language), primaryLanguage(allowedLanguage)) {
+				return nil
+			}
+		}
+	}
+	return newBidRejection(bid.Bid.ID, pbsmetrics.BidRejectionLanguage, "Bid \"%s\" is in %s, but the request only allows %s", bid.Bid.ID, strings.Join(languages, ", "), strings.Join(allowed, ", "))
+}
+
+// bidLanguages returns the languages which the Bid declares in its bid.ext.
+func bidLanguages(bid *PBSOrtbBid) []string {
+	var languages []string
+	if language, err := jsonparser.GetString(bid.Bid.Ext, "language"); err == nil && language != "" {
+		languages = append(languages, language)
+	}
+	jsonparser.ArrayEach(bid.Bid.Ext, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
+		if dataType == jsonparser.String && len(value) > 0 {
+			languages = append(languages, string(value))
+		}
+	}, "langs")
+	return languages
+}
+
+// primaryLanguage returns the primary subtag of a BCP 47 language tag, like "en" for "en-GB".
+func primaryLanguage(tag string) string {
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		return tag[:i]
+	}
+	return tag
+}
+
+// normalizeDomain strips the parts of a domain which bidders and publishers commonly write inconsistently,
+// such as the scheme, the "www." prefix, trailing paths and letter case.
+func normalizeDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if i := strings.Index(domain, "://"); i >= 0 {
+		domain = domain[i+3:]
+	}
+	if i := strings.IndexAny(domain, "/?#"); i >= 0 {
+		domain = domain[:i]
+	}
+	return strings.TrimPrefix(domain, "www.")
+}
@@ -1,13 +1,25 @@
 package exchange
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/mxmCherry/openrtb"
+	"github.com/prebid/prebid-server/adapters"
+	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/currencies"
+	"github.com/prebid/prebid-server/errortypes"
+	"github.com/prebid/prebid-server/openrtb_ext"
+	"github.com/prebid/prebid-server/pbsmetrics"
 )
 
 func TestAllValidBids(t *testing.T) {
-	brq := &openrtb.BidRequest{}
+	brq := &openrtb.BidRequest{
+		Imp: newTestImps("thisImp", "thatImp", "456"),
+	}
 
 	bids := make([]*PBSOrtbBid, 3)
 
@@ -17,6 +29,7 @@ func TestAllValidBids(t *testing.T) {
 			ImpID: "thisImp",
 			Price: 0.45,
 			CrID:  "thisCreative",
+			AdM:   "some-markup",
 		},
 	}
 	bids[1] = &PBSOrtbBid{
@@ -25,6 +38,7 @@ func TestAllValidBids(t *testing.T) {
 			ImpID: "thatImp",
 			Price: 0.40,
 			CrID:  "thatCreative",
+			AdM:   "some-markup",
 		},
 	}
 	bids[2] = &PBSOrtbBid{
@@ -33,6 +47,7 @@ func TestAllValidBids(t *testing.T) {
 			ImpID: "456",
 			Price: 0.44,
 			CrID:  "789",
+			AdM:   "some-markup",
 		},
 	}
 	brw := &BidResponseWrapper{
@@ -44,7 +59,9 @@ func TestAllValidBids(t *testing.T) {
 }
 
 func TestAllBadBids(t *testing.T) {
-	brq := &openrtb.BidRequest{}
+	brq := &openrtb.BidRequest{
+		Imp: newTestImps("thisImp", "thatImp", "456"),
+	}
 	bids := make([]*PBSOrtbBid, 5)
 
 	bids[0] = &PBSOrtbBid{
@@ -52,6 +69,7 @@ func TestAllBadBids(t *testing.T) {
 			ID:    "one-bid",
 			Price: 0.45,
 			CrID:  "thisCreative",
+			AdM:   "some-markup",
 		},
 	}
 	bids[1] = &PBSOrtbBid{
@@ -59,6 +77,7 @@ func TestAllBadBids(t *testing.T) {
 			ID:    "thatBid",
 			ImpID: "thatImp",
 			CrID:  "thatCreative",
+			AdM:   "some-markup",
 		},
 	}
 	bids[2] = &PBSOrtbBid{
@@ -73,6 +92,7 @@ func TestAllBadBids(t *testing.T) {
 			ImpID: "456",
 			Price: 0.44,
 			CrID:  "blah",
+			AdM:   "some-markup",
 		},
 	}
 	bids[4] = &PBSOrtbBid{}
@@ -85,7 +105,9 @@ func TestAllBadBids(t *testing.T) {
 }
 
 func TestMixeddBids(t *testing.T) {
-	brq := &openrtb.BidRequest{}
+	brq := &openrtb.BidRequest{
+		Imp: newTestImps("thisImp", "thatImp", "456"),
+	}
 
 	bids := make([]*PBSOrtbBid, 5)
 	bids[0] = &PBSOrtbBid{
@@ -94,6 +116,7 @@ func TestMixeddBids(t *testing.T) {
 			ImpID: "thisImp",
 			Price: 0.45,
 			CrID:  "thisCreative",
+			AdM:   "some-markup",
 		},
 	}
 	bids[1] = &PBSOrtbBid{
@@ -101,6 +124,7 @@ func TestMixeddBids(t *testing.T) {
 			ID:    "thatBid",
 			ImpID: "thatImp",
 			CrID:  "thatCreative",
+			AdM:   "some-markup",
 		},
 	}
 	bids[2] = &PBSOrtbBid{
@@ -109,6 +133,7 @@ func TestMixeddBids(t *testing.T) {
 			ImpID: "456",
 			Price: 0.44,
 			CrID:  "789",
+			AdM:   "some-markup",
 		},
 	}
 	bids[3] = &PBSOrtbBid{
@@ -116,6 +141,7 @@ func TestMixeddBids(t *testing.T) {
 			ImpID: "456",
 			Price: 0.44,
 			CrID:  "blah",
+			AdM:   "some-markup",
 		},
 	}
 	bids[4] = &PBSOrtbBid{}
@@ -127,132 +153,3609 @@ func TestMixeddBids(t *testing.T) {
 	assertBids(t, brq, brw, 2, 3)
 }
 
-func TestCurrencyBids(t *testing.T) {
-	currencyTestCases := []struct {
-		brqCur           []string
-		brpCur           string
-		defaultCur       string
-		expectedValidBid bool
-	}{
-		// Case bid request and bid response don't specify any currencies.
-		// Expected to be valid since both bid request / response will be overriden with default currency (USD).
-		{
-			brqCur:           []string{},
-			brpCur:           "",
-			expectedValidBid: true,
+func TestBidsWithoutMarkup(t *testing.T) {
+	brq := &openrtb.BidRequest{
+		Imp: newTestImps("thisImp", "thatImp", "456"),
+	}
+
+	bids := make([]*PBSOrtbBid, 3)
+	bids[0] = &PBSOrtbBid{
+		Bid: &openrtb.Bid{
+			ID:    "adm-bid",
+			ImpID: "thisImp",
+			Price: 0.45,
+			CrID:  "thisCreative",
+			AdM:   "some-markup",
 		},
-		// Case bid request specifies a currency (default one) but bid response doesn't.
-		// Expected to be valid since bid response will be overriden with default currency (USD).
-		{
-			brqCur:           []string{"USD"},
-			brpCur:           "",
-			expectedValidBid: true,
+	}
+	bids[1] = &PBSOrtbBid{
+		Bid: &openrtb.Bid{
+			ID:    "nurl-bid",
+			ImpID: "thatImp",
+			Price: 0.40,
+			CrID:  "thatCreative",
+			NURL:  "http://some.url/win",
 		},
-		// Case bid request specifies more than 1 currency (default one and another one) but bid response doesn't.
-		// Expected to be valid since bid response will be overriden with default currency (USD).
-		{
-			brqCur:           []string{"USD", "EUR"},
-			brpCur:           "",
-			expectedValidBid: true,
+	}
+	bids[2] = &PBSOrtbBid{
+		Bid: &openrtb.Bid{
+			ID:    "empty-bid",
+			ImpID: "456",
+			Price: 0.44,
+			CrID:  "789",
 		},
-		// Case bid request specifies more than 1 currency (default one and another one) and bid response specifies default currency (USD).
-		// Expected to be valid.
-		{
-			brqCur:           []string{"USD", "EUR"},
-			brpCur:           "USD",
-			expectedValidBid: true,
+	}
+	brw := &BidResponseWrapper{
+		AdapterBids: &PBSOrtbSeatBid{
+			Bids: bids,
 		},
-		// Case bid request specifies more than 1 currency (default one and another one) and bid response specifies the second currency allowed (not USD).
-		// Expected to be valid.
-		{
-			brqCur:           []string{"USD", "EUR"},
-			brpCur:           "EUR",
-			expectedValidBid: true,
+	}
+	assertBids(t, brq, brw, 2, 1)
+}
+
+func TestBidsWithoutMarkupSkipped(t *testing.T) {
+	brq := &openrtb.BidRequest{
+		Imp: newTestImps("456"),
+	}
+
+	bids := make([]*PBSOrtbBid, 1)
+	bids[0] = &PBSOrtbBid{
+		Bid: &openrtb.Bid{
+			ID:    "empty-bid",
+			ImpID: "456",
+			Price: 0.44,
+			CrID:  "789",
 		},
-		// Case bid request specifies only 1 currency which is not the default one.
-		// Bid response doesn't specify any currency.
-		// Expected to be invalid.
-		{
-			brqCur:           []string{"JPY"},
-			brpCur:           "",
-			expectedValidBid: false,
+	}
+	brw := &BidResponseWrapper{
+		AdapterBids: &PBSOrtbSeatBid{
+			Bids: bids,
 		},
-		// Case bid request doesn't specify any currencies.
-		// Bid response specifies a currency which is not the default one.
-		// Expected to be invalid.
-		{
-			brqCur:           []string{},
-			brpCur:           "JPY",
-			expectedValidBid: false,
+	}
+	errs, _ := brw.ValidateBids(brq, &openrtb_ext.ExtRequestValidation{SkipMarkupCheck: true}, config.BidValidation{}, nil, nil)
+	if len(errs) != 0 {
+		t.Errorf("Expected 0 Errors validating bids, found %d", len(errs))
+	}
+	if len(brw.AdapterBids.Bids) != 1 {
+		t.Errorf("Expected 1 bids, found %d bids", len(brw.AdapterBids.Bids))
+	}
+}
+
+func TestUnknownImpIDs(t *testing.T) {
+	brq := &openrtb.BidRequest{
+		Imp: newTestImps("thisImp", "thatImp"),
+	}
+	bids := []*PBSOrtbBid{{
+		Bid: &openrtb.Bid{
+			ID:    "one-bid",
+			ImpID: "thisImp",
+			Price: 0.45,
+			CrID:  "thisCreative",
+			AdM:   "some-markup",
 		},
-		// Case bid request specifies a currency.
-		// Bid response specifies a currency which is not the one specified in bid request.
-		// Expected to be invalid.
-		{
-			brqCur:           []string{"USD"},
-			brpCur:           "EUR",
-			expectedValidBid: false,
+	}, {
+		Bid: &openrtb.Bid{
+			ID:    "thatBid",
+			ImpID: "thatImp",
+			Price: 0.40,
+			CrID:  "thatCreative",
+			AdM:   "some-markup",
 		},
-		// Case bid request specifies several currencies.
-		// Bid response specifies a currency which is not the one specified in bid request.
-		// Expected to be invalid.
-		{
-			brqCur:           []string{"USD", "EUR"},
-			brpCur:           "JPY",
-			expectedValidBid: false,
+	}, {
+		Bid: &openrtb.Bid{
+			ID:    "bogusBid",
+			ImpID: "copyPastedImp",
+			Price: 0.50,
+			CrID:  "bogusCreative",
+			AdM:   "some-markup",
+		},
+	}}
+	brw := &BidResponseWrapper{
+		AdapterBids: &PBSOrtbSeatBid{
+			Bids: bids,
 		},
 	}
+	errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{}, nil, nil)
 
-	for _, tc := range currencyTestCases {
+	if len(brw.AdapterBids.Bids) != 2 {
+		t.Errorf("Expected the bids on known imps to be kept. Got %d bids", len(brw.AdapterBids.Bids))
+	}
+	if len(errs) != 1 {
+		t.Fatalf("Expected 1 error. Got %v", errs)
+	}
+	expected := "Bid \"bogusBid\" has impid \"copyPastedImp\", which is not in the request"
+	if errs[0].Error() != expected {
+		t.Errorf("Expected %q. Got %q", expected, errs[0].Error())
+	}
+	if rejection, ok := errs[0].(*BidRejectionError); !ok || rejection.Reason != pbsmetrics.BidRejectionUnknownImpID {
+		t.Errorf("Expected an unknown_impid rejection. Got %v", errs[0])
+	}
+}
 
-		brq := &openrtb.BidRequest{
-			Cur: tc.brqCur,
+func TestBidSizes(t *testing.T) {
+	brq := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{{
+			ID: "bannerImp",
+			Banner: &openrtb.Banner{
+				W: openrtb.Uint64Ptr(728),
+				H: openrtb.Uint64Ptr(90),
+				Format: []openrtb.Format{{
+					W: 300,
+					H: 250,
+				}, {
+					W: 300,
+					H: 600,
+				}},
+			},
+		}, {
+			ID: "videoImp",
+			Video: &openrtb.Video{
+				MIMEs: []string{"video/mp4"},
+			},
+		}},
+	}
+
+	sizeTestCases := []struct {
+		impID         string
+		bidType       openrtb_ext.BidType
+		w             uint64
+		h             uint64
+		expectedValid bool
+	}{
+		// Matches one of the imp.banner.format sizes
+		{impID: "bannerImp", bidType: openrtb_ext.BidTypeBanner, w: 300, h: 600, expectedValid: true},
+		// Matches the top-level imp.banner.w/h
+		{impID: "bannerImp", bidType: openrtb_ext.BidTypeBanner, w: 728, h: 90, expectedValid: true},
+		// Fluid bids don't define a size
+		{impID: "bannerImp", bidType: openrtb_ext.BidTypeBanner, w: 0, h: 0, expectedValid: true},
+		// Video imps aren't checked
+		{impID: "videoImp", bidType: openrtb_ext.BidTypeVideo, w: 640, h: 480, expectedValid: true},
+		// Not a size which was offered
+		{impID: "bannerImp", bidType: openrtb_ext.BidTypeBanner, w: 300, h: 50, expectedValid: false},
+	}
+
+	for _, tc := range sizeTestCases {
+		bids := []*PBSOrtbBid{{
+			Bid: &openrtb.Bid{
+				ID:    "one-bid",
+				ImpID: tc.impID,
+				Price: 0.45,
+				CrID:  "thisCreative",
+				AdM:   "some-markup",
+				W:     tc.w,
+				H:     tc.h,
+			},
+			BidType:  tc.bidType,
+			BidVideo: &openrtb_ext.ExtBidPrebidVideo{Duration: 30},
+		}, {
+			Bid: &openrtb.Bid{
+				ID:    "thatBid",
+				ImpID: "bannerImp",
+				Price: 0.40,
+				CrID:  "thatCreative",
+				AdM:   "some-markup",
+				W:     300,
+				H:     250,
+			},
+			BidType: openrtb_ext.BidTypeBanner,
+		}}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: bids,
+			},
 		}
+		if tc.expectedValid {
+			assertBids(t, brq, brw, 2, 0)
+		} else {
+			assertBids(t, brq, brw, 1, 1)
+		}
+	}
+}
 
-		bids := make([]*PBSOrtbBid, 2)
-		bids[0] = &PBSOrtbBid{
+func TestBidCurrencyHints(t *testing.T) {
+	hintTestCases := []struct {
+		seatCur       string
+		bidExt        string
+		expectedValid bool
+	}{
+		// No hint, so the seat currency is assumed.
+		{seatCur: "USD", bidExt: ``, expectedValid: true},
+		{seatCur: "USD", bidExt: `{"someparam":1}`, expectedValid: true},
+		// The hint agrees with the seat currency.
+		{seatCur: "EUR", bidExt: `{"cur":"EUR"}`, expectedValid: true},
+		{seatCur: "EUR", bidExt: `{"cur":"eur"}`, expectedValid: true},
+		// An empty seat currency means USD.
+		{seatCur: "", bidExt: `{"cur":"USD"}`, expectedValid: true},
+		// The hint contradicts the seat currency.
+		{seatCur: "USD", bidExt: `{"cur":"EUR"}`, expectedValid: false},
+		{seatCur: "", bidExt: `{"cur":"EUR"}`, expectedValid: false},
+	}
+
+	for _, tc := range hintTestCases {
+		brq := &openrtb.BidRequest{
+			Cur: []string{"USD", "EUR"},
+			Imp: newTestImps("thisImp", "thatImp"),
+		}
+		bids := []*PBSOrtbBid{{
 			Bid: &openrtb.Bid{
 				ID:    "one-bid",
 				ImpID: "thisImp",
 				Price: 0.45,
 				CrID:  "thisCreative",
+				AdM:   "some-markup",
+				Ext:   json.RawMessage(tc.bidExt),
 			},
-		}
-		bids[1] = &PBSOrtbBid{
+		}, {
 			Bid: &openrtb.Bid{
 				ID:    "thatBid",
 				ImpID: "thatImp",
-				Price: 0.44,
+				Price: 0.40,
 				CrID:  "thatCreative",
+				AdM:   "some-markup",
+			},
+		}}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids:     bids,
+				Currency: tc.seatCur,
 			},
 		}
+		if tc.expectedValid {
+			assertBids(t, brq, brw, 2, 0)
+		} else {
+			assertBids(t, brq, brw, 1, 1)
+		}
+	}
+}
+
+func TestBidFloors(t *testing.T) {
+	conversions := currencies.NewConversionCache(currencies.NewRates(time.Time{}, map[string]map[string]float64{
+		"USD": {
+			"EUR": 0.8,
+		},
+	}))
+	floorTestCases := []struct {
+		bidFloor      float64
+		bidFloorCur   string
+		price         float64
+		seatCur       string
+		skipFloors    bool
+		expectedValid bool
+	}{
+		// No floor
+		{bidFloor: 0, price: 0.1, expectedValid: true},
+		// Same currency
+		{bidFloor: 0.5, price: 0.5, expectedValid: true},
+		{bidFloor: 0.5, price: 0.4, expectedValid: false},
+		{bidFloor: 0.5, bidFloorCur: "USD", price: 0.4, seatCur: "USD", expectedValid: false},
+		// 0.6 USD is 0.48 EUR
+		{bidFloor: 0.45, bidFloorCur: "EUR", price: 0.6, expectedValid: true},
+		{bidFloor: 0.5, bidFloorCur: "EUR", price: 0.6, expectedValid: false},
+		// No rate is known, so the bid can't be compared
+		{bidFloor: 0.5, bidFloorCur: "JPY", price: 10, expectedValid: false},
+		// Floors are ignored when the request asks to skip them
+		{bidFloor: 0.5, price: 0.4, skipFloors: true, expectedValid: true},
+	}
 
+	for _, tc := range floorTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID:          "flooredImp",
+				BidFloor:    tc.bidFloor,
+				BidFloorCur: tc.bidFloorCur,
+			}, {
+				ID: "otherImp",
+			}},
+		}
+		bids := []*PBSOrtbBid{{
+			Bid: &openrtb.Bid{
+				ID:    "one-bid",
+				ImpID: "flooredImp",
+				Price: tc.price,
+				CrID:  "thisCreative",
+				AdM:   "some-markup",
+			},
+		}, {
+			Bid: &openrtb.Bid{
+				ID:    "thatBid",
+				ImpID: "otherImp",
+				Price: 0.01,
+				CrID:  "thatCreative",
+				AdM:   "some-markup",
+			},
+		}}
 		brw := &BidResponseWrapper{
 			AdapterBids: &PBSOrtbSeatBid{
 				Bids:     bids,
-				Currency: tc.brpCur,
+				Currency: tc.seatCur,
 			},
 		}
+		errs, _ := brw.ValidateBids(brq, &openrtb_ext.ExtRequestValidation{SkipFloorCheck: tc.skipFloors}, config.BidValidation{}, conversions, nil)
+		expectedBids, expectedErrs := 2, 0
+		if !tc.expectedValid {
+			expectedBids, expectedErrs = 1, 1
+		}
+		if len(errs) != expectedErrs {
+			t.Errorf("Expected %d Errors validating bids, found %d", expectedErrs, len(errs))
+		}
+		if len(brw.AdapterBids.Bids) != expectedBids {
+			t.Errorf("Expected %d bids, found %d bids", expectedBids, len(brw.AdapterBids.Bids))
+		}
+	}
+}
 
-		expectedValidBids := len(bids)
-		expectedErrs := 0
+func TestCustomBidValidators(t *testing.T) {
+	brq := &openrtb.BidRequest{
+		Imp: newTestImps("thisImp", "thatImp"),
+	}
 
-		if tc.expectedValidBid != true {
-			// If currency mistmatch, we should have one error
-			expectedErrs = 1
-			expectedValidBids = 0
+	bids := make([]*PBSOrtbBid, 3)
+	bids[0] = &PBSOrtbBid{
+		Bid: &openrtb.Bid{
+			ID:      "one-bid",
+			ImpID:   "thisImp",
+			Price:   0.45,
+			CrID:    "thisCreative",
+			AdM:     "some-markup",
+			ADomain: []string{"good.com"},
+		},
+	}
+	bids[1] = &PBSOrtbBid{
+		Bid: &openrtb.Bid{
+			ID:      "thatBid",
+			ImpID:   "thatImp",
+			Price:   0.40,
+			CrID:    "thatCreative",
+			AdM:     "some-markup",
+			ADomain: []string{"good.com", "banned.com"},
+		},
+	}
+	// Fails the built-in checks, so the custom validators should never see it.
+	bids[2] = &PBSOrtbBid{}
+	brw := &BidResponseWrapper{
+		AdapterBids: &PBSOrtbSeatBid{
+			Bids: bids,
+		},
+	}
+
+	validators := []BidValidator{
+		BidValidatorFunc(func(request *openrtb.BidRequest, bid *PBSOrtbBid) error {
+			if bid.Bid == nil {
+				t.Errorf("Custom validators should not receive bids which failed the built-in checks")
+			}
+			return nil
+		}),
+		&bannedDomainValidator{domain: "banned.com"},
+	}
+	errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{}, nil, validators)
+	if len(errs) != 2 {
+		t.Errorf("Expected 2 Errors validating bids, found %d", len(errs))
+	}
+	if len(brw.AdapterBids.Bids) != 1 || brw.AdapterBids.Bids[0].Bid.ID != "one-bid" {
+		t.Errorf("Expected only bid one-bid to survive. Got %d bids", len(brw.AdapterBids.Bids))
+	}
+}
+
+type bannedDomainValidator struct {
+	domain string
+}
+
+func (v *bannedDomainValidator) Validate(request *openrtb.BidRequest, bid *PBSOrtbBid) error {
+	for _, domain := range bid.Bid.ADomain {
+		if domain == v.domain {
+			return fmt.Errorf("Bid \"%s\" advertises the banned domain %s", bid.Bid.ID, domain)
 		}
+	}
+	return nil
+}
 
-		assertBids(t, brq, brw, expectedValidBids, expectedErrs)
+func TestBlockedAdvertiserDomains(t *testing.T) {
+	domainTestCases := []struct {
+		adomain       []string
+		expectedValid bool
+	}{
+		// Bids without an adomain can't be checked
+		{adomain: nil, expectedValid: true},
+		{adomain: []string{"good.com"}, expectedValid: true},
+		// Only full labels match, so this isn't a subdomain of evil.com
+		{adomain: []string{"notevil.com"}, expectedValid: true},
+		{adomain: []string{"evil.com"}, expectedValid: false},
+		{adomain: []string{"good.com", "sub.evil.com"}, expectedValid: false},
+		{adomain: []string{"https://www.Evil.com/landing"}, expectedValid: false},
+		{adomain: []string{"bad.org"}, expectedValid: false},
+	}
+
+	for _, tc := range domainTestCases {
+		brq := &openrtb.BidRequest{
+			BAdv: []string{"evil.com", "www.bad.org"},
+			Imp:  newTestImps("thisImp", "thatImp"),
+		}
+		bids := []*PBSOrtbBid{{
+			Bid: &openrtb.Bid{
+				ID:      "one-bid",
+				ImpID:   "thisImp",
+				Price:   0.45,
+				CrID:    "thisCreative",
+				AdM:     "some-markup",
+				ADomain: tc.adomain,
+			},
+		}, {
+			Bid: &openrtb.Bid{
+				ID:    "thatBid",
+				ImpID: "thatImp",
+				Price: 0.40,
+				CrID:  "thatCreative",
+				AdM:   "some-markup",
+			},
+		}}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: bids,
+			},
+		}
+		if tc.expectedValid {
+			assertBids(t, brq, brw, 2, 0)
+		} else {
+			assertBids(t, brq, brw, 1, 1)
+		}
 	}
 }
 
-func assertBids(t *testing.T, brq *openrtb.BidRequest, brw *BidResponseWrapper, ebids int, eerrs int) {
-	errs := brw.ValidateBids(brq)
-	if len(errs) != eerrs {
-		t.Errorf("Expected %d Errors validating bids, found %d", eerrs, len(errs))
+func TestBlockedCategories(t *testing.T) {
+	categoryTestCases := []struct {
+		cat           []string
+		expectedValid bool
+	}{
+		// Bids without categories can't be checked
+		{cat: nil, expectedValid: true},
+		{cat: []string{"IAB1"}, expectedValid: true},
+		// Categories match exactly, so subcategories aren't blocked by their parent
+		{cat: []string{"IAB7-1"}, expectedValid: true},
+		{cat: []string{"IAB7"}, expectedValid: false},
+		{cat: []string{"IAB1", "IAB25-3"}, expectedValid: false},
 	}
-	if len(brw.AdapterBids.Bids) != ebids {
-		t.Errorf("Expected %d bids, found %d bids", ebids, len(brw.AdapterBids.Bids))
+
+	for _, tc := range categoryTestCases {
+		brq := &openrtb.BidRequest{
+			BCat: []string{"IAB7", "IAB25-3"},
+			Imp:  newTestImps("thisImp", "thatImp"),
+		}
+		bids := []*PBSOrtbBid{{
+			Bid: &openrtb.Bid{
+				ID:    "one-bid",
+				ImpID: "thisImp",
+				Price: 0.45,
+				CrID:  "thisCreative",
+				AdM:   "some-markup",
+				Cat:   tc.cat,
+			},
+		}, {
+			Bid: &openrtb.Bid{
+				ID:    "thatBid",
+				ImpID: "thatImp",
+				Price: 0.40,
+				CrID:  "thatCreative",
+				AdM:   "some-markup",
+			},
+		}}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: bids,
+			},
+		}
+		if tc.expectedValid {
+			assertBids(t, brq, brw, 2, 0)
+		} else {
+			assertBids(t, brq, brw, 1, 1)
+		}
+	}
+}
+
+func TestBidDeals(t *testing.T) {
+	dealTestCases := []struct {
+		pmp           *openrtb.PMP
+		dealID        string
+		expectedValid bool
+	}{
+		// Imps which aren't private auctions accept any bid
+		{pmp: nil, dealID: "", expectedValid: true},
+		{pmp: nil, dealID: "some-deal", expectedValid: true},
+		{pmp: &openrtb.PMP{Deals: []openrtb.Deal{{ID: "some-deal"}}}, dealID: "other-deal", expectedValid: true},
+		// Private auctions only accept the deals they offer
+		{pmp: &openrtb.PMP{PrivateAuction: 1, Deals: []openrtb.Deal{{ID: "some-deal"}}}, dealID: "some-deal", expectedValid: true},
+		{pmp: &openrtb.PMP{PrivateAuction: 1, Deals: []openrtb.Deal{{ID: "some-deal"}}}, dealID: "other-deal", expectedValid: false},
+		{pmp: &openrtb.PMP{PrivateAuction: 1, Deals: []openrtb.Deal{{ID: "some-deal"}}}, dealID: "", expectedValid: false},
+	}
+
+	for _, tc := range dealTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID:  "thisImp",
+				PMP: tc.pmp,
+			}, {
+				ID: "thatImp",
+			}},
+		}
+		bids := []*PBSOrtbBid{{
+			Bid: &openrtb.Bid{
+				ID:     "one-bid",
+				ImpID:  "thisImp",
+				Price:  0.45,
+				CrID:   "thisCreative",
+				AdM:    "some-markup",
+				DealID: tc.dealID,
+			},
+		}, {
+			Bid: &openrtb.Bid{
+				ID:    "thatBid",
+				ImpID: "thatImp",
+				Price: 0.40,
+				CrID:  "thatCreative",
+				AdM:   "some-markup",
+			},
+		}}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: bids,
+			},
+		}
+		if tc.expectedValid {
+			assertBids(t, brq, brw, 2, 0)
+		} else {
+			assertBids(t, brq, brw, 1, 1)
+		}
+	}
+}
+
+func TestDealFloors(t *testing.T) {
+	rates := currencies.NewRates(time.Time{}, map[string]map[string]float64{
+		"USD": {
+			"EUR": 0.8,
+		},
+	})
+	pmp := &openrtb.PMP{Deals: []openrtb.Deal{
+		{ID: "usd-deal", BidFloor: 2},
+		{ID: "eur-deal", BidFloor: 2, BidFloorCur: "EUR"},
+		{ID: "unfloored-deal"},
+	}}
+	dealFloorTestCases := []struct {
+		description     string
+		dealID          string
+		price           float64
+		expectedValid   bool
+		expectedMessage string
+	}{
+		{
+			description:   "Deal bids above their deal's floor are kept, even if it's below the imp's",
+			dealID:        "usd-deal",
+			price:         2.5,
+			expectedValid: true,
+		},
+		{
+			description:     "Deal bids below their deal's floor are rejected",
+			dealID:          "usd-deal",
+			price:           1.5,
+			expectedMessage: `Bid "one-bid" price 1.500000 below deal "usd-deal" floor 2.000000`,
+		},
+		{
+			description:     "Deal bids are converted into the deal's currency",
+			dealID:          "eur-deal",
+			price:           2.4,
+			expectedMessage: `Bid "one-bid" price 1.920000 below deal "eur-deal" floor 2.000000`,
+		},
+		{
+			description:   "Deals without a floor accept any price",
+			dealID:        "unfloored-deal",
+			price:         0.5,
+			expectedValid: true,
+		},
+		{
+			description:     "Bids for deals which the imp didn't offer use the imp's floor",
+			dealID:          "unknown-deal",
+			price:           2.5,
+			expectedMessage: `Bid "one-bid" price 2.500000 below imp floor 3.000000`,
+		},
+		{
+			description:   "Open market bids use the imp's floor",
+			price:         3,
+			expectedValid: true,
+		},
+	}
+
+	for _, tc := range dealFloorTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID:       "thisImp",
+				BidFloor: 3,
+				PMP:      pmp,
+			}},
+		}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: []*PBSOrtbBid{{
+					Bid: &openrtb.Bid{
+						ID:     "one-bid",
+						ImpID:  "thisImp",
+						Price:  tc.price,
+						CrID:   "thisCreative",
+						AdM:    "some-markup",
+						DealID: tc.dealID,
+					},
+				}},
+			},
+		}
+		errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{}, currencies.NewConversionCache(rates), nil)
+		if tc.expectedValid {
+			if len(errs) != 0 {
+				t.Errorf("%s: expected no errors. Got %v", tc.description, errs)
+			}
+			continue
+		}
+		if len(errs) != 1 {
+			t.Fatalf("%s: expected 1 error. Got %v", tc.description, errs)
+		}
+		rejection, ok := errs[0].(*BidRejectionError)
+		if !ok || rejection.Reason != pbsmetrics.BidRejectionBelowFloor {
+			t.Errorf("%s: expected a %s rejection. Got %v", tc.description, pbsmetrics.BidRejectionBelowFloor, errs[0])
+			continue
+		}
+		if rejection.Error() != tc.expectedMessage {
+			t.Errorf("%s: expected message %q. Got %q", tc.description, tc.expectedMessage, rejection.Error())
+		}
+	}
+}
+
+func TestBidDurations(t *testing.T) {
+	durationTestCases := []struct {
+		video         *openrtb.Video
+		bidType       openrtb_ext.BidType
+		bidVideo      *openrtb_ext.ExtBidPrebidVideo
+		expectedValid bool
+	}{
+		// Non-video imps and bids aren't checked
+		{video: nil, bidType: openrtb_ext.BidTypeBanner, bidVideo: nil, expectedValid: true},
+		{video: &openrtb.Video{MinDuration: 15}, bidType: openrtb_ext.BidTypeBanner, bidVideo: nil, expectedValid: true},
+		// Video bids need a positive duration
+		{video: &openrtb.Video{}, bidType: openrtb_ext.BidTypeVideo, bidVideo: &openrtb_ext.ExtBidPrebidVideo{Duration: 30}, expectedValid: true},
+		{video: &openrtb.Video{}, bidType: openrtb_ext.BidTypeVideo, bidVideo: nil, expectedValid: false},
+		{video: &openrtb.Video{}, bidType: openrtb_ext.BidTypeVideo, bidVideo: &openrtb_ext.ExtBidPrebidVideo{Duration: 0}, expectedValid: false},
+		// The duration must be within the imp's range
+		{video: &openrtb.Video{MinDuration: 15, MaxDuration: 30}, bidType: openrtb_ext.BidTypeVideo, bidVideo: &openrtb_ext.ExtBidPrebidVideo{Duration: 15}, expectedValid: true},
+		{video: &openrtb.Video{MinDuration: 15, MaxDuration: 30}, bidType: openrtb_ext.BidTypeVideo, bidVideo: &openrtb_ext.ExtBidPrebidVideo{Duration: 30}, expectedValid: true},
+		{video: &openrtb.Video{MinDuration: 15, MaxDuration: 30}, bidType: openrtb_ext.BidTypeVideo, bidVideo: &openrtb_ext.ExtBidPrebidVideo{Duration: 10}, expectedValid: false},
+		{video: &openrtb.Video{MinDuration: 15, MaxDuration: 30}, bidType: openrtb_ext.BidTypeVideo, bidVideo: &openrtb_ext.ExtBidPrebidVideo{Duration: 60}, expectedValid: false},
+		{video: &openrtb.Video{MaxDuration: 30}, bidType: openrtb_ext.BidTypeVideo, bidVideo: &openrtb_ext.ExtBidPrebidVideo{Duration: 5}, expectedValid: true},
+	}
+
+	for _, tc := range durationTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID:     "thisImp",
+				Banner: &openrtb.Banner{},
+				Video:  tc.video,
+			}, {
+				ID: "thatImp",
+			}},
+		}
+		bids := []*PBSOrtbBid{{
+			Bid: &openrtb.Bid{
+				ID:    "one-bid",
+				ImpID: "thisImp",
+				Price: 0.45,
+				CrID:  "thisCreative",
+				AdM:   "some-markup",
+			},
+			BidType:  tc.bidType,
+			BidVideo: tc.bidVideo,
+		}, {
+			Bid: &openrtb.Bid{
+				ID:    "thatBid",
+				ImpID: "thatImp",
+				Price: 0.40,
+				CrID:  "thatCreative",
+				AdM:   "some-markup",
+			},
+		}}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: bids,
+			},
+		}
+		if tc.expectedValid {
+			assertBids(t, brq, brw, 2, 0)
+		} else {
+			assertBids(t, brq, brw, 1, 1)
+		}
+	}
+}
+
+func TestBidImpMediaTypes(t *testing.T) {
+	imps := map[openrtb_ext.BidType]openrtb.Imp{
+		openrtb_ext.BidTypeBanner: {ID: "thisImp", Banner: &openrtb.Banner{}},
+		openrtb_ext.BidTypeVideo:  {ID: "thisImp", Video: &openrtb.Video{}},
+		openrtb_ext.BidTypeAudio:  {ID: "thisImp", Audio: &openrtb.Audio{}},
+		openrtb_ext.BidTypeNative: {ID: "thisImp", Native: &openrtb.Native{}},
+	}
+	for impType, imp := range imps {
+		for bidType := range imps {
+			err := validateBidImpMediaType(&PBSOrtbBid{Bid: &openrtb.Bid{ID: "one-bid", ImpID: "thisImp"}, BidType: bidType}, &imp)
+			if bidType == impType && err != nil {
+				t.Errorf("%s bids on %s imps should be allowed. Got %v", bidType, impType, err)
+			}
+			if bidType != impType {
+				if rejection, ok := err.(*BidRejectionError); !ok || rejection.Reason != pbsmetrics.BidRejectionMediaTypeMismatch {
+					t.Errorf("%s bids on %s imps should be rejected with %s. Got %v", bidType, impType, pbsmetrics.BidRejectionMediaTypeMismatch, err)
+				}
+			}
+		}
+	}
+
+	multiFormat := openrtb.Imp{ID: "thisImp", Banner: &openrtb.Banner{}, Video: &openrtb.Video{}}
+	for _, bidType := range []openrtb_ext.BidType{openrtb_ext.BidTypeBanner, openrtb_ext.BidTypeVideo} {
+		if err := validateBidImpMediaType(&PBSOrtbBid{Bid: &openrtb.Bid{ID: "one-bid", ImpID: "thisImp"}, BidType: bidType}, &multiFormat); err != nil {
+			t.Errorf("%s bids on banner and video imps should be allowed. Got %v", bidType, err)
+		}
+	}
+	if err := validateBidImpMediaType(&PBSOrtbBid{Bid: &openrtb.Bid{ID: "one-bid", ImpID: "thisImp"}}, &multiFormat); err != nil {
+		t.Errorf("Bids which don't declare a type should be allowed. Got %v", err)
+	}
+}
+
+func TestBidOpenRTBVersions(t *testing.T) {
+	versionTestCases := []struct {
+		description   string
+		version       string
+		mtype         openrtb_ext.MarkupType
+		dur           int64
+		expectedValid bool
+	}{
+		{
+			description:   "OpenRTB 2.5 bidders may leave out the 2.6 fields",
+			version:       adapters.OpenRTBVersion25,
+			expectedValid: true,
+		},
+		{
+			description:   "OpenRTB 2.5 bidders may not send a bid.mtype",
+			version:       adapters.OpenRTBVersion25,
+			mtype:         openrtb_ext.MarkupVideo,
+			expectedValid: false,
+		},
+		{
+			description:   "OpenRTB 2.5 bidders may not send a bid.dur",
+			version:       adapters.OpenRTBVersion25,
+			dur:           30,
+			expectedValid: false,
+		},
+		{
+			description:   "OpenRTB 2.6 bidders may send both",
+			version:       adapters.OpenRTBVersion26,
+			mtype:         openrtb_ext.MarkupVideo,
+			dur:           30,
+			expectedValid: true,
+		},
+		{
+			description:   "Bidders which don't declare a version may send both",
+			mtype:         openrtb_ext.MarkupVideo,
+			dur:           30,
+			expectedValid: true,
+		},
+	}
+
+	for _, tc := range versionTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID:    "thisImp",
+				Video: &openrtb.Video{},
+			}},
+		}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: []*PBSOrtbBid{{
+					Bid: &openrtb.Bid{
+						ID:    "one-bid",
+						ImpID: "thisImp",
+						Price: 0.45,
+						CrID:  "thisCreative",
+						AdM:   "<VAST></VAST>",
+					},
+					BidType:  openrtb_ext.BidTypeVideo,
+					BidVideo: &openrtb_ext.ExtBidPrebidVideo{Duration: 30},
+					BidMType: tc.mtype,
+					BidDur:   tc.dur,
+				}},
+				OpenRTBVersion: tc.version,
+			},
+		}
+		errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{}, nil, nil)
+		if tc.expectedValid {
+			if len(errs) != 0 {
+				t.Errorf("%s: expected the bid to be kept. Got %v", tc.description, errs)
+			}
+			continue
+		}
+		if len(errs) != 1 {
+			t.Errorf("%s: expected the bid to be rejected. Got %v", tc.description, errs)
+		} else if rejection, ok := errs[0].(*BidRejectionError); !ok || rejection.Reason != pbsmetrics.BidRejectionOpenRTBVersion {
+			t.Errorf("%s: expected a %s rejection. Got %v", tc.description, pbsmetrics.BidRejectionOpenRTBVersion, errs[0])
+		}
+	}
+}
+
+func TestBidDurationFallback(t *testing.T) {
+	durationTestCases := []struct {
+		description      string
+		video            *openrtb_ext.ExtBidPrebidVideo
+		dur              int64
+		expectedDuration int64
+	}{
+		{
+			description:      "The ext.prebid.video duration is used first",
+			video:            &openrtb_ext.ExtBidPrebidVideo{Duration: 15},
+			dur:              30,
+			expectedDuration: 15,
+		},
+		{
+			description:      "The bid.dur is used if the ext doesn't give a duration",
+			video:            &openrtb_ext.ExtBidPrebidVideo{},
+			dur:              30,
+			expectedDuration: 30,
+		},
+		{
+			description:      "The bid.dur is used without an ext.prebid.video",
+			dur:              30,
+			expectedDuration: 30,
+		},
+		{
+			description: "Bids may give neither",
+		},
+	}
+
+	for _, tc := range durationTestCases {
+		bid := &PBSOrtbBid{
+			Bid:      &openrtb.Bid{ID: "one-bid"},
+			BidVideo: tc.video,
+			BidDur:   tc.dur,
+		}
+		if duration := bidDuration(bid); duration != tc.expectedDuration {
+			t.Errorf("%s: expected duration %d. Got %d", tc.description, tc.expectedDuration, duration)
+		}
+	}
+}
+
+func TestBidMType(t *testing.T) {
+	mtypeTestCases := []struct {
+		description     string
+		imp             openrtb.Imp
+		bidType         openrtb_ext.BidType
+		mtype           openrtb_ext.MarkupType
+		expectedValid   bool
+		expectedMessage string
+	}{
+		{
+			description:   "mtypes which match the imp are allowed",
+			imp:           openrtb.Imp{ID: "thisImp", Banner: &openrtb.Banner{}, Video: &openrtb.Video{}},
+			mtype:         openrtb_ext.MarkupVideo,
+			expectedValid: true,
+		},
+		{
+			description:   "mtypes which agree with the ext.prebid.type are allowed",
+			imp:           openrtb.Imp{ID: "thisImp", Native: &openrtb.Native{}},
+			bidType:       openrtb_ext.BidTypeNative,
+			mtype:         openrtb_ext.MarkupNative,
+			expectedValid: true,
+		},
+		{
+			description:     "mtypes which contradict the ext.prebid.type are rejected",
+			imp:             openrtb.Imp{ID: "thisImp", Banner: &openrtb.Banner{}, Video: &openrtb.Video{}},
+			bidType:         openrtb_ext.BidTypeBanner,
+			mtype:           openrtb_ext.MarkupVideo,
+			expectedMessage: `Bid "one-bid" has mtype 2 (video), which contradicts its type banner`,
+		},
+		{
+			description:     "mtypes which the imp doesn't offer are rejected",
+			imp:             openrtb.Imp{ID: "thisImp", Banner: &openrtb.Banner{}},
+			mtype:           openrtb_ext.MarkupAudio,
+			expectedMessage: `Bid "one-bid" has mtype 3 (audio), but imp "thisImp" doesn't offer it`,
+		},
+		{
+			description:     "Unknown mtypes are rejected",
+			imp:             openrtb.Imp{ID: "thisImp", Banner: &openrtb.Banner{}},
+			mtype:           7,
+			expectedMessage: `Bid "one-bid" has an unknown mtype 7`,
+		},
+		{
+			description:   "Bids without an mtype aren't checked",
+			imp:           openrtb.Imp{ID: "thisImp", Banner: &openrtb.Banner{}},
+			bidType:       openrtb_ext.BidTypeBanner,
+			expectedValid: true,
+		},
+	}
+
+	for _, tc := range mtypeTestCases {
+		bid := &PBSOrtbBid{
+			Bid:      &openrtb.Bid{ID: "one-bid", ImpID: "thisImp"},
+			BidType:  tc.bidType,
+			BidMType: tc.mtype,
+		}
+		err := validateBidMType(bid, &tc.imp)
+		if tc.expectedValid {
+			if err != nil {
+				t.Errorf("%s: expected no error. Got %v", tc.description, err)
+			}
+			continue
+		}
+		rejection, ok := err.(*BidRejectionError)
+		if !ok || rejection.Reason != pbsmetrics.BidRejectionMediaTypeMismatch {
+			t.Errorf("%s: expected a %s rejection. Got %v", tc.description, pbsmetrics.BidRejectionMediaTypeMismatch, err)
+			continue
+		}
+		if rejection.Error() != tc.expectedMessage {
+			t.Errorf("%s: expected message %q. Got %q", tc.description, tc.expectedMessage, rejection.Error())
+		}
+	}
+}
+
+func TestBidMediaTypeFromMType(t *testing.T) {
+	request := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{{ID: "thisImp", Banner: &openrtb.Banner{}, Video: &openrtb.Video{}}},
+	}
+	withMType := &PBSOrtbBid{Bid: &openrtb.Bid{ImpID: "thisImp"}, BidMType: openrtb_ext.MarkupVideo}
+	if mediaType := bidMediaType(request, withMType); mediaType != openrtb_ext.BidTypeVideo {
+		t.Errorf("Bids with an mtype should use it. Got %q", mediaType)
+	}
+	withoutMType := &PBSOrtbBid{Bid: &openrtb.Bid{ImpID: "thisImp"}}
+	if mediaType := bidMediaType(request, withoutMType); mediaType != "" {
+		t.Errorf("Bids without an mtype on multi-format imps should have no type. Got %q", mediaType)
+	}
+}
+
+func TestBidImpMediaTypeMismatch(t *testing.T) {
+	brq := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{{
+			ID:     "thisImp",
+			Banner: &openrtb.Banner{},
+		}},
+	}
+	brw := &BidResponseWrapper{
+		AdapterBids: &PBSOrtbSeatBid{
+			Bids: []*PBSOrtbBid{{
+				Bid:      &openrtb.Bid{ID: "video-bid", ImpID: "thisImp", Price: 0.45, CrID: "thisCreative", AdM: "<VAST></VAST>"},
+				BidType:  openrtb_ext.BidTypeVideo,
+				BidVideo: &openrtb_ext.ExtBidPrebidVideo{Duration: 30},
+			}, {
+				Bid:     &openrtb.Bid{ID: "banner-bid", ImpID: "thisImp", Price: 0.40, CrID: "thatCreative", AdM: "some-markup"},
+				BidType: openrtb_ext.BidTypeBanner,
+			}},
+		},
+	}
+	errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{}, nil, nil)
+
+	assertBidIDs(t, brw.AdapterBids, []string{"banner-bid"})
+	if len(errs) != 1 {
+		t.Fatalf("Expected 1 error. Got %v", errs)
+	}
+	if expected := `Bid "video-bid" is type video, but imp "thisImp" doesn't offer it`; errs[0].Error() != expected {
+		t.Errorf("Expected message %q. Got %q", expected, errs[0].Error())
+	}
+}
+
+func TestAudioBids(t *testing.T) {
+	const audioVAST = `<?xml version="1.0" encoding="UTF-8"?><VAST version="3.0"><Ad><InLine><Creatives><Creative><Linear><Duration>00:00:15</Duration></Linear></Creative></Creatives></InLine></Ad></VAST>`
+	const audioDAAST = `<DAAST version="1.0"><Ad><InLine></InLine></Ad></DAAST>`
+	const bannerMarkup = `<div><img src="https://some-cdn.com/banner.png"></div>`
+	audioTestCases := []struct {
+		description   string
+		banner        *openrtb.Banner
+		audio         *openrtb.Audio
+		bidType       openrtb_ext.BidType
+		adm           string
+		bidVideo      *openrtb_ext.ExtBidPrebidVideo
+		expectedValid bool
+	}{
+		{
+			description:   "Non-audio imps aren't checked",
+			banner:        &openrtb.Banner{},
+			bidType:       openrtb_ext.BidTypeBanner,
+			adm:           bannerMarkup,
+			expectedValid: true,
+		},
+		{
+			description:   "Audio bids with VAST and a duration are allowed",
+			audio:         &openrtb.Audio{MinDuration: 5, MaxDuration: 30},
+			bidType:       openrtb_ext.BidTypeAudio,
+			adm:           audioVAST,
+			bidVideo:      &openrtb_ext.ExtBidPrebidVideo{Duration: 15},
+			expectedValid: true,
+		},
+		{
+			description:   "Audio bids with DAAST are allowed",
+			audio:         &openrtb.Audio{},
+			bidType:       openrtb_ext.BidTypeAudio,
+			adm:           "\n  " + audioDAAST,
+			bidVideo:      &openrtb_ext.ExtBidPrebidVideo{Duration: 30},
+			expectedValid: true,
+		},
+		{
+			description:   "Audio imps infer the type of bids which don't declare it",
+			audio:         &openrtb.Audio{},
+			adm:           bannerMarkup,
+			bidVideo:      &openrtb_ext.ExtBidPrebidVideo{Duration: 30},
+			expectedValid: false,
+		},
+		{
+			description:   "Banner markup for an audio imp is rejected",
+			audio:         &openrtb.Audio{},
+			bidType:       openrtb_ext.BidTypeAudio,
+			adm:           bannerMarkup,
+			bidVideo:      &openrtb_ext.ExtBidPrebidVideo{Duration: 30},
+			expectedValid: false,
+		},
+		{
+			description:   "Non-XML markup for an audio imp is rejected",
+			audio:         &openrtb.Audio{},
+			bidType:       openrtb_ext.BidTypeAudio,
+			adm:           "https://some-cdn.com/ad.mp3",
+			bidVideo:      &openrtb_ext.ExtBidPrebidVideo{Duration: 30},
+			expectedValid: false,
+		},
+		{
+			description:   "Audio bids need a duration",
+			audio:         &openrtb.Audio{},
+			bidType:       openrtb_ext.BidTypeAudio,
+			adm:           audioVAST,
+			expectedValid: false,
+		},
+		{
+			description:   "Audio bids must be within the imp's duration range",
+			audio:         &openrtb.Audio{MinDuration: 5, MaxDuration: 30},
+			bidType:       openrtb_ext.BidTypeAudio,
+			adm:           audioVAST,
+			bidVideo:      &openrtb_ext.ExtBidPrebidVideo{Duration: 60},
+			expectedValid: false,
+		},
+	}
+
+	for _, tc := range audioTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID:     "thisImp",
+				Banner: tc.banner,
+				Audio:  tc.audio,
+			}, {
+				ID: "thatImp",
+			}},
+		}
+		bids := []*PBSOrtbBid{{
+			Bid: &openrtb.Bid{
+				ID:    "one-bid",
+				ImpID: "thisImp",
+				Price: 0.45,
+				CrID:  "thisCreative",
+				AdM:   tc.adm,
+			},
+			BidType:  tc.bidType,
+			BidVideo: tc.bidVideo,
+		}, {
+			Bid: &openrtb.Bid{
+				ID:    "thatBid",
+				ImpID: "thatImp",
+				Price: 0.40,
+				CrID:  "thatCreative",
+				AdM:   "some-markup",
+			},
+		}}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: bids,
+			},
+		}
+		errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{}, nil, nil)
+		if tc.expectedValid && len(errs) != 0 {
+			t.Errorf("%s: expected no errors. Got %v", tc.description, errs)
+		}
+		if !tc.expectedValid && len(errs) != 1 {
+			t.Errorf("%s: expected 1 error. Got %v", tc.description, errs)
+		}
+	}
+}
+
+func TestBidMIMEs(t *testing.T) {
+	mimeTestCases := []struct {
+		video         *openrtb.Video
+		bidType       openrtb_ext.BidType
+		ext           string
+		expectedValid bool
+	}{
+		// Non-video imps and bids aren't checked
+		{video: nil, bidType: openrtb_ext.BidTypeBanner, ext: `{"mime":"text/html"}`, expectedValid: true},
+		{video: &openrtb.Video{MIMEs: []string{"video/mp4"}}, bidType: openrtb_ext.BidTypeBanner, ext: `{"mime":"text/html"}`, expectedValid: true},
+		// Imps without mimes accept anything
+		{video: &openrtb.Video{}, bidType: openrtb_ext.BidTypeVideo, ext: `{"mime":"video/webm"}`, expectedValid: true},
+		// Allowed MIME types
+		{video: &openrtb.Video{MIMEs: []string{"video/mp4", "video/webm"}}, bidType: openrtb_ext.BidTypeVideo, ext: `{"mime":"video/webm"}`, expectedValid: true},
+		{video: &openrtb.Video{MIMEs: []string{"video/mp4"}}, bidType: openrtb_ext.BidTypeVideo, ext: `{"mime":"Video/MP4"}`, expectedValid: true},
+		// Disallowed MIME types
+		{video: &openrtb.Video{MIMEs: []string{"video/mp4"}}, bidType: openrtb_ext.BidTypeVideo, ext: `{"mime":"video/x-flv"}`, expectedValid: false},
+		{video: &openrtb.Video{MIMEs: []string{"video/mp4"}}, bidType: openrtb_ext.BidTypeVideo, ext: `{"mime":"application/javascript"}`, expectedValid: false},
+		// Bids which don't declare a MIME type pass
+		{video: &openrtb.Video{MIMEs: []string{"video/mp4"}}, bidType: openrtb_ext.BidTypeVideo, ext: ``, expectedValid: true},
+		{video: &openrtb.Video{MIMEs: []string{"video/mp4"}}, bidType: openrtb_ext.BidTypeVideo, ext: `{"mime":""}`, expectedValid: true},
+	}
+
+	for _, tc := range mimeTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID:     "thisImp",
+				Banner: &openrtb.Banner{},
+				Video:  tc.video,
+			}, {
+				ID: "thatImp",
+			}},
+		}
+		bids := []*PBSOrtbBid{{
+			Bid: &openrtb.Bid{
+				ID:    "one-bid",
+				ImpID: "thisImp",
+				Price: 0.45,
+				CrID:  "thisCreative",
+				AdM:   "some-markup",
+				Ext:   json.RawMessage(tc.ext),
+			},
+			BidType:  tc.bidType,
+			BidVideo: &openrtb_ext.ExtBidPrebidVideo{Duration: 30},
+		}, {
+			Bid: &openrtb.Bid{
+				ID:    "thatBid",
+				ImpID: "thatImp",
+				Price: 0.40,
+				CrID:  "thatCreative",
+				AdM:   "some-markup",
+			},
+		}}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: bids,
+			},
+		}
+		if tc.expectedValid {
+			assertBids(t, brq, brw, 2, 0)
+		} else {
+			assertBids(t, brq, brw, 1, 1)
+		}
+	}
+}
+
+func TestBannerMIMEs(t *testing.T) {
+	bannerMIMETestCases := []struct {
+		description   string
+		allowed       []string
+		bidType       openrtb_ext.BidType
+		ext           string
+		expectedValid bool
+	}{
+		{
+			description:   "Without an allow-list, any type passes",
+			bidType:       openrtb_ext.BidTypeBanner,
+			ext:           `{"mime":"application/x-shockwave-flash"}`,
+			expectedValid: true,
+		},
+		{
+			description:   "Allowed types pass",
+			allowed:       []string{"image/png", "text/html"},
+			bidType:       openrtb_ext.BidTypeBanner,
+			ext:           `{"mime":"text/html"}`,
+			expectedValid: true,
+		},
+		{
+			description:   "Types are compared case-insensitively",
+			allowed:       []string{"image/png"},
+			bidType:       openrtb_ext.BidTypeBanner,
+			ext:           `{"mime":"Image/PNG"}`,
+			expectedValid: true,
+		},
+		{
+			description:   "Disallowed types are rejected",
+			allowed:       []string{"image/png", "text/html"},
+			bidType:       openrtb_ext.BidTypeBanner,
+			ext:           `{"mime":"application/x-shockwave-flash"}`,
+			expectedValid: false,
+		},
+		{
+			description:   "Bids which don't declare a type pass",
+			allowed:       []string{"image/png"},
+			bidType:       openrtb_ext.BidTypeBanner,
+			expectedValid: true,
+		},
+		{
+			description:   "Other media types aren't checked",
+			allowed:       []string{"image/png"},
+			bidType:       openrtb_ext.BidTypeVideo,
+			ext:           `{"mime":"video/mp4"}`,
+			expectedValid: true,
+		},
+	}
+
+	for _, tc := range bannerMIMETestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID:     "thisImp",
+				Banner: &openrtb.Banner{},
+				Video:  &openrtb.Video{},
+			}},
+		}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: []*PBSOrtbBid{{
+					Bid: &openrtb.Bid{
+						ID:    "one-bid",
+						ImpID: "thisImp",
+						Price: 0.45,
+						CrID:  "thisCreative",
+						AdM:   "some-markup",
+						Ext:   json.RawMessage(tc.ext),
+					},
+					BidType:  tc.bidType,
+					BidVideo: &openrtb_ext.ExtBidPrebidVideo{Duration: 30},
+				}},
+			},
+		}
+		errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{BannerMIMEs: tc.allowed}, nil, nil)
+		if tc.expectedValid {
+			if len(errs) != 0 || len(brw.AdapterBids.Bids) != 1 {
+				t.Errorf("%s: expected the bid to be kept. Got %v", tc.description, errs)
+			}
+			continue
+		}
+		if len(brw.AdapterBids.Bids) != 0 || len(errs) != 1 {
+			t.Errorf("%s: expected the bid to be rejected. Got %v", tc.description, errs)
+		} else if rejection, ok := errs[0].(*BidRejectionError); !ok || rejection.Reason != pbsmetrics.BidRejectionInvalidMIME {
+			t.Errorf("%s: expected a %s rejection. Got %v", tc.description, pbsmetrics.BidRejectionInvalidMIME, errs[0])
+		}
+	}
+}
+
+func TestBlockedCreativeAttributes(t *testing.T) {
+	autoPlayAudio := openrtb.CreativeAttribute(1)
+	userInitiatedAudio := openrtb.CreativeAttribute(2)
+	expandable := openrtb.CreativeAttribute(3)
+	attrTestCases := []struct {
+		banner        *openrtb.Banner
+		video         *openrtb.Video
+		bidType       openrtb_ext.BidType
+		attr          []openrtb.CreativeAttribute
+		expectedValid bool
+	}{
+		// Bids without attributes pass
+		{banner: &openrtb.Banner{BAttr: []openrtb.CreativeAttribute{autoPlayAudio}}, bidType: openrtb_ext.BidTypeBanner, attr: nil, expectedValid: true},
+		// Attributes which aren't blocked pass
+		{banner: &openrtb.Banner{BAttr: []openrtb.CreativeAttribute{autoPlayAudio}}, bidType: openrtb_ext.BidTypeBanner, attr: []openrtb.CreativeAttribute{userInitiatedAudio}, expectedValid: true},
+		{banner: &openrtb.Banner{}, bidType: openrtb_ext.BidTypeBanner, attr: []openrtb.CreativeAttribute{autoPlayAudio}, expectedValid: true},
+		// Any blocked attribute rejects the bid
+		{banner: &openrtb.Banner{BAttr: []openrtb.CreativeAttribute{autoPlayAudio}}, bidType: openrtb_ext.BidTypeBanner, attr: []openrtb.CreativeAttribute{autoPlayAudio}, expectedValid: false},
+		{banner: &openrtb.Banner{BAttr: []openrtb.CreativeAttribute{autoPlayAudio, expandable}}, bidType: openrtb_ext.BidTypeBanner, attr: []openrtb.CreativeAttribute{userInitiatedAudio, expandable}, expectedValid: false},
+		{video: &openrtb.Video{BAttr: []openrtb.CreativeAttribute{autoPlayAudio}}, bidType: openrtb_ext.BidTypeVideo, attr: []openrtb.CreativeAttribute{autoPlayAudio}, expectedValid: false},
+		// Only the battr for the bid's media type applies
+		{banner: &openrtb.Banner{}, video: &openrtb.Video{BAttr: []openrtb.CreativeAttribute{autoPlayAudio}}, bidType: openrtb_ext.BidTypeBanner, attr: []openrtb.CreativeAttribute{autoPlayAudio}, expectedValid: true},
+		// If the media type is unknown, every battr applies
+		{banner: &openrtb.Banner{}, video: &openrtb.Video{BAttr: []openrtb.CreativeAttribute{autoPlayAudio}}, attr: []openrtb.CreativeAttribute{autoPlayAudio}, expectedValid: false},
+	}
+
+	for _, tc := range attrTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID:     "thisImp",
+				Banner: tc.banner,
+				Video:  tc.video,
+			}, {
+				ID: "thatImp",
+			}},
+		}
+		bids := []*PBSOrtbBid{{
+			Bid: &openrtb.Bid{
+				ID:    "one-bid",
+				ImpID: "thisImp",
+				Price: 0.45,
+				CrID:  "thisCreative",
+				AdM:   "some-markup",
+				Attr:  tc.attr,
+			},
+			BidType:  tc.bidType,
+			BidVideo: &openrtb_ext.ExtBidPrebidVideo{Duration: 30},
+		}, {
+			Bid: &openrtb.Bid{
+				ID:    "thatBid",
+				ImpID: "thatImp",
+				Price: 0.40,
+				CrID:  "thatCreative",
+				AdM:   "some-markup",
+			},
+		}}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: bids,
+			},
+		}
+		if tc.expectedValid {
+			assertBids(t, brq, brw, 2, 0)
+		} else {
+			assertBids(t, brq, brw, 1, 1)
+		}
+	}
+}
+
+func TestSecureMarkup(t *testing.T) {
+	secure := int8(1)
+	insecure := int8(0)
+	markupTestCases := []struct {
+		secure        *int8
+		adm           string
+		skipCheck     bool
+		skipHostCheck bool
+		expectedValid bool
+	}{
+		// Secure imps accept secure markup
+		{secure: &secure, adm: `<img src="https://cdn.com/ad.png">`, expectedValid: true},
+		{secure: &secure, adm: `<svg xmlns="http://www.w3.org/2000/svg"><image href="https://cdn.com/ad.png"/></svg>`, expectedValid: true},
+		{secure: &secure, adm: `<a href="https://advertiser.com">Visit http://advertiser.com</a>`, expectedValid: true},
+		// Secure imps reject insecure resources
+		{secure: &secure, adm: `<img src="https://cdn.com/ad.png"><script src="http://cdn.com/track.js"></script>`, expectedValid: false},
+		{secure: &secure, adm: `<div style="background: url('http://cdn.com/bg.png')"></div>`, expectedValid: false},
+		{secure: &secure, adm: `<VAST><Ad><Impression><![CDATA[http://tracker.com/imp]]></Impression></Ad></VAST>`, expectedValid: false},
+		{secure: &secure, adm: `<IMG SRC=http://cdn.com/ad.png>`, expectedValid: false},
+		// The check can be turned off
+		{secure: &secure, adm: `<img src="http://cdn.com/ad.png">`, skipCheck: true, expectedValid: true},
+		{secure: &secure, adm: `<img src="http://cdn.com/ad.png">`, skipHostCheck: true, expectedValid: true},
+		// Insecure imps accept anything
+		{secure: nil, adm: `<img src="http://cdn.com/ad.png">`, expectedValid: true},
+		{secure: &insecure, adm: `<img src="http://cdn.com/ad.png">`, expectedValid: true},
+	}
+
+	for _, tc := range markupTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID:     "thisImp",
+				Secure: tc.secure,
+			}, {
+				ID: "thatImp",
+			}},
+		}
+		bids := []*PBSOrtbBid{{
+			Bid: &openrtb.Bid{
+				ID:    "one-bid",
+				ImpID: "thisImp",
+				Price: 0.45,
+				CrID:  "thisCreative",
+				AdM:   tc.adm,
+			},
+		}, {
+			Bid: &openrtb.Bid{
+				ID:    "thatBid",
+				ImpID: "thatImp",
+				Price: 0.40,
+				CrID:  "thatCreative",
+				AdM:   "some-markup",
+			},
+		}}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: bids,
+			},
+		}
+		validation := &openrtb_ext.ExtRequestValidation{SkipSecureCheck: tc.skipCheck}
+		errs, _ := brw.ValidateBids(brq, validation, config.BidValidation{SkipSecureMarkupCheck: tc.skipHostCheck}, nil, nil)
+		if tc.expectedValid && len(errs) != 0 {
+			t.Errorf("Expected markup %s to be valid. Got %v", tc.adm, errs)
+		}
+		if !tc.expectedValid && len(errs) != 1 {
+			t.Errorf("Expected markup %s to be rejected. Got %d errors", tc.adm, len(errs))
+		}
+	}
+}
+
+func TestSecureNURL(t *testing.T) {
+	secure := int8(1)
+	nurlTestCases := []struct {
+		description   string
+		secure        *int8
+		nurl          string
+		checkNURL     bool
+		skipCheck     bool
+		expectedValid bool
+	}{
+		{
+			description:   "Secure imps accept https:// nurls",
+			secure:        &secure,
+			nurl:          "https://adserver.com/win",
+			checkNURL:     true,
+			expectedValid: true,
+		},
+		{
+			description:   "Secure imps reject http:// nurls",
+			secure:        &secure,
+			nurl:          "http://adserver.com/win",
+			checkNURL:     true,
+			expectedValid: false,
+		},
+		{
+			description:   "The scheme is case-insensitive",
+			secure:        &secure,
+			nurl:          "HTTP://adserver.com/win",
+			checkNURL:     true,
+			expectedValid: false,
+		},
+		{
+			description:   "Bids without a nurl pass",
+			secure:        &secure,
+			checkNURL:     true,
+			expectedValid: true,
+		},
+		{
+			description:   "Insecure imps accept http:// nurls",
+			nurl:          "http://adserver.com/win",
+			checkNURL:     true,
+			expectedValid: true,
+		},
+		{
+			description:   "The host must turn the check on",
+			secure:        &secure,
+			nurl:          "http://adserver.com/win",
+			expectedValid: true,
+		},
+		{
+			description:   "Requests which skip the secure check skip this one too",
+			secure:        &secure,
+			nurl:          "http://adserver.com/win",
+			checkNURL:     true,
+			skipCheck:     true,
+			expectedValid: true,
+		},
+	}
+
+	for _, tc := range nurlTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID:     "thisImp",
+				Secure: tc.secure,
+			}},
+		}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: []*PBSOrtbBid{{
+					Bid: &openrtb.Bid{
+						ID:    "one-bid",
+						ImpID: "thisImp",
+						Price: 0.45,
+						CrID:  "thisCreative",
+						AdM:   `<img src="https://cdn.com/ad.png">`,
+						NURL:  tc.nurl,
+					},
+				}},
+			},
+		}
+		validation := &openrtb_ext.ExtRequestValidation{SkipSecureCheck: tc.skipCheck}
+		errs, _ := brw.ValidateBids(brq, validation, config.BidValidation{CheckSecureNURL: tc.checkNURL}, nil, nil)
+		if tc.expectedValid {
+			if len(errs) != 0 {
+				t.Errorf("%s: expected the bid to be kept. Got %v", tc.description, errs)
+			}
+			continue
+		}
+		if len(errs) != 1 {
+			t.Errorf("%s: expected the bid to be rejected. Got %v", tc.description, errs)
+		} else if rejection, ok := errs[0].(*BidRejectionError); !ok || rejection.Reason != pbsmetrics.BidRejectionInsecureNURL {
+			t.Errorf("%s: expected a %s rejection. Got %v", tc.description, pbsmetrics.BidRejectionInsecureNURL, errs[0])
+		}
+	}
+}
+
+func TestVASTMarkup(t *testing.T) {
+	vastTestCases := []struct {
+		adm           string
+		bidType       openrtb_ext.BidType
+		checkVAST     bool
+		expectedValid bool
+	}{
+		// Valid VAST and wrappers pass
+		{adm: `<?xml version="1.0"?><VAST version="3.0"><Ad id="1"><InLine><AdSystem>PBS</AdSystem></InLine></Ad></VAST>`, bidType: openrtb_ext.BidTypeVideo, checkVAST: true, expectedValid: true},
+		{adm: `<VAST version="3.0"><Ad><Wrapper><VASTAdTagURI><![CDATA[https://adserver.com/vast]]></VASTAdTagURI></Wrapper></Ad></VAST>`, bidType: openrtb_ext.BidTypeVideo, checkVAST: true, expectedValid: true},
+		// Garbage, broken XML and other documents are rejected
+		{adm: `not xml at all`, bidType: openrtb_ext.BidTypeVideo, checkVAST: true, expectedValid: false},
+		{adm: `<VAST version="3.0"><Ad><InLine>`, bidType: openrtb_ext.BidTypeVideo, checkVAST: true, expectedValid: false},
+		{adm: `<html><body>banner</body></html>`, bidType: openrtb_ext.BidTypeVideo, checkVAST: true, expectedValid: false},
+		// Only video bids are checked, and only if the host asks
+		{adm: `not xml at all`, bidType: openrtb_ext.BidTypeBanner, checkVAST: true, expectedValid: true},
+		{adm: `not xml at all`, bidType: openrtb_ext.BidTypeVideo, checkVAST: false, expectedValid: true},
+	}
+
+	for _, tc := range vastTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID:     "thisImp",
+				Banner: &openrtb.Banner{},
+				Video:  &openrtb.Video{},
+			}},
+		}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: []*PBSOrtbBid{{
+					Bid: &openrtb.Bid{
+						ID:    "one-bid",
+						ImpID: "thisImp",
+						Price: 0.45,
+						CrID:  "thisCreative",
+						AdM:   tc.adm,
+					},
+					BidType:  tc.bidType,
+					BidVideo: &openrtb_ext.ExtBidPrebidVideo{Duration: 30},
+				}},
+			},
+		}
+		errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{CheckVAST: tc.checkVAST}, nil, nil)
+		if tc.expectedValid && len(errs) != 0 {
+			t.Errorf("Expected markup %s to be valid. Got %v", tc.adm, errs)
+		}
+		if !tc.expectedValid && len(errs) != 1 {
+			t.Errorf("Expected markup %s to be rejected. Got %d errors", tc.adm, len(errs))
+		}
+	}
+}
+
+func TestVASTWrappers(t *testing.T) {
+	const inline = `<VAST version="3.0"><Ad id="1"><InLine><AdSystem>PBS</AdSystem></InLine></Ad></VAST>`
+	const wrapper = `<VAST version="3.0"><Ad><Wrapper><VASTAdTagURI><![CDATA[https://adserver.com/vast]]></VASTAdTagURI></Wrapper></Ad></VAST>`
+	const mixed = `<VAST version="3.0"><Ad sequence="1"><Wrapper><VASTAdTagURI><![CDATA[https://adserver.com/vast]]></VASTAdTagURI></Wrapper></Ad><Ad sequence="2"><InLine><AdSystem>PBS</AdSystem></InLine></Ad></VAST>`
+
+	wrapperTestCases := []struct {
+		description   string
+		adm           string
+		impExt        string
+		expectedValid bool
+	}{
+		{description: "Inline ads pass", adm: inline, impExt: `{"prebid":{"disallowvastwrappers":true}}`, expectedValid: true},
+		{description: "Pure wrappers are rejected", adm: wrapper, impExt: `{"prebid":{"disallowvastwrappers":true}}`, expectedValid: false},
+		{description: "Wrappers with an inline ad pass", adm: mixed, impExt: `{"prebid":{"disallowvastwrappers":true}}`, expectedValid: true},
+		{description: "Wrappers pass unless the imp disallows them", adm: wrapper, impExt: `{"prebid":{}}`, expectedValid: true},
+		{description: "Wrappers pass on imps without an ext", adm: wrapper, expectedValid: true},
+	}
+
+	for _, tc := range wrapperTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID:    "thisImp",
+				Video: &openrtb.Video{},
+				Ext:   json.RawMessage(tc.impExt),
+			}},
+		}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: []*PBSOrtbBid{{
+					Bid: &openrtb.Bid{
+						ID:    "one-bid",
+						ImpID: "thisImp",
+						Price: 0.45,
+						CrID:  "thisCreative",
+						AdM:   tc.adm,
+					},
+					BidType:  openrtb_ext.BidTypeVideo,
+					BidVideo: &openrtb_ext.ExtBidPrebidVideo{Duration: 30},
+				}},
+			},
+		}
+		errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{}, nil, nil)
+		if tc.expectedValid && len(errs) != 0 {
+			t.Errorf("%s: expected no errors. Got %v", tc.description, errs)
+		}
+		if !tc.expectedValid {
+			if len(errs) != 1 {
+				t.Errorf("%s: expected 1 error. Got %v", tc.description, errs)
+			} else if rejection, ok := errs[0].(*BidRejectionError); !ok || rejection.Reason != pbsmetrics.BidRejectionVASTWrapper {
+				t.Errorf("%s: expected a %s rejection. Got %v", tc.description, pbsmetrics.BidRejectionVASTWrapper, errs[0])
+			}
+		}
+	}
+}
+
+func TestNativeAssets(t *testing.T) {
+	const nativeRequest = `{"ver":"1.2","assets":[{"id":0,"required":1,"title":{"len":90}},{"id":1,"required":1,"img":{"type":3}},{"id":2,"data":{"type":2}}]}`
+
+	nativeTestCases := []struct {
+		description   string
+		adm           string
+		checkNative   bool
+		expectedValid bool
+	}{
+		{
+			description:   "Bids with every required asset pass",
+			adm:           `{"assets":[{"id":0,"title":{"text":"Buy now"}},{"id":1,"img":{"url":"https://cdn.com/ad.png"}}],"link":{"url":"https://advertiser.com"}}`,
+			checkNative:   true,
+			expectedValid: true,
+		},
+		{
+			description:   "Bids missing a required title are rejected",
+			adm:           `{"assets":[{"id":1,"img":{"url":"https://cdn.com/ad.png"}},{"id":2,"data":{"value":"Sponsored"}}],"link":{"url":"https://advertiser.com"}}`,
+			checkNative:   true,
+			expectedValid: false,
+		},
+		{
+			description:   "Native 1.1 responses are unwrapped",
+			adm:           `{"native":{"assets":[{"id":0,"title":{"text":"Buy now"}},{"id":1,"img":{"url":"https://cdn.com/ad.png"}}],"link":{"url":"https://advertiser.com"}}}`,
+			checkNative:   true,
+			expectedValid: true,
+		},
+		{
+			description:   "Assets without IDs match by type",
+			adm:           `{"assets":[{"title":{"text":"Buy now"}},{"img":{"url":"https://cdn.com/ad.png"}}],"link":{"url":"https://advertiser.com"}}`,
+			checkNative:   true,
+			expectedValid: true,
+		},
+		{
+			description:   "Malformed native markup is rejected",
+			adm:           `<div>not native</div>`,
+			checkNative:   true,
+			expectedValid: false,
+		},
+		{
+			description:   "Bids aren't checked unless the host asks",
+			adm:           `{"assets":[],"link":{"url":"https://advertiser.com"}}`,
+			expectedValid: true,
+		},
+	}
+
+	for _, tc := range nativeTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID:     "thisImp",
+				Native: &openrtb.Native{Request: nativeRequest},
+			}},
+		}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: []*PBSOrtbBid{{
+					Bid: &openrtb.Bid{
+						ID:    "one-bid",
+						ImpID: "thisImp",
+						Price: 0.45,
+						CrID:  "thisCreative",
+						AdM:   tc.adm,
+					},
+					BidType: openrtb_ext.BidTypeNative,
+				}},
+			},
+		}
+		errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{CheckNativeAssets: tc.checkNative}, nil, nil)
+		if tc.expectedValid && len(errs) != 0 {
+			t.Errorf("%s: expected no errors. Got %v", tc.description, errs)
+		}
+		if !tc.expectedValid {
+			if len(errs) != 1 {
+				t.Errorf("%s: expected 1 error. Got %v", tc.description, errs)
+			} else if rejection, ok := errs[0].(*BidRejectionError); !ok || rejection.Reason != pbsmetrics.BidRejectionInvalidNative {
+				t.Errorf("%s: expected a %s rejection. Got %v", tc.description, pbsmetrics.BidRejectionInvalidNative, errs[0])
+			}
+		}
+	}
+}
+
+func TestNativeMarkup(t *testing.T) {
+	markupTestCases := []struct {
+		description     string
+		imp             openrtb.Imp
+		bidType         openrtb_ext.BidType
+		adm             string
+		expectedMessage string
+	}{
+		{
+			description: "Native 1.2 markup is allowed",
+			imp:         openrtb.Imp{ID: "thisImp", Native: &openrtb.Native{}},
+			adm:         `{"assets":[{"id":0,"title":{"text":"Buy now"}}],"link":{"url":"https://advertiser.com"}}`,
+		},
+		{
+			description: "Native 1.1 markup is allowed",
+			imp:         openrtb.Imp{ID: "thisImp", Native: &openrtb.Native{}},
+			adm:         `{"native":{"assets":[{"id":0,"title":{"text":"Buy now"}}],"link":{"url":"https://advertiser.com"}}}`,
+		},
+		{
+			description:     "HTML is rejected",
+			imp:             openrtb.Imp{ID: "thisImp", Native: &openrtb.Native{}},
+			adm:             `<div>an ad</div>`,
+			expectedMessage: `Bid "one-bid" has malformed native markup: invalid character '<' looking for beginning of value`,
+		},
+		{
+			description:     "JSON which isn't a native response is rejected",
+			imp:             openrtb.Imp{ID: "thisImp", Native: &openrtb.Native{}},
+			adm:             `{"link":{"url":"https://advertiser.com"}}`,
+			expectedMessage: `Bid "one-bid" has native markup without "native" or "assets"`,
+		},
+		{
+			description:     "Bids which say they're native are checked on multi-format imps",
+			imp:             openrtb.Imp{ID: "thisImp", Banner: &openrtb.Banner{}, Native: &openrtb.Native{}},
+			bidType:         openrtb_ext.BidTypeNative,
+			adm:             `<div>an ad</div>`,
+			expectedMessage: `Bid "one-bid" has malformed native markup: invalid character '<' looking for beginning of value`,
+		},
+		{
+			description: "Banner Bids on multi-format imps aren't checked",
+			imp:         openrtb.Imp{ID: "thisImp", Banner: &openrtb.Banner{}, Native: &openrtb.Native{}},
+			bidType:     openrtb_ext.BidTypeBanner,
+			adm:         `<div>an ad</div>`,
+		},
+		{
+			description: "Bids without markup aren't checked",
+			imp:         openrtb.Imp{ID: "thisImp", Native: &openrtb.Native{}},
+		},
+	}
+
+	for _, tc := range markupTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{tc.imp},
+		}
+		bid := &PBSOrtbBid{
+			Bid:     &openrtb.Bid{ID: "one-bid", ImpID: "thisImp", AdM: tc.adm},
+			BidType: tc.bidType,
+		}
+		err := validateBidNativeMarkup(bid, &brq.Imp[0], bidMediaType(brq, bid))
+		if tc.expectedMessage == "" {
+			if err != nil {
+				t.Errorf("%s: expected no error. Got %v", tc.description, err)
+			}
+			continue
+		}
+		rejection, ok := err.(*BidRejectionError)
+		if !ok || rejection.Reason != pbsmetrics.BidRejectionInvalidNative {
+			t.Errorf("%s: expected a %s rejection. Got %v", tc.description, pbsmetrics.BidRejectionInvalidNative, err)
+			continue
+		}
+		if rejection.Error() != tc.expectedMessage {
+			t.Errorf("%s: expected message %q. Got %q", tc.description, tc.expectedMessage, rejection.Error())
+		}
+	}
+}
+
+func TestRequiredMacros(t *testing.T) {
+	required := map[string][]string{
+		"banner": {"${AUCTION_PRICE}"},
+		"video":  {"${AUCTION_PRICE}", "${AUCTION_ID}"},
+	}
+	macroTestCases := []struct {
+		description     string
+		bidType         openrtb_ext.BidType
+		adm             string
+		expectedMessage string
+	}{
+		{
+			description: "Markup with the macro is allowed",
+			bidType:     openrtb_ext.BidTypeBanner,
+			adm:         `<img src="https://dsp.com/win?price=${AUCTION_PRICE}">`,
+		},
+		{
+			description:     "Markup without the macro is rejected",
+			bidType:         openrtb_ext.BidTypeBanner,
+			adm:             `<img src="https://dsp.com/win?price=0.5">`,
+			expectedMessage: `Bid "one-bid" has banner markup without the macro ${AUCTION_PRICE}, which the host requires`,
+		},
+		{
+			description:     "Markup needs every macro for its media type",
+			bidType:         openrtb_ext.BidTypeVideo,
+			adm:             `<VAST><Impression>https://dsp.com/imp?price=${AUCTION_PRICE}</Impression></VAST>`,
+			expectedMessage: `Bid "one-bid" has video markup without the macro ${AUCTION_ID}, which the host requires`,
+		},
+		{
+			description: "Media types without required macros aren't checked",
+			bidType:     openrtb_ext.BidTypeNative,
+			adm:         `{"assets":[]}`,
+		},
+		{
+			description: "Bids of unknown types aren't checked",
+			adm:         `<div>an ad</div>`,
+		},
+		{
+			description: "Bids without markup aren't checked",
+			bidType:     openrtb_ext.BidTypeBanner,
+		},
+	}
+
+	for _, tc := range macroTestCases {
+		bid := &PBSOrtbBid{
+			Bid:     &openrtb.Bid{ID: "one-bid", ImpID: "thisImp", AdM: tc.adm},
+			BidType: tc.bidType,
+		}
+		err := validateBidMacros(bid, tc.bidType, required)
+		if tc.expectedMessage == "" {
+			if err != nil {
+				t.Errorf("%s: expected no error. Got %v", tc.description, err)
+			}
+			continue
+		}
+		rejection, ok := err.(*BidRejectionError)
+		if !ok || rejection.Reason != pbsmetrics.BidRejectionMissingMacro {
+			t.Errorf("%s: expected a %s rejection. Got %v", tc.description, pbsmetrics.BidRejectionMissingMacro, err)
+			continue
+		}
+		if rejection.Error() != tc.expectedMessage {
+			t.Errorf("%s: expected message %q. Got %q", tc.description, tc.expectedMessage, rejection.Error())
+		}
+	}
+
+	if err := validateBidMacros(&PBSOrtbBid{Bid: &openrtb.Bid{ID: "one-bid", AdM: "<div>an ad</div>"}}, openrtb_ext.BidTypeBanner, nil); err != nil {
+		t.Errorf("Expected no macros to be required by default. Got %v", err)
+	}
+}
+
+func TestBidLanguages(t *testing.T) {
+	languageTestCases := []struct {
+		description     string
+		ext             string
+		allowed         []string
+		expectedMessage string
+	}{
+		{
+			description: "Bids in an allowed language pass",
+			ext:         `{"language":"fr"}`,
+			allowed:     []string{"de", "fr"},
+		},
+		{
+			description: "Regional variants match their language",
+			ext:         `{"language":"en-GB"}`,
+			allowed:     []string{"EN"},
+		},
+		{
+			description: "Bids in several languages pass if any of them is allowed",
+			ext:         `{"langs":["de","fr"]}`,
+			allowed:     []string{"fr"},
+		},
+		{
+			description:     "Bids in other languages are rejected",
+			ext:             `{"language":"es"}`,
+			allowed:         []string{"en", "fr"},
+			expectedMessage: `Bid "one-bid" is in es, but the request only allows en, fr`,
+		},
+		{
+			description:     "Bids whose languages are all disallowed are rejected",
+			ext:             `{"langs":["es","pt"]}`,
+			allowed:         []string{"en"},
+			expectedMessage: `Bid "one-bid" is in es, pt, but the request only allows en`,
+		},
+		{
+			description: "Bids without a language pass",
+			ext:         `{"mime":"image/png"}`,
+			allowed:     []string{"en"},
+		},
+		{
+			description: "Bids aren't checked unless the request lists languages",
+			ext:         `{"language":"es"}`,
+		},
+	}
+
+	for _, tc := range languageTestCases {
+		bid := &PBSOrtbBid{
+			Bid: &openrtb.Bid{ID: "one-bid", Ext: json.RawMessage(tc.ext)},
+		}
+		err := validateBidLanguage(bid, tc.allowed)
+		if tc.expectedMessage == "" {
+			if err != nil {
+				t.Errorf("%s: expected no error. Got %v", tc.description, err)
+			}
+			continue
+		}
+		rejection, ok := err.(*BidRejectionError)
+		if !ok || rejection.Reason != pbsmetrics.BidRejectionLanguage {
+			t.Errorf("%s: expected a %s rejection. Got %v", tc.description, pbsmetrics.BidRejectionLanguage, err)
+			continue
+		}
+		if rejection.Error() != tc.expectedMessage {
+			t.Errorf("%s: expected message %q. Got %q", tc.description, tc.expectedMessage, rejection.Error())
+		}
+	}
+}
+
+func TestRequestLanguages(t *testing.T) {
+	brq := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{{ID: "thisImp", Banner: &openrtb.Banner{}}},
+	}
+	brw := &BidResponseWrapper{
+		AdapterBids: &PBSOrtbSeatBid{
+			Bids: []*PBSOrtbBid{
+				{Bid: &openrtb.Bid{ID: "english-bid", ImpID: "thisImp", Price: 0.45, CrID: "thisCreative", AdM: "<div>ad</div>", Ext: json.RawMessage(`{"language":"en"}`)}},
+				{Bid: &openrtb.Bid{ID: "spanish-bid", ImpID: "thisImp", Price: 0.45, CrID: "thisCreative", AdM: "<div>ad</div>", Ext: json.RawMessage(`{"language":"es"}`)}},
+				{Bid: &openrtb.Bid{ID: "unknown-bid", ImpID: "thisImp", Price: 0.45, CrID: "thisCreative", AdM: "<div>ad</div>"}},
+			},
+		},
+	}
+	errs, _ := brw.ValidateBids(brq, &openrtb_ext.ExtRequestValidation{Languages: []string{"en"}}, config.BidValidation{}, nil, nil)
+
+	assertBidIDs(t, brw.AdapterBids, []string{"english-bid", "unknown-bid"})
+	if len(errs) != 1 {
+		t.Errorf("Expected 1 error. Got %v", errs)
+	}
+}
+
+func TestUnsupportedMediaTypes(t *testing.T) {
+	validator := &mediaTypeValidator{
+		bidder: openrtb_ext.BidderAppnexus,
+		capabilities: &adapters.CapabilitiesInfo{
+			Site: &adapters.PlatformInfo{
+				MediaTypes: []openrtb_ext.BidType{openrtb_ext.BidTypeBanner},
+			},
+			App: &adapters.PlatformInfo{
+				MediaTypes: []openrtb_ext.BidType{openrtb_ext.BidTypeBanner, openrtb_ext.BidTypeVideo},
+			},
+		},
+	}
+	mediaTypeTestCases := []struct {
+		app           *openrtb.App
+		imp           openrtb.Imp
+		bidType       openrtb_ext.BidType
+		expectedValid bool
+	}{
+		{imp: openrtb.Imp{ID: "thisImp", Banner: &openrtb.Banner{}}, bidType: openrtb_ext.BidTypeBanner, expectedValid: true},
+		{imp: openrtb.Imp{ID: "thisImp", Video: &openrtb.Video{}}, bidType: openrtb_ext.BidTypeVideo, expectedValid: false},
+		// Each platform has its own media types
+		{app: &openrtb.App{}, imp: openrtb.Imp{ID: "thisImp", Video: &openrtb.Video{}}, bidType: openrtb_ext.BidTypeVideo, expectedValid: true},
+		// Untyped bids take the imp's type, if it only has one
+		{imp: openrtb.Imp{ID: "thisImp", Video: &openrtb.Video{}}, expectedValid: false},
+		{imp: openrtb.Imp{ID: "thisImp", Banner: &openrtb.Banner{}, Video: &openrtb.Video{}}, expectedValid: true},
+	}
+
+	for _, tc := range mediaTypeTestCases {
+		brq := &openrtb.BidRequest{
+			App: tc.app,
+			Imp: []openrtb.Imp{tc.imp},
+		}
+		bid := &PBSOrtbBid{
+			Bid: &openrtb.Bid{
+				ID:    "one-bid",
+				ImpID: "thisImp",
+				Price: 0.45,
+				CrID:  "thisCreative",
+				AdM:   "some-markup",
+			},
+			BidType:  tc.bidType,
+			BidVideo: &openrtb_ext.ExtBidPrebidVideo{Duration: 30},
+		}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: []*PBSOrtbBid{bid},
+			},
+		}
+		errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{}, nil, []BidValidator{validator})
+		if tc.expectedValid && len(errs) != 0 {
+			t.Errorf("Expected %s bid to be valid. Got %v", tc.bidType, errs)
+		}
+		if !tc.expectedValid && len(errs) != 1 {
+			t.Errorf("Expected %s bid to be rejected. Got %d errors", tc.bidType, len(errs))
+		}
+	}
+
+	brq := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{{ID: "thisImp", Video: &openrtb.Video{}}},
+	}
+	bid := &PBSOrtbBid{
+		Bid:     &openrtb.Bid{ID: "one-bid", ImpID: "thisImp"},
+		BidType: openrtb_ext.BidTypeVideo,
+	}
+	if err := validator.Validate(brq, bid); err == nil || err.Error() != `Bid "one-bid" is type video but bidder appnexus only supports banner` {
+		t.Errorf("Unexpected error for unsupported media type: %v", err)
+	}
+}
+
+func TestBidRejectionReasons(t *testing.T) {
+	rejectionTestCases := []struct {
+		bid            *openrtb.Bid
+		expectedReason pbsmetrics.BidRejectionReason
+		expectedBidID  string
+	}{
+		{
+			bid:            &openrtb.Bid{ImpID: "thisImp", Price: 0.45, CrID: "thisCreative", AdM: "some-markup"},
+			expectedReason: pbsmetrics.BidRejectionMissingID,
+		},
+		{
+			bid:            &openrtb.Bid{ID: "one-bid", ImpID: "thisImp", Price: 0, CrID: "thisCreative", AdM: "some-markup"},
+			expectedReason: pbsmetrics.BidRejectionNonPositivePrice,
+			expectedBidID:  "one-bid",
+		},
+		{
+			bid:            &openrtb.Bid{ID: "one-bid", ImpID: "thisImp", Price: 0.45, CrID: "thisCreative"},
+			expectedReason: pbsmetrics.BidRejectionEmptyMarkup,
+			expectedBidID:  "one-bid",
+		},
+		{
+			bid:            &openrtb.Bid{ID: "one-bid", ImpID: "thisImp", Price: 0.05, CrID: "thisCreative", AdM: "some-markup"},
+			expectedReason: pbsmetrics.BidRejectionBelowFloor,
+			expectedBidID:  "one-bid",
+		},
+		{
+			bid:            &openrtb.Bid{ID: "one-bid", ImpID: "thisImp", Price: 0.45, CrID: "thisCreative", AdM: "some-markup", Cat: []string{"IAB7"}},
+			expectedReason: pbsmetrics.BidRejectionBlockedCategory,
+			expectedBidID:  "one-bid",
+		},
+	}
+
+	for _, tc := range rejectionTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID:       "thisImp",
+				BidFloor: 0.1,
+			}},
+			BCat: []string{"IAB7"},
+		}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: []*PBSOrtbBid{{Bid: tc.bid}},
+			},
+		}
+		errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{}, nil, nil)
+		if len(errs) != 1 {
+			t.Errorf("Expected 1 error. Got %d", len(errs))
+			continue
+		}
+		rejection, ok := errs[0].(*BidRejectionError)
+		if !ok {
+			t.Errorf("Expected a *BidRejectionError. Got %T", errs[0])
+			continue
+		}
+		if rejection.Reason != tc.expectedReason {
+			t.Errorf("Expected reason %s. Got %s", tc.expectedReason, rejection.Reason)
+		}
+		if rejection.BidID != tc.expectedBidID {
+			t.Errorf("Expected bid ID \"%s\". Got \"%s\"", tc.expectedBidID, rejection.BidID)
+		}
+	}
+}
+
+func TestCurrencyBids(t *testing.T) {
+	currencyTestCases := []struct {
+		brqCur           []string
+		brpCur           string
+		defaultCur       string
+		expectedValidBid bool
+	}{
+		// Case bid request and bid response don't specify any currencies.
+		// Expected to be valid since both bid request / response will be overriden with default currency (USD).
+		{
+			brqCur:           []string{},
+			brpCur:           "",
+			expectedValidBid: true,
+		},
+		// Case bid request specifies a currency (default one) but bid response doesn't.
+		// Expected to be valid since bid response will be overriden with default currency (USD).
+		{
+			brqCur:           []string{"USD"},
+			brpCur:           "",
+			expectedValidBid: true,
+		},
+		// Case bid request specifies more than 1 currency (default one and another one) but bid response doesn't.
+		// Expected to be valid since bid response will be overriden with default currency (USD).
+		{
+			brqCur:           []string{"USD", "EUR"},
+			brpCur:           "",
+			expectedValidBid: true,
+		},
+		// Case bid request specifies more than 1 currency (default one and another one) and bid response specifies default currency (USD).
+		// Expected to be valid.
+		{
+			brqCur:           []string{"USD", "EUR"},
+			brpCur:           "USD",
+			expectedValidBid: true,
+		},
+		// Case bid request specifies more than 1 currency (default one and another one) and bid response specifies the second currency allowed (not USD).
+		// Expected to be valid.
+		{
+			brqCur:           []string{"USD", "EUR"},
+			brpCur:           "EUR",
+			expectedValidBid: true,
+		},
+		// Case bid request specifies only 1 currency which is not the default one.
+		// Bid response doesn't specify any currency.
+		// Expected to be invalid.
+		{
+			brqCur:           []string{"JPY"},
+			brpCur:           "",
+			expectedValidBid: false,
+		},
+		// Case bid request doesn't specify any currencies.
+		// Bid response specifies a currency which is not the default one.
+		// Expected to be invalid.
+		{
+			brqCur:           []string{},
+			brpCur:           "JPY",
+			expectedValidBid: false,
+		},
+		// Case bid request specifies a currency.
+		// Bid response specifies a currency which is not the one specified in bid request.
+		// Expected to be invalid.
+		{
+			brqCur:           []string{"USD"},
+			brpCur:           "EUR",
+			expectedValidBid: false,
+		},
+		// Case bid request specifies several currencies.
+		// Bid response specifies a currency which is not the one specified in bid request.
+		// Expected to be invalid.
+		{
+			brqCur:           []string{"USD", "EUR"},
+			brpCur:           "JPY",
+			expectedValidBid: false,
+		},
+	}
+
+	for _, tc := range currencyTestCases {
+
+		brq := &openrtb.BidRequest{
+			Cur: tc.brqCur,
+			Imp: newTestImps("thisImp", "thatImp"),
+		}
+
+		bids := make([]*PBSOrtbBid, 2)
+		bids[0] = &PBSOrtbBid{
+			Bid: &openrtb.Bid{
+				ID:    "one-bid",
+				ImpID: "thisImp",
+				Price: 0.45,
+				CrID:  "thisCreative",
+				AdM:   "some-markup",
+			},
+		}
+		bids[1] = &PBSOrtbBid{
+			Bid: &openrtb.Bid{
+				ID:    "thatBid",
+				ImpID: "thatImp",
+				Price: 0.44,
+				CrID:  "thatCreative",
+				AdM:   "some-markup",
+			},
+		}
+
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids:     bids,
+				Currency: tc.brpCur,
+			},
+		}
+
+		expectedValidBids := len(bids)
+		expectedErrs := 0
+
+		if tc.expectedValidBid != true {
+			// If currency mistmatch, we should have one error
+			expectedErrs = 1
+			expectedValidBids = 0
+		}
+
+		assertBids(t, brq, brw, expectedValidBids, expectedErrs)
+	}
+}
+
+func TestExtraCurrencies(t *testing.T) {
+	extraCurrencyTestCases := []struct {
+		brqCur          []string
+		brpCur          string
+		extraCurrencies []string
+		expectedValid   bool
+	}{
+		// Non-ISO currencies are rejected by default
+		{brqCur: []string{"XBT"}, brpCur: "XBT", expectedValid: false},
+		// Hosts can allow them
+		{brqCur: []string{"XBT"}, brpCur: "XBT", extraCurrencies: []string{"XBT"}, expectedValid: true},
+		{brqCur: []string{"xbt"}, brpCur: "Xbt", extraCurrencies: []string{"XBT"}, expectedValid: true},
+		// The request must still allow them
+		{brqCur: []string{"USD"}, brpCur: "XBT", extraCurrencies: []string{"XBT"}, expectedValid: false},
+		// ISO currencies still work
+		{brqCur: []string{"EUR"}, brpCur: "EUR", extraCurrencies: []string{"XBT"}, expectedValid: true},
+	}
+
+	for _, tc := range extraCurrencyTestCases {
+		brq := &openrtb.BidRequest{
+			Cur: tc.brqCur,
+			Imp: []openrtb.Imp{{
+				ID: "thisImp",
+			}},
+		}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: []*PBSOrtbBid{{
+					Bid: &openrtb.Bid{
+						ID:    "one-bid",
+						ImpID: "thisImp",
+						Price: 0.45,
+						CrID:  "thisCreative",
+						AdM:   "some-markup",
+					},
+				}},
+				Currency: tc.brpCur,
+			},
+		}
+		errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{ExtraCurrencies: tc.extraCurrencies}, nil, nil)
+		if tc.expectedValid && len(errs) != 0 {
+			t.Errorf("Expected %s to be allowed by %v. Got %v", tc.brpCur, tc.brqCur, errs)
+		}
+		if !tc.expectedValid && len(errs) != 1 {
+			t.Errorf("Expected %s to be rejected by %v. Got %d errors", tc.brpCur, tc.brqCur, len(errs))
+		}
+	}
+}
+
+func TestBidderCurrencies(t *testing.T) {
+	bidderCurrencyTestCases := []struct {
+		description       string
+		brqCur            []string
+		brpCur            string
+		allowedCurrencies []string
+		expectedValid     bool
+	}{
+		{
+			description:       "Bidders restricted to USD may not bid in EUR, even if the request accepts it",
+			brqCur:            []string{"USD", "EUR"},
+			brpCur:            "EUR",
+			allowedCurrencies: []string{"USD"},
+			expectedValid:     false,
+		},
+		{
+			description:       "Bidders restricted to USD may bid in USD",
+			brqCur:            []string{"USD", "EUR"},
+			brpCur:            "usd",
+			allowedCurrencies: []string{"USD"},
+			expectedValid:     true,
+		},
+		{
+			description:       "Bids without a currency are in the default one",
+			allowedCurrencies: []string{"EUR"},
+			expectedValid:     false,
+		},
+		{
+			description:       "Bidders still need the request to accept their currency",
+			brqCur:            []string{"USD"},
+			brpCur:            "EUR",
+			allowedCurrencies: []string{"EUR"},
+			expectedValid:     false,
+		},
+		{
+			description:   "Bidders without a restriction may bid in any currency which the request accepts",
+			brqCur:        []string{"USD", "EUR"},
+			brpCur:        "EUR",
+			expectedValid: true,
+		},
+	}
+
+	for _, tc := range bidderCurrencyTestCases {
+		brq := &openrtb.BidRequest{
+			Cur: tc.brqCur,
+			Imp: []openrtb.Imp{{
+				ID: "thisImp",
+			}},
+		}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: []*PBSOrtbBid{{
+					Bid: &openrtb.Bid{
+						ID:    "one-bid",
+						ImpID: "thisImp",
+						Price: 0.45,
+						CrID:  "thisCreative",
+						AdM:   "some-markup",
+					},
+				}},
+				Currency:          tc.brpCur,
+				AllowedCurrencies: tc.allowedCurrencies,
+			},
+		}
+		errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{}, nil, nil)
+		if tc.expectedValid && len(errs) != 0 {
+			t.Errorf("%s: expected no errors. Got %v", tc.description, errs)
+		}
+		if !tc.expectedValid {
+			if len(errs) != 1 {
+				t.Errorf("%s: expected 1 error. Got %v", tc.description, errs)
+			} else if rejection, ok := errs[0].(*BidRejectionError); !ok || rejection.Reason != pbsmetrics.BidRejectionCurrencyNotAllowed {
+				t.Errorf("%s: expected a %s rejection. Got %v", tc.description, pbsmetrics.BidRejectionCurrencyNotAllowed, errs[0])
+			}
+		}
+	}
+}
+
+func TestPerBidCurrencyCheck(t *testing.T) {
+	brq := &openrtb.BidRequest{
+		Cur: []string{"USD"},
+		Imp: []openrtb.Imp{{
+			ID: "thisImp",
+		}},
+	}
+	newSeatBid := func() *PBSOrtbSeatBid {
+		return &PBSOrtbSeatBid{
+			Bids: []*PBSOrtbBid{
+				newCurrencyHintBid("eur-bid", ""),
+				newCurrencyHintBid("usd-bid", `{"cur":"USD"}`),
+				newCurrencyHintBid("gbp-bid", `{"cur":"GBP"}`),
+			},
+			Currency: "EUR",
+		}
+	}
+
+	brw := &BidResponseWrapper{AdapterBids: newSeatBid()}
+	errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{CurrencyCheck: config.CurrencyCheckBid}, nil, nil)
+	if len(brw.AdapterBids.Bids) != 1 || brw.AdapterBids.Bids[0].Bid.ID != "usd-bid" {
+		t.Errorf("Expected only usd-bid to survive. Got %v", brw.AdapterBids.Bids)
+	}
+	if brw.AdapterBids.Currency != "USD" {
+		t.Errorf("Expected the seat currency to become USD. Got %s", brw.AdapterBids.Currency)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("Expected one error for each rejected bid. Got %v", errs)
+	}
+	for i, expectedID := range []string{"eur-bid", "gbp-bid"} {
+		if rejection, ok := errs[i].(*BidRejectionError); !ok || rejection.BidID != expectedID || rejection.Reason != pbsmetrics.BidRejectionCurrencyNotAllowed {
+			t.Errorf("Expected a %s rejection for %s. Got %v", pbsmetrics.BidRejectionCurrencyNotAllowed, expectedID, errs[i])
+		}
+	}
+
+	brw = &BidResponseWrapper{AdapterBids: newSeatBid()}
+	errs, _ = brw.ValidateBids(brq, nil, config.BidValidation{}, nil, nil)
+	if len(brw.AdapterBids.Bids) != 0 {
+		t.Errorf("Expected the seat check to reject every bid. Got %v", brw.AdapterBids.Bids)
+	}
+	if len(errs) != 1 {
+		t.Errorf("Expected one error for the whole seat. Got %v", errs)
+	}
+}
+
+func TestPerBidCurrencyCheckKeepsAllowedSeatCurrency(t *testing.T) {
+	brq := &openrtb.BidRequest{
+		Cur: []string{"USD", "EUR"},
+		Imp: []openrtb.Imp{{
+			ID: "thisImp",
+		}},
+	}
+	brw := &BidResponseWrapper{
+		AdapterBids: &PBSOrtbSeatBid{
+			Bids: []*PBSOrtbBid{
+				newCurrencyHintBid("eur-bid", ""),
+				newCurrencyHintBid("usd-bid", `{"cur":"USD"}`),
+			},
+			Currency: "EUR",
+		},
+	}
+	errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{CurrencyCheck: config.CurrencyCheckBid}, nil, nil)
+	if brw.AdapterBids.Currency != "EUR" {
+		t.Errorf("Expected the seat currency to stay EUR. Got %s", brw.AdapterBids.Currency)
+	}
+	if len(brw.AdapterBids.Bids) != 1 || brw.AdapterBids.Bids[0].Bid.ID != "eur-bid" {
+		t.Errorf("Expected only eur-bid to survive. Got %v", brw.AdapterBids.Bids)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("Expected 1 error. Got %v", errs)
+	}
+	if rejection, ok := errs[0].(*BidRejectionError); !ok || rejection.Reason != pbsmetrics.BidRejectionCurrencyMismatch {
+		t.Errorf("Expected a %s rejection. Got %v", pbsmetrics.BidRejectionCurrencyMismatch, errs[0])
+	}
+}
+
+func newCurrencyHintBid(id string, ext string) *PBSOrtbBid {
+	bid := &PBSOrtbBid{
+		Bid: &openrtb.Bid{
+			ID:    id,
+			ImpID: "thisImp",
+			Price: 0.45,
+			CrID:  "thisCreative",
+			AdM:   "some-markup",
+		},
+	}
+	if ext != "" {
+		bid.Bid.Ext = json.RawMessage(ext)
+	}
+	return bid
+}
+
+func TestDefaultCurrency(t *testing.T) {
+	defaultCurrencyTestCases := []struct {
+		description     string
+		defaultCurrency string
+		brqCur          []string
+		brpCur          string
+		expectedValid   bool
+	}{
+		{description: "Seats without a currency take the account default", defaultCurrency: "EUR", expectedValid: true},
+		{description: "Requests without cur allow the account default", defaultCurrency: "EUR", brpCur: "EUR", expectedValid: true},
+		{description: "Requests without cur don't allow USD if the account defaults to another currency", defaultCurrency: "EUR", brpCur: "USD", expectedValid: false},
+		{description: "The request cur takes priority over the account default", defaultCurrency: "EUR", brqCur: []string{"USD"}, brpCur: "USD", expectedValid: true},
+		{description: "Seats without a currency are in the default, which the request must allow", defaultCurrency: "EUR", brqCur: []string{"USD"}, expectedValid: false},
+		{description: "USD is the default without account config", brpCur: "USD", expectedValid: true},
+		{description: "Other currencies need a request cur without account config", brpCur: "EUR", expectedValid: false},
+	}
+
+	for _, tc := range defaultCurrencyTestCases {
+		brq := &openrtb.BidRequest{
+			Cur: tc.brqCur,
+			Imp: []openrtb.Imp{{
+				ID: "thisImp",
+			}},
+		}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: []*PBSOrtbBid{{
+					Bid: &openrtb.Bid{
+						ID:    "one-bid",
+						ImpID: "thisImp",
+						Price: 0.45,
+						CrID:  "thisCreative",
+						AdM:   "some-markup",
+					},
+				}},
+				Currency: tc.brpCur,
+			},
+		}
+		errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{DefaultCurrency: tc.defaultCurrency}, nil, nil)
+		if tc.expectedValid && len(errs) != 0 {
+			t.Errorf("%s: Expected no errors. Got %v", tc.description, errs)
+		}
+		if !tc.expectedValid && len(errs) != 1 {
+			t.Errorf("%s: Expected 1 error. Got %d", tc.description, len(errs))
+		}
+	}
+}
+
+func TestDefaultCurrencyMixedBids(t *testing.T) {
+	brq := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{{
+			ID: "thisImp",
+		}},
+	}
+	brw := &BidResponseWrapper{
+		AdapterBids: &PBSOrtbSeatBid{
+			Bids: []*PBSOrtbBid{{
+				Bid: &openrtb.Bid{
+					ID:    "no-currency",
+					ImpID: "thisImp",
+					Price: 0.45,
+					CrID:  "thisCreative",
+					AdM:   "some-markup",
+				},
+			}, {
+				Bid: &openrtb.Bid{
+					ID:    "eur",
+					ImpID: "thisImp",
+					Price: 0.45,
+					CrID:  "thisCreative",
+					AdM:   "some-markup",
+					Ext:   json.RawMessage(`{"cur":"EUR"}`),
+				},
+			}, {
+				Bid: &openrtb.Bid{
+					ID:    "usd",
+					ImpID: "thisImp",
+					Price: 0.45,
+					CrID:  "thisCreative",
+					AdM:   "some-markup",
+					Ext:   json.RawMessage(`{"cur":"USD"}`),
+				},
+			}},
+		},
+	}
+	errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{DefaultCurrency: "EUR"}, nil, nil)
+	if len(errs) != 1 {
+		t.Fatalf("Expected 1 error. Got %v", errs)
+	}
+	if rejection, ok := errs[0].(*BidRejectionError); !ok || rejection.BidID != "usd" || rejection.Reason != pbsmetrics.BidRejectionCurrencyMismatch {
+		t.Errorf("Expected the USD bid to be rejected for its currency. Got %v", errs[0])
+	}
+	if len(brw.AdapterBids.Bids) != 2 {
+		t.Errorf("Expected the bids in the account's default currency to be kept. Got %d bids", len(brw.AdapterBids.Bids))
+	}
+}
+
+func TestDuplicateBidIDs(t *testing.T) {
+	duplicateTestCases := []struct {
+		description  string
+		policy       string
+		expectedKept []float64
+	}{
+		{description: "The first bid is kept by default", expectedKept: []float64{0.5, 0.3}},
+		{description: "The first bid is kept", policy: config.DuplicateBidIDsKeepFirst, expectedKept: []float64{0.5, 0.3}},
+		{description: "The highest priced bid is kept", policy: config.DuplicateBidIDsKeepHighestPrice, expectedKept: []float64{0.3, 0.9}},
+	}
+
+	for _, tc := range duplicateTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID: "thisImp",
+			}},
+		}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: []*PBSOrtbBid{
+					newDuplicateTestBid("dupe", 0.5),
+					newDuplicateTestBid("unique", 0.3),
+					newDuplicateTestBid("dupe", 0.9),
+					newDuplicateTestBid("dupe", 0.9),
+				},
+			},
+		}
+		errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{DuplicateBidIDs: tc.policy}, nil, nil)
+		if len(errs) != 2 {
+			t.Errorf("%s: Expected 2 errors. Got %v", tc.description, errs)
+		}
+		for _, err := range errs {
+			if rejection, ok := err.(*BidRejectionError); !ok || rejection.Reason != pbsmetrics.BidRejectionDuplicateID || rejection.BidID != "dupe" {
+				t.Errorf("%s: Expected duplicate_id rejections for bid dupe. Got %v", tc.description, err)
+			}
+		}
+		if len(brw.AdapterBids.Bids) != len(tc.expectedKept) {
+			t.Errorf("%s: Expected %d bids. Got %d", tc.description, len(tc.expectedKept), len(brw.AdapterBids.Bids))
+			continue
+		}
+		for i, price := range tc.expectedKept {
+			if brw.AdapterBids.Bids[i].Bid.Price != price {
+				t.Errorf("%s: Expected bid %d to have price %f. Got %f", tc.description, i, price, brw.AdapterBids.Bids[i].Bid.Price)
+			}
+		}
+	}
+}
+
+func TestDuplicateBidIDsAcrossSeats(t *testing.T) {
+	brq := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{{
+			ID: "thisImp",
+		}},
+	}
+	for _, seat := range []string{"appnexus", "rubicon"} {
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: []*PBSOrtbBid{newDuplicateTestBid("shared", 0.5)},
+			},
+		}
+		errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{}, nil, nil)
+		if len(errs) != 0 || len(brw.AdapterBids.Bids) != 1 {
+			t.Errorf("Bid IDs only need to be unique within a seat, so %s's bid should be kept. Got %v", seat, errs)
+		}
+	}
+}
+
+func newDuplicateTestBid(id string, price float64) *PBSOrtbBid {
+	return &PBSOrtbBid{
+		Bid: &openrtb.Bid{
+			ID:    id,
+			ImpID: "thisImp",
+			Price: price,
+			CrID:  "thisCreative",
+			AdM:   "some-markup",
+		},
+	}
+}
+
+func TestInterstitialSizes(t *testing.T) {
+	instlTestCases := []struct {
+		description   string
+		instl         int8
+		device        *openrtb.Device
+		maxRatio      float64
+		w             uint64
+		h             uint64
+		expectedValid bool
+	}{
+		{
+			description:   "Bids which fit the screen pass",
+			instl:         1,
+			device:        &openrtb.Device{W: 375, H: 667},
+			maxRatio:      1,
+			w:             320,
+			h:             480,
+			expectedValid: true,
+		},
+		{
+			description:   "Bids as large as the ratio allows pass",
+			instl:         1,
+			device:        &openrtb.Device{W: 320, H: 480},
+			maxRatio:      1.5,
+			w:             480,
+			h:             720,
+			expectedValid: true,
+		},
+		{
+			description:   "Bids which are too wide are rejected",
+			instl:         1,
+			device:        &openrtb.Device{W: 375, H: 667},
+			maxRatio:      1,
+			w:             768,
+			h:             480,
+			expectedValid: false,
+		},
+		{
+			description:   "Bids which are too tall are rejected",
+			instl:         1,
+			device:        &openrtb.Device{W: 375, H: 667},
+			maxRatio:      1,
+			w:             320,
+			h:             1024,
+			expectedValid: false,
+		},
+		{
+			description:   "Imps which aren't interstitial aren't checked",
+			device:        &openrtb.Device{W: 375, H: 667},
+			maxRatio:      1,
+			w:             768,
+			h:             1024,
+			expectedValid: true,
+		},
+		{
+			description:   "Bids pass if the device has no screen size",
+			instl:         1,
+			device:        &openrtb.Device{},
+			maxRatio:      1,
+			w:             768,
+			h:             1024,
+			expectedValid: true,
+		},
+		{
+			description:   "Bids pass if the request has no device",
+			instl:         1,
+			maxRatio:      1,
+			w:             768,
+			h:             1024,
+			expectedValid: true,
+		},
+		{
+			description:   "Bids pass if the host sets no limit",
+			instl:         1,
+			device:        &openrtb.Device{W: 375, H: 667},
+			w:             768,
+			h:             1024,
+			expectedValid: true,
+		},
+	}
+
+	for _, tc := range instlTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID:    "thisImp",
+				Instl: tc.instl,
+			}},
+			Device: tc.device,
+		}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: []*PBSOrtbBid{{
+					Bid: &openrtb.Bid{
+						ID:    "one-bid",
+						ImpID: "thisImp",
+						Price: 0.45,
+						CrID:  "thisCreative",
+						AdM:   "some-markup",
+						W:     tc.w,
+						H:     tc.h,
+					},
+				}},
+			},
+		}
+		errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{MaxInterstitialRatio: tc.maxRatio}, nil, nil)
+		if tc.expectedValid {
+			if len(errs) != 0 {
+				t.Errorf("%s: expected the bid to be kept. Got %v", tc.description, errs)
+			}
+			continue
+		}
+		if len(errs) != 1 {
+			t.Errorf("%s: expected the bid to be rejected. Got %v", tc.description, errs)
+		} else if rejection, ok := errs[0].(*BidRejectionError); !ok || rejection.Reason != pbsmetrics.BidRejectionInvalidSize {
+			t.Errorf("%s: expected a %s rejection. Got %v", tc.description, pbsmetrics.BidRejectionInvalidSize, errs[0])
+		}
+	}
+}
+
+func TestAdmSizeLimit(t *testing.T) {
+	admSizeTestCases := []struct {
+		description   string
+		maxAdmSize    int
+		adm           string
+		nurl          string
+		expectedValid bool
+	}{
+		{
+			description:   "There's no limit by default",
+			adm:           "0123456789",
+			expectedValid: true,
+		},
+		{
+			description:   "An adm exactly at the limit is allowed",
+			maxAdmSize:    10,
+			adm:           "0123456789",
+			expectedValid: true,
+		},
+		{
+			description:   "An adm one byte over the limit is rejected",
+			maxAdmSize:    10,
+			adm:           "0123456789a",
+			expectedValid: false,
+		},
+		{
+			description:   "nurl-only bids have no adm, so they pass",
+			maxAdmSize:    10,
+			nurl:          "http://some-url.com/a-very-long-path-to-the-creative",
+			expectedValid: true,
+		},
+	}
+
+	for _, tc := range admSizeTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID: "thisImp",
+			}},
+		}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: []*PBSOrtbBid{{
+					Bid: &openrtb.Bid{
+						ID:    "one-bid",
+						ImpID: "thisImp",
+						Price: 0.45,
+						CrID:  "thisCreative",
+						AdM:   tc.adm,
+						NURL:  tc.nurl,
+					},
+				}},
+			},
+		}
+		errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{MaxAdmSize: tc.maxAdmSize}, nil, nil)
+		if tc.expectedValid {
+			if len(errs) != 0 {
+				t.Errorf("%s: expected no errors. Got %v", tc.description, errs)
+			}
+			continue
+		}
+		if len(errs) != 1 {
+			t.Fatalf("%s: expected 1 error. Got %v", tc.description, errs)
+		}
+		rejection, ok := errs[0].(*BidRejectionError)
+		if !ok || rejection.Reason != pbsmetrics.BidRejectionMarkupTooLarge {
+			t.Errorf("%s: expected a %s rejection. Got %v", tc.description, pbsmetrics.BidRejectionMarkupTooLarge, errs[0])
+			continue
+		}
+		if expected := `Bid "one-bid" has an adm of 11 bytes, which exceeds the limit of 10 bytes`; rejection.Error() != expected {
+			t.Errorf("%s: expected message %q. Got %q", tc.description, expected, rejection.Error())
+		}
+	}
+}
+
+func TestCrIDLengthLimit(t *testing.T) {
+	crIDTestCases := []struct {
+		description   string
+		maxCrIDLength int
+		crID          string
+		expectedValid bool
+	}{
+		{
+			description:   "There's no limit by default",
+			crID:          "a-very-long-creative-id-which-some-ad-servers-would-truncate",
+			expectedValid: true,
+		},
+		{
+			description:   "A crid exactly at the limit is allowed",
+			maxCrIDLength: 10,
+			crID:          "0123456789",
+			expectedValid: true,
+		},
+		{
+			description:   "A crid one byte over the limit is rejected",
+			maxCrIDLength: 10,
+			crID:          "0123456789a",
+			expectedValid: false,
+		},
+	}
+
+	for _, tc := range crIDTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: newTestImps("thisImp"),
+		}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: []*PBSOrtbBid{{
+					Bid: &openrtb.Bid{
+						ID:    "one-bid",
+						ImpID: "thisImp",
+						Price: 0.45,
+						CrID:  tc.crID,
+						AdM:   "<div>an ad</div>",
+					},
+				}},
+			},
+		}
+		errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{MaxCrIDLength: tc.maxCrIDLength}, nil, nil)
+		if tc.expectedValid {
+			if len(errs) != 0 {
+				t.Errorf("%s: expected no errors. Got %v", tc.description, errs)
+			}
+			continue
+		}
+		if len(errs) != 1 {
+			t.Fatalf("%s: expected 1 error. Got %v", tc.description, errs)
+		}
+		rejection, ok := errs[0].(*BidRejectionError)
+		if !ok || rejection.Reason != pbsmetrics.BidRejectionCreativeIDTooLong {
+			t.Errorf("%s: expected a %s rejection. Got %v", tc.description, pbsmetrics.BidRejectionCreativeIDTooLong, errs[0])
+			continue
+		}
+		if expected := `Bid "one-bid" has a crid of 11 bytes, which exceeds the limit of 10 bytes`; rejection.Error() != expected {
+			t.Errorf("%s: expected message %q. Got %q", tc.description, expected, rejection.Error())
+		}
+	}
+}
+
+func TestRequiredBidMeta(t *testing.T) {
+	metaTestCases := []struct {
+		description     string
+		requiredMeta    []string
+		backfill        bool
+		meta            *openrtb_ext.ExtBidPrebidMeta
+		adomain         []string
+		expectedValid   bool
+		expectedDomains []string
+	}{
+		{
+			description:   "Bids don't need meta unless the host requires it",
+			expectedValid: true,
+		},
+		{
+			description:     "Bids with the required meta pass",
+			requiredMeta:    []string{"advertiserDomains"},
+			meta:            &openrtb_ext.ExtBidPrebidMeta{AdvertiserDomains: []string{"advertiser.com"}},
+			expectedValid:   true,
+			expectedDomains: []string{"advertiser.com"},
+		},
+		{
+			description:   "Bids without meta are rejected",
+			requiredMeta:  []string{"advertiserDomains"},
+			adomain:       []string{"advertiser.com"},
+			expectedValid: false,
+		},
+		{
+			description:   "Bids missing one of several required keys are rejected",
+			requiredMeta:  []string{"advertiserDomains", "brandName"},
+			meta:          &openrtb_ext.ExtBidPrebidMeta{AdvertiserDomains: []string{"advertiser.com"}},
+			expectedValid: false,
+		},
+		{
+			description:     "The adomain can be backfilled into the meta",
+			requiredMeta:    []string{"advertiserDomains"},
+			backfill:        true,
+			adomain:         []string{"advertiser.com"},
+			expectedValid:   true,
+			expectedDomains: []string{"advertiser.com"},
+		},
+		{
+			description:     "Backfilling doesn't override the bidder's meta",
+			requiredMeta:    []string{"advertiserDomains"},
+			backfill:        true,
+			meta:            &openrtb_ext.ExtBidPrebidMeta{AdvertiserDomains: []string{"brand.com"}},
+			adomain:         []string{"advertiser.com"},
+			expectedValid:   true,
+			expectedDomains: []string{"brand.com"},
+		},
+		{
+			description:   "Bids without an adomain can't be backfilled",
+			requiredMeta:  []string{"advertiserDomains"},
+			backfill:      true,
+			expectedValid: false,
+		},
+	}
+
+	for _, tc := range metaTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID: "thisImp",
+			}},
+		}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: []*PBSOrtbBid{{
+					Bid: &openrtb.Bid{
+						ID:      "one-bid",
+						ImpID:   "thisImp",
+						Price:   0.45,
+						CrID:    "thisCreative",
+						AdM:     "some-markup",
+						ADomain: tc.adomain,
+					},
+					BidMeta: tc.meta,
+				}},
+			},
+		}
+		hostValidation := config.BidValidation{
+			RequiredBidMeta:           tc.requiredMeta,
+			BackfillAdvertiserDomains: tc.backfill,
+		}
+		errs, _ := brw.ValidateBids(brq, nil, hostValidation, nil, nil)
+		if !tc.expectedValid {
+			if len(errs) != 1 {
+				t.Errorf("%s: expected 1 error. Got %v", tc.description, errs)
+			} else if rejection, ok := errs[0].(*BidRejectionError); !ok || rejection.Reason != pbsmetrics.BidRejectionMissingMeta {
+				t.Errorf("%s: expected a %s rejection. Got %v", tc.description, pbsmetrics.BidRejectionMissingMeta, errs[0])
+			}
+			continue
+		}
+		if len(errs) != 0 || len(brw.AdapterBids.Bids) != 1 {
+			t.Errorf("%s: expected the bid to be kept. Got %v", tc.description, errs)
+			continue
+		}
+		if len(tc.expectedDomains) == 0 {
+			continue
+		}
+		meta := brw.AdapterBids.Bids[0].BidMeta
+		if meta == nil || len(meta.AdvertiserDomains) != 1 || meta.AdvertiserDomains[0] != tc.expectedDomains[0] {
+			t.Errorf("%s: expected meta.advertiserDomains %v. Got %v", tc.description, tc.expectedDomains, meta)
+		}
+	}
+}
+
+func TestPermissiveValidation(t *testing.T) {
+	brq := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{{
+			ID:       "thisImp",
+			BidFloor: 0.5,
+		}},
+	}
+	brw := &BidResponseWrapper{
+		AdapterBids: &PBSOrtbSeatBid{
+			Bids: []*PBSOrtbBid{
+				{Bid: &openrtb.Bid{ID: "valid-bid", ImpID: "thisImp", Price: 0.75, CrID: "thisCreative", AdM: "some-markup"}},
+				{Bid: &openrtb.Bid{ID: "cheap-bid", ImpID: "thisImp", Price: 0.25, CrID: "thisCreative", AdM: "some-markup"}},
+				{Bid: &openrtb.Bid{ID: "lost-bid", ImpID: "otherImp", Price: 0.75, CrID: "thisCreative", AdM: "some-markup"}},
+			},
+		},
+	}
+	errs, warnings := brw.ValidateBids(brq, nil, config.BidValidation{Mode: config.ValidationModePermissive}, nil, nil)
+
+	if len(errs) != 0 {
+		t.Errorf("Permissive validation shouldn't reject any bids. Got %v", errs)
+	}
+	assertBidIDs(t, brw.AdapterBids, []string{"valid-bid", "cheap-bid", "lost-bid"})
+	if len(warnings) != 2 {
+		t.Fatalf("Expected a warning for each invalid bid. Got %v", warnings)
+	}
+	for i, bidID := range []string{"cheap-bid", "lost-bid"} {
+		if _, ok := warnings[i].(*errortypes.Warning); !ok {
+			t.Errorf("Expected an *errortypes.Warning for %s. Got %#v", bidID, warnings[i])
+		}
+		if !strings.Contains(warnings[i].Error(), bidID) || !strings.HasSuffix(warnings[i].Error(), "It was kept because bid validation is permissive") {
+			t.Errorf("Expected the warning to explain why %s was kept. Got %q", bidID, warnings[i].Error())
+		}
+	}
+}
+
+func TestEnforcedValidation(t *testing.T) {
+	brq := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{{
+			ID:       "thisImp",
+			BidFloor: 0.5,
+		}},
+	}
+	brw := &BidResponseWrapper{
+		AdapterBids: &PBSOrtbSeatBid{
+			Bids: []*PBSOrtbBid{
+				{Bid: &openrtb.Bid{ID: "valid-bid", ImpID: "thisImp", Price: 0.75, CrID: "thisCreative", AdM: "some-markup"}},
+				{Bid: &openrtb.Bid{ID: "cheap-bid", ImpID: "thisImp", Price: 0.25, CrID: "thisCreative", AdM: "some-markup"}},
+			},
+		},
+	}
+	errs, warnings := brw.ValidateBids(brq, nil, config.BidValidation{Mode: config.ValidationModeEnforce}, nil, nil)
+
+	if len(errs) != 1 || len(warnings) != 0 {
+		t.Errorf("Expected 1 error and no warnings. Got %v and %v", errs, warnings)
+	}
+	assertBidIDs(t, brw.AdapterBids, []string{"valid-bid"})
+}
+
+// TestBidProblemsAreRejectionReasons makes sure that the problems from openrtb_ext.ValidateBid are recorded under known reasons.
+func TestBidProblemsAreRejectionReasons(t *testing.T) {
+	known := make(map[pbsmetrics.BidRejectionReason]bool)
+	for _, reason := range pbsmetrics.BidRejectionReasons() {
+		known[reason] = true
+	}
+	problems := []openrtb_ext.BidProblem{
+		openrtb_ext.BidProblemEmptyBid,
+		openrtb_ext.BidProblemMissingID,
+		openrtb_ext.BidProblemMissingImpID,
+		openrtb_ext.BidProblemNonPositivePrice,
+		openrtb_ext.BidProblemMissingCreativeID,
+		openrtb_ext.BidProblemEmptyMarkup,
+	}
+	for _, problem := range problems {
+		if !known[pbsmetrics.BidRejectionReason(problem)] {
+			t.Errorf("Bid problem %s is not a BidRejectionReason", problem)
+		}
+	}
+}
+
+func TestCOPPABids(t *testing.T) {
+	coppaTestCases := []struct {
+		description   string
+		coppa         bool
+		attr          []openrtb.CreativeAttribute
+		meta          *openrtb_ext.ExtBidPrebidMeta
+		expectedValid bool
+	}{
+		{
+			description:   "COPPA requests accept Bids without flagged attributes",
+			coppa:         true,
+			attr:          []openrtb.CreativeAttribute{1},
+			expectedValid: true,
+		},
+		{
+			description:   "COPPA requests reject Bids with prohibited attributes",
+			coppa:         true,
+			attr:          []openrtb.CreativeAttribute{1, 8},
+			expectedValid: false,
+		},
+		{
+			description:   "COPPA requests reject behaviorally targeted Bids",
+			coppa:         true,
+			meta:          &openrtb_ext.ExtBidPrebidMeta{BehavioralTargeting: true},
+			expectedValid: false,
+		},
+		{
+			description:   "Other requests accept Bids with prohibited attributes",
+			attr:          []openrtb.CreativeAttribute{8},
+			expectedValid: true,
+		},
+		{
+			description:   "Other requests accept behaviorally targeted Bids",
+			meta:          &openrtb_ext.ExtBidPrebidMeta{BehavioralTargeting: true},
+			expectedValid: true,
+		},
+	}
+
+	for _, tc := range coppaTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID: "thisImp",
+			}},
+		}
+		if tc.coppa {
+			brq.Regs = &openrtb.Regs{COPPA: 1}
+		}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: []*PBSOrtbBid{{
+					Bid: &openrtb.Bid{
+						ID:    "one-bid",
+						ImpID: "thisImp",
+						Price: 0.45,
+						CrID:  "thisCreative",
+						AdM:   "some-markup",
+						Attr:  tc.attr,
+					},
+					BidMeta: tc.meta,
+				}},
+			},
+		}
+		hostValidation := config.BidValidation{
+			COPPAProhibitedAttributes: []int{8, 9},
+		}
+		errs, _ := brw.ValidateBids(brq, nil, hostValidation, nil, nil)
+		if tc.expectedValid {
+			if len(errs) != 0 || len(brw.AdapterBids.Bids) != 1 {
+				t.Errorf("%s: expected the bid to be kept. Got %v", tc.description, errs)
+			}
+			continue
+		}
+		if len(errs) != 1 {
+			t.Errorf("%s: expected 1 error. Got %v", tc.description, errs)
+		} else if rejection, ok := errs[0].(*BidRejectionError); !ok || rejection.Reason != pbsmetrics.BidRejectionCOPPA {
+			t.Errorf("%s: expected a %s rejection. Got %v", tc.description, pbsmetrics.BidRejectionCOPPA, errs[0])
+		}
+	}
+}
+
+func TestDNTBids(t *testing.T) {
+	dntTestCases := []struct {
+		description   string
+		dnt           bool
+		checkDNT      bool
+		attr          []openrtb.CreativeAttribute
+		meta          *openrtb_ext.ExtBidPrebidMeta
+		expectedValid bool
+	}{
+		{
+			description:   "DNT requests accept unflagged Bids",
+			dnt:           true,
+			checkDNT:      true,
+			attr:          []openrtb.CreativeAttribute{1},
+			meta:          &openrtb_ext.ExtBidPrebidMeta{},
+			expectedValid: true,
+		},
+		{
+			description:   "DNT requests reject behaviorally targeted Bids",
+			dnt:           true,
+			checkDNT:      true,
+			meta:          &openrtb_ext.ExtBidPrebidMeta{BehavioralTargeting: true},
+			expectedValid: false,
+		},
+		{
+			description:   "DNT requests reject Bids with prohibited attributes",
+			dnt:           true,
+			checkDNT:      true,
+			attr:          []openrtb.CreativeAttribute{1, 9},
+			expectedValid: false,
+		},
+		{
+			description:   "Other requests accept behaviorally targeted Bids",
+			checkDNT:      true,
+			meta:          &openrtb_ext.ExtBidPrebidMeta{BehavioralTargeting: true},
+			expectedValid: true,
+		},
+		{
+			description:   "Other requests accept Bids with prohibited attributes",
+			checkDNT:      true,
+			attr:          []openrtb.CreativeAttribute{9},
+			expectedValid: true,
+		},
+		{
+			description:   "DNT isn't checked unless the host turns it on",
+			dnt:           true,
+			meta:          &openrtb_ext.ExtBidPrebidMeta{BehavioralTargeting: true},
+			expectedValid: true,
+		},
+	}
+
+	for _, tc := range dntTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID: "thisImp",
+			}},
+			Device: &openrtb.Device{},
+		}
+		if tc.dnt {
+			brq.Device.DNT = 1
+		}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: []*PBSOrtbBid{{
+					Bid: &openrtb.Bid{
+						ID:    "one-bid",
+						ImpID: "thisImp",
+						Price: 0.45,
+						CrID:  "thisCreative",
+						AdM:   "some-markup",
+						Attr:  tc.attr,
+					},
+					BidMeta: tc.meta,
+				}},
+			},
+		}
+		hostValidation := config.BidValidation{
+			CheckDNT:                tc.checkDNT,
+			DNTProhibitedAttributes: []int{9},
+		}
+		errs, _ := brw.ValidateBids(brq, nil, hostValidation, nil, nil)
+		if tc.expectedValid {
+			if len(errs) != 0 || len(brw.AdapterBids.Bids) != 1 {
+				t.Errorf("%s: expected the bid to be kept. Got %v", tc.description, errs)
+			}
+			continue
+		}
+		if len(errs) != 1 {
+			t.Errorf("%s: expected 1 error. Got %v", tc.description, errs)
+		} else if rejection, ok := errs[0].(*BidRejectionError); !ok || rejection.Reason != pbsmetrics.BidRejectionDNT {
+			t.Errorf("%s: expected a %s rejection. Got %v", tc.description, pbsmetrics.BidRejectionDNT, errs[0])
+		}
+	}
+}
+
+func TestBidPriceCeiling(t *testing.T) {
+	rates := currencies.NewRates(time.Time{}, map[string]map[string]float64{
+		"EUR": {
+			"USD": 1.2,
+		},
+	})
+	ceilingTestCases := []struct {
+		description       string
+		maxBidPrice       float64
+		requestCurrencies []string
+		defaultCurrency   string
+		currency          string
+		price             float64
+		expectedValid     bool
+		expectedError     string
+	}{
+		{
+			description:   "There's no ceiling by default",
+			price:         1000000,
+			expectedValid: true,
+		},
+		{
+			description:   "Bids at exactly the ceiling are kept",
+			maxBidPrice:   100,
+			price:         100,
+			expectedValid: true,
+		},
+		{
+			description:   "Bids above the ceiling are rejected",
+			maxBidPrice:   100,
+			price:         100.01,
+			expectedValid: false,
+			expectedError: `Bid "one-bid" price 100.010000 USD exceeds the ceiling of 100.000000 USD`,
+		},
+		{
+			description:   "Bids are converted into USD before they're compared",
+			maxBidPrice:   100,
+			currency:      "EUR",
+			price:         90,
+			expectedValid: false,
+			expectedError: `Bid "one-bid" price 108.000000 USD exceeds the ceiling of 100.000000 USD`,
+		},
+		{
+			description:   "Converted bids under the ceiling are kept",
+			maxBidPrice:   100,
+			currency:      "EUR",
+			price:         80,
+			expectedValid: true,
+		},
+		{
+			description:       "The ceiling is in the request's currency",
+			maxBidPrice:       100,
+			requestCurrencies: []string{"EUR"},
+			currency:          "EUR",
+			price:             100,
+			expectedValid:     true,
+		},
+		{
+			description:       "Bids above a ceiling in the request's currency are rejected",
+			maxBidPrice:       100,
+			requestCurrencies: []string{"EUR"},
+			currency:          "EUR",
+			price:             100.01,
+			expectedValid:     false,
+			expectedError:     `Bid "one-bid" price 100.010000 EUR exceeds the ceiling of 100.000000 EUR`,
+		},
+		{
+			description:       "The ceiling is in the default currency if the request doesn't have one",
+			maxBidPrice:       100,
+			requestCurrencies: []string{},
+			defaultCurrency:   "EUR",
+			price:             100.01,
+			expectedValid:     false,
+			expectedError:     `Bid "one-bid" price 100.010000 EUR exceeds the ceiling of 100.000000 EUR`,
+		},
+	}
+
+	for _, tc := range ceilingTestCases {
+		requestCurrencies := tc.requestCurrencies
+		if requestCurrencies == nil {
+			requestCurrencies = []string{"USD", "EUR"}
+		}
+		brq := &openrtb.BidRequest{
+			Imp: newTestImps("thisImp"),
+			Cur: requestCurrencies,
+		}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Currency: tc.currency,
+				Bids: []*PBSOrtbBid{{
+					Bid: &openrtb.Bid{
+						ID:    "one-bid",
+						ImpID: "thisImp",
+						Price: tc.price,
+						CrID:  "thisCreative",
+						AdM:   "some-markup",
+					},
+				}},
+			},
+		}
+		errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{MaxBidPrice: tc.maxBidPrice, DefaultCurrency: tc.defaultCurrency}, currencies.NewConversionCache(rates), nil)
+		if tc.expectedValid {
+			if len(errs) != 0 {
+				t.Errorf("%s: expected no errors. Got %v", tc.description, errs)
+			}
+			continue
+		}
+		if len(errs) != 1 {
+			t.Fatalf("%s: expected 1 error. Got %v", tc.description, errs)
+		}
+		rejection, ok := errs[0].(*BidRejectionError)
+		if !ok || rejection.Reason != pbsmetrics.BidRejectionPriceTooHigh {
+			t.Errorf("%s: expected a %s rejection. Got %v", tc.description, pbsmetrics.BidRejectionPriceTooHigh, errs[0])
+			continue
+		}
+		if rejection.Error() != tc.expectedError {
+			t.Errorf("%s: expected message %q. Got %q", tc.description, tc.expectedError, rejection.Error())
+		}
+	}
+}
+
+func TestInvalidConversionRates(t *testing.T) {
+	rates := currencies.NewRates(time.Time{}, map[string]map[string]float64{
+		"EUR": {
+			"USD": 0,
+			"GBP": 0,
+		},
+	})
+	rateTestCases := []struct {
+		description   string
+		floor         float64
+		floorCurrency string
+		maxBidPrice   float64
+		expectedError string
+	}{
+		{
+			description:   "Floors can't be compared with an invalid rate",
+			floor:         0.5,
+			floorCurrency: "GBP",
+			expectedError: `Bid "one-bid" could not be compared to the floor of imp "thisImp": conversion rate invalid: EUR->GBP is 0`,
+		},
+		{
+			description:   "Ceilings can't be compared with an invalid rate",
+			maxBidPrice:   100,
+			expectedError: `Bid "one-bid" could not be compared to the price ceiling: conversion rate invalid: EUR->USD is 0`,
+		},
+	}
+
+	for _, tc := range rateTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID:          "thisImp",
+				BidFloor:    tc.floor,
+				BidFloorCur: tc.floorCurrency,
+			}},
+			// The ceiling is in the request's first currency, so the EUR bids need to be converted.
+			Cur: []string{"USD", "EUR"},
+		}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Currency: "EUR",
+				Bids: []*PBSOrtbBid{{
+					Bid: &openrtb.Bid{
+						ID:    "one-bid",
+						ImpID: "thisImp",
+						Price: 1.5,
+						CrID:  "thisCreative",
+						AdM:   "some-markup",
+					},
+				}},
+			},
+		}
+		errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{MaxBidPrice: tc.maxBidPrice}, currencies.NewConversionCache(rates), nil)
+		if len(errs) != 1 {
+			t.Fatalf("%s: expected 1 error. Got %v", tc.description, errs)
+		}
+		rejection, ok := errs[0].(*BidRejectionError)
+		if !ok || rejection.Reason != pbsmetrics.BidRejectionInvalidRate {
+			t.Errorf("%s: expected a %s rejection. Got %v", tc.description, pbsmetrics.BidRejectionInvalidRate, errs[0])
+			continue
+		}
+		if rejection.Error() != tc.expectedError {
+			t.Errorf("%s: expected message %q. Got %q", tc.description, tc.expectedError, rejection.Error())
+		}
+	}
+}
+
+func TestBidFloorTolerance(t *testing.T) {
+	toleranceTestCases := []struct {
+		description      string
+		tolerance        float64
+		price            float64
+		expectedValid    bool
+		expectedWarnings int
+	}{
+		{
+			description:   "Bids at exactly the floor are kept without a warning",
+			tolerance:     0.01,
+			price:         1,
+			expectedValid: true,
+		},
+		{
+			description:      "Bids within the tolerance are kept with a warning",
+			tolerance:        0.01,
+			price:            0.995,
+			expectedValid:    true,
+			expectedWarnings: 1,
+		},
+		{
+			description:   "Bids clearly below the floor are rejected",
+			tolerance:     0.01,
+			price:         0.95,
+			expectedValid: false,
+		},
+		{
+			description:   "Without a tolerance, bids slightly below the floor are rejected",
+			price:         0.995,
+			expectedValid: false,
+		},
+	}
+
+	for _, tc := range toleranceTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID:       "flooredImp",
+				BidFloor: 1,
+			}},
+		}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: []*PBSOrtbBid{{
+					Bid: &openrtb.Bid{
+						ID:    "one-bid",
+						ImpID: "flooredImp",
+						Price: tc.price,
+						CrID:  "thisCreative",
+						AdM:   "some-markup",
+					},
+				}},
+			},
+		}
+		errs, warnings := brw.ValidateBids(brq, nil, config.BidValidation{FloorTolerance: tc.tolerance}, nil, nil)
+		if tc.expectedValid {
+			if len(errs) != 0 || len(brw.AdapterBids.Bids) != 1 {
+				t.Errorf("%s: expected the bid to be kept. Got errors %v", tc.description, errs)
+			}
+		} else {
+			if len(errs) != 1 {
+				t.Fatalf("%s: expected 1 error. Got %v", tc.description, errs)
+			}
+			if rejection, ok := errs[0].(*BidRejectionError); !ok || rejection.Reason != pbsmetrics.BidRejectionBelowFloor {
+				t.Errorf("%s: expected a %s rejection. Got %v", tc.description, pbsmetrics.BidRejectionBelowFloor, errs[0])
+			}
+		}
+		if len(warnings) != tc.expectedWarnings {
+			t.Errorf("%s: expected %d warnings. Got %v", tc.description, tc.expectedWarnings, warnings)
+		}
+		for _, warning := range warnings {
+			if errortypes.DecodeError(warning) != errortypes.WarningCode {
+				t.Errorf("%s: expected a warning. Got %v", tc.description, warning)
+			}
+		}
+	}
+}
+
+func TestZeroPriceBids(t *testing.T) {
+	zeroPriceTestCases := []struct {
+		description      string
+		allowZeroPrice   bool
+		price            float64
+		expectedValid    bool
+		expectedWarnings int
+	}{
+		{
+			description:   "Zero-price bids are rejected by default",
+			price:         0,
+			expectedValid: false,
+		},
+		{
+			description:      "Zero-price bids are kept with a warning if the host allows them",
+			allowZeroPrice:   true,
+			price:            0,
+			expectedValid:    true,
+			expectedWarnings: 1,
+		},
+		{
+			description:    "Negative prices are rejected even if the host allows zero-price bids",
+			allowZeroPrice: true,
+			price:          -0.01,
+			expectedValid:  false,
+		},
+		{
+			description:    "Positive prices don't warn",
+			allowZeroPrice: true,
+			price:          0.45,
+			expectedValid:  true,
+		},
+	}
+
+	for _, tc := range zeroPriceTestCases {
+		brq := &openrtb.BidRequest{
+			Imp: []openrtb.Imp{{
+				ID: "thisImp",
+			}},
+		}
+		brw := &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: []*PBSOrtbBid{{
+					Bid: &openrtb.Bid{
+						ID:    "one-bid",
+						ImpID: "thisImp",
+						Price: tc.price,
+						CrID:  "thisCreative",
+						AdM:   "some-markup",
+					},
+				}},
+			},
+		}
+		errs, warnings := brw.ValidateBids(brq, nil, config.BidValidation{AllowZeroPriceBids: tc.allowZeroPrice}, nil, nil)
+		if tc.expectedValid {
+			if len(errs) != 0 || len(brw.AdapterBids.Bids) != 1 {
+				t.Errorf("%s: expected the bid to be kept. Got errors %v", tc.description, errs)
+			}
+		} else {
+			if len(errs) != 1 {
+				t.Fatalf("%s: expected 1 error. Got %v", tc.description, errs)
+			}
+			if rejection, ok := errs[0].(*BidRejectionError); !ok || rejection.Reason != pbsmetrics.BidRejectionNonPositivePrice {
+				t.Errorf("%s: expected a %s rejection. Got %v", tc.description, pbsmetrics.BidRejectionNonPositivePrice, errs[0])
+			}
+			if len(brw.AdapterBids.Bids) != 0 {
+				t.Errorf("%s: expected the bid to be removed", tc.description)
+			}
+		}
+		if len(warnings) != tc.expectedWarnings {
+			t.Errorf("%s: expected %d warnings. Got %v", tc.description, tc.expectedWarnings, warnings)
+		}
+		for _, warning := range warnings {
+			if errortypes.DecodeError(warning) != errortypes.WarningCode {
+				t.Errorf("%s: expected a warning. Got %v", tc.description, warning)
+			}
+		}
+	}
+}
+
+// TestValidateBidsConcurrently is meant to be run with the race detector.
+// Different seats share the request, the validators and the underlying rates, like they do in an auction.
+func TestValidateBidsConcurrently(t *testing.T) {
+	brq := &openrtb.BidRequest{
+		Imp: []openrtb.Imp{{
+			ID:          "thisImp",
+			BidFloor:    0.5,
+			BidFloorCur: "EUR",
+		}},
+	}
+	rates := currencies.NewRates(time.Time{}, map[string]map[string]float64{
+		"USD": {
+			"EUR": 0.85,
+		},
+	})
+	validators := []BidValidator{BidValidatorFunc(func(request *openrtb.BidRequest, bid *PBSOrtbBid) error {
+		return nil
+	})}
+
+	const numSeats = 50
+	wrappers := make([]*BidResponseWrapper, numSeats)
+	originals := make([][]*PBSOrtbBid, numSeats)
+	for i := 0; i < numSeats; i++ {
+		bids := []*PBSOrtbBid{{
+			Bid: &openrtb.Bid{ID: "good-bid", ImpID: "thisImp", Price: 1, CrID: "creative", AdM: "some-markup"},
+		}, {
+			Bid: &openrtb.Bid{ID: "cheap-bid", ImpID: "thisImp", Price: 0.1, CrID: "creative", AdM: "some-markup"},
+		}}
+		originals[i] = bids
+		wrappers[i] = &BidResponseWrapper{
+			AdapterBids: &PBSOrtbSeatBid{
+				Bids: bids,
+			},
+		}
+	}
+
+	done := make(chan []error, numSeats)
+	for i := 0; i < numSeats; i++ {
+		go func(brw *BidResponseWrapper) {
+			errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{}, currencies.NewConversionCache(rates), validators)
+			done <- errs
+		}(wrappers[i])
+	}
+	for i := 0; i < numSeats; i++ {
+		if errs := <-done; len(errs) != 1 {
+			t.Errorf("Expected 1 error from each seat. Got %v", errs)
+		}
+	}
+
+	for i := 0; i < numSeats; i++ {
+		if len(wrappers[i].AdapterBids.Bids) != 1 || wrappers[i].AdapterBids.Bids[0].Bid.ID != "good-bid" {
+			t.Errorf("Seat %d should only keep good-bid. Got %d bids", i, len(wrappers[i].AdapterBids.Bids))
+		}
+		if len(originals[i]) != 2 || originals[i][1].Bid.ID != "cheap-bid" {
+			t.Errorf("Seat %d's original bids should not be mutated", i)
+		}
+	}
+}
+
+func assertBids(t *testing.T, brq *openrtb.BidRequest, brw *BidResponseWrapper, ebids int, eerrs int) {
+	errs, _ := brw.ValidateBids(brq, nil, config.BidValidation{}, nil, nil)
+	if len(errs) != eerrs {
+		t.Errorf("Expected %d Errors validating bids, found %d", eerrs, len(errs))
+	}
+	if len(brw.AdapterBids.Bids) != ebids {
+		t.Errorf("Expected %d bids, found %d bids", ebids, len(brw.AdapterBids.Bids))
+	}
+}
+
+// newTestImps returns Imps with the given IDs, so that Bids on them aren't rejected for naming an unknown Imp.
+func newTestImps(ids ...string) []openrtb.Imp {
+	imps := make([]openrtb.Imp, len(ids))
+	for i, id := range ids {
+		imps[i].ID = id
 	}
+	return imps
 }
@@ -14,8 +14,21 @@ type ExtBid struct {
 // ExtBidPrebid defines the contract for bidresponse.seatbid.bid[i].ext.prebid
 type ExtBidPrebid struct {
 	Cache     *ExtBidPrebidCache `json:"cache,omitempty"`
+	Meta      *ExtBidPrebidMeta  `json:"meta,omitempty"`
 	Targeting map[string]string  `json:"targeting,omitempty"`
 	Type      BidType            `json:"type"`
+	Video     *ExtBidPrebidVideo `json:"video,omitempty"`
+	// OriginalBidCPM and OriginalBidCur are the price and currency which the bidder quoted.
+	// They're only set if Prebid Server converted the Bid into another currency.
+	OriginalBidCPM float64 `json:"origbidcpm,omitempty"`
+	OriginalBidCur string  `json:"origbidcur,omitempty"`
+	// DealPriority is the Bidder's ranking of the deal. DealTierSatisfied is true if it met the
+	// publisher's minimum deal tier, in which case the tier is also sent as the hb_deal_tier targeting key.
+	DealPriority      int                 `json:"dealpriority,omitempty"`
+	DealTierSatisfied bool                `json:"dealtiersatisfied,omitempty"`
+	Events            *ExtBidPrebidEvents `json:"events,omitempty"`
+	// ClearingPrice is what the winning Bid pays in a second-price auction, in the response currency.
+	ClearingPrice float64 `json:"clearingprice,omitempty"`
 }
 
 // ExtBidPrebidCache defines the contract for  bidresponse.seatbid.bid[i].ext.prebid.cache
@@ -24,6 +37,35 @@ type ExtBidPrebidCache struct {
 	Url string `json:"url"`
 }
 
+// ExtBidPrebidEvents defines the contract for bidresponse.seatbid.bid[i].ext.prebid.events
+// Publishers call these URLs when the Bid wins, and when its ad renders.
+type ExtBidPrebidEvents struct {
+	Win string `json:"win,omitempty"`
+	Imp string `json:"imp,omitempty"`
+}
+
+// ExtBidPrebidMeta defines the contract for bidresponse.seatbid.bid[i].ext.prebid.meta
+// It describes who is behind the Bid, for publishers with transparency requirements.
+type ExtBidPrebidMeta struct {
+	AdvertiserDomains []string `json:"advertiserDomains,omitempty"`
+	AdvertiserID      int      `json:"advertiserId,omitempty"`
+	AdvertiserName    string   `json:"advertiserName,omitempty"`
+	AgencyID          int      `json:"agencyId,omitempty"`
+	AgencyName        string   `json:"agencyName,omitempty"`
+	BrandID           int      `json:"brandId,omitempty"`
+	BrandName         string   `json:"brandName,omitempty"`
+	NetworkID         int      `json:"networkId,omitempty"`
+	NetworkName       string   `json:"networkName,omitempty"`
+	// BehavioralTargeting is true if the ad was chosen using the user's behavior. These Bids are rejected on COPPA requests.
+	BehavioralTargeting bool `json:"behavioralTargeting,omitempty"`
+}
+
+// ExtBidPrebidVideo defines the contract for bidresponse.seatbid.bid[i].ext.prebid.video
+type ExtBidPrebidVideo struct {
+	// Duration is the length of the video creative, in seconds.
+	Duration int `json:"duration"`
+}
+
 // BidType describes the allowed values for bidresponse.seatbid.bid[i].ext.prebid.type
 type BidType string
 
@@ -58,6 +100,33 @@ func ParseBidType(bidType string) (BidType, error) {
 	}
 }
 
+// MarkupType describes the allowed values for the OpenRTB 2.6 bid.mtype, which says what kind of creative the markup is.
+// It's 0 if the Bidder didn't say.
+type MarkupType int8
+
+const (
+	MarkupBanner MarkupType = 1
+	MarkupVideo  MarkupType = 2
+	MarkupAudio  MarkupType = 3
+	MarkupNative MarkupType = 4
+)
+
+// BidType returns the media type which the MarkupType stands for, or "" if it isn't one of the known values.
+func (t MarkupType) BidType() BidType {
+	switch t {
+	case MarkupBanner:
+		return BidTypeBanner
+	case MarkupVideo:
+		return BidTypeVideo
+	case MarkupAudio:
+		return BidTypeAudio
+	case MarkupNative:
+		return BidTypeNative
+	default:
+		return ""
+	}
+}
+
 // TargetingKeys are used throughout Prebid as keys which can be used in an ad server like DFP.
 // Clients set the values we assign on the request to the ad server, where they can be substituted like macros into
 // Creatives.
@@ -87,6 +156,10 @@ const (
 	HbCreativeLoadMethodConstantKey TargetingKey = "hb_creative_loadtype"
 	HbDealIdConstantKey             TargetingKey = "hb_deal"
 
+	// HbDealTierKey is the deal tier bucket of a Bid which satisfied its Bidder's deal tier config.
+	// The value is the configured prefix followed by the Bid's deal priority. For example, "tier5".
+	HbDealTierKey TargetingKey = "hb_deal_tier"
+
 	// HbCacheKey and HbVastCacheKey store UUIDs which can be used to fetch things from prebid cache.
 	// Callers should *never* assume that either of these exist, since the call to the cache may always fail.
 	//
@@ -30,6 +30,22 @@ func TestBidParsing(t *testing.T) {
 	}
 }
 
+func TestMarkupTypes(t *testing.T) {
+	expected := map[MarkupType]BidType{
+		MarkupBanner: BidTypeBanner,
+		MarkupVideo:  BidTypeVideo,
+		MarkupAudio:  BidTypeAudio,
+		MarkupNative: BidTypeNative,
+		0:            "",
+		5:            "",
+	}
+	for markupType, bidType := range expected {
+		if markupType.BidType() != bidType {
+			t.Errorf("mtype %d should be %q. Got %q", markupType, bidType, markupType.BidType())
+		}
+	}
+}
+
 func assertBidParse(t *testing.T, s string, bidType BidType) {
 	t.Helper()
 
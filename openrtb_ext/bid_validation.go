@@ -0,0 +1,73 @@
+package openrtb_ext
+
+import (
+	"fmt"
+
+	"github.com/mxmCherry/openrtb"
+)
+
+// BidProblem names the check which an invalid Bid failed.
+// The values match the pbsmetrics.BidRejectionReasons, so they can be reported in the same metrics.
+type BidProblem string
+
+const (
+	BidProblemEmptyBid          BidProblem = "empty_bid"
+	BidProblemMissingID         BidProblem = "missing_id"
+	BidProblemMissingImpID      BidProblem = "missing_impid"
+	BidProblemNonPositivePrice  BidProblem = "non_positive_price"
+	BidProblemMissingCreativeID BidProblem = "missing_crid"
+	BidProblemEmptyMarkup       BidProblem = "empty_markup"
+)
+
+// InvalidBidError explains why ValidateBid rejected a Bid.
+type InvalidBidError struct {
+	Problem BidProblem
+	Message string
+}
+
+func (err *InvalidBidError) Error() string {
+	return err.Message
+}
+
+// BidValidationOptions relax the checks which ValidateBid makes. The zero value makes every check.
+type BidValidationOptions struct {
+	// SkipMarkupCheck accepts Bids which have neither an adm nor an nurl.
+	SkipMarkupCheck bool
+	// AllowZeroPrice accepts Bids with a zero price. Negative prices are always rejected.
+	AllowZeroPrice bool
+}
+
+// ValidateBid makes the checks which every Bid must pass, regardless of the request it's for:
+// it must have an ID, an ImpID, a CrID, a positive price, and some markup to render.
+// It returns an *InvalidBidError if the Bid fails any of them, or nil if it passes.
+//
+// Checks which need the request, like floors, sizes and currencies, are left to the caller.
+func ValidateBid(bid *openrtb.Bid, options BidValidationOptions) error {
+	if bid == nil {
+		return newInvalidBidError(BidProblemEmptyBid, "Empty bid object submitted.")
+	}
+	// These are the three required fields for bids
+	if bid.ID == "" {
+		return newInvalidBidError(BidProblemMissingID, "Bid missing required field 'id'")
+	}
+	if bid.ImpID == "" {
+		return newInvalidBidError(BidProblemMissingImpID, "Bid \"%s\" missing required field 'impid'", bid.ID)
+	}
+	if bid.Price < 0.0 || (bid.Price == 0.0 && !options.AllowZeroPrice) {
+		return newInvalidBidError(BidProblemNonPositivePrice, "Bid \"%s\" does not contain a positive 'price'", bid.ID)
+	}
+	if bid.CrID == "" {
+		return newInvalidBidError(BidProblemMissingCreativeID, "Bid \"%s\" missing creative ID", bid.ID)
+	}
+	if !options.SkipMarkupCheck && bid.AdM == "" && bid.NURL == "" {
+		return newInvalidBidError(BidProblemEmptyMarkup, "Bid \"%s\" has no markup (both adm and nurl empty)", bid.ID)
+	}
+	return nil
+}
+
+func newInvalidBidError(problem BidProblem, format string, args ...interface{}) error {
+	return &InvalidBidError{
+		Problem: problem,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
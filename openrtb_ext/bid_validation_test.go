@@ -0,0 +1,99 @@
+package openrtb_ext
+
+import (
+	"testing"
+
+	"github.com/mxmCherry/openrtb"
+)
+
+func TestValidateBid(t *testing.T) {
+	testCases := []struct {
+		description     string
+		bid             *openrtb.Bid
+		options         BidValidationOptions
+		expectedProblem BidProblem
+	}{
+		{
+			description: "Complete bids are valid",
+			bid:         newValidationTestBid(),
+		},
+		{
+			description:     "Nil bids are invalid",
+			expectedProblem: BidProblemEmptyBid,
+		},
+		{
+			description:     "Bids need an ID",
+			bid:             withBidChange(func(bid *openrtb.Bid) { bid.ID = "" }),
+			expectedProblem: BidProblemMissingID,
+		},
+		{
+			description:     "Bids need an ImpID",
+			bid:             withBidChange(func(bid *openrtb.Bid) { bid.ImpID = "" }),
+			expectedProblem: BidProblemMissingImpID,
+		},
+		{
+			description:     "Bids need a creative ID",
+			bid:             withBidChange(func(bid *openrtb.Bid) { bid.CrID = "" }),
+			expectedProblem: BidProblemMissingCreativeID,
+		},
+		{
+			description:     "Zero-price bids are invalid by default",
+			bid:             withBidChange(func(bid *openrtb.Bid) { bid.Price = 0 }),
+			expectedProblem: BidProblemNonPositivePrice,
+		},
+		{
+			description: "Zero-price bids can be allowed",
+			bid:         withBidChange(func(bid *openrtb.Bid) { bid.Price = 0 }),
+			options:     BidValidationOptions{AllowZeroPrice: true},
+		},
+		{
+			description:     "Negative prices are always invalid",
+			bid:             withBidChange(func(bid *openrtb.Bid) { bid.Price = -1 }),
+			options:         BidValidationOptions{AllowZeroPrice: true},
+			expectedProblem: BidProblemNonPositivePrice,
+		},
+		{
+			description:     "Bids need markup",
+			bid:             withBidChange(func(bid *openrtb.Bid) { bid.AdM = "" }),
+			expectedProblem: BidProblemEmptyMarkup,
+		},
+		{
+			description: "An nurl counts as markup",
+			bid:         withBidChange(func(bid *openrtb.Bid) { bid.AdM, bid.NURL = "", "https://ads.com/win" }),
+		},
+		{
+			description: "The markup check can be skipped",
+			bid:         withBidChange(func(bid *openrtb.Bid) { bid.AdM = "" }),
+			options:     BidValidationOptions{SkipMarkupCheck: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		err := ValidateBid(tc.bid, tc.options)
+		if tc.expectedProblem == "" {
+			if err != nil {
+				t.Errorf("%s: expected no error. Got %v", tc.description, err)
+			}
+			continue
+		}
+		if invalid, ok := err.(*InvalidBidError); !ok || invalid.Problem != tc.expectedProblem {
+			t.Errorf("%s: expected a %s problem. Got %v", tc.description, tc.expectedProblem, err)
+		}
+	}
+}
+
+func newValidationTestBid() *openrtb.Bid {
+	return &openrtb.Bid{
+		ID:    "one-bid",
+		ImpID: "thisImp",
+		Price: 0.45,
+		CrID:  "thisCreative",
+		AdM:   "some-markup",
+	}
+}
+
+func withBidChange(change func(bid *openrtb.Bid)) *openrtb.Bid {
+	bid := newValidationTestBid()
+	change(bid)
+	return bid
+}
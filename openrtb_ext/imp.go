@@ -12,6 +12,11 @@ type ExtImp struct {
 // ExtImpPrebid defines the contract for bidrequest.imp[i].ext.prebid
 type ExtImpPrebid struct {
 	StoredRequest *ExtStoredRequest `json:"storedrequest"`
+	// DisallowVASTWrappers rejects video Bids whose VAST only wraps other ads, without an inline creative.
+	DisallowVASTWrappers bool `json:"disallowvastwrappers,omitempty"`
+	// TMax is a hint, in milliseconds, of how long the bidders should get for this imp. Bidders get the shortest
+	// hint of the imps in their request, if it's shorter than the rest of the auction.
+	TMax int64 `json:"tmax,omitempty"`
 }
 
 // ExtStoredRequest defines the contract for bidrequest.imp[i].ext.prebid.storedrequest
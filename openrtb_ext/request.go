@@ -3,6 +3,7 @@ package openrtb_ext
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 )
 
 // ExtRequest defines the contract for bidrequest.ext
@@ -15,8 +16,25 @@ type ExtRequestPrebid struct {
 	Aliases              map[string]string      `json:"aliases,omitempty"`
 	BidAdjustmentFactors map[string]float64     `json:"bidadjustmentfactors,omitempty"`
 	Cache                *ExtRequestPrebidCache `json:"cache,omitempty"`
-	StoredRequest        *ExtStoredRequest      `json:"storedrequest,omitempty"`
-	Targeting            *ExtRequestTargeting   `json:"targeting,omitempty"`
+	// DealTiers are keyed by bidder or alias.
+	DealTiers map[string]DealTier `json:"dealtiers,omitempty"`
+	// Debug adds debugging info to the response ext, like request.test does, but without making the auction a test.
+	Debug            bool `json:"debug,omitempty"`
+	DedupeCategories bool `json:"dedupecategories,omitempty"`
+	MaxBidsPerImp    int  `json:"maxbidsperimp,omitempty"`
+	// MediaTypeBidAdjustmentFactors are keyed by bidder, then by media type. They override the BidAdjustmentFactors.
+	MediaTypeBidAdjustmentFactors map[string]map[BidType]float64 `json:"mediatypebidadjustmentfactors,omitempty"`
+	StoredRequest                 *ExtStoredRequest              `json:"storedrequest,omitempty"`
+	Targeting                     *ExtRequestTargeting           `json:"targeting,omitempty"`
+	Validation                    *ExtRequestValidation          `json:"validation,omitempty"`
+}
+
+// DealTier defines the contract for bidrequest.ext.prebid.dealtiers.{bidder}
+type DealTier struct {
+	// Prefix is prepended to the Bid's deal priority to make the hb_deal_tier targeting value.
+	Prefix string `json:"prefix"`
+	// MinDealTier is the lowest deal priority which qualifies for a tier.
+	MinDealTier int `json:"mindealtier"`
 }
 
 // ExtRequestPrebidCache defines the contract for bidrequest.ext.prebid.cache
@@ -47,11 +65,30 @@ type ExtRequestPrebidCacheBids struct{}
 // ExtRequestPrebidCacheVAST defines the contract for bidrequest.ext.prebid.cache.vastxml
 type ExtRequestPrebidCacheVAST struct{}
 
+// ExtRequestValidation defines the contract for bidrequest.ext.prebid.validation
+type ExtRequestValidation struct {
+	// SkipMarkupCheck disables the check which rejects bids with neither an adm nor an nurl.
+	SkipMarkupCheck bool `json:"skipmarkupcheck,omitempty"`
+	// SkipFloorCheck disables the check which rejects bids priced below their imp.bidfloor.
+	SkipFloorCheck bool `json:"skipfloorcheck,omitempty"`
+	// SkipSecureCheck disables the check which rejects bids with http:// resources in their adm when the imp is secure.
+	SkipSecureCheck bool `json:"skipsecurecheck,omitempty"`
+	// SkipAll disables every check on the bids, for fully trusted demand. It's ignored unless the publisher's
+	// account is allowed to skip bid validation.
+	SkipAll bool `json:"skipall,omitempty"`
+	// Languages restricts bids to creatives in these languages, as ISO 639-1 codes. Bids which don't declare a language pass.
+	Languages []string `json:"languages,omitempty"`
+}
+
 // ExtRequestTargeting defines the contract for bidrequest.ext.prebid.targeting
 type ExtRequestTargeting struct {
 	PriceGranularity  PriceGranularity `json:"pricegranularity"`
 	IncludeWinners    bool             `json:"includewinners"`
 	IncludeBidderKeys bool             `json:"includebidderkeys"`
+	// MediaTypePriceGranularity overrides the PriceGranularity for Bids of each media type.
+	MediaTypePriceGranularity map[BidType]PriceGranularity `json:"mediatypepricegranularity,omitempty"`
+	// PreferDeals makes Bids which satisfy their bidder's dealtiers win over all the others, ranked by deal priority.
+	PreferDeals bool `json:"preferdeals,omitempty"`
 }
 
 // Make an unmarshaller that will set a default PriceGranularity
@@ -73,6 +110,11 @@ func (ert *ExtRequestTargeting) UnmarshalJSON(b []byte) error {
 		if !defaults.IncludeWinners && !defaults.IncludeBidderKeys {
 			return errors.New("ext.prebid.targeting: At least one of includewinners or includebidderkeys must be enabled to enable targeting support")
 		}
+		for mediaType := range defaults.MediaTypePriceGranularity {
+			if _, err := ParseBidType(string(mediaType)); err != nil {
+				return fmt.Errorf("ext.prebid.targeting.mediatypepricegranularity.%s is not a known media type", mediaType)
+			}
+		}
 		*ert = ExtRequestTargeting(*defaults)
 	}
 
@@ -69,6 +69,30 @@ const ext3 = `{
 	}
 }`
 
+func TestExtRequestTargetingMediaTypePriceGranularity(t *testing.T) {
+	var targeting ExtRequestTargeting
+	err := json.Unmarshal([]byte(`{"pricegranularity":"med","mediatypepricegranularity":{"video":"low","banner":{"ranges":[{"max":10,"increment":0.5}]}}}`), &targeting)
+	assert.NoError(t, err)
+	assert.Equal(t, PriceGranularityFromString("med"), targeting.PriceGranularity)
+	assert.Equal(t, PriceGranularityFromString("low"), targeting.MediaTypePriceGranularity[BidTypeVideo])
+	assert.Equal(t, PriceGranularity{
+		Precision: 2,
+		Ranges: []GranularityRange{{
+			Min:       0,
+			Max:       10,
+			Increment: 0.5,
+		}},
+	}, targeting.MediaTypePriceGranularity[BidTypeBanner])
+	_, hasNative := targeting.MediaTypePriceGranularity[BidTypeNative]
+	assert.False(t, hasNative)
+}
+
+func TestExtRequestTargetingMediaTypePriceGranularityBad(t *testing.T) {
+	var targeting ExtRequestTargeting
+	assert.Error(t, json.Unmarshal([]byte(`{"mediatypepricegranularity":{"popup":"low"}}`), &targeting), "Unknown media types should be rejected")
+	assert.Error(t, json.Unmarshal([]byte(`{"mediatypepricegranularity":{"video":{"ranges":[]}}}`), &targeting), "Each granularity should be validated")
+}
+
 func TestCacheIllegal(t *testing.T) {
 	var bids ExtRequestPrebidCache
 	if err := json.Unmarshal([]byte(`{}`), &bids); err == nil {
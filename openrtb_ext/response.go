@@ -9,10 +9,28 @@ type ExtBidResponse struct {
 	Debug *ExtResponseDebug `json:"debug,omitempty"`
 	// ExtResponseErrors defines the contract for bidresponse.ext.errors
 	Errors map[BidderName][]ExtBidderError `json:"errors,omitempty"`
+	// Warnings defines the contract for bidresponse.ext.warnings. These are informational, and don't mean that anything went wrong.
+	Warnings map[BidderName][]ExtBidderError `json:"warnings,omitempty"`
 	// ExtResponseTimeMillis defines the contract for bidresponse.ext.responsetimemillis
 	ResponseTimeMillis map[BidderName]int `json:"responsetimemillis,omitempty"`
 	// ExtResponseUserSync defines the contract for bidresponse.ext.usersync
 	Usersync map[BidderName]*ExtResponseSyncData `json:"usersync,omitempty"`
+	// Prebid defines the contract for bidresponse.ext.prebid
+	Prebid *ExtResponsePrebid `json:"prebid,omitempty"`
+}
+
+// ExtResponsePrebid defines the contract for bidresponse.ext.prebid
+type ExtResponsePrebid struct {
+	// NoBidReasons explains why Imps without any Bids didn't get one. It's keyed by imp ID, and only set if the host enables it.
+	NoBidReasons map[string][]ExtNoBidReason `json:"nobidreasons,omitempty"`
+}
+
+// ExtNoBidReason defines the contract for bidresponse.ext.prebid.nobidreasons.{impID}[i]
+// It counts the Bids which a Bidder made on the Imp that were rejected for the same reason.
+type ExtNoBidReason struct {
+	Bidder BidderName `json:"bidder"`
+	Reason string     `json:"reason"`
+	Count  int        `json:"count"`
 }
 
 // ExtResponseDebug defines the contract for bidresponse.ext.debug
@@ -21,6 +39,35 @@ type ExtResponseDebug struct {
 	HttpCalls map[BidderName][]*ExtHttpCall `json:"httpcalls,omitempty"`
 	// Request after resolution of stored requests and debug overrides
 	ResolvedRequest *openrtb.BidRequest `json:"resolvedrequest,omitempty"`
+	// RejectedBids defines the contract for bidresponse.ext.debug.rejectedbids
+	RejectedBids map[BidderName][]ExtRejectedBid `json:"rejectedbids,omitempty"`
+	// CurrencyRates defines the contract for bidresponse.ext.debug.currencyrates. It's only set if the rates were stale.
+	CurrencyRates *ExtResponseCurrencyRates `json:"currencyrates,omitempty"`
+	// CurrencySelection defines the contract for bidresponse.ext.debug.currencyselection. It's only set if the request.cur had several currencies.
+	CurrencySelection *ExtResponseCurrencySelection `json:"currencyselection,omitempty"`
+}
+
+// ExtResponseCurrencySelection describes how the currency which the Bids were converted into was chosen.
+type ExtResponseCurrencySelection struct {
+	Policy string `json:"policy"`
+	// Target is empty if none of the request.cur currencies worked, so the Bids weren't converted.
+	Target string `json:"target"`
+}
+
+// ExtResponseCurrencyRates describes what happened when the auction's currency rates were stale or missing.
+type ExtResponseCurrencyRates struct {
+	Policy string `json:"policy"`
+	// AgeSeconds is 0 if no rates had been fetched.
+	AgeSeconds int64  `json:"ageseconds"`
+	Message    string `json:"message"`
+}
+
+// ExtRejectedBid defines the contract for bidresponse.ext.debug.rejectedbids.{bidder}[i]
+type ExtRejectedBid struct {
+	// BidID is empty if the whole SeatBid was rejected, like when it uses a currency which the request doesn't allow.
+	BidID   string `json:"bidid"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
 }
 
 // ExtResponseSyncData defines the contract for bidresponse.ext.usersync.{bidder}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/prebid/prebid-server/config"
+	"github.com/prebid/prebid-server/exchange"
 	pbc "github.com/prebid/prebid-server/prebid_cache_client"
 	"github.com/prebid/prebid-server/router"
 	"github.com/prebid/prebid-server/server"
@@ -40,7 +41,8 @@ func main() {
 }
 
 func serve(revision string, cfg *config.Configuration) error {
-	r, err := router.New(cfg)
+	// Hosts can register their own exchange.Plugins here.
+	r, err := router.New(cfg, exchange.Plugins{})
 	if err != nil {
 		return err
 	}
@@ -48,7 +50,7 @@ func serve(revision string, cfg *config.Configuration) error {
 	pbc.InitPrebidCache(cfg.CacheURL.GetBaseURL())
 	// Add cors support
 	corsRouter := router.SupportCORS(r)
-	server.Listen(cfg, router.NoCache{Handler: corsRouter}, router.Admin(revision), r.MetricsEngine)
+	server.Listen(cfg, router.NoCache{Handler: corsRouter}, router.Admin(revision, r.DisabledBidders, r.BlockedCreatives), r.MetricsEngine)
 	r.Shutdown()
 	return nil
 }
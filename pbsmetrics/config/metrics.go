@@ -125,6 +125,20 @@ func (me *MultiMetricsEngine) RecordAdapterTime(labels pbsmetrics.AdapterLabels,
 	}
 }
 
+// RecordAdapterBidRejected across all engines
+func (me *MultiMetricsEngine) RecordAdapterBidRejected(labels pbsmetrics.AdapterLabels, reason pbsmetrics.BidRejectionReason) {
+	for _, thisME := range *me {
+		thisME.RecordAdapterBidRejected(labels, reason)
+	}
+}
+
+// RecordAdapterResponseSize across all engines
+func (me *MultiMetricsEngine) RecordAdapterResponseSize(labels pbsmetrics.AdapterLabels, bytes int) {
+	for _, thisME := range *me {
+		thisME.RecordAdapterResponseSize(labels, bytes)
+	}
+}
+
 // RecordCookieSync across all engines
 func (me *MultiMetricsEngine) RecordCookieSync(labels pbsmetrics.Labels) {
 	for _, thisME := range *me {
@@ -187,6 +201,16 @@ func (me *DummyMetricsEngine) RecordAdapterTime(labels pbsmetrics.AdapterLabels,
 	return
 }
 
+// RecordAdapterBidRejected as a noop
+func (me *DummyMetricsEngine) RecordAdapterBidRejected(labels pbsmetrics.AdapterLabels, reason pbsmetrics.BidRejectionReason) {
+	return
+}
+
+// RecordAdapterResponseSize as a noop
+func (me *DummyMetricsEngine) RecordAdapterResponseSize(labels pbsmetrics.AdapterLabels, bytes int) {
+	return
+}
+
 // RecordCookieSync as a noop
 func (me *DummyMetricsEngine) RecordCookieSync(labels pbsmetrics.Labels) {
 	return
@@ -51,6 +51,10 @@ type AdapterMetrics struct {
 	PriceHistogram    metrics.Histogram
 	BidsReceivedMeter metrics.Meter
 	MarkupMetrics     map[openrtb_ext.BidType]*MarkupDeliveryMetrics
+	// Only registered for adapters, since one meter per account, adapter and reason would be too many
+	BidRejectedMeters map[BidRejectionReason]metrics.Meter
+	// Only registered for adapters. Tracks the serialized size, in bytes, of the bids which survived validation
+	ResponseSizeHistogram metrics.Histogram
 }
 
 type MarkupDeliveryMetrics struct {
@@ -163,10 +167,15 @@ func makeBlankAdapterMetrics() *AdapterMetrics {
 		PriceHistogram:    &metrics.NilHistogram{},
 		BidsReceivedMeter: blankMeter,
 		MarkupMetrics:     makeBlankBidMarkupMetrics(),
+		BidRejectedMeters: make(map[BidRejectionReason]metrics.Meter),
 	}
+	newAdapter.ResponseSizeHistogram = &metrics.NilHistogram{}
 	for _, err := range AdapterErrors() {
 		newAdapter.ErrorMeters[err] = blankMeter
 	}
+	for _, reason := range BidRejectionReasons() {
+		newAdapter.BidRejectedMeters[reason] = blankMeter
+	}
 	return newAdapter
 }
 
@@ -203,6 +212,11 @@ func registerAdapterMetrics(registry metrics.Registry, adapterOrAccount string,
 	}
 	if adapterOrAccount != "adapter" {
 		am.BidsReceivedMeter = metrics.GetOrRegisterMeter(fmt.Sprintf("%[1]s.%[2]s.bids_received", adapterOrAccount, exchange), registry)
+	} else {
+		for reason := range am.BidRejectedMeters {
+			am.BidRejectedMeters[reason] = metrics.GetOrRegisterMeter(fmt.Sprintf("%s.%s.bids_rejected.%s", adapterOrAccount, exchange, reason), registry)
+		}
+		am.ResponseSizeHistogram = metrics.GetOrRegisterHistogram(fmt.Sprintf("%[1]s.%[2]s.response_size", adapterOrAccount, exchange), registry, metrics.NewExpDecaySample(1028, 0.015))
 	}
 }
 
@@ -390,6 +404,30 @@ func (me *Metrics) RecordAdapterTime(labels AdapterLabels, length time.Duration)
 	aam.RequestTimer.Update(length)
 }
 
+// RecordAdapterBidRejected implements a part of the MetricsEngine interface. Records a bid which was removed from the auction
+func (me *Metrics) RecordAdapterBidRejected(labels AdapterLabels, reason BidRejectionReason) {
+	am, ok := me.AdapterMetrics[labels.Adapter]
+	if !ok {
+		glog.Errorf("Trying to run adapter bid rejection metrics on %s: adapter metrics not found", string(labels.Adapter))
+		return
+	}
+	if meter, ok := am.BidRejectedMeters[reason]; ok {
+		meter.Mark(1)
+	} else {
+		glog.Errorf("bid rejection metrics map entry does not exist for reason %s. This is a bug, and should be reported.", reason)
+	}
+}
+
+// RecordAdapterResponseSize implements a part of the MetricsEngine interface. Records the size of the bids which an adapter returned
+func (me *Metrics) RecordAdapterResponseSize(labels AdapterLabels, bytes int) {
+	am, ok := me.AdapterMetrics[labels.Adapter]
+	if !ok {
+		glog.Errorf("Trying to run adapter response size metrics on %s: adapter metrics not found", string(labels.Adapter))
+		return
+	}
+	am.ResponseSizeHistogram.Update(int64(bytes))
+}
+
 // RecordCookieSync implements a part of the MetricsEngine interface. Records a cookie sync request
 func (me *Metrics) RecordCookieSync(labels Labels) {
 	me.CookieSyncMeter.Mark(1)
@@ -58,6 +58,34 @@ func TestRecordBidType(t *testing.T) {
 	VerifyMetrics(t, "Appnexus Video Nurl Bids", m.AdapterMetrics[openrtb_ext.BidderAppnexus].MarkupMetrics[openrtb_ext.BidTypeVideo].NurlMeter.Count(), 1)
 }
 
+func TestRecordBidRejected(t *testing.T) {
+	registry := metrics.NewRegistry()
+	m := NewMetrics(registry, []openrtb_ext.BidderName{openrtb_ext.BidderAppnexus})
+
+	m.RecordAdapterBidRejected(AdapterLabels{
+		Adapter: openrtb_ext.BidderAppnexus,
+	}, BidRejectionBelowFloor)
+	ensureContains(t, registry, "adapter.appnexus.bids_rejected.below_floor", m.AdapterMetrics[openrtb_ext.BidderAppnexus].BidRejectedMeters[BidRejectionBelowFloor])
+	VerifyMetrics(t, "Appnexus Below Floor Rejections", m.AdapterMetrics[openrtb_ext.BidderAppnexus].BidRejectedMeters[BidRejectionBelowFloor].Count(), 1)
+	VerifyMetrics(t, "Appnexus Empty Markup Rejections", m.AdapterMetrics[openrtb_ext.BidderAppnexus].BidRejectedMeters[BidRejectionEmptyMarkup].Count(), 0)
+}
+
+func TestRecordAdapterResponseSize(t *testing.T) {
+	registry := metrics.NewRegistry()
+	m := NewMetrics(registry, []openrtb_ext.BidderName{openrtb_ext.BidderAppnexus, openrtb_ext.BidderRubicon})
+
+	m.RecordAdapterResponseSize(AdapterLabels{
+		Adapter: openrtb_ext.BidderAppnexus,
+	}, 1200)
+	m.RecordAdapterResponseSize(AdapterLabels{
+		Adapter: openrtb_ext.BidderAppnexus,
+	}, 800)
+	ensureContains(t, registry, "adapter.appnexus.response_size", m.AdapterMetrics[openrtb_ext.BidderAppnexus].ResponseSizeHistogram)
+	VerifyMetrics(t, "Appnexus Response Sizes", m.AdapterMetrics[openrtb_ext.BidderAppnexus].ResponseSizeHistogram.Count(), 2)
+	VerifyMetrics(t, "Appnexus Max Response Size", m.AdapterMetrics[openrtb_ext.BidderAppnexus].ResponseSizeHistogram.Max(), 1200)
+	VerifyMetrics(t, "Rubicon Response Sizes", m.AdapterMetrics[openrtb_ext.BidderRubicon].ResponseSizeHistogram.Count(), 0)
+}
+
 func TestRecordGDPRRejection(t *testing.T) {
 	registry := metrics.NewRegistry()
 	m := NewMetrics(registry, []openrtb_ext.BidderName{openrtb_ext.BidderAppnexus})
@@ -160,6 +160,101 @@ func AdapterErrors() []AdapterError {
 	}
 }
 
+// BidRejectionReason : Why Prebid Server removed a bid from the auction
+type BidRejectionReason string
+
+// Bid rejection reasons
+const (
+	BidRejectionEmptyBid              BidRejectionReason = "empty_bid"
+	BidRejectionMissingID             BidRejectionReason = "missing_id"
+	BidRejectionMissingImpID          BidRejectionReason = "missing_impid"
+	BidRejectionNonPositivePrice      BidRejectionReason = "non_positive_price"
+	BidRejectionMissingCreativeID     BidRejectionReason = "missing_crid"
+	BidRejectionEmptyMarkup           BidRejectionReason = "empty_markup"
+	BidRejectionInvalidSize           BidRejectionReason = "invalid_size"
+	BidRejectionCurrencyNotAllowed    BidRejectionReason = "currency_not_allowed"
+	BidRejectionCurrencyMismatch      BidRejectionReason = "currency_mismatch"
+	BidRejectionCurrencyUnconvertible BidRejectionReason = "currency_unconvertible"
+	BidRejectionBelowFloor            BidRejectionReason = "below_floor"
+	BidRejectionInvalidDeal           BidRejectionReason = "invalid_deal"
+	BidRejectionInvalidDuration       BidRejectionReason = "invalid_duration"
+	BidRejectionInsecureMarkup        BidRejectionReason = "insecure_markup"
+	BidRejectionUnsupportedMediaType  BidRejectionReason = "unsupported_media_type"
+	BidRejectionInvalidVAST           BidRejectionReason = "invalid_vast"
+	BidRejectionInvalidNative         BidRejectionReason = "invalid_native"
+	BidRejectionMarkupTooLarge        BidRejectionReason = "markup_too_large"
+	BidRejectionVASTWrapper           BidRejectionReason = "vast_wrapper"
+	BidRejectionMissingMeta           BidRejectionReason = "missing_meta"
+	BidRejectionBlockedDomain         BidRejectionReason = "blocked_domain"
+	BidRejectionBlockedCategory       BidRejectionReason = "blocked_category"
+	BidRejectionDuplicateCategory     BidRejectionReason = "duplicate_category"
+	BidRejectionCOPPA                 BidRejectionReason = "coppa"
+	BidRejectionRewriteFailed         BidRejectionReason = "rewrite_failed"
+	BidRejectionMissingSeat           BidRejectionReason = "missing_seat"
+	BidRejectionDuplicateID           BidRejectionReason = "duplicate_id"
+	BidRejectionInvalidMIME           BidRejectionReason = "invalid_mime"
+	BidRejectionUnknownImpID          BidRejectionReason = "unknown_impid"
+	BidRejectionBlockedAttribute      BidRejectionReason = "blocked_attribute"
+	BidRejectionCreativeIDTooLong     BidRejectionReason = "crid_too_long"
+	BidRejectionPriceTooHigh          BidRejectionReason = "price_too_high"
+	BidRejectionMediaTypeMismatch     BidRejectionReason = "media_type_mismatch"
+	BidRejectionInvalidRate           BidRejectionReason = "conversion_rate_invalid"
+	BidRejectionInsecureNURL          BidRejectionReason = "insecure_nurl"
+	BidRejectionOpenRTBVersion        BidRejectionReason = "openrtb_version_mismatch"
+	BidRejectionLanguage              BidRejectionReason = "language_mismatch"
+	BidRejectionBlockedCreative       BidRejectionReason = "blocked_creative"
+	BidRejectionMissingMacro          BidRejectionReason = "missing_macro"
+	BidRejectionDNT                   BidRejectionReason = "dnt"
+	// BidRejectionCustom covers bids rejected by the host's own BidValidators
+	BidRejectionCustom BidRejectionReason = "custom"
+)
+
+func BidRejectionReasons() []BidRejectionReason {
+	return []BidRejectionReason{
+		BidRejectionEmptyBid,
+		BidRejectionMissingID,
+		BidRejectionMissingImpID,
+		BidRejectionNonPositivePrice,
+		BidRejectionMissingCreativeID,
+		BidRejectionEmptyMarkup,
+		BidRejectionInvalidSize,
+		BidRejectionCurrencyNotAllowed,
+		BidRejectionCurrencyMismatch,
+		BidRejectionCurrencyUnconvertible,
+		BidRejectionBelowFloor,
+		BidRejectionInvalidDeal,
+		BidRejectionInvalidDuration,
+		BidRejectionInsecureMarkup,
+		BidRejectionUnsupportedMediaType,
+		BidRejectionInvalidVAST,
+		BidRejectionInvalidNative,
+		BidRejectionMarkupTooLarge,
+		BidRejectionVASTWrapper,
+		BidRejectionMissingMeta,
+		BidRejectionBlockedDomain,
+		BidRejectionBlockedCategory,
+		BidRejectionDuplicateCategory,
+		BidRejectionCOPPA,
+		BidRejectionRewriteFailed,
+		BidRejectionMissingSeat,
+		BidRejectionDuplicateID,
+		BidRejectionInvalidMIME,
+		BidRejectionUnknownImpID,
+		BidRejectionBlockedAttribute,
+		BidRejectionCreativeIDTooLong,
+		BidRejectionPriceTooHigh,
+		BidRejectionMediaTypeMismatch,
+		BidRejectionInvalidRate,
+		BidRejectionInsecureNURL,
+		BidRejectionOpenRTBVersion,
+		BidRejectionLanguage,
+		BidRejectionBlockedCreative,
+		BidRejectionMissingMacro,
+		BidRejectionDNT,
+		BidRejectionCustom,
+	}
+}
+
 // UserLabels : Labels for /setuid endpoint
 type UserLabels struct {
 	Action RequestAction
@@ -195,6 +290,10 @@ type MetricsEngine interface {
 	RecordAdapterBidReceived(labels AdapterLabels, bidType openrtb_ext.BidType, hasAdm bool)
 	RecordAdapterPrice(labels AdapterLabels, cpm float64)
 	RecordAdapterTime(labels AdapterLabels, length time.Duration)
+	// This records each bid which Prebid Server removed from the auction, and why.
+	RecordAdapterBidRejected(labels AdapterLabels, reason BidRejectionReason)
+	// This records the size, in bytes, of the markup and exts of the bids which survived validation.
+	RecordAdapterResponseSize(labels AdapterLabels, bytes int)
 	RecordCookieSync(labels Labels)        // May ignore all labels
 	RecordUserIDSet(userLabels UserLabels) // Function should verify bidder values
 }
@@ -23,6 +23,8 @@ type Metrics struct {
 	adaptBids     *prometheus.CounterVec
 	adaptPrices   *prometheus.HistogramVec
 	adaptErrors   *prometheus.CounterVec
+	adaptRejects  *prometheus.CounterVec
+	adaptSizes    *prometheus.HistogramVec
 	cookieSync    prometheus.Counter
 	userID        *prometheus.CounterVec
 }
@@ -89,6 +91,16 @@ func NewMetrics(cfg config.PrometheusMetrics) *Metrics {
 		errorLabelNames,
 	)
 	metrics.Registry.MustRegister(metrics.adaptErrors)
+	metrics.adaptRejects = newCounter(cfg, "adapter_bids_rejected_total",
+		"Number of bids from each bidder which were removed from the auction, by reason.",
+		[]string{"adapter", "reason"},
+	)
+	metrics.Registry.MustRegister(metrics.adaptRejects)
+	metrics.adaptSizes = newHistogram(cfg, "adapter_response_size_bytes",
+		"Size of the markup and exts of the bids from each bidder which survived validation.",
+		[]string{"adapter"}, prometheus.ExponentialBuckets(256, 2, 12),
+	)
+	metrics.Registry.MustRegister(metrics.adaptSizes)
 	metrics.cookieSync = newCookieSync(cfg)
 	metrics.Registry.MustRegister(metrics.cookieSync)
 	metrics.userID = newCounter(cfg, "usersync_total",
@@ -193,6 +205,14 @@ func (me *Metrics) RecordAdapterTime(labels pbsmetrics.AdapterLabels, length tim
 	me.adaptTimer.With(resolveAdapterLabels(labels)).Observe(time)
 }
 
+func (me *Metrics) RecordAdapterBidRejected(labels pbsmetrics.AdapterLabels, reason pbsmetrics.BidRejectionReason) {
+	me.adaptRejects.With(resolveBidRejectionLabels(labels, reason)).Inc()
+}
+
+func (me *Metrics) RecordAdapterResponseSize(labels pbsmetrics.AdapterLabels, bytes int) {
+	me.adaptSizes.With(prometheus.Labels{"adapter": string(labels.Adapter)}).Observe(float64(bytes))
+}
+
 func (me *Metrics) RecordCookieSync(labels pbsmetrics.Labels) {
 	me.cookieSync.Inc()
 }
@@ -254,6 +274,13 @@ func resolveAdapterErrorLabels(labels pbsmetrics.AdapterLabels, errorType string
 	}
 }
 
+func resolveBidRejectionLabels(labels pbsmetrics.AdapterLabels, reason pbsmetrics.BidRejectionReason) prometheus.Labels {
+	return prometheus.Labels{
+		"adapter": string(labels.Adapter),
+		"reason":  string(reason),
+	}
+}
+
 func resolveUserSyncLabels(userLabels pbsmetrics.UserLabels) prometheus.Labels {
 	return prometheus.Labels{
 		"action": string(userLabels.Action),
@@ -301,6 +328,16 @@ func initializeTimeSeries(m *Metrics) {
 	for _, l := range labels {
 		_ = m.adaptErrors.With(l)
 	}
+	// Bid rejection labels
+	labels = addDimension([]prometheus.Labels{}, "adapter", adaptersAsString())
+	labels = addDimension(labels, "reason", bidRejectionReasonsAsString())
+	for _, l := range labels {
+		_ = m.adaptRejects.With(l)
+	}
+	// Response size labels
+	for _, l := range addDimension([]prometheus.Labels{}, "adapter", adaptersAsString()) {
+		_ = m.adaptSizes.With(l)
+	}
 }
 
 // addDimesion will expand a slice of labels to add the dimension of a new set of values for a new label name
@@ -418,3 +455,12 @@ func bidTypesAsString() []string {
 	return output
 
 }
+
+func bidRejectionReasonsAsString() []string {
+	list := pbsmetrics.BidRejectionReasons()
+	output := make([]string, len(list))
+	for i, s := range list {
+		output[i] = string(s)
+	}
+	return output
+}
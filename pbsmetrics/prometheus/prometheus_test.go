@@ -249,6 +249,47 @@ func TestAdapterTimeMetrics(t *testing.T) {
 
 }
 
+func TestAdapterBidRejectedMetrics(t *testing.T) {
+	proMetrics := newTestMetricsEngine()
+
+	metrics0 := dto.Metric{}
+	metrics1 := dto.Metric{}
+	metrics2 := dto.Metric{}
+
+	proMetrics.RecordAdapterBidRejected(adaptLabels[0], pbsmetrics.BidRejectionBelowFloor)
+	proMetrics.RecordAdapterBidRejected(adaptLabels[0], pbsmetrics.BidRejectionBelowFloor)
+	proMetrics.RecordAdapterBidRejected(adaptLabels[0], pbsmetrics.BidRejectionEmptyMarkup)
+
+	proMetrics.adaptRejects.With(resolveBidRejectionLabels(adaptLabels[0], pbsmetrics.BidRejectionBelowFloor)).Write(&metrics0)
+	proMetrics.adaptRejects.With(resolveBidRejectionLabels(adaptLabels[0], pbsmetrics.BidRejectionEmptyMarkup)).Write(&metrics1)
+	proMetrics.adaptRejects.With(resolveBidRejectionLabels(adaptLabels[0], pbsmetrics.BidRejectionBlockedDomain)).Write(&metrics2)
+
+	assertCounterValue(t, "adapter_bids_rejected[below_floor]", &metrics0, 2)
+	assertCounterValue(t, "adapter_bids_rejected[empty_markup]", &metrics1, 1)
+	assertCounterValue(t, "adapter_bids_rejected[blocked_domain]", &metrics2, 0)
+}
+
+func TestAdapterResponseSizeMetrics(t *testing.T) {
+	proMetrics := newTestMetricsEngine()
+
+	metrics0 := dto.Metric{}
+	metrics1 := dto.Metric{}
+	metrics2 := dto.Metric{}
+
+	proMetrics.RecordAdapterResponseSize(adaptLabels[0], 512)
+	proMetrics.RecordAdapterResponseSize(adaptLabels[1], 2048)
+	proMetrics.RecordAdapterResponseSize(adaptLabels[3], 100)
+
+	// adaptLabels[0] and adaptLabels[3] share an adapter, which is the only label on this histogram.
+	proMetrics.adaptSizes.With(prometheus.Labels{"adapter": string(adaptLabels[0].Adapter)}).(prometheus.Histogram).Write(&metrics0)
+	proMetrics.adaptSizes.With(prometheus.Labels{"adapter": string(adaptLabels[1].Adapter)}).(prometheus.Histogram).Write(&metrics1)
+	proMetrics.adaptSizes.With(prometheus.Labels{"adapter": string(adaptLabels[2].Adapter)}).(prometheus.Histogram).Write(&metrics2)
+
+	assertHistogramValue(t, "adapter_response_size[0]", &metrics0, 2)
+	assertHistogramValue(t, "adapter_response_size[1]", &metrics1, 1)
+	assertHistogramValue(t, "adapter_response_size[2]", &metrics2, 0)
+}
+
 func TestCookieMetrics(t *testing.T) {
 	proMetrics := newTestMetricsEngine()
 
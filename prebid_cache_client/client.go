@@ -36,6 +36,8 @@ type Cacheable struct {
 	Type       PayloadType
 	Data       json.RawMessage
 	TTLSeconds int64
+	// Signature is the creative's HMAC from SignCreative. It's stored alongside the value, if the host signs creatives.
+	Signature string
 }
 
 func NewClient(conf *config.Cache) Client {
@@ -141,13 +143,18 @@ func encodeValueToBuffer(value Cacheable, leadingComma bool, buffer *bytes.Buffe
 
 	buffer.WriteString(`{"type":"`)
 	buffer.WriteString(string(value.Type))
+	buffer.WriteByte('"')
 	if value.TTLSeconds > 0 {
-		buffer.WriteString(`","ttlseconds":`)
+		buffer.WriteString(`,"ttlseconds":`)
 		buffer.WriteString(strconv.FormatInt(value.TTLSeconds, 10))
-		buffer.WriteString(`,"value":`)
-	} else {
-		buffer.WriteString(`","value":`)
 	}
+	if value.Signature != "" {
+		// Signatures are hex encoded, so they never need escaping.
+		buffer.WriteString(`,"signature":"`)
+		buffer.WriteString(value.Signature)
+		buffer.WriteByte('"')
+	}
+	buffer.WriteString(`,"value":`)
 	buffer.Write(value.Data)
 	buffer.WriteByte('}')
 	return nil
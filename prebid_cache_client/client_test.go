@@ -113,6 +113,19 @@ func TestEncodeValueToBuffer(t *testing.T) {
 	assertStringEqual(t, expected, actual)
 }
 
+func TestEncodeSignedValueToBuffer(t *testing.T) {
+	buf := new(bytes.Buffer)
+	testCache := Cacheable{
+		Type:      TypeXML,
+		Data:      json.RawMessage(`"<VAST></VAST>"`),
+		Signature: "abc123",
+	}
+	expected := string(`{"type":"xml","signature":"abc123","value":"<VAST></VAST>"}`)
+	_ = encodeValueToBuffer(testCache, false, buf)
+	actual := buf.String()
+	assertStringEqual(t, expected, actual)
+}
+
 func assertIntEqual(t *testing.T, expected, actual int) {
 	t.Helper()
 	if expected != actual {
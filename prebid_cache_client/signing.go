@@ -0,0 +1,27 @@
+package prebid_cache_client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// SignCreative returns the hex encoded HMAC-SHA256 of the adm, keyed with the host's secret.
+// It's stored alongside the cached creative, so that whatever renders it can detect tampering.
+func SignCreative(secret string, adm string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(adm))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyCreative returns true if the signature was made by SignCreative for this adm and secret.
+// It should be called on creatives fetched from the cache before they're rendered.
+func VerifyCreative(secret string, adm string, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(adm))
+	return hmac.Equal(mac.Sum(nil), expected)
+}
@@ -0,0 +1,32 @@
+package prebid_cache_client
+
+import (
+	"testing"
+)
+
+func TestVerifyCreative(t *testing.T) {
+	signature := SignCreative("host-secret", "<div>ad</div>")
+	if !VerifyCreative("host-secret", "<div>ad</div>", signature) {
+		t.Errorf("The signature should verify for the creative which it was made from.")
+	}
+}
+
+func TestVerifyCorruptedCreative(t *testing.T) {
+	signature := SignCreative("host-secret", "<div>ad</div>")
+	if VerifyCreative("host-secret", "<div>ad</div><script src=\"http://evil.com\"></script>", signature) {
+		t.Errorf("The signature should not verify for a tampered creative.")
+	}
+}
+
+func TestVerifyCreativeWrongSecret(t *testing.T) {
+	signature := SignCreative("host-secret", "<div>ad</div>")
+	if VerifyCreative("other-secret", "<div>ad</div>", signature) {
+		t.Errorf("The signature should not verify with a different secret.")
+	}
+}
+
+func TestVerifyMalformedSignature(t *testing.T) {
+	if VerifyCreative("host-secret", "<div>ad</div>", "not-hex") {
+		t.Errorf("A malformed signature should never verify.")
+	}
+}
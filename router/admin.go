@@ -5,9 +5,10 @@ import (
 	"net/http/pprof"
 
 	"github.com/prebid/prebid-server/endpoints"
+	"github.com/prebid/prebid-server/exchange"
 )
 
-func Admin(revision string) *http.ServeMux {
+func Admin(revision string, disabledBidders *exchange.DisabledBidders, blockedCreatives *exchange.BlockedCreatives) *http.ServeMux {
 	// Add endpoints to the admin server
 	// Making sure to add pprof routes
 	mux := http.NewServeMux()
@@ -19,5 +20,7 @@ func Admin(revision string) *http.ServeMux {
 	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
 	// Register prebid-server defined admin handlers
 	mux.HandleFunc("/version", endpoints.NewVersionEndpoint(revision))
+	mux.HandleFunc("/bidders/disabled", endpoints.NewDisabledBiddersEndpoint(disabledBidders))
+	mux.HandleFunc("/creatives/blocked", endpoints.NewBlockedCreativesEndpoint(blockedCreatives))
 	return mux
 }
@@ -165,9 +165,14 @@ type Router struct {
 	MetricsEngine   *metricsConf.DetailedMetricsEngine
 	ParamsValidator openrtb_ext.BidderParamValidator
 	Shutdown        func()
+	// DisabledBidders can be changed through the admin server to switch bidders off without a redeploy.
+	DisabledBidders *exchange.DisabledBidders
+	// BlockedCreatives can be changed through the admin server to block bad creatives without a redeploy.
+	BlockedCreatives *exchange.BlockedCreatives
 }
 
-func New(cfg *config.Configuration) (r *Router, err error) {
+// New builds the Router for Prebid Server. The plugins let hosts customize the OpenRTB Exchange.
+func New(cfg *config.Configuration, plugins exchange.Plugins) (r *Router, err error) {
 	const schemaDirectory = "./static/bidder-params"
 	const infoDirectory = "./static/bidder-info"
 	disabledBidders := map[string]string{
@@ -215,7 +220,15 @@ func New(cfg *config.Configuration) (r *Router, err error) {
 	gdprPerms := gdpr.NewPermissions(context.Background(), cfg.GDPR, adapters.GDPRAwareSyncerIDs(syncers), theClient)
 
 	exchanges = newExchangeMap(cfg)
-	theExchange := exchange.NewExchange(theClient, pbc.NewClient(&cfg.CacheURL), cfg, r.MetricsEngine, bidderInfos, gdprPerms)
+	if plugins.DisabledBidders == nil {
+		plugins.DisabledBidders = exchange.NewDisabledBidders()
+	}
+	r.DisabledBidders = plugins.DisabledBidders
+	if plugins.BlockedCreatives == nil {
+		plugins.BlockedCreatives = exchange.NewBlockedCreatives()
+	}
+	r.BlockedCreatives = plugins.BlockedCreatives
+	theExchange := exchange.NewExchange(theClient, pbc.NewClient(&cfg.CacheURL), cfg, r.MetricsEngine, bidderInfos, gdprPerms, plugins)
 
 	openrtbEndpoint, err := openrtb2.NewEndpoint(theExchange, paramsValidator, fetcher, cfg, r.MetricsEngine, pbsAnalytics, disabledBidders, defReqJSON)
 	if err != nil {
@@ -235,6 +248,9 @@ func New(cfg *config.Configuration) (r *Router, err error) {
 	r.GET("/bidders/params", NewJsonDirectoryServer(schemaDirectory, paramsValidator, defaultAliases))
 	r.POST("/cookie_sync", endpoints.NewCookieSyncEndpoint(syncers, cfg, gdprPerms, r.MetricsEngine, pbsAnalytics))
 	r.GET("/status", endpoints.NewStatusEndpoint(cfg.StatusResponse))
+	if cfg.BidValidation.EndpointEnabled {
+		r.POST("/validation/bids", endpoints.NewValidateBidsEndpoint(bidderInfos, plugins.BidValidators, cfg.BidValidation))
+	}
 	r.GET("/", serveIndex)
 	r.ServeFiles("/static/*filepath", http.Dir("static"))
 
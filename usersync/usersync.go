@@ -6,9 +6,12 @@ type Usersyncer interface {
 	//
 	// gdpr should be 1 if GDPR is active, 0 if not, and an empty string if we're not sure.
 	// consent should be an empty string or a raw base64 url-encoded IAB Vendor Consent String.
+	// usPrivacy should be an empty string or an IAB US Privacy (CCPA) String.
+	// gpp should be an empty string or an IAB Global Privacy Platform String, and gppSID the comma-separated
+	// IDs of the GPP sections which apply. Callers which only have the legacy strings should pass empty ones.
 	//
 	// For more information about user syncs, see http://clearcode.cc/2015/12/cookie-syncing/
-	GetUsersyncInfo(gdpr string, consent string) *UsersyncInfo
+	GetUsersyncInfo(gdpr string, consent string, usPrivacy string, gpp string, gppSID string) *UsersyncInfo
 	// FamilyName should be the same as the `BidderName` for this Usersyncer.
 	// This function only exists for legacy reasons.
 	// TODO #362: when the appnexus usersyncer is consistent, delete this and use the key